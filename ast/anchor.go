@@ -0,0 +1,65 @@
+package ast
+
+import (
+	"github.com/goccy/go-yaml/token"
+)
+
+// AnchorInfo usage of anchor defined in file
+type AnchorInfo struct {
+	Name    string
+	Anchor  *AnchorNode  // node which defines the anchor
+	Aliases []*AliasNode // aliases which refer to the anchor in document order
+}
+
+// Position returns the position of the anchor definition ( e.g. `&a` )
+func (a *AnchorInfo) Position() *token.Position {
+	return a.Anchor.Start.Position
+}
+
+// AliasPositions returns the positions of the aliases which refer to the anchor ( e.g. `*a` )
+func (a *AnchorInfo) AliasPositions() []*token.Position {
+	positions := make([]*token.Position, 0, len(a.Aliases))
+	for _, alias := range a.Aliases {
+		positions = append(positions, alias.Start.Position)
+	}
+	return positions
+}
+
+// IsUnused whether no alias refers to the anchor. The anchor can be removed without changing the content of document
+func (a *AnchorInfo) IsUnused() bool {
+	return len(a.Aliases) == 0
+}
+
+// AnchorUsage returns the usage of all anchors in file in the order of definition.
+// Alias refers to the closest anchor of the same name defined before it in the same document,
+// so the anchor redefined by the same name is reported as the different anchor.
+// Aliases which refer to undefined anchor are not reported.
+func AnchorUsage(file *File) []*AnchorInfo {
+	c := &anchorUsageCollector{}
+	for _, doc := range file.Docs {
+		c.defined = map[string]*AnchorInfo{}
+		if doc.Body != nil {
+			WalkAll(c, doc.Body)
+		}
+	}
+	return c.anchors
+}
+
+type anchorUsageCollector struct {
+	anchors []*AnchorInfo
+	defined map[string]*AnchorInfo // anchors defined in the current document
+}
+
+func (c *anchorUsageCollector) Visit(node Node) Visitor {
+	switch n := node.(type) {
+	case *AnchorNode:
+		info := &AnchorInfo{Name: n.Name.GetToken().Value, Anchor: n}
+		c.anchors = append(c.anchors, info)
+		c.defined[info.Name] = info
+	case *AliasNode:
+		if info, exists := c.defined[n.Value.GetToken().Value]; exists {
+			info.Aliases = append(info.Aliases, n)
+		}
+	}
+	return c
+}
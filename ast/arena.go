@@ -0,0 +1,158 @@
+package ast
+
+import (
+	"unsafe"
+
+	"github.com/goccy/go-yaml/token"
+)
+
+const (
+	// arenaChunkSize number of nodes allocated at once for each the node type
+	arenaChunkSize = 64
+)
+
+// Arena allocates frequently used nodes from chunks instead of allocating them one by one.
+// Nodes allocated from the same Arena are released together
+// when all of them become unreachable ( e.g. when the parsed ast.File is discarded ),
+// which reduces the number of allocations and GC pressure for applications that parse many documents.
+type Arena struct {
+	nulls          []NullNode
+	bools          []BoolNode
+	integers       []IntegerNode
+	floats         []FloatNode
+	strings        []StringNode
+	mappings       []MappingNode
+	mappingValues  []MappingValueNode
+	sequences      []SequenceNode
+	allocatedNodes int
+	allocatedBytes int
+}
+
+// NewArena create Arena instance
+func NewArena() *Arena {
+	return &Arena{}
+}
+
+// AllocatedNodes returns the number of nodes allocated from Arena
+func (a *Arena) AllocatedNodes() int {
+	return a.allocatedNodes
+}
+
+// AllocatedBytes returns the total size of chunks reserved by Arena
+func (a *Arena) AllocatedBytes() int {
+	return a.allocatedBytes
+}
+
+// Null create node for null value from Arena
+func (a *Arena) Null(tk *token.Token) Node {
+	if len(a.nulls) == 0 {
+		a.nulls = make([]NullNode, arenaChunkSize)
+		a.allocatedBytes += arenaChunkSize * int(unsafe.Sizeof(NullNode{}))
+	}
+	n := &a.nulls[0]
+	a.nulls = a.nulls[1:]
+	a.allocatedNodes++
+	n.Token = tk
+	return n
+}
+
+// Bool create node for boolean value from Arena
+func (a *Arena) Bool(tk *token.Token) Node {
+	if len(a.bools) == 0 {
+		a.bools = make([]BoolNode, arenaChunkSize)
+		a.allocatedBytes += arenaChunkSize * int(unsafe.Sizeof(BoolNode{}))
+	}
+	n := &a.bools[0]
+	a.bools = a.bools[1:]
+	a.allocatedNodes++
+	n.Token = tk
+	n.Value = boolValue(tk)
+	return n
+}
+
+// Integer create node for integer value from Arena
+func (a *Arena) Integer(tk *token.Token) Node {
+	if len(a.integers) == 0 {
+		a.integers = make([]IntegerNode, arenaChunkSize)
+		a.allocatedBytes += arenaChunkSize * int(unsafe.Sizeof(IntegerNode{}))
+	}
+	n := &a.integers[0]
+	a.integers = a.integers[1:]
+	a.allocatedNodes++
+	n.Token = tk
+	n.Value = integerValue(tk)
+	return n
+}
+
+// Float create node for float value from Arena
+func (a *Arena) Float(tk *token.Token) Node {
+	if len(a.floats) == 0 {
+		a.floats = make([]FloatNode, arenaChunkSize)
+		a.allocatedBytes += arenaChunkSize * int(unsafe.Sizeof(FloatNode{}))
+	}
+	n := &a.floats[0]
+	a.floats = a.floats[1:]
+	a.allocatedNodes++
+	n.Token = tk
+	n.Value = floatValue(tk)
+	return n
+}
+
+// String create node for string value from Arena
+func (a *Arena) String(tk *token.Token) Node {
+	if len(a.strings) == 0 {
+		a.strings = make([]StringNode, arenaChunkSize)
+		a.allocatedBytes += arenaChunkSize * int(unsafe.Sizeof(StringNode{}))
+	}
+	n := &a.strings[0]
+	a.strings = a.strings[1:]
+	a.allocatedNodes++
+	n.Token = tk
+	n.Value = tk.Value
+	return n
+}
+
+// Mapping create node for map from Arena
+func (a *Arena) Mapping(tk *token.Token, isFlowStyle bool) *MappingNode {
+	if len(a.mappings) == 0 {
+		a.mappings = make([]MappingNode, arenaChunkSize)
+		a.allocatedBytes += arenaChunkSize * int(unsafe.Sizeof(MappingNode{}))
+	}
+	n := &a.mappings[0]
+	a.mappings = a.mappings[1:]
+	a.allocatedNodes++
+	n.Start = tk
+	n.IsFlowStyle = isFlowStyle
+	n.Values = []*MappingValueNode{}
+	return n
+}
+
+// MappingValue create node for mapping value from Arena
+func (a *Arena) MappingValue(tk *token.Token, key Node, value Node) *MappingValueNode {
+	if len(a.mappingValues) == 0 {
+		a.mappingValues = make([]MappingValueNode, arenaChunkSize)
+		a.allocatedBytes += arenaChunkSize * int(unsafe.Sizeof(MappingValueNode{}))
+	}
+	n := &a.mappingValues[0]
+	a.mappingValues = a.mappingValues[1:]
+	a.allocatedNodes++
+	n.Start = tk
+	n.Key = key
+	n.Value = value
+	return n
+}
+
+// Sequence create node for sequence from Arena
+func (a *Arena) Sequence(tk *token.Token, isFlowStyle bool) *SequenceNode {
+	if len(a.sequences) == 0 {
+		a.sequences = make([]SequenceNode, arenaChunkSize)
+		a.allocatedBytes += arenaChunkSize * int(unsafe.Sizeof(SequenceNode{}))
+	}
+	n := &a.sequences[0]
+	a.sequences = a.sequences[1:]
+	a.allocatedNodes++
+	n.Start = tk
+	n.IsFlowStyle = isFlowStyle
+	n.Values = []Node{}
+	return n
+}
@@ -0,0 +1,92 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/token"
+)
+
+func TestArena(t *testing.T) {
+	pos := &token.Position{Line: 1, Column: 1}
+	tests := []struct {
+		name     string
+		tk       *token.Token
+		alloc    func(*ast.Arena, *token.Token) ast.Node
+		expected func(*token.Token) ast.Node
+	}{
+		{"null", token.New("null", "null", pos), (*ast.Arena).Null, ast.Null},
+		{"bool", token.New("true", "true", pos), (*ast.Arena).Bool, ast.Bool},
+		{"integer", token.New("0x1F", "0x1F", pos), (*ast.Arena).Integer, ast.Integer},
+		{"float", token.New("1.5", "1.5", pos), (*ast.Arena).Float, ast.Float},
+		{"string", token.New("hello", "hello", pos), (*ast.Arena).String, ast.String},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			arena := ast.NewArena()
+			actual := test.alloc(arena, test.tk)
+			expected := test.expected(test.tk)
+			if actual.Type() != expected.Type() {
+				t.Fatalf("unexpected type: %s", actual.Type())
+			}
+			if actual.GetToken() != test.tk {
+				t.Fatal("token must be set to the node")
+			}
+			if actual.String() != expected.String() {
+				t.Fatalf("unexpected text: %s", actual.String())
+			}
+			if actual.(ast.ScalarNode).GetValue() != expected.(ast.ScalarNode).GetValue() {
+				t.Fatalf("unexpected value: %v", actual.(ast.ScalarNode).GetValue())
+			}
+		})
+	}
+}
+
+func TestArena_Collection(t *testing.T) {
+	arena := ast.NewArena()
+	pos := &token.Position{Line: 1, Column: 1}
+	mapping := arena.Mapping(token.New("{", "{", pos), true)
+	if !mapping.IsFlowStyle || mapping.Values == nil {
+		t.Fatalf("unexpected mapping: %+v", mapping)
+	}
+	key := arena.String(token.New("a", "a", pos))
+	value := arena.Integer(token.New("1", "1", pos))
+	mapping.Values = append(mapping.Values, arena.MappingValue(token.New(":", ":", pos), key, value))
+	sequence := arena.Sequence(token.New("[", "[", pos), true)
+	if !sequence.IsFlowStyle || sequence.Values == nil {
+		t.Fatalf("unexpected sequence: %+v", sequence)
+	}
+	sequence.Values = append(sequence.Values, mapping)
+	if actual := sequence.String(); actual != "[{a: 1}]" {
+		t.Fatalf("unexpected text: %s", actual)
+	}
+	if arena.AllocatedNodes() != 5 {
+		t.Fatalf("unexpected number of allocated nodes: %d", arena.AllocatedNodes())
+	}
+}
+
+func TestArena_Chunk(t *testing.T) {
+	arena := ast.NewArena()
+	pos := &token.Position{Line: 1, Column: 1}
+	first := arena.Integer(token.New("0", "0", pos))
+	chunkBytes := arena.AllocatedBytes()
+	if chunkBytes == 0 {
+		t.Fatal("failed to account allocated bytes")
+	}
+	nodes := map[ast.Node]struct{}{first: {}}
+	for i := 1; i < 100; i++ {
+		nodes[arena.Integer(token.New("1", "1", pos))] = struct{}{}
+	}
+	if len(nodes) != 100 {
+		t.Fatalf("nodes must be allocated at different addresses: %d", len(nodes))
+	}
+	if arena.AllocatedNodes() != 100 {
+		t.Fatalf("unexpected number of allocated nodes: %d", arena.AllocatedNodes())
+	}
+	if arena.AllocatedBytes() != chunkBytes*2 {
+		t.Fatalf("chunks must be reserved only when the previous chunk is used up: %d", arena.AllocatedBytes())
+	}
+	if v := first.(*ast.IntegerNode).Value; v != uint64(0) {
+		t.Fatalf("node must not be overwritten by the following allocation: %v", v)
+	}
+}
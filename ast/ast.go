@@ -5,6 +5,7 @@ import (
 	"math"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/goccy/go-yaml/token"
 )
@@ -102,12 +103,39 @@ type Node interface {
 	GetToken() *token.Token
 	// Type returns type of node
 	Type() NodeType
+	// Kind returns kind of node in the representation graph ( e.g. ScalarKind for all scalars )
+	Kind() Kind
+	// Style returns presentation style of node
+	Style() Style
+	// SetStyle changes presentation style of node rendered by String. Styles which the node cannot have are ignored
+	SetStyle(Style)
 }
 
 // File contains all documents in YAML file
 type File struct {
-	Name string
-	Docs []*Document
+	Name      string
+	Docs      []*Document
+	DocRanges []DocumentRange // byte ranges of Docs in source. DocRanges[i] is the range of Docs[i]
+	Stats     FileStats
+}
+
+// DocumentRange zero-based byte offset range of document in source.
+// It includes the directives, the leading comments, `---` and `...` of the document,
+// and the ranges of all documents in File cover the whole source without overlap,
+// so source[Start:End] can be replaced to edit only the document.
+type DocumentRange struct {
+	Start int
+	End   int // offset after the last character
+}
+
+// FileStats statistics collected while parsing a File with parser.CollectStats mode
+type FileStats struct {
+	Bytes          int           // size of source in bytes
+	Tokens         int           // number of tokens including comments
+	Nodes          int           // number of nodes in all documents
+	MaxDepth       int           // maximum nesting depth of nodes ( top level node is 1 )
+	AllocatedBytes int           // bytes reserved for nodes by Arena
+	ParseDuration  time.Duration // time spent to tokenize ( if source is given ) and parse
 }
 
 // String all documents to text
@@ -121,13 +149,18 @@ func (f *File) String() string {
 
 // Document type of Document
 type Document struct {
-	Start *token.Token // position of DocumentHeader ( `---` )
-	End   *token.Token // position of DocumentEnd ( `...` )
-	Body  Node
+	Comments
+	Directives []*DirectiveNode // directives before DocumentHeader ( e.g. `%YAML 1.2` )
+	Start      *token.Token     // position of DocumentHeader ( `---` )
+	End        *token.Token     // position of DocumentEnd ( `...` )
+	Body       Node
 }
 
 // GetToken returns token instance
 func (d *Document) GetToken() *token.Token {
+	if d.Body == nil {
+		return d.Start
+	}
 	return d.Body.GetToken()
 }
 
@@ -137,10 +170,25 @@ func (d *Document) Type() NodeType { return DocumentType }
 // String document to text
 func (d *Document) String() string {
 	doc := []string{}
+	if d.HeadComment != "" {
+		doc = append(doc, d.HeadComment)
+	}
+	for _, directive := range d.Directives {
+		doc = append(doc, directive.String())
+	}
 	if d.Start != nil {
-		doc = append(doc, d.Start.Value)
+		doc = append(doc, d.withLineComment(d.Start.Value))
+	}
+	if d.Body != nil {
+		body := d.Body.String()
+		if _, ok := d.Body.(*MappingValueNode); !ok {
+			body = commentsOf(d.Body).withHeadFootComment(body, "")
+		}
+		doc = append(doc, body)
+	}
+	if d.FootComment != "" {
+		doc = append(doc, d.FootComment)
 	}
-	doc = append(doc, d.Body.String())
 	if d.End != nil {
 		doc = append(doc, d.End.Value)
 	}
@@ -162,19 +210,30 @@ func Null(tk *token.Token) Node {
 
 // Bool create node for boolean value
 func Bool(tk *token.Token) Node {
-	b, _ := strconv.ParseBool(tk.Value)
 	return &BoolNode{
 		Token: tk,
-		Value: b,
+		Value: boolValue(tk),
 	}
 }
 
+func boolValue(tk *token.Token) bool {
+	b, _ := strconv.ParseBool(tk.Value)
+	return b
+}
+
 func removeUnderScoreFromNumber(num string) string {
 	return strings.ReplaceAll(num, "_", "")
 }
 
 // Integer create node for integer value
 func Integer(tk *token.Token) Node {
+	return &IntegerNode{
+		Token: tk,
+		Value: integerValue(tk),
+	}
+}
+
+func integerValue(tk *token.Token) interface{} {
 	value := removeUnderScoreFromNumber(tk.Value)
 	switch tk.Type {
 	case token.BinaryIntegerType:
@@ -187,31 +246,31 @@ func Integer(tk *token.Token) Node {
 		}
 		if len(negativePrefix) > 0 {
 			i, _ := strconv.ParseInt(negativePrefix+value[skipCharacterNum:], 2, 64)
-			return &IntegerNode{Token: tk, Value: i}
+			return i
 		}
 		i, _ := strconv.ParseUint(negativePrefix+value[skipCharacterNum:], 2, 64)
-		return &IntegerNode{Token: tk, Value: i}
+		return i
 	case token.OctetIntegerType:
 		// octet token starts with '0o' or '-0o' or '0' or '-0'
 		skipCharacterNum := 1
 		negativePrefix := ""
 		if value[0] == '-' {
 			skipCharacterNum++
-			if value[2] == 'o' {
+			if len(value) > 2 && value[2] == 'o' {
 				skipCharacterNum++
 			}
 			negativePrefix = "-"
 		} else {
-			if value[1] == 'o' {
+			if len(value) > 1 && value[1] == 'o' {
 				skipCharacterNum++
 			}
 		}
 		if len(negativePrefix) > 0 {
 			i, _ := strconv.ParseInt(negativePrefix+value[skipCharacterNum:], 8, 64)
-			return &IntegerNode{Token: tk, Value: i}
+			return i
 		}
 		i, _ := strconv.ParseUint(value[skipCharacterNum:], 8, 64)
-		return &IntegerNode{Token: tk, Value: i}
+		return i
 	case token.HexIntegerType:
 		// hex token starts with '0x' or '-0x'
 		skipCharacterNum := 2
@@ -222,35 +281,39 @@ func Integer(tk *token.Token) Node {
 		}
 		if len(negativePrefix) > 0 {
 			i, _ := strconv.ParseInt(negativePrefix+value[skipCharacterNum:], 16, 64)
-			return &IntegerNode{Token: tk, Value: i}
+			return i
 		}
 		i, _ := strconv.ParseUint(value[skipCharacterNum:], 16, 64)
-		return &IntegerNode{Token: tk, Value: i}
+		return i
 	}
 	if value[0] == '-' || value[0] == '+' {
 		i, _ := strconv.ParseInt(value, 10, 64)
-		return &IntegerNode{Token: tk, Value: i}
+		return i
 	}
 	i, _ := strconv.ParseUint(value, 10, 64)
-	return &IntegerNode{Token: tk, Value: i}
+	return i
 }
 
 // Float create node for float value
 func Float(tk *token.Token) Node {
-	f, _ := strconv.ParseFloat(removeUnderScoreFromNumber(tk.Value), 64)
 	return &FloatNode{
 		Token: tk,
-		Value: f,
+		Value: floatValue(tk),
 	}
 }
 
+func floatValue(tk *token.Token) float64 {
+	f, _ := strconv.ParseFloat(removeUnderScoreFromNumber(tk.Value), 64)
+	return f
+}
+
 // Infinity create node for .inf or -.inf value
 func Infinity(tk *token.Token) Node {
 	node := &InfinityNode{
 		Token: tk,
 	}
 	switch tk.Value {
-	case ".inf", ".Inf", ".INF":
+	case ".inf", ".Inf", ".INF", "+.inf", "+.Inf", "+.INF":
 		node.Value = math.Inf(0)
 	case "-.inf", "-.Inf", "-.INF":
 		node.Value = math.Inf(-1)
@@ -299,6 +362,7 @@ func Sequence(tk *token.Token, isFlowStyle bool) *SequenceNode {
 // NullNode type of null node
 type NullNode struct {
 	ScalarNode
+	Comments
 	Token *token.Token
 }
 
@@ -315,14 +379,24 @@ func (n *NullNode) GetValue() interface{} {
 	return nil
 }
 
-// String returns `null` text
+// String returns `null` text.
+// The null which isn't written in source ( e.g. the value of `a:` followed by the sibling key ) is rendered as empty text.
 func (n *NullNode) String() string {
-	return "null"
+	if n.isImplicit() {
+		return n.LineComment
+	}
+	return n.withLineComment("null")
+}
+
+// isImplicit whether the null isn't written in source. parser creates such a null with the token which has empty origin
+func (n *NullNode) isImplicit() bool {
+	return n.Token != nil && n.Token.Origin == "" && n.Token.Value == "null"
 }
 
 // IntegerNode type of integer node
 type IntegerNode struct {
 	ScalarNode
+	Comments
 	Token *token.Token
 	Value interface{} // int64 or uint64 value
 }
@@ -342,12 +416,13 @@ func (n *IntegerNode) GetValue() interface{} {
 
 // String int64 to text
 func (n *IntegerNode) String() string {
-	return n.Token.Value
+	return n.withLineComment(n.Token.Value)
 }
 
 // FloatNode type of float node
 type FloatNode struct {
 	ScalarNode
+	Comments
 	Token     *token.Token
 	Precision int
 	Value     float64
@@ -368,12 +443,13 @@ func (n *FloatNode) GetValue() interface{} {
 
 // String float64 to text
 func (n *FloatNode) String() string {
-	return n.Token.Value
+	return n.withLineComment(n.Token.Value)
 }
 
 // StringNode type of string node
 type StringNode struct {
 	ScalarNode
+	Comments
 	Token *token.Token
 	Value string
 }
@@ -393,11 +469,19 @@ func (n *StringNode) GetValue() interface{} {
 
 // String string value to text with quote if required
 func (n *StringNode) String() string {
+	return n.withLineComment(n.stringValue())
+}
+
+func (n *StringNode) stringValue() string {
 	switch n.Token.Type {
 	case token.SingleQuoteType:
-		return fmt.Sprintf(`'%s'`, n.Value)
+		if strings.IndexByte(n.Value, '\n') < 0 {
+			return fmt.Sprintf(`'%s'`, strings.ReplaceAll(n.Value, "'", "''"))
+		}
+		// line break in single quoted scalar is folded, so escape it by double quote
+		return strconv.Quote(n.Value)
 	case token.DoubleQuoteType:
-		return fmt.Sprintf(`"%s"`, n.Value)
+		return strconv.Quote(n.Value)
 	}
 	return n.Value
 }
@@ -405,6 +489,7 @@ func (n *StringNode) String() string {
 // LiteralNode type of literal node
 type LiteralNode struct {
 	ScalarNode
+	Comments
 	Start *token.Token
 	Value *StringNode
 }
@@ -422,14 +507,24 @@ func (n *LiteralNode) GetValue() interface{} {
 	return n.Value.GetValue()
 }
 
-// String literal to text
+// String literal to text.
+// The content of parsed literal is rendered as it is in source.
+// The content of literal built without source ( e.g. by encoder ) is indented by the column of value token.
 func (n *LiteralNode) String() string {
-	return n.Value.String()
+	tk := n.Value.GetToken()
+	content := strings.TrimRight(tk.Origin, " ")
+	if content == "" {
+		content = blockContent(n.Start.Value, n.Value.Value, columnOf(tk))
+	}
+	// the line break at the end of literal is added by parent node
+	content = strings.TrimSuffix(content, "\n")
+	return n.withLineComment(fmt.Sprintf("%s\n%s", n.Start.Value, content))
 }
 
 // MergeKeyNode type of merge key node
 type MergeKeyNode struct {
 	ScalarNode
+	Comments
 	Token *token.Token
 }
 
@@ -454,6 +549,7 @@ func (n *MergeKeyNode) String() string {
 // BoolNode type of boolean node
 type BoolNode struct {
 	ScalarNode
+	Comments
 	Token *token.Token
 	Value bool
 }
@@ -473,12 +569,13 @@ func (n *BoolNode) GetValue() interface{} {
 
 // String boolean to text
 func (n *BoolNode) String() string {
-	return n.Token.Value
+	return n.withLineComment(n.Token.Value)
 }
 
 // InfinityNode type of infinity node
 type InfinityNode struct {
 	ScalarNode
+	Comments
 	Token *token.Token
 	Value float64
 }
@@ -498,12 +595,13 @@ func (n *InfinityNode) GetValue() interface{} {
 
 // String infinity to text
 func (n *InfinityNode) String() string {
-	return n.Token.Value
+	return n.withLineComment(n.Token.Value)
 }
 
 // NanNode type of nan node
 type NanNode struct {
 	ScalarNode
+	Comments
 	Token *token.Token
 }
 
@@ -522,7 +620,7 @@ func (n *NanNode) GetValue() interface{} {
 
 // String returns .nan
 func (n *NanNode) String() string {
-	return n.Token.Value
+	return n.withLineComment(n.Token.Value)
 }
 
 // MapNode interface of MappingValueNode / MappingNode
@@ -560,6 +658,7 @@ func (m *MapNodeIter) Value() Node {
 
 // MappingNode type of mapping node
 type MappingNode struct {
+	Comments
 	Start       *token.Token
 	End         *token.Token
 	IsFlowStyle bool
@@ -576,13 +675,13 @@ func (n *MappingNode) GetToken() *token.Token {
 
 func (n *MappingNode) flowStyleString() string {
 	if len(n.Values) == 0 {
-		return "{}"
+		return n.withLineComment("{}")
 	}
 	values := []string{}
 	for _, value := range n.Values {
 		values = append(values, strings.TrimLeft(value.String(), " "))
 	}
-	return fmt.Sprintf("{%s}", strings.Join(values, ", "))
+	return n.withLineComment(fmt.Sprintf("{%s}", strings.Join(values, ", ")))
 }
 
 func (n *MappingNode) blockStyleString() string {
@@ -593,7 +692,7 @@ func (n *MappingNode) blockStyleString() string {
 	for _, value := range n.Values {
 		values = append(values, value.String())
 	}
-	return strings.Join(values, "\n")
+	return strings.Join(alignValues(values), "\n")
 }
 
 // String mapping values to text
@@ -614,6 +713,7 @@ func (n *MappingNode) MapRange() *MapNodeIter {
 
 // MappingValueNode type of mapping value
 type MappingValueNode struct {
+	Comments
 	Start *token.Token
 	Key   Node
 	Value Node
@@ -627,25 +727,56 @@ func (n *MappingValueNode) GetToken() *token.Token {
 	return n.Start
 }
 
-// String mapping value to text
+// String mapping value to text with comments
 func (n *MappingValueNode) String() string {
-	space := strings.Repeat(" ", n.Key.GetToken().Position.Column-1)
-	keyIndentLevel := n.Key.GetToken().Position.IndentLevel
-	valueIndentLevel := n.Value.GetToken().Position.IndentLevel
+	space := strings.Repeat(" ", columnOf(n.Key.GetToken())-1)
+	return n.withHeadFootComment(n.withLineComment(n.stringWithoutComment()), space)
+}
+
+// stringWithoutComment mapping value to text without own comments.
+// Comments of mapping value rendered by the parent node ( e.g. SequenceNode ) use this.
+func (n *MappingValueNode) stringWithoutComment() string {
+	space := strings.Repeat(" ", columnOf(n.Key.GetToken())-1)
+	keyIndentLevel := indentLevelOf(n.Key.GetToken())
+	valueIndentLevel := indentLevelOf(n.Value.GetToken())
+	if commentsOf(n.Value).HeadComment != "" {
+		// head comment of value is placed between key and value
+		return fmt.Sprintf("%s%s:\n%s", space, n.Key.String(), n.alignValue(n.valueStringWithComment(), 0))
+	}
 	if _, ok := n.Value.(ScalarNode); ok {
+		if value := n.Value.String(); value == "" {
+			return fmt.Sprintf("%s%s:", space, n.Key.String())
+		}
 		return fmt.Sprintf("%s%s: %s", space, n.Key.String(), n.Value.String())
-	} else if keyIndentLevel < valueIndentLevel {
-		return fmt.Sprintf("%s%s:\n%s", space, n.Key.String(), n.Value.String())
 	} else if m, ok := n.Value.(*MappingNode); ok && m.IsFlowStyle {
 		return fmt.Sprintf("%s%s: %s", space, n.Key.String(), n.Value.String())
 	} else if s, ok := n.Value.(*SequenceNode); ok && s.IsFlowStyle {
 		return fmt.Sprintf("%s%s: %s", space, n.Key.String(), n.Value.String())
+	} else if keyIndentLevel < valueIndentLevel {
+		return fmt.Sprintf("%s%s:\n%s", space, n.Key.String(), n.alignValue(n.valueStringWithComment(), 0))
 	} else if _, ok := n.Value.(*AnchorNode); ok {
-		return fmt.Sprintf("%s%s: %s", space, n.Key.String(), n.Value.String())
+		return fmt.Sprintf("%s%s: %s", space, n.Key.String(), n.alignValue(n.Value.String(), 1))
 	} else if _, ok := n.Value.(*AliasNode); ok {
 		return fmt.Sprintf("%s%s: %s", space, n.Key.String(), n.Value.String())
+	} else if _, ok := n.Value.(*TagNode); ok {
+		return fmt.Sprintf("%s%s: %s", space, n.Key.String(), n.alignValue(n.Value.String(), 1))
 	}
-	return fmt.Sprintf("%s%s:\n%s", space, n.Key.String(), n.Value.String())
+	return fmt.Sprintf("%s%s:\n%s", space, n.Key.String(), n.alignValue(n.valueStringWithComment(), 0))
+}
+
+// valueStringWithComment value to text with head and foot comments of value placed at the next line of key
+func (n *MappingValueNode) valueStringWithComment() string {
+	value := n.Value.String()
+	if _, ok := n.Value.(*MappingValueNode); ok {
+		return value
+	}
+	comments := commentsOf(n.Value)
+	space := strings.Repeat(" ", columnOf(n.Value.GetToken())-1)
+	if comments.HeadComment != "" && !isBlockCollection(n.Value) {
+		// text of block collection is already indented
+		value = space + value
+	}
+	return comments.withHeadFootComment(value, space)
 }
 
 // MapRange implements MapNode protocol
@@ -687,10 +818,14 @@ func (m *ArrayNodeIter) Len() int {
 
 // SequenceNode type of sequence node
 type SequenceNode struct {
+	Comments
 	Start       *token.Token
 	End         *token.Token
 	IsFlowStyle bool
-	Values      []Node
+	// IsMappingOnNextLine whether block mapping values start at the line next to `-` ( e.g. "-\n  a: b" ).
+	// If false, the first key of mapping is on the line of `-` ( e.g. "- a: b" )
+	IsMappingOnNextLine bool
+	Values              []Node
 }
 
 // Type returns SequenceType
@@ -706,28 +841,73 @@ func (n *SequenceNode) flowStyleString() string {
 	for _, value := range n.Values {
 		values = append(values, value.String())
 	}
-	return fmt.Sprintf("[%s]", strings.Join(values, ", "))
+	return n.withLineComment(fmt.Sprintf("[%s]", strings.Join(values, ", ")))
 }
 
 func (n *SequenceNode) blockStyleString() string {
-	space := strings.Repeat(" ", n.Start.Position.Column-1)
+	space := strings.Repeat(" ", columnOf(n.Start)-1)
 	values := []string{}
 	for _, value := range n.Values {
-		valueStr := value.String()
+		var valueStr string
+		if mv, ok := value.(*MappingValueNode); ok {
+			// head and foot comments of entry are placed at the indent of `-`
+			valueStr = mv.withLineComment(mv.stringWithoutComment())
+		} else {
+			valueStr = value.String()
+		}
 		splittedValues := strings.Split(valueStr, "\n")
 		trimmedFirstValue := strings.TrimLeft(splittedValues[0], " ")
 		diffLength := len(splittedValues[0]) - len(trimmedFirstValue)
+		if literal, ok := value.(*LiteralNode); ok {
+			// content of literal is indented from the column of content instead of the header
+			diffLength = columnOf(literal.Value.GetToken()) - 1
+		}
 		newValues := []string{trimmedFirstValue}
-		for i := 1; i < len(splittedValues); i++ {
-			trimmed := splittedValues[i][diffLength:]
-			newValues = append(newValues, fmt.Sprintf("%s  %s", space, trimmed))
+		lines := splittedValues[1:]
+		shiftLines(lines, -diffLength)
+		for _, line := range lines {
+			if line == "" {
+				// empty line in literal
+				newValues = append(newValues, line)
+				continue
+			}
+			newValues = append(newValues, fmt.Sprintf("%s  %s", space, line))
 		}
 		newValue := strings.Join(newValues, "\n")
-		values = append(values, fmt.Sprintf("%s- %s", space, newValue))
+		comments := commentsOf(value)
+		if isBlockMapping(value) && (n.IsMappingOnNextLine || hasEntryComment(value)) {
+			entry := fmt.Sprintf("%s-\n%s  %s", space, space, newValue)
+			if _, ok := value.(*MappingNode); ok {
+				// line comment of mapping is placed after `-`
+				entry = comments.withLineComment(entry)
+			}
+			values = append(values, comments.withHeadFootComment(entry, space))
+			continue
+		}
+		values = append(values, comments.withHeadFootComment(fmt.Sprintf("%s- %s", space, newValue), space))
 	}
 	return strings.Join(values, "\n")
 }
 
+// hasEntryComment whether block mapping has the comment which cannot be placed on the line of `-`
+func hasEntryComment(node Node) bool {
+	m, ok := node.(*MappingNode)
+	if !ok || len(m.Values) == 0 {
+		return false
+	}
+	return m.LineComment != "" || m.Values[0].HeadComment != ""
+}
+
+func isBlockMapping(node Node) bool {
+	switch n := node.(type) {
+	case *MappingNode:
+		return !n.IsFlowStyle && len(n.Values) > 0
+	case *MappingValueNode:
+		return true
+	}
+	return false
+}
+
 // String sequence to text
 func (n *SequenceNode) String() string {
 	if n.IsFlowStyle {
@@ -746,6 +926,7 @@ func (n *SequenceNode) ArrayRange() *ArrayNodeIter {
 
 // AnchorNode type of anchor node
 type AnchorNode struct {
+	Comments
 	Start *token.Token
 	Name  Node
 	Value Node
@@ -762,14 +943,15 @@ func (n *AnchorNode) GetToken() *token.Token {
 // String anchor to text
 func (n *AnchorNode) String() string {
 	value := n.Value.String()
-	if len(strings.Split(value, "\n")) > 1 {
-		return fmt.Sprintf("&%s\n%s", n.Name.String(), value)
+	if len(strings.Split(value, "\n")) > 1 || isBlockMapping(n.Value) {
+		return n.withLineComment(fmt.Sprintf("&%s\n%s", n.Name.String(), value))
 	}
-	return fmt.Sprintf("&%s %s", n.Name.String(), value)
+	return n.withLineComment(fmt.Sprintf("&%s %s", n.Name.String(), value))
 }
 
 // AliasNode type of alias node
 type AliasNode struct {
+	Comments
 	Start *token.Token
 	Value Node
 }
@@ -784,7 +966,7 @@ func (n *AliasNode) GetToken() *token.Token {
 
 // String alias to text
 func (n *AliasNode) String() string {
-	return fmt.Sprintf("*%s", n.Value.String())
+	return n.withLineComment(fmt.Sprintf("*%s", n.Value.String()))
 }
 
 // DirectiveNode type of directive node
@@ -806,10 +988,38 @@ func (n *DirectiveNode) String() string {
 	return fmt.Sprintf("%s%s", n.Start.Value, n.Value.String())
 }
 
+// Name returns name of directive ( e.g. `YAML` for `%YAML 1.2` )
+func (n *DirectiveNode) Name() string {
+	if n.Value == nil {
+		return ""
+	}
+	fields := strings.Fields(n.Value.GetToken().Value)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// Parameters returns parameters of directive ( e.g. `["!e!", "tag:example.com,2000:"]` for `%TAG !e! tag:example.com,2000:` )
+func (n *DirectiveNode) Parameters() []string {
+	if n.Value == nil {
+		return nil
+	}
+	fields := strings.Fields(n.Value.GetToken().Value)
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields[1:]
+}
+
 // TagNode type of tag node
 type TagNode struct {
+	Comments
 	Start *token.Token
 	Value Node
+	// URI full tag resolved by the tag handle ( e.g. `tag:yaml.org,2002:str` for `!!str` ).
+	// It's empty for the non-specific tag `!` and the node which isn't created by parser.
+	URI string
 }
 
 // Type returns TagType
@@ -822,7 +1032,72 @@ func (n *TagNode) GetToken() *token.Token {
 
 // String tag to text
 func (n *TagNode) String() string {
-	return fmt.Sprintf("%s %s", n.Start.Value, n.Value.String())
+	tag := n.withLineComment(n.Start.Value)
+	switch v := n.Value.(type) {
+	case *MappingValueNode:
+		return fmt.Sprintf("%s\n%s", tag, v.String())
+	case *MappingNode:
+		if !v.IsFlowStyle {
+			return fmt.Sprintf("%s\n%s", tag, v.String())
+		}
+	case *SequenceNode:
+		if !v.IsFlowStyle {
+			return fmt.Sprintf("%s\n%s", tag, v.String())
+		}
+	}
+	return fmt.Sprintf("%s %s", tag, n.Value.String())
+}
+
+// IsNull whether node is null ( e.g. `null`, `~` or the empty value of `key:` ).
+// nil node is also null. Anchors and tags are looked through, but aliases are not resolved.
+func IsNull(node Node) bool {
+	switch n := node.(type) {
+	case nil:
+		return true
+	case *NullNode:
+		return true
+	case *AnchorNode:
+		return IsNull(n.Value)
+	case *TagNode:
+		return IsNull(n.Value)
+	}
+	return false
+}
+
+// IsEmpty whether node is null, empty mapping ( `{}` ), empty sequence ( `[]` ) or empty string ( e.g. `""` ).
+// Anchors and tags are looked through, but aliases are not resolved.
+// Zero values of the other scalars ( e.g. `0` or `false` ) are not empty.
+func IsEmpty(node Node) bool {
+	if IsNull(node) {
+		return true
+	}
+	switch n := node.(type) {
+	case *MappingNode:
+		return len(n.Values) == 0
+	case *SequenceNode:
+		return len(n.Values) == 0
+	case *StringNode:
+		return n.Value == ""
+	case *LiteralNode:
+		return n.Value == nil || n.Value.Value == ""
+	case *AnchorNode:
+		return IsEmpty(n.Value)
+	case *TagNode:
+		return IsEmpty(n.Value)
+	}
+	return false
+}
+
+// Unwrap returns the value of AnchorNode or TagNode.
+// Nested anchors and tags ( e.g. `&a !!str b` ) are unwrapped until the other node appears.
+func Unwrap(node Node) Node {
+	switch n := node.(type) {
+	case *AnchorNode:
+		return Unwrap(n.Value)
+	case *TagNode:
+		return Unwrap(n.Value)
+	}
+	return node
 }
 
 // Visitor has Visit method that is invokded for each node encountered by Walk.
@@ -836,7 +1111,17 @@ type Visitor interface {
 // If the visitor w returned by v.Visit(node) is not nil,
 // Walk is invoked recursively with visitor w for each of the non-nil children of node,
 // followed by a call of w.Visit(nil).
+// The values of TagNode, LiteralNode and DirectiveNode aren't visited. Use WalkAll to visit them.
 func Walk(v Visitor, node Node) {
+	walk(v, node, false)
+}
+
+// WalkAll traverses an AST like Walk, and also visits the values of TagNode, LiteralNode and DirectiveNode.
+func WalkAll(v Visitor, node Node) {
+	walk(v, node, true)
+}
+
+func walk(v Visitor, node Node, all bool) {
 	if v = v.Visit(node); v == nil {
 		return
 	}
@@ -852,19 +1137,61 @@ func Walk(v Visitor, node Node) {
 	case *NanNode:
 	case *MappingNode:
 		for _, value := range n.Values {
-			Walk(v, value)
+			walk(v, value, all)
 		}
 	case *MappingValueNode:
-		Walk(v, n.Key)
-		Walk(v, n.Value)
+		walk(v, n.Key, all)
+		walk(v, n.Value, all)
 	case *SequenceNode:
 		for _, value := range n.Values {
-			Walk(v, value)
+			walk(v, value, all)
 		}
 	case *AnchorNode:
-		Walk(v, n.Name)
-		Walk(v, n.Value)
+		walk(v, n.Name, all)
+		walk(v, n.Value, all)
 	case *AliasNode:
-		Walk(v, n.Value)
+		walk(v, n.Value, all)
+	case *TagNode:
+		if all && n.Value != nil {
+			walk(v, n.Value, all)
+		}
+	case *LiteralNode:
+		if all && n.Value != nil {
+			walk(v, n.Value, all)
+		}
+	case *DirectiveNode:
+		if all && n.Value != nil {
+			walk(v, n.Value, all)
+		}
+	}
+}
+
+// Tokens returns the tokens of node and its children to find the range of node in source.
+// The end tokens of flow collections ( `}` and `]` ) are included, but nil tokens are not.
+// The tokens are collected by WalkAll, so they are not sorted by the position.
+func Tokens(node Node) []*token.Token {
+	c := &tokenCollector{}
+	WalkAll(c, node)
+	return c.tokens
+}
+
+type tokenCollector struct {
+	tokens []*token.Token
+}
+
+func (c *tokenCollector) Visit(node Node) Visitor {
+	c.add(node.GetToken())
+	switch n := node.(type) {
+	case *MappingNode:
+		c.add(n.End)
+	case *SequenceNode:
+		c.add(n.End)
+	}
+	return c
+}
+
+func (c *tokenCollector) add(tk *token.Token) {
+	if tk != nil {
+		c.tokens = append(c.tokens, tk)
 	}
 }
@@ -0,0 +1,37 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+type nodeCounter struct {
+	nodes []ast.Node
+}
+
+func (c *nodeCounter) Visit(node ast.Node) ast.Visitor {
+	c.nodes = append(c.nodes, node)
+	return c
+}
+
+func TestWalk(t *testing.T) {
+	f, err := parser.ParseBytes([]byte("a: !!str 1\nb: |\n  c\n"), 0)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	body := f.Docs[0].Body
+	var walked nodeCounter
+	ast.Walk(&walked, body)
+	// mapping, the mapping values and keys of a and b, tag and literal
+	if len(walked.nodes) != 7 {
+		t.Fatalf("unexpected number of nodes visited by Walk: %d", len(walked.nodes))
+	}
+	var walkedAll nodeCounter
+	ast.WalkAll(&walkedAll, body)
+	// and the values of tag and literal too
+	if len(walkedAll.nodes) != 9 {
+		t.Fatalf("unexpected number of nodes visited by WalkAll: %d", len(walkedAll.nodes))
+	}
+}
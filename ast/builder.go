@@ -0,0 +1,105 @@
+package ast
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml/token"
+)
+
+const (
+	// builderIndent number of spaces to indent the block mapping in the mapping value created by NewMappingValue
+	builderIndent = 2
+)
+
+// newPosition create position of the node built programmatically.
+// Nodes start from the first column, and the column is shifted when the node is put in the block collection.
+func newPosition() *token.Position {
+	return &token.Position{Line: 1, Column: 1}
+}
+
+// NewNull create node for null value without source text
+func NewNull() *NullNode {
+	return Null(token.New("null", "null", newPosition())).(*NullNode)
+}
+
+// NewBool create node for boolean value without source text
+func NewBool(v bool) *BoolNode {
+	value := strconv.FormatBool(v)
+	return Bool(token.New(value, value, newPosition())).(*BoolNode)
+}
+
+// NewInteger create node for integer value without source text
+func NewInteger(v int64) *IntegerNode {
+	value := strconv.FormatInt(v, 10)
+	return Integer(token.New(value, value, newPosition())).(*IntegerNode)
+}
+
+// NewFloat create node for float value without source text
+func NewFloat(v float64) *FloatNode {
+	value := strconv.FormatFloat(v, 'g', -1, 64)
+	if !strings.ContainsAny(value, ".eEn") {
+		// keep the text as float ( e.g. `1.0` instead of `1` )
+		value += ".0"
+	}
+	return Float(token.New(value, value, newPosition())).(*FloatNode)
+}
+
+// NewString create node for string value without source text.
+// The value is double quoted if it is read as another type or it has special characters ( e.g. `"true"`, `"a: b"` ).
+func NewString(v string) *StringNode {
+	var tk *token.Token
+	if token.IsNeedQuoted(v) {
+		tk = token.DoubleQuote(v, strconv.Quote(v), newPosition())
+	} else {
+		tk = token.New(v, v, newPosition())
+	}
+	return String(tk).(*StringNode)
+}
+
+// NewMappingValue create node for key value pair without source text ( e.g. `key: value` ).
+// Block mapping of value is indented under the key.
+func NewMappingValue(key string, value Node) *MappingValueNode {
+	if value == nil {
+		value = NewNull()
+	}
+	if isBlockMapping(Unwrap(value)) {
+		shiftColumn(value, builderIndent)
+	}
+	return &MappingValueNode{
+		Start: token.MappingValue(newPosition()),
+		Key:   NewString(key),
+		Value: value,
+	}
+}
+
+// NewMapping create node for block mapping which has the pairs without source text.
+// Empty mapping is flow style ( e.g. `{}` ) because it cannot be rendered by block style.
+func NewMapping(values ...*MappingValueNode) *MappingNode {
+	node := Mapping(token.New("", "", newPosition()), len(values) == 0)
+	node.Values = append(node.Values, values...)
+	return node
+}
+
+// NewSequence create node for block sequence which has the values without source text.
+// Empty sequence is flow style ( e.g. `[]` ) because it cannot be rendered by block style.
+func NewSequence(values ...Node) *SequenceNode {
+	node := Sequence(token.SequenceEntry("-", newPosition()), len(values) == 0)
+	node.Values = append(node.Values, values...)
+	return node
+}
+
+// NewDocument create document which has the body without source text
+func NewDocument(body Node) *Document {
+	return &Document{Body: body}
+}
+
+// NewComment create comment lines for HeadComment, LineComment or FootComment of Comments.
+// Each text doesn't include `#` character ( e.g. NewComment(" comment") returns `# comment` ).
+func NewComment(texts ...string) string {
+	lines := make([]string, 0, len(texts))
+	for _, text := range texts {
+		lines = append(lines, "#"+text)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,98 @@
+package ast
+
+import (
+	"strings"
+)
+
+// Comments comments associated with the node.
+// Each comment includes `#` character, and comment lines are joined by line break.
+type Comments struct {
+	// HeadComment comment lines in front of the node ( e.g. `# head` for "# head\na: b" )
+	HeadComment string
+	// LineComment comment at the end of the line of the node ( e.g. `# line` for "a: b # line" )
+	LineComment string
+	// FootComment comment lines after the node that precede the dedented node ( e.g. `# foot` for "a:\n  b: c\n  # foot\nd: e" )
+	FootComment string
+}
+
+// GetComments returns comments associated with the node
+func (c *Comments) GetComments() *Comments {
+	return c
+}
+
+// CommentedNode type of node which can have comments
+type CommentedNode interface {
+	Node
+	GetComments() *Comments
+}
+
+// withLineComment returns text appended line comment to the end of the first line
+func (c *Comments) withLineComment(text string) string {
+	if c.LineComment == "" {
+		return text
+	}
+	if idx := strings.IndexByte(text, '\n'); idx >= 0 {
+		return text[:idx] + " " + c.LineComment + text[idx:]
+	}
+	return text + " " + c.LineComment
+}
+
+// withHeadFootComment returns text surrounded by head comment and foot comment.
+// Each comment line is indented by space.
+func (c *Comments) withHeadFootComment(text, space string) string {
+	if c.HeadComment != "" {
+		text = indentComment(c.HeadComment, space) + "\n" + text
+	}
+	if c.FootComment != "" {
+		text = text + "\n" + indentComment(c.FootComment, space)
+	}
+	return text
+}
+
+// WrapComment wraps the comment lines ( e.g. `# text` ) longer than width at spaces,
+// assuming that they are indented to column. Wrapped lines keep `#` and the spaces following it.
+// Each line has one word at least, so the word longer than width isn't split.
+func WrapComment(comment string, column, width int) string {
+	lines := strings.Split(comment, "\n")
+	wrapped := make([]string, 0, len(lines))
+	for _, line := range lines {
+		wrapped = append(wrapped, wrapCommentLine(line, width-(column-1))...)
+	}
+	return strings.Join(wrapped, "\n")
+}
+
+func wrapCommentLine(line string, width int) []string {
+	if len(line) <= width || !strings.HasPrefix(line, "#") {
+		return []string{line}
+	}
+	text := strings.TrimLeft(line[1:], " ")
+	prefix := line[:len(line)-len(text)]
+	var lines []string
+	current := prefix
+	for _, word := range strings.Fields(text) {
+		if current != prefix && len(current)+len(" ")+len(word) > width {
+			lines = append(lines, current)
+			current = prefix
+		}
+		if current != prefix {
+			current += " "
+		}
+		current += word
+	}
+	return append(lines, current)
+}
+
+func indentComment(comment, space string) string {
+	lines := strings.Split(comment, "\n")
+	for idx, line := range lines {
+		lines[idx] = space + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+func commentsOf(node Node) *Comments {
+	if n, ok := node.(CommentedNode); ok {
+		return n.GetComments()
+	}
+	return &Comments{}
+}
@@ -0,0 +1,391 @@
+package ast
+
+import (
+	"fmt"
+
+	"github.com/goccy/go-yaml/token"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// DefaultMaxAliasCount default limit of the number of alias expansions in a document
+	DefaultMaxAliasCount = 100000
+	// DefaultAliasDepthLimit default limit of the depth of nested alias expansion ( e.g. alias in the anchored value )
+	DefaultAliasDepthLimit = 100
+)
+
+// AliasLimitError error returned when the expansion of aliases exceeds DefaultMaxAliasCount or DefaultAliasDepthLimit
+// ( e.g. "billion laughs" which expands exponentially by the aliases to the anchored values which have aliases ).
+type AliasLimitError struct {
+	Alias *AliasNode // alias which exceeds the limit
+	Kind  string     // kind of the limit ( "number" or "depth" )
+	Limit int
+}
+
+func (e *AliasLimitError) Error() string {
+	pos := e.Alias.Start.Position
+	return fmt.Sprintf("alias *%s at line %d, column %d exceeds the limit %d of the %s of alias expansions",
+		e.Alias.Value.GetToken().Value, pos.Line, pos.Column, e.Limit, e.Kind)
+}
+
+// ExpandAliases returns deep copy of doc whose aliases are replaced by the copy of the anchored values
+// and merge keys ( `<<` ) are replaced by the mapping values of the merged mappings.
+// Anchors are removed from the copy, so consumers ( e.g. diff, hashing or conversion to JSON ) never see them.
+// Keys defined explicitly take precedence over merged keys, and the first merged mapping takes precedence over the others.
+// Columns of the copied nodes are adjusted to the place of the alias, so the copy can be printed as YAML.
+// doc is not modified. An error is returned if an alias refers to the undefined anchor or the anchor which contains the alias itself,
+// and *AliasLimitError is returned if the expansion exceeds DefaultMaxAliasCount or DefaultAliasDepthLimit.
+func ExpandAliases(doc *Document) (*Document, error) {
+	e := &aliasExpander{
+		anchors:   map[string]*anchorDefinition{},
+		expanding: map[string]bool{},
+	}
+	return e.expandDocument(doc)
+}
+
+// ExpandMergeKeys returns deep copy of doc whose merge keys ( `<<` ) are replaced by the mapping values of the merged mappings
+// in the same way as ExpandAliases. Unlike ExpandAliases, the other anchors and aliases are kept in the copy,
+// so the copy has the same content as doc without relying on merge keys.
+// doc is not modified. The expansion of aliases is limited like ExpandAliases.
+func ExpandMergeKeys(doc *Document) (*Document, error) {
+	e := &aliasExpander{
+		anchors:      map[string]*anchorDefinition{},
+		expanding:    map[string]bool{},
+		keepsAliases: true,
+	}
+	return e.expandDocument(doc)
+}
+
+func (e *aliasExpander) expandDocument(doc *Document) (*Document, error) {
+	expanded := &Document{
+		Start: copyToken(doc.Start),
+		End:   copyToken(doc.End),
+	}
+	for _, directive := range doc.Directives {
+		value, err := e.expand(directive.Value, 0)
+		if err != nil {
+			return nil, err
+		}
+		expanded.Directives = append(expanded.Directives, &DirectiveNode{
+			Start: copyToken(directive.Start),
+			Value: value,
+		})
+	}
+	if doc.Body != nil {
+		body, err := e.expand(doc.Body, 0)
+		if err != nil {
+			return nil, err
+		}
+		expanded.Body = body
+	}
+	return expanded, nil
+}
+
+type anchorDefinition struct {
+	value  Node
+	column int // column of the key or the sequence entry which has the anchor
+}
+
+type aliasExpander struct {
+	anchors   map[string]*anchorDefinition
+	expanding map[string]bool
+
+	// defined has names of anchors defined in the copy.
+	// It is set only by Extract, which keeps anchors, aliases and merge keys in the copy
+	defined map[string]bool
+
+	// keepsAliases is set by ExpandMergeKeys, which expands only merge keys.
+	// aliasDepth is the depth of the aliases being expanded for merge keys. Anchors in them are removed from the copy
+	keepsAliases bool
+	aliasDepth   int
+
+	// aliasCount is the number of expanded aliases and nestedAliases is the depth of the aliases being expanded
+	aliasCount    int
+	nestedAliases int
+}
+
+// expand copies node with expanding aliases.
+// column is the column of the key or the sequence entry which has node.
+func (e *aliasExpander) expand(node Node, column int) (Node, error) {
+	switch n := node.(type) {
+	case nil:
+		return nil, nil
+	case *NullNode:
+		return &NullNode{Token: copyToken(n.Token)}, nil
+	case *BoolNode:
+		return &BoolNode{Token: copyToken(n.Token), Value: n.Value}, nil
+	case *IntegerNode:
+		return &IntegerNode{Token: copyToken(n.Token), Value: n.Value}, nil
+	case *FloatNode:
+		return &FloatNode{Token: copyToken(n.Token), Precision: n.Precision, Value: n.Value}, nil
+	case *InfinityNode:
+		return &InfinityNode{Token: copyToken(n.Token), Value: n.Value}, nil
+	case *NanNode:
+		return &NanNode{Token: copyToken(n.Token)}, nil
+	case *StringNode:
+		return &StringNode{Token: copyToken(n.Token), Value: n.Value}, nil
+	case *MergeKeyNode:
+		return &MergeKeyNode{Token: copyToken(n.Token)}, nil
+	case *LiteralNode:
+		return &LiteralNode{
+			Start: copyToken(n.Start),
+			Value: &StringNode{Token: copyToken(n.Value.Token), Value: n.Value.Value},
+		}, nil
+	case *TagNode:
+		value, err := e.expand(n.Value, column)
+		if err != nil {
+			return nil, err
+		}
+		return &TagNode{Start: copyToken(n.Start), Value: value, URI: n.URI}, nil
+	case *AnchorNode:
+		name := n.Name.GetToken().Value
+		e.anchors[name] = &anchorDefinition{value: n.Value, column: column}
+		e.expanding[name] = true
+		defer delete(e.expanding, name)
+		if e.keepsAliases && e.aliasDepth == 0 {
+			return e.copyAnchor(n.Start, n.Name, n.Value, column)
+		}
+		if e.defined == nil {
+			return e.expand(n.Value, column)
+		}
+		e.defined[name] = true
+		return e.copyAnchor(n.Start, n.Name, n.Value, column)
+	case *AliasNode:
+		if e.defined != nil {
+			return e.extractAlias(n, column)
+		}
+		if e.keepsAliases {
+			value, err := e.expand(n.Value, column)
+			if err != nil {
+				return nil, err
+			}
+			return &AliasNode{Start: copyToken(n.Start), Value: value}, nil
+		}
+		return e.expandAlias(n, column)
+	case *MappingValueNode:
+		if !e.isExpandedMergeKey(n.Key) {
+			return e.expandMappingValue(n)
+		}
+		values, err := e.expandMappingValues([]*MappingValueNode{n})
+		if err != nil {
+			return nil, err
+		}
+		return &MappingNode{Start: copyToken(n.Start), Values: values}, nil
+	case *MappingNode:
+		values, err := e.expandMappingValues(n.Values)
+		if err != nil {
+			return nil, err
+		}
+		return &MappingNode{
+			Start:       copyToken(n.Start),
+			End:         copyToken(n.End),
+			IsFlowStyle: n.IsFlowStyle,
+			Values:      values,
+		}, nil
+	case *SequenceNode:
+		values := make([]Node, 0, len(n.Values))
+		for _, value := range n.Values {
+			expanded, err := e.expand(value, n.Start.Position.Column)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, expanded)
+		}
+		return &SequenceNode{
+			Start:               copyToken(n.Start),
+			End:                 copyToken(n.End),
+			IsFlowStyle:         n.IsFlowStyle,
+			IsMappingOnNextLine: n.IsMappingOnNextLine,
+			Values:              values,
+		}, nil
+	}
+	return nil, xerrors.Errorf("cannot expand %s node", node.Type())
+}
+
+func (e *aliasExpander) expandAlias(n *AliasNode, column int) (Node, error) {
+	name := n.Value.GetToken().Value
+	pos := n.Start.Position
+	anchor, exists := e.anchors[name]
+	if !exists {
+		return nil, xerrors.Errorf("alias *%s at line %d, column %d refers to undefined anchor", name, pos.Line, pos.Column)
+	}
+	if e.expanding[name] {
+		return nil, xerrors.Errorf("alias *%s at line %d, column %d refers to anchor which contains the alias itself", name, pos.Line, pos.Column)
+	}
+	e.aliasCount++
+	if e.aliasCount > DefaultMaxAliasCount {
+		return nil, &AliasLimitError{Alias: n, Kind: "number", Limit: DefaultMaxAliasCount}
+	}
+	if e.nestedAliases >= DefaultAliasDepthLimit {
+		return nil, &AliasLimitError{Alias: n, Kind: "depth", Limit: DefaultAliasDepthLimit}
+	}
+	e.nestedAliases++
+	defer func() { e.nestedAliases-- }()
+	e.expanding[name] = true
+	defer delete(e.expanding, name)
+	value, err := e.expand(anchor.value, anchor.column)
+	if err != nil {
+		return nil, err
+	}
+	shiftColumn(value, column-anchor.column)
+	return value, nil
+}
+
+func (e *aliasExpander) expandMappingValue(n *MappingValueNode) (*MappingValueNode, error) {
+	column := n.Key.GetToken().Position.Column
+	key, err := e.expand(n.Key, column)
+	if err != nil {
+		return nil, err
+	}
+	value, err := e.expand(n.Value, column)
+	if err != nil {
+		return nil, err
+	}
+	return &MappingValueNode{Start: copyToken(n.Start), Key: key, Value: value}, nil
+}
+
+// expandMappingValues copies values with replacing merge keys by the merged mapping values.
+func (e *aliasExpander) expandMappingValues(values []*MappingValueNode) ([]*MappingValueNode, error) {
+	keys := map[interface{}]bool{}
+	for _, value := range values {
+		if value.Key.Type() != MergeKeyType {
+			keys[KeyValue(value.Key)] = true
+		}
+	}
+	expanded := make([]*MappingValueNode, 0, len(values))
+	for _, value := range values {
+		if !e.isExpandedMergeKey(value.Key) {
+			mvnode, err := e.expandMappingValue(value)
+			if err != nil {
+				return nil, err
+			}
+			expanded = append(expanded, mvnode)
+			continue
+		}
+		column := value.Key.GetToken().Position.Column
+		merged, err := e.expandMergedValue(value.Value, column)
+		if err != nil {
+			return nil, err
+		}
+		mergedValues, err := mergedMappingValues(merged)
+		if err != nil {
+			pos := value.Key.GetToken().Position
+			return nil, xerrors.Errorf("cannot merge value at line %d, column %d: %w", pos.Line, pos.Column, err)
+		}
+		for _, mvnode := range mergedValues {
+			key := KeyValue(mvnode.Key)
+			if keys[key] {
+				continue
+			}
+			keys[key] = true
+			shiftColumn(mvnode, column-mvnode.Key.GetToken().Position.Column)
+			expanded = append(expanded, mvnode)
+		}
+	}
+	return expanded, nil
+}
+
+// expandMergedValue copies the value of merge key with expanding the aliases which refer to the merged mappings
+func (e *aliasExpander) expandMergedValue(node Node, column int) (Node, error) {
+	if !e.keepsAliases {
+		return e.expand(node, column)
+	}
+	switch n := node.(type) {
+	case *AliasNode:
+		e.aliasDepth++
+		defer func() { e.aliasDepth-- }()
+		return e.expandAlias(n, column)
+	case *AnchorNode:
+		// the anchor can't be kept because the value of merge key is removed from the copy
+		pos := n.Start.Position
+		return nil, xerrors.Errorf("anchor &%s at line %d, column %d is defined on the value of merge key", n.Name.GetToken().Value, pos.Line, pos.Column)
+	case *SequenceNode:
+		values := make([]Node, 0, len(n.Values))
+		for _, value := range n.Values {
+			expanded, err := e.expandMergedValue(value, n.Start.Position.Column)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, expanded)
+		}
+		return &SequenceNode{
+			Start:       copyToken(n.Start),
+			End:         copyToken(n.End),
+			IsFlowStyle: n.IsFlowStyle,
+			Values:      values,
+		}, nil
+	}
+	return e.expand(node, column)
+}
+
+func (e *aliasExpander) isExpandedMergeKey(key Node) bool {
+	return e.defined == nil && key.Type() == MergeKeyType
+}
+
+// mergedMappingValues returns mapping values of node which is the value of merge key
+func mergedMappingValues(node Node) ([]*MappingValueNode, error) {
+	switch n := node.(type) {
+	case *MappingNode:
+		return n.Values, nil
+	case *MappingValueNode:
+		return []*MappingValueNode{n}, nil
+	case *TagNode:
+		return mergedMappingValues(n.Value)
+	case *SequenceNode:
+		values := []*MappingValueNode{}
+		for _, value := range n.Values {
+			if _, ok := value.(*SequenceNode); ok {
+				return nil, xerrors.New("merged sequence must contain only mappings")
+			}
+			mvnodes, err := mergedMappingValues(value)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, mvnodes...)
+		}
+		return values, nil
+	}
+	return nil, xerrors.Errorf("%s node is not mapping or sequence of mappings", node.Type())
+}
+
+func copyToken(tk *token.Token) *token.Token {
+	if tk == nil {
+		return nil
+	}
+	copied := *tk
+	if tk.Position != nil {
+		pos := *tk.Position
+		copied.Position = &pos
+	}
+	return &copied
+}
+
+type columnShifter int
+
+func (s columnShifter) Visit(node Node) Visitor {
+	switch n := node.(type) {
+	case nil:
+		return nil
+	case *MappingNode:
+		shiftTokenColumn(n.End, int(s))
+	case *SequenceNode:
+		shiftTokenColumn(n.End, int(s))
+	}
+	shiftTokenColumn(node.GetToken(), int(s))
+	return s
+}
+
+// shiftColumn moves all tokens of copied node by diff columns
+func shiftColumn(node Node, diff int) {
+	if node == nil || diff == 0 {
+		return
+	}
+	WalkAll(columnShifter(diff), node)
+}
+
+func shiftTokenColumn(tk *token.Token, diff int) {
+	if tk == nil || tk.Position == nil {
+		return
+	}
+	tk.Position.Column += diff
+}
@@ -0,0 +1,150 @@
+package ast
+
+import (
+	"github.com/goccy/go-yaml/token"
+	"golang.org/x/xerrors"
+)
+
+// Extract returns standalone document which has the deep copy of node ( e.g. a service in a big file ).
+// Anchors referred by aliases in node are searched in node and scopes ( e.g. the document which has node ).
+// The definition of the anchor defined out of node is copied to the place of the first alias which refers to it,
+// so the document can be decoded without the original source.
+// Columns of the copy are adjusted to start from the first column. node and scopes are not modified.
+func Extract(node Node, scopes ...Node) (*File, error) {
+	if doc, ok := node.(*Document); ok {
+		node = doc.Body
+	}
+	e := &aliasExpander{
+		anchors:   map[string]*anchorDefinition{},
+		expanding: map[string]bool{},
+		defined:   map[string]bool{},
+	}
+	for _, scope := range scopes {
+		collectAnchors(e.anchors, scope, 0, node)
+	}
+	body, err := e.expand(node, baseColumn(node))
+	if err != nil {
+		return nil, err
+	}
+	shiftColumn(body, 1-baseColumn(body))
+	return &File{Docs: []*Document{{Body: body}}}, nil
+}
+
+// extractAlias copies alias. If the anchor is not defined in the copy yet,
+// the alias is replaced by the anchor which has the copy of the anchored value.
+func (e *aliasExpander) extractAlias(n *AliasNode, column int) (Node, error) {
+	name := n.Value.GetToken().Value
+	if e.defined[name] {
+		value, err := e.expand(n.Value, column)
+		if err != nil {
+			return nil, err
+		}
+		return &AliasNode{Start: copyToken(n.Start), Value: value}, nil
+	}
+	pos := n.Start.Position
+	anchor, exists := e.anchors[name]
+	if !exists {
+		return nil, xerrors.Errorf("alias *%s at line %d, column %d refers to undefined anchor", name, pos.Line, pos.Column)
+	}
+	e.defined[name] = true
+	e.expanding[name] = true
+	defer delete(e.expanding, name)
+	var anchorPos *token.Position
+	if pos != nil {
+		copied := *pos
+		anchorPos = &copied
+	}
+	node, err := e.copyAnchor(token.Anchor("&", anchorPos), n.Value, anchor.value, anchor.column)
+	if err != nil {
+		return nil, err
+	}
+	shiftColumn(node.Value, column-anchor.column)
+	return node, nil
+}
+
+func (e *aliasExpander) copyAnchor(start *token.Token, name, value Node, column int) (*AnchorNode, error) {
+	copiedName, err := e.expand(name, column)
+	if err != nil {
+		return nil, err
+	}
+	copiedValue, err := e.expand(value, column)
+	if err != nil {
+		return nil, err
+	}
+	return &AnchorNode{Start: copyToken(start), Name: copiedName, Value: copiedValue}, nil
+}
+
+// collectAnchors records anchors defined in node before stop. It reports whether stop is found.
+// column is the column of the key or the sequence entry which has node.
+func collectAnchors(anchors map[string]*anchorDefinition, node Node, column int, stop Node) bool {
+	if node == stop {
+		return true
+	}
+	switch n := node.(type) {
+	case *Document:
+		return collectAnchors(anchors, n.Body, 0, stop)
+	case *TagNode:
+		return collectAnchors(anchors, n.Value, column, stop)
+	case *AnchorNode:
+		anchors[n.Name.GetToken().Value] = &anchorDefinition{value: n.Value, column: column}
+		return collectAnchors(anchors, n.Value, column, stop)
+	case *MappingValueNode:
+		keyColumn := n.Key.GetToken().Position.Column
+		if collectAnchors(anchors, n.Key, keyColumn, stop) {
+			return true
+		}
+		return collectAnchors(anchors, n.Value, keyColumn, stop)
+	case *MappingNode:
+		for _, value := range n.Values {
+			if collectAnchors(anchors, value, column, stop) {
+				return true
+			}
+		}
+	case *SequenceNode:
+		for _, value := range n.Values {
+			if collectAnchors(anchors, value, n.Start.Position.Column, stop) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// baseColumn returns the column where node starts in block context
+func baseColumn(node Node) int {
+	switch n := node.(type) {
+	case nil:
+		return 1
+	case *MappingNode:
+		if !n.IsFlowStyle && len(n.Values) > 0 {
+			return baseColumn(n.Values[0])
+		}
+	case *MappingValueNode:
+		return n.Key.GetToken().Position.Column
+	case *AnchorNode:
+		if isBlockCollection(n.Value) {
+			return baseColumn(n.Value)
+		}
+	case *TagNode:
+		if isBlockCollection(n.Value) {
+			return baseColumn(n.Value)
+		}
+	}
+	tk := node.GetToken()
+	if tk == nil || tk.Position == nil {
+		return 1
+	}
+	return tk.Position.Column
+}
+
+func isBlockCollection(node Node) bool {
+	switch n := node.(type) {
+	case *MappingNode:
+		return !n.IsFlowStyle
+	case *MappingValueNode:
+		return true
+	case *SequenceNode:
+		return !n.IsFlowStyle
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package ast
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/goccy/go-yaml/token"
+)
+
+// taggedKey value of the key which has custom tag ( e.g. `!custom a` ).
+// The key is different from the key which has the same value without the tag.
+type taggedKey struct {
+	tag   string
+	value interface{}
+}
+
+// aliasKey value of the alias used as key. It is the same key as the alias to the same anchor
+type aliasKey string
+
+// collectionKey value of the mapping or sequence used as key ( e.g. `[a, b]: c` ).
+// It is the text of the values separated by the canonical indicators, so it doesn't depend on the spaces and quotes in the source.
+type collectionKey string
+
+// KeyValue returns the value of key node to compare the keys as the spec defines.
+// Quotes, escapes and core schema tags are resolved, so `a`, `'a'`, `"\x61"` and `!!str a` are the same key,
+// but `1` and `"1"` are different because they have different types.
+// The returned value is comparable, so it can be used as the key of Go map to detect duplicated keys.
+func KeyValue(node Node) interface{} {
+	switch n := node.(type) {
+	case nil:
+		return nil
+	case *AnchorNode:
+		return KeyValue(n.Value)
+	case *AliasNode:
+		return aliasKey(n.Value.GetToken().Value)
+	case *TagNode:
+		return taggedKeyValue(n)
+	case *LiteralNode:
+		return n.Value.GetValue()
+	case *IntegerNode:
+		if v, ok := n.Value.(uint64); ok && v <= math.MaxInt64 {
+			return int64(v)
+		}
+		return n.Value
+	case ScalarNode:
+		return n.GetValue()
+	case *MappingValueNode:
+		return collectionKey(fmt.Sprintf("{%s}", mappingValueKeyText(n)))
+	case *MappingNode:
+		values := make([]string, 0, len(n.Values))
+		for _, value := range n.Values {
+			values = append(values, mappingValueKeyText(value))
+		}
+		return collectionKey(fmt.Sprintf("{%s}", strings.Join(values, ",")))
+	case *SequenceNode:
+		values := make([]string, 0, len(n.Values))
+		for _, value := range n.Values {
+			values = append(values, keyText(value))
+		}
+		return collectionKey(fmt.Sprintf("[%s]", strings.Join(values, ",")))
+	}
+	return node.String()
+}
+
+// KeyEqual reports whether the key nodes a and b are the same key as the spec defines.
+// It is used for detecting duplicated keys, resolving the keys overridden by merge key and the members of `!!set`
+// instead of comparing the text of tokens.
+func KeyEqual(a, b Node) bool {
+	return KeyValue(a) == KeyValue(b)
+}
+
+func taggedKeyValue(n *TagNode) interface{} {
+	switch n.Start.Value {
+	case token.StringTag:
+		if scalar, ok := Unwrap(n.Value).(ScalarNode); ok && scalar.GetToken() != nil {
+			// `!!str 1` is the string key "1"
+			if s, ok := scalar.GetValue().(string); ok {
+				return s
+			}
+			return scalar.GetToken().Value
+		}
+	case string(token.IntegerTag), token.FloatTag, token.NullTag, token.BooleanTag,
+		token.SequenceTag, token.MappingTag:
+		// the type of value is already resolved by the tag
+		return KeyValue(n.Value)
+	}
+	return taggedKey{tag: n.Start.Value, value: KeyValue(n.Value)}
+}
+
+func mappingValueKeyText(n *MappingValueNode) string {
+	return fmt.Sprintf("%s:%s", keyText(n.Key), keyText(n.Value))
+}
+
+// keyText returns the text of key value with its type to distinguish the values in collection key ( e.g. `[1]` and `["1"]` )
+func keyText(node Node) string {
+	return fmt.Sprintf("%#v", KeyValue(node))
+}
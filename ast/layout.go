@@ -0,0 +1,102 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/goccy/go-yaml/token"
+)
+
+// columnOf returns the column of token. Token built programmatically may not have the position,
+// so it is placed at the first column.
+func columnOf(tk *token.Token) int {
+	if tk == nil || tk.Position == nil || tk.Position.Column < 1 {
+		return 1
+	}
+	return tk.Position.Column
+}
+
+func indentLevelOf(tk *token.Token) int {
+	if tk == nil || tk.Position == nil {
+		return 0
+	}
+	return tk.Position.IndentLevel
+}
+
+// alignValue indents lines of the value text from the start-th line to place them under the key.
+// The position of the value doesn't always follow the key
+// when the value is built programmatically or moved from another tree ( e.g. the value at the first column ).
+func (n *MappingValueNode) alignValue(text string, start int) string {
+	lines := strings.Split(text, "\n")
+	if start >= len(lines) {
+		return text
+	}
+	indent := indentOfLines(lines[start:])
+	if indent < 0 {
+		return text
+	}
+	keyIndent := columnOf(n.Key.GetToken()) - 1
+	if s, ok := Unwrap(n.Value).(*SequenceNode); ok && !s.IsFlowStyle {
+		// block sequence can be placed at the indent of key ( e.g. "a:\n- b" )
+		if indent >= keyIndent {
+			return text
+		}
+		shiftLines(lines[start:], keyIndent-indent)
+		return strings.Join(lines, "\n")
+	}
+	if indent > keyIndent {
+		return text
+	}
+	shiftLines(lines[start:], keyIndent+2-indent)
+	return strings.Join(lines, "\n")
+}
+
+// alignValues aligns the text of mapping values to the indent of the first value,
+// because the values of the same mapping must be placed at the same indent.
+func alignValues(values []string) []string {
+	if len(values) == 0 {
+		return values
+	}
+	base := indentOfLines(strings.Split(values[0], "\n"))
+	for idx := 1; idx < len(values); idx++ {
+		lines := strings.Split(values[idx], "\n")
+		indent := indentOfLines(lines)
+		if base < 0 || indent < 0 || indent == base {
+			continue
+		}
+		shiftLines(lines, base-indent)
+		values[idx] = strings.Join(lines, "\n")
+	}
+	return values
+}
+
+// indentOfLines returns the number of spaces in front of the first non-empty line.
+// If all lines are empty, returns -1.
+func indentOfLines(lines []string) int {
+	for _, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" {
+			continue
+		}
+		return len(line) - len(trimmed)
+	}
+	return -1
+}
+
+// shiftLines moves non-empty lines by diff columns
+func shiftLines(lines []string, diff int) {
+	for idx, line := range lines {
+		if line == "" {
+			continue
+		}
+		if diff > 0 {
+			lines[idx] = strings.Repeat(" ", diff) + line
+			continue
+		}
+		trimmed := strings.TrimLeft(line, " ")
+		if removable := len(line) - len(trimmed); removable < -diff {
+			lines[idx] = trimmed
+		} else {
+			lines[idx] = line[-diff:]
+		}
+	}
+}
@@ -0,0 +1,446 @@
+package ast
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml/token"
+)
+
+// Kind kind of node in the representation graph like the kind of gopkg.in/yaml.v3.
+// All types of scalar node are ScalarKind, and anchors and tags have the kind of their values.
+type Kind int
+
+const (
+	// UnknownKind kind for the node which isn't a part of representation graph ( e.g. directive )
+	UnknownKind Kind = iota
+	// DocumentKind kind of document
+	DocumentKind
+	// MappingKind kind of mapping node and mapping value node
+	MappingKind
+	// SequenceKind kind of sequence node
+	SequenceKind
+	// ScalarKind kind of scalar nodes including literal and merge key
+	ScalarKind
+	// AliasKind kind of alias node
+	AliasKind
+)
+
+// String kind to text
+func (k Kind) String() string {
+	switch k {
+	case DocumentKind:
+		return "Document"
+	case MappingKind:
+		return "Mapping"
+	case SequenceKind:
+		return "Sequence"
+	case ScalarKind:
+		return "Scalar"
+	case AliasKind:
+		return "Alias"
+	}
+	return "Unknown"
+}
+
+// Style presentation style of node like the style of gopkg.in/yaml.v3.
+type Style int
+
+const (
+	// PlainStyle style of plain scalar and block collection
+	PlainStyle Style = iota
+	// SingleQuotedStyle style of single quoted scalar ( e.g. `'a'` )
+	SingleQuotedStyle
+	// DoubleQuotedStyle style of double quoted scalar ( e.g. `"a"` )
+	DoubleQuotedStyle
+	// LiteralStyle style of literal block scalar ( e.g. `|` )
+	LiteralStyle
+	// FoldedStyle style of folded block scalar ( e.g. `>` )
+	FoldedStyle
+	// FlowStyle style of flow collection ( e.g. `[a, b]` or `{a: b}` )
+	FlowStyle
+)
+
+// String style to text
+func (s Style) String() string {
+	switch s {
+	case PlainStyle:
+		return "Plain"
+	case SingleQuotedStyle:
+		return "SingleQuoted"
+	case DoubleQuotedStyle:
+		return "DoubleQuoted"
+	case LiteralStyle:
+		return "Literal"
+	case FoldedStyle:
+		return "Folded"
+	case FlowStyle:
+		return "Flow"
+	}
+	return "Unknown"
+}
+
+// Kind returns DocumentKind
+func (d *Document) Kind() Kind { return DocumentKind }
+
+// Style returns PlainStyle
+func (d *Document) Style() Style { return PlainStyle }
+
+// SetStyle sets style of body
+func (d *Document) SetStyle(style Style) {
+	if d.Body != nil {
+		d.Body.SetStyle(style)
+	}
+}
+
+// Kind returns ScalarKind
+func (n *NullNode) Kind() Kind { return ScalarKind }
+
+// Style returns PlainStyle
+func (n *NullNode) Style() Style { return PlainStyle }
+
+// SetStyle does nothing because null is always plain
+func (n *NullNode) SetStyle(Style) {}
+
+// Kind returns ScalarKind
+func (n *IntegerNode) Kind() Kind { return ScalarKind }
+
+// Style returns PlainStyle
+func (n *IntegerNode) Style() Style { return PlainStyle }
+
+// SetStyle does nothing because quoted integer is string
+func (n *IntegerNode) SetStyle(Style) {}
+
+// Kind returns ScalarKind
+func (n *FloatNode) Kind() Kind { return ScalarKind }
+
+// Style returns PlainStyle
+func (n *FloatNode) Style() Style { return PlainStyle }
+
+// SetStyle does nothing because quoted float is string
+func (n *FloatNode) SetStyle(Style) {}
+
+// Kind returns ScalarKind
+func (n *StringNode) Kind() Kind { return ScalarKind }
+
+// Style returns PlainStyle, SingleQuotedStyle or DoubleQuotedStyle.
+// The string built by encoder has the quoted text in Value.
+func (n *StringNode) Style() Style {
+	switch n.Token.Type {
+	case token.SingleQuoteType:
+		return SingleQuotedStyle
+	case token.DoubleQuoteType:
+		return DoubleQuotedStyle
+	}
+	// plain scalar cannot start with quote character
+	switch {
+	case strings.HasPrefix(n.Value, `'`):
+		return SingleQuotedStyle
+	case strings.HasPrefix(n.Value, `"`):
+		return DoubleQuotedStyle
+	}
+	return PlainStyle
+}
+
+// SetStyle sets PlainStyle, SingleQuotedStyle or DoubleQuotedStyle, and the origin of token is rewritten too.
+// PlainStyle is ignored if the string must be quoted ( e.g. `true` or `a: b` ),
+// and the string which has line break is written by double quote instead of single quote.
+// Replace the node by LiteralNode to write the string by block scalar.
+func (n *StringNode) SetStyle(style Style) {
+	value := n.unquotedValue()
+	var typ token.Type
+	switch style {
+	case PlainStyle:
+		if token.IsNeedQuoted(value) {
+			return
+		}
+		typ = token.StringType
+	case SingleQuotedStyle:
+		typ = token.SingleQuoteType
+	case DoubleQuotedStyle:
+		typ = token.DoubleQuoteType
+	default:
+		return
+	}
+	n.Value = value
+	n.Token.Type = typ
+	n.Token.Value = value
+	if n.Token.Origin != "" {
+		n.Token.Origin = replaceText(n.Token.Origin, n.stringValue())
+	}
+}
+
+// unquotedValue returns Value without quotes added by encoder
+func (n *StringNode) unquotedValue() string {
+	if n.Token.Type == token.SingleQuoteType || n.Token.Type == token.DoubleQuoteType {
+		return n.Value
+	}
+	switch n.Style() {
+	case SingleQuotedStyle:
+		if len(n.Value) >= 2 && strings.HasSuffix(n.Value, "'") {
+			return strings.ReplaceAll(n.Value[1:len(n.Value)-1], "''", "'")
+		}
+	case DoubleQuotedStyle:
+		if value, err := strconv.Unquote(n.Value); err == nil {
+			return value
+		}
+	}
+	return n.Value
+}
+
+// replaceText replaces text in origin keeping spaces and line breaks around it
+func replaceText(origin, text string) string {
+	trimmed := strings.TrimLeft(origin, " \t\n")
+	prefix := origin[:len(origin)-len(trimmed)]
+	suffix := trimmed[len(strings.TrimRight(trimmed, " \t\n")):]
+	return prefix + text + suffix
+}
+
+// Kind returns ScalarKind
+func (n *LiteralNode) Kind() Kind { return ScalarKind }
+
+// Style returns LiteralStyle or FoldedStyle
+func (n *LiteralNode) Style() Style {
+	if strings.HasPrefix(n.Start.Value, ">") {
+		return FoldedStyle
+	}
+	return LiteralStyle
+}
+
+// SetStyle sets LiteralStyle or FoldedStyle keeping the chomping indicator.
+// The content is rewritten so that the value isn't changed by folding.
+func (n *LiteralNode) SetStyle(style Style) {
+	var indicator string
+	switch style {
+	case LiteralStyle:
+		indicator = "|"
+	case FoldedStyle:
+		indicator = ">"
+	default:
+		return
+	}
+	if n.Style() == style {
+		return
+	}
+	header := indicator + n.Start.Value[1:]
+	n.Start.Origin = strings.Replace(n.Start.Origin, n.Start.Value, header, 1)
+	n.Start.Value = header
+	if style == FoldedStyle {
+		n.Start.Type = token.FoldedType
+	} else {
+		n.Start.Type = token.LiteralType
+	}
+	tk := n.Value.GetToken()
+	if tk.Origin == "" {
+		return
+	}
+	content := blockContent(header, n.Value.Value, columnOf(tk))
+	if strings.HasSuffix(tk.Origin, "\n") && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	tk.Origin = content
+}
+
+// blockContent renders value as the content of block scalar indented to column.
+// Folded scalar has an empty line between the lines which would be folded into a line.
+func blockContent(header, value string, column int) string {
+	space := strings.Repeat(" ", column-1)
+	isFolded := strings.HasPrefix(header, ">")
+	lines := strings.Split(value, "\n")
+	content := make([]string, 0, len(lines))
+	prevFoldable := false
+	for _, line := range lines {
+		if line == "" {
+			content = append(content, line)
+			continue
+		}
+		// more indented line isn't folded
+		foldable := !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t")
+		if isFolded && foldable && prevFoldable {
+			content = append(content, "")
+		}
+		prevFoldable = foldable
+		content = append(content, space+line)
+	}
+	return strings.Join(content, "\n")
+}
+
+// Kind returns ScalarKind
+func (n *MergeKeyNode) Kind() Kind { return ScalarKind }
+
+// Style returns PlainStyle
+func (n *MergeKeyNode) Style() Style { return PlainStyle }
+
+// SetStyle does nothing because quoted merge key is string
+func (n *MergeKeyNode) SetStyle(Style) {}
+
+// Kind returns ScalarKind
+func (n *BoolNode) Kind() Kind { return ScalarKind }
+
+// Style returns PlainStyle
+func (n *BoolNode) Style() Style { return PlainStyle }
+
+// SetStyle does nothing because quoted boolean is string
+func (n *BoolNode) SetStyle(Style) {}
+
+// Kind returns ScalarKind
+func (n *InfinityNode) Kind() Kind { return ScalarKind }
+
+// Style returns PlainStyle
+func (n *InfinityNode) Style() Style { return PlainStyle }
+
+// SetStyle does nothing because quoted infinity is string
+func (n *InfinityNode) SetStyle(Style) {}
+
+// Kind returns ScalarKind
+func (n *NanNode) Kind() Kind { return ScalarKind }
+
+// Style returns PlainStyle
+func (n *NanNode) Style() Style { return PlainStyle }
+
+// SetStyle does nothing because quoted nan is string
+func (n *NanNode) SetStyle(Style) {}
+
+// Kind returns MappingKind
+func (n *MappingNode) Kind() Kind { return MappingKind }
+
+// Style returns FlowStyle or PlainStyle for block mapping
+func (n *MappingNode) Style() Style {
+	if n.IsFlowStyle {
+		return FlowStyle
+	}
+	return PlainStyle
+}
+
+// SetStyle sets FlowStyle or PlainStyle for block mapping.
+// The nested collections are also changed to flow style by FlowStyle because block collection cannot be placed in flow collection.
+func (n *MappingNode) SetStyle(style Style) {
+	switch style {
+	case FlowStyle:
+		ToFlowStyle(n)
+	case PlainStyle:
+		if len(n.Values) > 0 {
+			// empty mapping cannot be rendered by block style
+			n.IsFlowStyle = false
+		}
+	}
+}
+
+// Kind returns MappingKind because mapping value is a mapping which has single value
+func (n *MappingValueNode) Kind() Kind { return MappingKind }
+
+// Style returns PlainStyle
+func (n *MappingValueNode) Style() Style { return PlainStyle }
+
+// SetStyle does nothing. Replace the node by the result of ToFlowStyle to write it by flow style
+func (n *MappingValueNode) SetStyle(Style) {}
+
+// Kind returns SequenceKind
+func (n *SequenceNode) Kind() Kind { return SequenceKind }
+
+// Style returns FlowStyle or PlainStyle for block sequence
+func (n *SequenceNode) Style() Style {
+	if n.IsFlowStyle {
+		return FlowStyle
+	}
+	return PlainStyle
+}
+
+// SetStyle sets FlowStyle or PlainStyle for block sequence.
+// The nested collections are also changed to flow style by FlowStyle because block collection cannot be placed in flow collection.
+func (n *SequenceNode) SetStyle(style Style) {
+	switch style {
+	case FlowStyle:
+		ToFlowStyle(n)
+	case PlainStyle:
+		if len(n.Values) > 0 {
+			// empty sequence cannot be rendered by block style
+			n.IsFlowStyle = false
+		}
+	}
+}
+
+// Kind returns the kind of anchored value
+func (n *AnchorNode) Kind() Kind { return n.Value.Kind() }
+
+// Style returns the style of anchored value
+func (n *AnchorNode) Style() Style { return n.Value.Style() }
+
+// SetStyle sets style of anchored value
+func (n *AnchorNode) SetStyle(style Style) { n.Value.SetStyle(style) }
+
+// Kind returns AliasKind
+func (n *AliasNode) Kind() Kind { return AliasKind }
+
+// Style returns PlainStyle
+func (n *AliasNode) Style() Style { return PlainStyle }
+
+// SetStyle does nothing because alias has the style of anchored value
+func (n *AliasNode) SetStyle(Style) {}
+
+// Kind returns UnknownKind
+func (n *DirectiveNode) Kind() Kind { return UnknownKind }
+
+// Style returns PlainStyle
+func (n *DirectiveNode) Style() Style { return PlainStyle }
+
+// SetStyle does nothing
+func (n *DirectiveNode) SetStyle(Style) {}
+
+// Kind returns the kind of tagged value
+func (n *TagNode) Kind() Kind {
+	if n.Value == nil {
+		return ScalarKind
+	}
+	return n.Value.Kind()
+}
+
+// Style returns the style of tagged value
+func (n *TagNode) Style() Style {
+	if n.Value == nil {
+		return PlainStyle
+	}
+	return n.Value.Style()
+}
+
+// SetStyle sets style of tagged value
+func (n *TagNode) SetStyle(style Style) {
+	if n.Value != nil {
+		n.Value.SetStyle(style)
+	}
+}
+
+// ToFlowStyle changes the style of collections in node to flow style to add it to flow style collection.
+// Mapping value is wrapped by flow mapping, and block scalar is replaced by double quoted string,
+// so the returned node must be placed instead of node.
+func ToFlowStyle(node Node) Node {
+	switch n := node.(type) {
+	case *AnchorNode:
+		n.Value = ToFlowStyle(n.Value)
+	case *TagNode:
+		n.Value = ToFlowStyle(n.Value)
+	case *MappingValueNode:
+		n.Value = ToFlowStyle(n.Value)
+		return &MappingNode{Start: n.Start, IsFlowStyle: true, Values: []*MappingValueNode{n}}
+	case *MappingNode:
+		n.IsFlowStyle = true
+		for _, value := range n.Values {
+			value.Value = ToFlowStyle(value.Value)
+		}
+	case *SequenceNode:
+		n.IsFlowStyle = true
+		for idx, value := range n.Values {
+			n.Values[idx] = ToFlowStyle(value)
+		}
+	case *NullNode:
+		if n.isImplicit() {
+			// empty value cannot be placed in flow collection
+			n.Token = token.New("null", "null", n.Token.Position)
+		}
+	case *LiteralNode:
+		// block scalar cannot be placed in flow collection
+		value := strconv.Quote(n.Value.Value)
+		return String(token.New(value, value, n.Start.Position))
+	}
+	return node
+}
@@ -13,12 +13,6 @@ import (
 	"github.com/mattn/go-colorable"
 )
 
-const escape = "\x1b"
-
-func format(attr color.Attribute) string {
-	return fmt.Sprintf("%s[%dm", escape, attr)
-}
-
 func _main(args []string) error {
 	if len(args) < 2 {
 		return errors.New("ycat: usage: ycat file.yml")
@@ -35,42 +29,7 @@ func _main(args []string) error {
 		fn := color.New(color.Bold, color.FgHiWhite).SprintFunc()
 		return fn(fmt.Sprintf("%2d | ", num))
 	}
-	p.Bool = func() *printer.Property {
-		return &printer.Property{
-			Prefix: format(color.FgHiMagenta),
-			Suffix: format(color.Reset),
-		}
-	}
-	p.Number = func() *printer.Property {
-		return &printer.Property{
-			Prefix: format(color.FgHiMagenta),
-			Suffix: format(color.Reset),
-		}
-	}
-	p.MapKey = func() *printer.Property {
-		return &printer.Property{
-			Prefix: format(color.FgHiCyan),
-			Suffix: format(color.Reset),
-		}
-	}
-	p.Anchor = func() *printer.Property {
-		return &printer.Property{
-			Prefix: format(color.FgHiYellow),
-			Suffix: format(color.Reset),
-		}
-	}
-	p.Alias = func() *printer.Property {
-		return &printer.Property{
-			Prefix: format(color.FgHiYellow),
-			Suffix: format(color.Reset),
-		}
-	}
-	p.String = func() *printer.Property {
-		return &printer.Property{
-			Prefix: format(color.FgHiGreen),
-			Suffix: format(color.Reset),
-		}
-	}
+	p.SetDefaultColorSet()
 	writer := colorable.NewColorableStdout()
 	writer.Write([]byte(p.PrintTokens(tokens) + "\n"))
 	return nil
@@ -0,0 +1,280 @@
+package yaml
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/internal/yamlpath"
+	"golang.org/x/xerrors"
+)
+
+// CommentPosition type of the position for comment
+type CommentPosition int
+
+const (
+	// CommentHeadPosition comment lines in front of the value
+	CommentHeadPosition CommentPosition = iota
+	// CommentLinePosition comment at the end of the line of the value
+	CommentLinePosition
+	// CommentFootPosition comment lines after the value
+	CommentFootPosition
+)
+
+// String position to text
+func (p CommentPosition) String() string {
+	switch p {
+	case CommentHeadPosition:
+		return "Head"
+	case CommentLinePosition:
+		return "Line"
+	case CommentFootPosition:
+		return "Foot"
+	}
+	return ""
+}
+
+// Comment comment lines at the position. Each text doesn't include `#` character ( e.g. ` comment` for `# comment` ).
+type Comment struct {
+	Texts    []string
+	Position CommentPosition
+}
+
+// HeadComment create Comment placed in front of the value
+func HeadComment(texts ...string) *Comment {
+	return &Comment{Texts: texts, Position: CommentHeadPosition}
+}
+
+// LineComment create Comment placed at the end of the line of the value
+func LineComment(text string) *Comment {
+	return &Comment{Texts: []string{text}, Position: CommentLinePosition}
+}
+
+// FootComment create Comment placed after the value
+func FootComment(texts ...string) *Comment {
+	return &Comment{Texts: texts, Position: CommentFootPosition}
+}
+
+// CommentMap map of YAMLPath ( e.g. `$.a.b[0]` ) to the comments of the value at the path.
+// The comments of the mapping value are keyed by the path of the key,
+// and the comments of the document are keyed by `$`.
+type CommentMap map[string][]*Comment
+
+func (m CommentMap) add(path string, comments *ast.Comments) {
+	if comments.HeadComment != "" {
+		m[path] = append(m[path], &Comment{Texts: commentTexts(comments.HeadComment), Position: CommentHeadPosition})
+	}
+	if comments.LineComment != "" {
+		m[path] = append(m[path], &Comment{Texts: commentTexts(comments.LineComment), Position: CommentLinePosition})
+	}
+	if comments.FootComment != "" {
+		m[path] = append(m[path], &Comment{Texts: commentTexts(comments.FootComment), Position: CommentFootPosition})
+	}
+}
+
+// addDocument collects comments in the document
+func (m CommentMap) addDocument(doc *ast.Document) {
+	m.add("$", &doc.Comments)
+	if doc.Body != nil {
+		m.addNode("$", doc.Body)
+	}
+}
+
+func (m CommentMap) addNode(path string, node ast.Node) {
+	if n, ok := node.(ast.CommentedNode); ok {
+		if _, isMappingValue := node.(*ast.MappingValueNode); !isMappingValue {
+			m.add(path, n.GetComments())
+		}
+	}
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		for _, value := range n.Values {
+			m.addMappingValue(path, value)
+		}
+	case *ast.MappingValueNode:
+		m.addMappingValue(path, n)
+	case *ast.SequenceNode:
+		for idx, value := range n.Values {
+			elemPath := fmt.Sprintf("%s[%d]", path, idx)
+			if mv, ok := value.(*ast.MappingValueNode); ok {
+				// comments of mapping value which is the entry of sequence belong to the entry
+				m.add(elemPath, &mv.Comments)
+				m.addMappingValueWithoutComments(elemPath, mv)
+				continue
+			}
+			m.addNode(elemPath, value)
+		}
+	case *ast.AnchorNode:
+		m.addNode(path, n.Value)
+	case *ast.TagNode:
+		m.addNode(path, n.Value)
+	}
+}
+
+func (m CommentMap) addMappingValue(path string, mv *ast.MappingValueNode) {
+	m.add(yamlpath.AppendKey(path, keyText(mv.Key)), &mv.Comments)
+	m.addMappingValueWithoutComments(path, mv)
+}
+
+func (m CommentMap) addMappingValueWithoutComments(path string, mv *ast.MappingValueNode) {
+	m.addNode(yamlpath.AppendKey(path, keyText(mv.Key)), mv.Value)
+}
+
+func keyText(key ast.Node) string {
+	if scalar, ok := key.(ast.ScalarNode); ok {
+		return fmt.Sprint(scalar.GetValue())
+	}
+	return key.String()
+}
+
+// commentTexts splits comment lines and removes `#` character from each line
+func commentTexts(comment string) []string {
+	lines := strings.Split(comment, "\n")
+	for idx, line := range lines {
+		lines[idx] = strings.TrimPrefix(line, "#")
+	}
+	return lines
+}
+
+func commentText(texts []string) string {
+	lines := make([]string, 0, len(texts))
+	for _, text := range texts {
+		lines = append(lines, "#"+text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// commentTarget comments of the value at the path
+type commentTarget struct {
+	head, line, foot *string
+}
+
+func newCommentTarget(comments *ast.Comments) *commentTarget {
+	return &commentTarget{head: &comments.HeadComment, line: &comments.LineComment, foot: &comments.FootComment}
+}
+
+func (t *commentTarget) set(comment *Comment) {
+	text := commentText(comment.Texts)
+	var dst *string
+	switch comment.Position {
+	case CommentHeadPosition:
+		dst = t.head
+	case CommentLinePosition:
+		dst = t.line
+	case CommentFootPosition:
+		dst = t.foot
+	default:
+		return
+	}
+	if *dst != "" {
+		text = *dst + "\n" + text
+	}
+	*dst = text
+}
+
+// commentTargets collects the comments of values by path in the node encoded by Encoder
+func commentTargets(targets map[string]*commentTarget, path string, node ast.Node) {
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		for _, value := range n.Values {
+			keyPath := yamlpath.AppendKey(path, keyText(value.Key))
+			targets[keyPath] = newCommentTarget(&value.Comments)
+			commentTargets(targets, keyPath, value.Value)
+		}
+	case *ast.MappingValueNode:
+		keyPath := yamlpath.AppendKey(path, keyText(n.Key))
+		targets[keyPath] = newCommentTarget(&n.Comments)
+		commentTargets(targets, keyPath, n.Value)
+	case *ast.SequenceNode:
+		for idx, value := range n.Values {
+			elemPath := fmt.Sprintf("%s[%d]", path, idx)
+			if v, ok := value.(ast.CommentedNode); ok {
+				targets[elemPath] = newCommentTarget(v.GetComments())
+			}
+			commentTargets(targets, elemPath, value)
+		}
+	case *ast.AnchorNode:
+		commentTargets(targets, path, n.Value)
+	case *ast.TagNode:
+		commentTargets(targets, path, n.Value)
+	}
+}
+
+// encodeComment set the comments of CommentMap to the node.
+// Comments of the root path ( `$` ) are placed in the document which has the node as body.
+func (e *Encoder) encodeComment(node ast.Node) (ast.Node, error) {
+	doc, ok := node.(*ast.Document)
+	if !ok {
+		doc = &ast.Document{Body: node}
+	}
+	targets := map[string]*commentTarget{}
+	commentTargets(targets, "$", doc.Body)
+	targets["$"] = newCommentTarget(&doc.Comments)
+	for path, comments := range e.commentMap {
+		target, exists := targets[path]
+		if !exists {
+			return nil, xerrors.Errorf("failed to set comment to %s: %w", path, ErrNotFoundNode)
+		}
+		for _, comment := range comments {
+			target.set(comment)
+		}
+	}
+	return doc, nil
+}
+
+// wrapComments wraps head and foot comments in node at width by the column where they are placed.
+// Line comment isn't wrapped because it can't be continued at the next line.
+func wrapComments(node ast.Node, column, width int) {
+	switch n := node.(type) {
+	case *ast.Document:
+		wrapHeadFootComment(&n.Comments, 1, width)
+		if n.Body != nil {
+			wrapComments(n.Body, 1, width)
+		}
+	case *ast.MappingNode:
+		for _, value := range n.Values {
+			wrapComments(value, column, width)
+		}
+	case *ast.MappingValueNode:
+		wrapHeadFootComment(&n.Comments, columnOf(n.Key), width)
+		wrapComments(n.Value, columnOf(n.Value), width)
+	case *ast.SequenceNode:
+		for _, value := range n.Values {
+			if v, ok := value.(ast.CommentedNode); ok {
+				// comments of entry are placed at the column of `-`
+				wrapHeadFootComment(v.GetComments(), columnOf(n), width)
+			}
+			switch v := value.(type) {
+			case *ast.MappingValueNode:
+				wrapComments(v.Value, columnOf(v.Value), width)
+			case *ast.MappingNode, *ast.SequenceNode:
+				wrapComments(v, columnOf(v), width)
+			}
+		}
+	case *ast.AnchorNode:
+		wrapComments(n.Value, column, width)
+	case *ast.TagNode:
+		wrapComments(n.Value, column, width)
+	default:
+		if v, ok := node.(ast.CommentedNode); ok {
+			wrapHeadFootComment(v.GetComments(), column, width)
+		}
+	}
+}
+
+func wrapHeadFootComment(comments *ast.Comments, column, width int) {
+	if comments.HeadComment != "" {
+		comments.HeadComment = ast.WrapComment(comments.HeadComment, column, width)
+	}
+	if comments.FootComment != "" {
+		comments.FootComment = ast.WrapComment(comments.FootComment, column, width)
+	}
+}
+
+func columnOf(node ast.Node) int {
+	tk := node.GetToken()
+	if tk == nil || tk.Position == nil || tk.Position.Column < 1 {
+		return 1
+	}
+	return tk.Position.Column
+}
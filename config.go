@@ -0,0 +1,84 @@
+package yaml
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/goccy/go-yaml/internal/errors"
+	"golang.org/x/xerrors"
+)
+
+// Config holds pre-validated options for Decoder and Encoder.
+// Config is immutable after creation, so it can be shared across goroutines
+// and used to create Decoders and Encoders without evaluating options on each call.
+type Config struct {
+	decoder *Decoder
+	encoder *Encoder
+}
+
+// NewConfig validates passed options and creates Config.
+// Reference files and directories passed by DecodeOption are read at this point.
+// CommentToMap and Stats cannot be passed because the Decoders created from Config would write to them concurrently.
+func NewConfig(decodeOpts []DecodeOption, encodeOpts []EncodeOption) (*Config, error) {
+	dec := NewDecoder(nil, decodeOpts...)
+	if err := dec.resolveReference(); err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve decode options")
+	}
+	if dec.toCommentMap != nil {
+		return nil, xerrors.New("CommentToMap cannot be shared by Config. pass it to NewDecoder instead")
+	}
+	if dec.stats != nil {
+		return nil, xerrors.New("Stats cannot be shared by Config. pass it to NewDecoder instead")
+	}
+	// reference readers are already read into the anchors
+	dec.referenceReaders = nil
+	enc := NewEncoder(nil, encodeOpts...)
+	if err := enc.applyOptions(); err != nil {
+		return nil, errors.Wrapf(err, "failed to apply encode options")
+	}
+	return &Config{
+		decoder: dec,
+		encoder: enc,
+	}, nil
+}
+
+// NewDecoder returns a new decoder that reads from r with options of Config.
+func (c *Config) NewDecoder(r io.Reader) *Decoder {
+	dec := *c.decoder
+	dec.Reset(r)
+	return &dec
+}
+
+// NewEncoder returns a new encoder that writes to w with options of Config.
+func (c *Config) NewEncoder(w io.Writer) *Encoder {
+	enc := *c.encoder
+	enc.Reset(w)
+	return &enc
+}
+
+// Marshal serializes the value provided into a YAML document with options of Config.
+// See the documentation of yaml.Marshal for details.
+func (c *Config) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal")
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes the first document found within the in byte slice
+// and assigns decoded values into the out value with options of Config.
+// See the documentation of yaml.Unmarshal for details.
+func (c *Config) Unmarshal(data []byte, v interface{}) error {
+	dec := c.NewDecoder(bytes.NewBuffer(data))
+	if err := dec.Decode(v); err != nil {
+		if err == io.EOF {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to unmarshal")
+	}
+	if err := dec.validateRestDocuments(); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal")
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package yaml_test
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+)
+
+func TestConfig(t *testing.T) {
+	cfg, err := yaml.NewConfig(
+		[]yaml.DecodeOption{yaml.ReferenceFiles("testdata/anchor.yml")},
+		[]yaml.EncodeOption{yaml.Flow(true)},
+	)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var v struct {
+				A struct {
+					B int
+					C string
+				}
+			}
+			if err := cfg.Unmarshal([]byte("a: *a\n"), &v); err != nil {
+				t.Errorf("%+v", err)
+				return
+			}
+			if v.A.B != 1 || v.A.C != "hello" {
+				t.Errorf("failed to decode with config: %+v", v)
+				return
+			}
+			b, err := cfg.Marshal(v)
+			if err != nil {
+				t.Errorf("%+v", err)
+				return
+			}
+			if string(b) != "{a: {b: 1, c: hello}}\n" {
+				t.Errorf("failed to encode with config: %s", string(b))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestConfig_InvalidOption(t *testing.T) {
+	tests := [][]yaml.DecodeOption{
+		{yaml.ReferenceFiles("testdata/not_found.yml")},
+		{yaml.CommentToMap(yaml.CommentMap{})},
+		{yaml.Stats(&yaml.DecodeStats{})},
+	}
+	for _, opts := range tests {
+		if _, err := yaml.NewConfig(opts, nil); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+}
+
+func TestConfig_ParallelDecoders(t *testing.T) {
+	cfg, err := yaml.NewConfig(
+		[]yaml.DecodeOption{
+			yaml.ReferenceReaders(strings.NewReader("a: &a [1, 2]\n")),
+			yaml.UseOrderedMap(),
+			yaml.Strict(),
+		},
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	src := "b: *a\nc: &c {d: e}\nf: *c\n---\ng: *a\n"
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dec := cfg.NewDecoder(strings.NewReader(src))
+			for {
+				var v interface{}
+				if err := dec.Decode(&v); err != nil {
+					if err != io.EOF {
+						t.Errorf("%+v", err)
+					}
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
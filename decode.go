@@ -1,6 +1,8 @@
 package yaml
 
 import (
+	"bufio"
+	"encoding"
 	"encoding/base64"
 	"fmt"
 	"io"
@@ -10,26 +12,98 @@ import (
 	"path/filepath"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/goccy/go-yaml/ast"
 	"github.com/goccy/go-yaml/internal/errors"
+	"github.com/goccy/go-yaml/lexer"
 	"github.com/goccy/go-yaml/parser"
 	"github.com/goccy/go-yaml/token"
 	"golang.org/x/xerrors"
 )
 
+const (
+	// DefaultMaxAliasCount default limit of the number of alias expansions in a document
+	DefaultMaxAliasCount = ast.DefaultMaxAliasCount
+	// DefaultAliasDepthLimit default limit of the depth of nested alias expansion ( e.g. alias in the anchored value )
+	DefaultAliasDepthLimit = ast.DefaultAliasDepthLimit
+	// SafeModeMaxDepth limit of the depth of nested mappings and sequences with SafeMode
+	SafeModeMaxDepth = 1000
+	// SafeModeMaxDocumentSize limit of the size of document in bytes with SafeMode
+	SafeModeMaxDocumentSize = 10 * 1024 * 1024
+)
+
 // Decoder reads and decodes YAML values from an input stream.
 type Decoder struct {
-	reader              io.Reader
-	referenceReaders    []io.Reader
-	anchorMap           map[string]ast.Node
-	opts                []DecodeOption
-	referenceFiles      []string
-	referenceDirs       []string
-	isRecursiveDir      bool
-	isResolvedReference bool
-	validator           StructValidator
+	reader                io.Reader
+	referenceReaders      []io.Reader
+	anchorMap             map[string]ast.Node
+	referenceAnchorMap    map[string]ast.Node
+	anchorValueCache      map[anchorValueCacheKey]*anchorValue
+	opts                  []DecodeOption
+	referenceFiles        []string
+	referenceDirs         []string
+	isRecursiveDir        bool
+	isResolvedReference   bool
+	isPreservedTag        bool
+	isPromotedScalar      bool
+	isCoercedToString     bool
+	disallowUnknown       bool
+	disallowDuplicateKey  bool
+	inlineSource          ast.Node            // source of inline struct field being decoded
+	inlineKeys            map[string]struct{} // keys of struct fields decoded from inlineSource
+	isDisabledMergeKey    bool
+	nullPolicy            NullPolicy
+	mergePolicy           MergePolicy
+	arrayLengthPolicy     ArrayLengthPolicy
+	duplicateAnchorPolicy DuplicateAnchorPolicy
+	warnDuplicateAnchor   func(name string, first, second *token.Position)
+	stopDecoding          func(string, interface{}) bool
+	documentHook          func(int, ast.Node, interface{}) error
+	validator             StructValidator
+	toCommentMap          CommentMap
+	documentNode          ast.Node // root node of the document being decoded
+	maxAliasCount         int
+	aliasDepthLimit       int
+	aliasCount            int   // number of aliases expanded in the document being decoded
+	aliasDepth            int   // depth of nested alias expansion
+	aliasPeakDepth        int   // deepest aliasDepth reached in the value being decoded
+	valueErr              error // error detected while converting node to value ( e.g. excessive aliasing )
+	maxDepth              int
+	maxDocumentSize       int
+	isSafeMode            bool
+	structFieldOption     structFieldOption
+	useOrderedMap         bool
+	isTypedMapKey         bool // keys of MapSlice are decoded by the type of key ( e.g. `1` as uint64 ) instead of string
+	stats                 *DecodeStats
+	timeLayouts           []string
+	isCoreSchema          bool
+	isLenientComment      bool
+
+	// state of reading documents from reader one by one
+	streamReader     *bufio.Reader
+	nextDocumentLine string
+	readLines        int
+	readBytes        int
+	documentLine     int
+	documentOffset   int
+	documentIndex    int // index of the next document in the stream
+}
+
+// anchorValueCacheKey key for caching decoded value of anchor by target type
+type anchorValueCacheKey struct {
+	node ast.Node
+	typ  reflect.Type
+}
+
+// anchorValue decoded value of anchor
+type anchorValue struct {
+	value reflect.Value
+	// isExpanded is true if the value is decoded via alias, and the aliases expanded in it are recorded
+	isExpanded bool
+	aliasCount int // number of aliases expanded while decoding the value
+	aliasDepth int // depth of nested alias expansion in the value
 }
 
 // NewDecoder returns a new decoder that reads from r.
@@ -37,12 +111,16 @@ func NewDecoder(r io.Reader, opts ...DecodeOption) *Decoder {
 	return &Decoder{
 		reader:              r,
 		anchorMap:           map[string]ast.Node{},
+		referenceAnchorMap:  map[string]ast.Node{},
+		anchorValueCache:    map[anchorValueCacheKey]*anchorValue{},
 		opts:                opts,
 		referenceReaders:    []io.Reader{},
 		referenceFiles:      []string{},
 		referenceDirs:       []string{},
 		isRecursiveDir:      false,
 		isResolvedReference: false,
+		maxAliasCount:       DefaultMaxAliasCount,
+		aliasDepthLimit:     DefaultAliasDepthLimit,
 	}
 }
 
@@ -97,33 +175,36 @@ func (d *Decoder) nodeToValue(node ast.Node) interface{} {
 	case *ast.NanNode:
 		return n.GetValue()
 	case *ast.TagNode:
-		switch n.Start.Value {
-		case token.TimestampTag:
-			t, _ := d.castToTime(n.Value)
-			return t
-		case token.FloatTag:
-			return d.castToFloat(d.nodeToValue(n.Value))
-		case token.NullTag:
-			return nil
-		case token.BinaryTag:
-			b, _ := base64.StdEncoding.DecodeString(d.nodeToValue(n.Value).(string))
-			return b
+		value := d.tagNodeToValue(n)
+		if d.isPreservedTag {
+			return TaggedValue{Tag: n.Start.Value, Value: value}
 		}
+		return value
 	case *ast.AnchorNode:
 		anchorName := n.Name.GetToken().Value
 		anchorValue := d.nodeToValue(n.Value)
 		d.anchorMap[anchorName] = n.Value
 		return anchorValue
 	case *ast.AliasNode:
+		if err := d.expandAlias(n); err != nil {
+			d.setValueErr(err)
+			return nil
+		}
+		defer d.finishAlias()
 		aliasName := n.Value.GetToken().Value
 		return d.nodeToValue(d.anchorMap[aliasName])
 	case *ast.LiteralNode:
 		return n.Value.GetValue()
+	case *ast.MergeKeyNode:
+		// merge key is decoded as normal key if it is disabled
+		return n.GetValue()
 	case *ast.MappingValueNode:
+		if d.useOrderedMap {
+			return d.nodeToMapSlice(n)
+		}
 		m := map[string]interface{}{}
-		if n.Key.Type() == ast.MergeKeyType {
-			mapValue := d.nodeToValue(n.Value).(map[string]interface{})
-			for k, v := range mapValue {
+		if d.isMergeKey(n.Key) {
+			for k, v := range d.mergedMap(n.Value) {
 				m[k] = v
 			}
 		} else {
@@ -132,6 +213,9 @@ func (d *Decoder) nodeToValue(node ast.Node) interface{} {
 		}
 		return m
 	case *ast.MappingNode:
+		if d.useOrderedMap {
+			return d.nodeToMapSlice(n)
+		}
 		m := map[string]interface{}{}
 		for _, value := range n.Values {
 			subMap := d.nodeToValue(value).(map[string]interface{})
@@ -150,29 +234,161 @@ func (d *Decoder) nodeToValue(node ast.Node) interface{} {
 	return nil
 }
 
+// nodeToMapSlice converts mapping node to MapSlice to keep the order of keys.
+func (d *Decoder) nodeToMapSlice(node ast.Node) MapSlice {
+	m := MapSlice{}
+	d.setToMapSlice(&m, node)
+	return m
+}
+
+func (d *Decoder) setToMapSlice(m *MapSlice, node ast.Node) {
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		for _, value := range n.Values {
+			d.setToMapSlice(m, value)
+		}
+	case *ast.MappingValueNode:
+		if d.isMergeKey(n.Key) {
+			for _, item := range d.mergedMapSlice(n.Value) {
+				setMapItem(m, item)
+			}
+			return
+		}
+		var key interface{} = n.Key.GetToken().Value
+		if d.isTypedMapKey {
+			key = d.nodeToValue(n.Key)
+		}
+		setMapItem(m, MapItem{Key: key, Value: d.nodeToValue(n.Value)})
+	}
+}
+
+// mergedMap converts the value of merge key to map.
+// The value can be a sequence of mappings ( e.g. `<<: [*a, *b]` ), and the former mapping takes precedence over the latter.
+func (d *Decoder) mergedMap(node ast.Node) map[string]interface{} {
+	m := map[string]interface{}{}
+	switch v := d.nodeToValue(node).(type) {
+	case map[string]interface{}:
+		return v
+	case []interface{}:
+		for i := len(v) - 1; i >= 0; i-- {
+			merged, _ := v[i].(map[string]interface{})
+			for k, value := range merged {
+				m[k] = value
+			}
+		}
+	}
+	return m
+}
+
+// mergedMapSlice converts the value of merge key to MapSlice in the same way as mergedMap
+func (d *Decoder) mergedMapSlice(node ast.Node) MapSlice {
+	switch v := d.nodeToValue(node).(type) {
+	case MapSlice:
+		return v
+	case []interface{}:
+		m := MapSlice{}
+		for _, elem := range v {
+			merged, _ := elem.(MapSlice)
+			for _, item := range merged {
+				if !hasMapItem(m, item.Key) {
+					m = append(m, item)
+				}
+			}
+		}
+		return m
+	}
+	return nil
+}
+
+func hasMapItem(m MapSlice, key interface{}) bool {
+	for _, item := range m {
+		if item.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// setMapItem replaces the value of item having same key in place, or appends item to m.
+func setMapItem(m *MapSlice, item MapItem) {
+	for i := range *m {
+		if (*m)[i].Key == item.Key {
+			(*m)[i].Value = item.Value
+			return
+		}
+	}
+	*m = append(*m, item)
+}
+
+// setValueErr keeps the first error detected while converting node to value, which is returned by Decode
+func (d *Decoder) setValueErr(err error) {
+	if d.valueErr == nil {
+		d.valueErr = err
+	}
+}
+
+func (d *Decoder) tagNodeToValue(n *ast.TagNode) interface{} {
+	switch n.Start.Value {
+	case token.TimestampTag:
+		t, _ := d.castToTime(n.Value)
+		return t
+	case token.FloatTag:
+		return d.castToFloat(d.nodeToValue(n.Value))
+	case token.NullTag:
+		return nil
+	case token.StringTag:
+		return n.Value.GetToken().Value
+	case token.BinaryTag:
+		b, err := d.binaryBytes(n.Value)
+		if err != nil {
+			d.setValueErr(err)
+			return nil
+		}
+		return b
+	}
+	return d.nodeToValue(n.Value)
+}
+
+// nodeToScalarValue converts node to value for the destination of scalar type.
+// Tag of the node isn't preserved because it can't be assigned to the destination.
+func (d *Decoder) nodeToScalarValue(node ast.Node) interface{} {
+	v := d.nodeToValue(node)
+	if tagged, ok := v.(TaggedValue); ok {
+		return tagged.Value
+	}
+	return v
+}
+
 func (d *Decoder) getMapNode(node ast.Node) (ast.MapNode, error) {
 	if _, ok := node.(*ast.NullNode); ok {
 		return nil, nil
 	}
+	if tag, ok := node.(*ast.TagNode); ok {
+		return d.getMapNode(tag.Value)
+	}
 	if anchor, ok := node.(*ast.AnchorNode); ok {
 		mapNode, ok := anchor.Value.(ast.MapNode)
 		if ok {
 			return mapNode, nil
 		}
-		return nil, xerrors.Errorf("%s node doesn't MapNode", anchor.Value.Type())
+		return nil, errors.ErrSyntax(errors.CodeUnexpectedNodeType, errorToken(anchor.Value), anchor.Value.Type(), ast.MappingType)
 	}
 	if alias, ok := node.(*ast.AliasNode); ok {
+		if err := d.expandAlias(alias); err != nil {
+			return nil, err
+		}
+		d.finishAlias()
 		aliasName := alias.Value.GetToken().Value
 		anchorNode := d.anchorMap[aliasName]
 		mapNode, ok := anchorNode.(ast.MapNode)
 		if ok {
 			return mapNode, nil
 		}
-		return nil, xerrors.Errorf("%s node doesn't MapNode", anchorNode.Type())
+		return nil, errors.ErrSyntax(errors.CodeUnexpectedNodeType, alias.GetToken(), anchorNode.Type(), ast.MappingType)
 	}
 	mapNode, ok := node.(ast.MapNode)
 	if !ok {
-		return nil, xerrors.Errorf("%s node doesn't MapNode", node.Type())
+		return nil, errors.ErrSyntax(errors.CodeUnexpectedNodeType, errorToken(node), node.Type(), ast.MappingType)
 	}
 	return mapNode, nil
 }
@@ -181,54 +397,210 @@ func (d *Decoder) getArrayNode(node ast.Node) (ast.ArrayNode, error) {
 	if _, ok := node.(*ast.NullNode); ok {
 		return nil, nil
 	}
+	if tag, ok := node.(*ast.TagNode); ok {
+		return d.getArrayNode(tag.Value)
+	}
 	if anchor, ok := node.(*ast.AnchorNode); ok {
 		arrayNode, ok := anchor.Value.(ast.ArrayNode)
 		if ok {
 			return arrayNode, nil
 		}
-		return nil, xerrors.Errorf("%s node doesn't ArrayNode", anchor.Value.Type())
+		return nil, errors.ErrSyntax(errors.CodeUnexpectedNodeType, errorToken(anchor.Value), anchor.Value.Type(), ast.SequenceType)
 	}
 	if alias, ok := node.(*ast.AliasNode); ok {
+		if err := d.expandAlias(alias); err != nil {
+			return nil, err
+		}
+		d.finishAlias()
 		aliasName := alias.Value.GetToken().Value
 		anchorNode := d.anchorMap[aliasName]
 		arrayNode, ok := anchorNode.(ast.ArrayNode)
 		if ok {
 			return arrayNode, nil
 		}
-		return nil, xerrors.Errorf("%s node doesn't ArrayNode", anchorNode.Type())
+		return nil, errors.ErrSyntax(errors.CodeUnexpectedNodeType, alias.GetToken(), anchorNode.Type(), ast.SequenceType)
 	}
 	arrayNode, ok := node.(ast.ArrayNode)
 	if !ok {
-		return nil, xerrors.Errorf("%s node doesn't ArrayNode", node.Type())
+		return nil, errors.ErrSyntax(errors.CodeUnexpectedNodeType, errorToken(node), node.Type(), ast.SequenceType)
 	}
 	return arrayNode, nil
 }
 
-func (d *Decoder) fileToNode(f *ast.File) ast.Node {
+func (d *Decoder) fileToNode(f *ast.File) (ast.Node, error) {
 	for _, doc := range f.Docs {
-		if v := d.nodeToValue(doc.Body); v != nil {
-			return doc.Body
+		if doc.Body != nil {
+			if err := d.newAnchorCollector().collect(doc.Body); err != nil {
+				return nil, err
+			}
+			if err := d.checkDuplicateKey(doc.Body); err != nil {
+				return nil, err
+			}
+			return doc.Body, nil
+		}
+	}
+	return nil, nil
+}
+
+// anchorCollector registers anchors defined in the document.
+// It doesn't follow aliases, so anchors are registered without expanding the aliased values.
+type anchorCollector struct {
+	d       *Decoder
+	defined map[string]*token.Token // first definition of anchors in the document
+	err     error
+}
+
+func (d *Decoder) newAnchorCollector() *anchorCollector {
+	return &anchorCollector{d: d, defined: map[string]*token.Token{}}
+}
+
+func (c *anchorCollector) collect(node ast.Node) error {
+	ast.WalkAll(c, node)
+	return c.err
+}
+
+func (c *anchorCollector) Visit(node ast.Node) ast.Visitor {
+	if c.err != nil {
+		return nil
+	}
+	anchor, ok := node.(*ast.AnchorNode)
+	if !ok {
+		return c
+	}
+	name := anchor.Name.GetToken().Value
+	if first, exists := c.defined[name]; exists {
+		if err := c.d.duplicateAnchor(name, first, anchor.GetToken()); err != nil {
+			c.err = err
+			return nil
+		}
+	} else {
+		c.defined[name] = anchor.GetToken()
+	}
+	c.d.anchorMap[name] = anchor.Value
+	return c
+}
+
+// duplicateKeyChecker finds the first key defined twice in a mapping
+type duplicateKeyChecker struct {
+	err error
+}
+
+func (c *duplicateKeyChecker) Visit(node ast.Node) ast.Visitor {
+	if c.err != nil {
+		return nil
+	}
+	mapping, ok := node.(*ast.MappingNode)
+	if !ok {
+		return c
+	}
+	keys := map[interface{}]ast.Node{}
+	for _, value := range mapping.Values {
+		if value.Key.Type() == ast.MergeKeyType {
+			continue
+		}
+		key := ast.KeyValue(value.Key)
+		if first, exists := keys[key]; exists {
+			pos := first.GetToken().Position
+			c.err = errors.ErrSyntax(errors.CodeDuplicateKey, value.Key.GetToken(), fmt.Sprint(key), pos.Line, pos.Column).
+				AddRelated(first.GetToken(), "first definition of the key")
+			return nil
+		}
+		keys[key] = value.Key
+	}
+	return c
+}
+
+// checkDuplicateKey returns error if a mapping in node has the same key twice and DisallowDuplicateKey option is enabled
+func (d *Decoder) checkDuplicateKey(node ast.Node) error {
+	if !d.disallowDuplicateKey || node == nil {
+		return nil
+	}
+	checker := &duplicateKeyChecker{}
+	ast.WalkAll(checker, node)
+	return checker.err
+}
+
+// nodeLimitChecker finds the first node rejected by MaxDepth or SafeMode.
+// depth is the number of mappings and sequences enclosing the visited node.
+type nodeLimitChecker struct {
+	d     *Decoder
+	depth int
+	err   *error
+}
+
+func (c *nodeLimitChecker) Visit(node ast.Node) ast.Visitor {
+	if *c.err != nil {
+		return nil
+	}
+	switch n := node.(type) {
+	case *ast.TagNode:
+		if c.d.isSafeMode && !isYAMLTag(n.Start.Value) {
+			*c.err = errors.ErrSyntax(errors.CodeUnsafeTag, n.Start, n.Start.Value)
+			return nil
+		}
+	case *ast.MappingValueNode, *ast.SequenceNode:
+		// mapping is counted at its values because a mapping which has one value is parsed as MappingValueNode
+		if c.d.maxDepth > 0 && c.depth >= c.d.maxDepth {
+			*c.err = errors.ErrSyntax(errors.CodeExcessiveNesting, node.GetToken(), c.d.maxDepth)
+			return nil
+		}
+		return &nodeLimitChecker{d: c.d, depth: c.depth + 1, err: c.err}
+	}
+	return c
+}
+
+// checkNodeLimit returns error if node is nested deeper than MaxDepth or has the tag rejected by SafeMode
+func (d *Decoder) checkNodeLimit(node ast.Node) error {
+	if node == nil || (d.maxDepth <= 0 && !d.isSafeMode) {
+		return nil
+	}
+	var err error
+	ast.WalkAll(&nodeLimitChecker{d: d, err: &err}, node)
+	return err
+}
+
+// isYAMLTag reports whether tag is defined by YAML ( e.g. `!!str` )
+func isYAMLTag(tag string) bool {
+	switch tag {
+	case string(token.IntegerTag), token.FloatTag, token.NullTag, token.BooleanTag, token.SequenceTag, token.MappingTag,
+		token.StringTag, token.BinaryTag, token.OrderedMapTag, token.SetTag, token.TimestampTag:
+		return true
+	}
+	return false
+}
+
+// duplicateAnchor handles the anchor defined twice in one document by DuplicateAnchorPolicy
+func (d *Decoder) duplicateAnchor(name string, first, second *token.Token) error {
+	switch d.duplicateAnchorPolicy {
+	case DuplicateAnchorPolicyError:
+		return errors.ErrSyntax(errors.CodeDuplicateAnchor, second, name, first.Position.Line, first.Position.Column)
+	case DuplicateAnchorPolicyWarn:
+		if d.warnDuplicateAnchor != nil {
+			d.warnDuplicateAnchor(name, first.Position, second.Position)
 		}
 	}
 	return nil
 }
 
-func (d *Decoder) convertValue(v reflect.Value, typ reflect.Type) reflect.Value {
-	if typ.Kind() != reflect.String {
-		return v.Convert(typ)
+// convertValue converts v to typ. It reports false if v cannot be converted ( e.g. mapping value to string ).
+func (d *Decoder) convertValue(v reflect.Value, typ reflect.Type) (reflect.Value, bool) {
+	if typ.Kind() == reflect.String {
+		// cast value to string
+		switch v.Type().Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return reflect.ValueOf(fmt.Sprint(v.Int())).Convert(typ), true
+		case reflect.Float32, reflect.Float64:
+			return reflect.ValueOf(fmt.Sprint(v.Float())).Convert(typ), true
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return reflect.ValueOf(fmt.Sprint(v.Uint())).Convert(typ), true
+		case reflect.Bool:
+			return reflect.ValueOf(fmt.Sprint(v.Bool())).Convert(typ), true
+		}
 	}
-	// cast value to string
-	switch v.Type().Kind() {
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return reflect.ValueOf(fmt.Sprint(v.Int()))
-	case reflect.Float32, reflect.Float64:
-		return reflect.ValueOf(fmt.Sprint(v.Float()))
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-		return reflect.ValueOf(fmt.Sprint(v.Uint()))
-	case reflect.Bool:
-		return reflect.ValueOf(fmt.Sprint(v.Bool()))
+	if !v.Type().ConvertibleTo(typ) {
+		return reflect.Value{}, false
 	}
-	return v.Convert(typ)
+	return v.Convert(typ), true
 }
 
 var (
@@ -236,6 +608,30 @@ var (
 	errTypeMismatch   = xerrors.New("type mismatch")
 )
 
+// typeMismatchError create error which has the position of src and is errTypeMismatch
+func typeMismatchError(src ast.Node, typ reflect.Type) error {
+	return errors.ErrSyntax(errors.CodeTypeMismatch, errorToken(src), ast.Unwrap(src).Type(), typ).Wrap(errTypeMismatch)
+}
+
+// invalidTimeValueError create error which has the position of src for text which cannot be parsed as typ
+func invalidTimeValueError(src ast.Node, text string, typ reflect.Type) error {
+	return errors.ErrSyntax(errors.CodeInvalidTimeValue, errorToken(src), text, typ)
+}
+
+// overflowNumberError create error which has the position of src and is errOverflowNumber
+func overflowNumberError(src ast.Node, typ reflect.Type) error {
+	return errors.ErrSyntax(errors.CodeOverflowNumber, errorToken(src), ast.Unwrap(src), typ).Wrap(errOverflowNumber)
+}
+
+// errorToken returns token which points the beginning of node
+func errorToken(node ast.Node) *token.Token {
+	if mv, ok := node.(*ast.MappingValueNode); ok {
+		// token of mapping value is ':'
+		return mv.Key.GetToken()
+	}
+	return node.GetToken()
+}
+
 func (d *Decoder) decodeValue(dst reflect.Value, src ast.Node) error {
 	valueType := dst.Type()
 	if unmarshaler, ok := dst.Addr().Interface().(BytesUnmarshaler); ok {
@@ -258,6 +654,21 @@ func (d *Decoder) decodeValue(dst reflect.Value, src ast.Node) error {
 			return errors.Wrapf(err, "failed to UnmarshalYAML")
 		}
 		return nil
+	} else if unmarshaler, ok := dst.Addr().Interface().(encoding.TextUnmarshaler); ok && valueType != reflect.TypeOf(time.Time{}) && isScalarValue(d.resolveAlias(src)) {
+		// time.Time is decoded by castToTime which supports more formats than UnmarshalText
+		text, ok := d.scalarText(src)
+		if !ok {
+			return nil
+		}
+		if err := unmarshaler.UnmarshalText([]byte(text)); err != nil {
+			return errors.Wrapf(err, "failed to UnmarshalText")
+		}
+		return nil
+	}
+	if alias, ok := src.(*ast.AliasNode); ok {
+		if anchor, exists := d.anchorMap[alias.Value.GetToken().Value]; exists {
+			return d.decodeAnchorValue(dst, alias, anchor)
+		}
 	}
 	switch valueType.Kind() {
 	case reflect.Ptr:
@@ -270,11 +681,18 @@ func (d *Decoder) decodeValue(dst reflect.Value, src ast.Node) error {
 			return nil
 		}
 		v := d.createDecodableValue(dst.Type())
+		d.initDecodableValue(v, dst)
 		if err := d.decodeValue(v, src); err != nil {
 			return errors.Wrapf(err, "failed to decode ptr value")
 		}
 		dst.Set(d.castToAssignableValue(v, dst.Type()))
 	case reflect.Interface:
+		if d.mergePolicy != MergePolicyOverwrite && !dst.IsNil() && src.Type() != ast.NullType {
+			if merged, ok := d.mergeGenericValue(dst.Elem(), src); ok {
+				dst.Set(merged)
+				return nil
+			}
+		}
 		v := reflect.ValueOf(d.nodeToValue(src))
 		if v.IsValid() {
 			dst.Set(v)
@@ -284,6 +702,12 @@ func (d *Decoder) decodeValue(dst reflect.Value, src ast.Node) error {
 	case reflect.Array:
 		return d.decodeArray(dst, src)
 	case reflect.Slice:
+		if mapSlice, ok := dst.Addr().Interface().(*MapSlice); ok {
+			return d.decodeMapSlice(mapSlice, src)
+		}
+		if value, ok := d.binaryValue(src); ok && valueType.Elem().Kind() == reflect.Uint8 {
+			return d.decodeBinary(dst, src, value)
+		}
 		return d.decodeSlice(dst, src)
 	case reflect.Struct:
 		if _, ok := dst.Addr().Interface().(*time.Time); ok {
@@ -291,7 +715,10 @@ func (d *Decoder) decodeValue(dst reflect.Value, src ast.Node) error {
 		}
 		return d.decodeStruct(dst, src)
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		v := d.nodeToValue(src)
+		v := d.nodeToScalarValue(src)
+		if s, ok := v.(string); ok && valueType == reflect.TypeOf(time.Duration(0)) {
+			return d.decodeDuration(dst, src, s)
+		}
 		switch vv := v.(type) {
 		case int64:
 			if !dst.OverflowInt(vv) {
@@ -305,15 +732,16 @@ func (d *Decoder) decodeValue(dst reflect.Value, src ast.Node) error {
 			}
 		case float64:
 			if vv <= math.MaxInt64 && !dst.OverflowInt(int64(vv)) {
+				d.addCoercion(src, dst.Type())
 				dst.SetInt(int64(vv))
 				return nil
 			}
 		default:
-			return errTypeMismatch
+			return typeMismatchError(src, dst.Type())
 		}
-		return errOverflowNumber
+		return overflowNumberError(src, dst.Type())
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		v := d.nodeToValue(src)
+		v := d.nodeToScalarValue(src)
 		switch vv := v.(type) {
 		case int64:
 			if 0 <= vv && !dst.OverflowUint(uint64(vv)) {
@@ -327,123 +755,441 @@ func (d *Decoder) decodeValue(dst reflect.Value, src ast.Node) error {
 			}
 		case float64:
 			if 0 <= vv && vv <= math.MaxUint64 && !dst.OverflowUint(uint64(vv)) {
+				d.addCoercion(src, dst.Type())
 				dst.SetUint(uint64(vv))
 				return nil
 			}
 		default:
-			return errTypeMismatch
+			return typeMismatchError(src, dst.Type())
+		}
+		return overflowNumberError(src, dst.Type())
+	case reflect.String:
+		if d.isCoercedToString {
+			if text, ok := d.scalarText(src); ok {
+				if _, isString := d.nodeToScalarValue(src).(string); !isString {
+					d.addCoercion(src, dst.Type())
+				}
+				dst.SetString(text)
+				return nil
+			}
 		}
-		return errOverflowNumber
 	}
-	v := reflect.ValueOf(d.nodeToValue(src))
+	v := reflect.ValueOf(d.nodeToScalarValue(src))
 	if v.IsValid() {
-		dst.Set(d.convertValue(v, dst.Type()))
+		converted, ok := d.convertValue(v, dst.Type())
+		if !ok {
+			return typeMismatchError(src, dst.Type())
+		}
+		if dst.Kind() == reflect.String && v.Kind() != reflect.String {
+			d.addCoercion(src, dst.Type())
+		}
+		dst.Set(converted)
 	}
 	return nil
 }
 
-func (d *Decoder) createDecodableValue(typ reflect.Type) reflect.Value {
-	for {
-		if typ.Kind() == reflect.Ptr {
-			typ = typ.Elem()
-			continue
-		}
-		break
+// addCoercion reports the conversion of scalar to DecodeStats set by Stats option
+func (d *Decoder) addCoercion(src ast.Node, typ reflect.Type) {
+	if d.stats != nil {
+		d.stats.addCoercion(src, typ)
 	}
-	return reflect.New(typ).Elem()
 }
 
-func (d *Decoder) castToAssignableValue(value reflect.Value, target reflect.Type) reflect.Value {
-	if target.Kind() != reflect.Ptr {
-		return value
+// scalarText returns the text of scalar in source. It reports false if src is null or not scalar.
+func (d *Decoder) scalarText(src ast.Node) (string, bool) {
+	switch n := ast.Unwrap(d.resolveAlias(src)).(type) {
+	case *ast.NullNode:
+		return "", false
+	case *ast.StringNode:
+		return n.Value, true
+	case *ast.LiteralNode:
+		return n.Value.Value, true
+	case ast.ScalarNode:
+		return n.GetToken().Value, true
 	}
-	maxTryCount := 5
-	tryCount := 0
-	for {
-		if tryCount > maxTryCount {
-			return value
+	return "", false
+}
+
+// decodeAnchorValue decodes the value referenced by alias.
+// Decoded value is cached by anchor node and target type,
+// so the anchor's subtree is walked only once per target type even if the alias appears many times.
+// The aliases expanded in the cached value are counted again as if the subtree was walked.
+func (d *Decoder) decodeAnchorValue(dst reflect.Value, alias *ast.AliasNode, anchor ast.Node) error {
+	if d.mergePolicy != MergePolicyOverwrite {
+		// decoded value depends on the existing value of dst
+		if err := d.expandAlias(alias); err != nil {
+			return err
 		}
-		if value.Type().AssignableTo(target) {
-			break
+		defer d.finishAlias()
+		return d.decodeValue(dst, anchor)
+	}
+	key := anchorValueCacheKey{node: anchor, typ: dst.Type()}
+	if cached, exists := d.anchorValueCache[key]; exists && cached.isExpanded {
+		if err := d.expandCachedAlias(alias, cached); err != nil {
+			return err
 		}
-		value = value.Addr()
-		tryCount++
+		// the aliases must not share slices, maps and pointers with each other like the values decoded one by one
+		dst.Set(copyValue(cached.value))
+		return nil
 	}
-	return value
-}
-
-func (d *Decoder) keyToNodeMap(node ast.Node) (map[string]ast.Node, error) {
-	mapNode, err := d.getMapNode(node)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to get map node")
+	if err := d.expandAlias(alias); err != nil {
+		return err
 	}
-	keyToNodeMap := map[string]ast.Node{}
-	if mapNode == nil {
-		return keyToNodeMap, nil
+	defer d.finishAlias()
+	count, peak := d.aliasCount, d.aliasPeakDepth
+	d.aliasPeakDepth = d.aliasDepth
+	if err := d.decodeValue(dst, anchor); err != nil {
+		return err
 	}
-	mapIter := mapNode.MapRange()
-	for mapIter.Next() {
-		keyNode := mapIter.Key()
-		if keyNode.Type() == ast.MergeKeyType {
-			mergeMap, err := d.keyToNodeMap(mapIter.Value())
-			if err != nil {
-				return nil, errors.Wrapf(err, "failed to get keyToNodeMap by MergeKey node")
-			}
-			for k, v := range mergeMap {
-				keyToNodeMap[k] = v
-			}
-		} else {
-			key, ok := d.nodeToValue(keyNode).(string)
-			if !ok {
-				return nil, errors.Wrapf(err, "failed to decode map key")
-			}
-			keyToNodeMap[key] = mapIter.Value()
-		}
+	cached := &anchorValue{
+		value:      reflect.New(dst.Type()).Elem(),
+		isExpanded: true,
+		aliasCount: d.aliasCount - count,
+		aliasDepth: d.aliasPeakDepth - d.aliasDepth,
 	}
-	return keyToNodeMap, nil
+	cached.value.Set(dst)
+	d.anchorValueCache[key] = cached
+	if peak > d.aliasPeakDepth {
+		d.aliasPeakDepth = peak
+	}
+	return nil
 }
 
-func (d *Decoder) setDefaultValueIfConflicted(v reflect.Value, fieldMap StructFieldMap) error {
-	typ := v.Type()
-	if typ.Kind() != reflect.Struct {
-		return nil
-	}
-	embeddedStructFieldMap, err := structFieldMap(typ)
-	if err != nil {
-		return errors.Wrapf(err, "failed to get struct field map by embedded type")
-	}
-	for i := 0; i < typ.NumField(); i++ {
-		field := typ.Field(i)
-		if isIgnoredStructField(field) {
-			continue
+// copyValue returns the deep copy of v.
+// Unexported fields of struct are copied shallowly because they cannot be set by reflection.
+func copyValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
 		}
-		structField := embeddedStructFieldMap[field.Name]
-		if !fieldMap.isIncludedRenderName(structField.RenderName) {
-			continue
+		ptr := reflect.New(v.Type().Elem())
+		ptr.Elem().Set(copyValue(v.Elem()))
+		return ptr
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
 		}
-		// if declared same key name, set default value
-		fieldValue := v.Field(i)
-		if fieldValue.CanSet() {
-			fieldValue.Set(reflect.Zero(fieldValue.Type()))
+		iface := reflect.New(v.Type()).Elem()
+		iface.Set(copyValue(v.Elem()))
+		return iface
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		slice := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			slice.Index(i).Set(copyValue(v.Index(i)))
+		}
+		return slice
+	case reflect.Map:
+		if v.IsNil() {
+			return v
 		}
+		m := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m.SetMapIndex(iter.Key(), copyValue(iter.Value()))
+		}
+		return m
+	case reflect.Array:
+		array := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			array.Index(i).Set(copyValue(v.Index(i)))
+		}
+		return array
+	case reflect.Struct:
+		st := reflect.New(v.Type()).Elem()
+		st.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if field := st.Field(i); field.CanSet() {
+				field.Set(copyValue(v.Field(i)))
+			}
+		}
+		return st
 	}
-	return nil
+	return v
 }
 
-// This is a subset of the formats allowed by the regular expression
-// defined at http://yaml.org/type/timestamp.html.
-var allowedTimestampFormats = []string{
-	"2006-1-2T15:4:5.999999999Z07:00", // RCF3339Nano with short date fields.
-	"2006-1-2t15:4:5.999999999Z07:00", // RFC3339Nano with short date fields and lower-case "t".
-	"2006-1-2 15:4:5.999999999",       // space separated with no time zone
-	"2006-1-2",                        // date only
+// expandAlias accounts the expansion of alias to reject the document which has excessive aliasing
+// ( e.g. "billion laughs" which expands exponentially by the aliases to the anchored values which have aliases ).
+// finishAlias must be called after the anchored value is decoded.
+func (d *Decoder) expandAlias(alias *ast.AliasNode) error {
+	if d.valueErr != nil {
+		return d.valueErr
+	}
+	d.aliasCount++
+	if d.maxAliasCount > 0 && d.aliasCount > d.maxAliasCount {
+		return errors.ErrSyntax(errors.CodeExcessiveAliasing, alias.GetToken(), "number", d.maxAliasCount)
+	}
+	if d.aliasDepthLimit > 0 && d.aliasDepth >= d.aliasDepthLimit {
+		return errors.ErrSyntax(errors.CodeExcessiveAliasing, alias.GetToken(), "depth", d.aliasDepthLimit)
+	}
+	d.aliasDepth++
+	if d.aliasDepth > d.aliasPeakDepth {
+		d.aliasPeakDepth = d.aliasDepth
+	}
+	return nil
+}
+
+// expandCachedAlias accounts the expansion of alias whose value is cached with the aliases expanded in it
+func (d *Decoder) expandCachedAlias(alias *ast.AliasNode, cached *anchorValue) error {
+	if err := d.expandAlias(alias); err != nil {
+		return err
+	}
+	defer d.finishAlias()
+	d.aliasCount += cached.aliasCount
+	if d.maxAliasCount > 0 && d.aliasCount > d.maxAliasCount {
+		return errors.ErrSyntax(errors.CodeExcessiveAliasing, alias.GetToken(), "number", d.maxAliasCount)
+	}
+	if d.aliasDepthLimit > 0 && d.aliasDepth+cached.aliasDepth > d.aliasDepthLimit {
+		return errors.ErrSyntax(errors.CodeExcessiveAliasing, alias.GetToken(), "depth", d.aliasDepthLimit)
+	}
+	return nil
+}
+
+func (d *Decoder) finishAlias() {
+	d.aliasDepth--
+}
+
+// isAnchorOrAliasField reports whether the field has anchor or alias option ( e.g. `yaml:"a,anchor"` )
+func isAnchorOrAliasField(field *StructField) bool {
+	return field.IsAutoAnchor || field.AnchorName != "" || field.IsAutoAlias || field.AliasName != ""
+}
+
+// anchoredNode returns the value of anchor which node defines or refers to
+func (d *Decoder) anchoredNode(node ast.Node) ast.Node {
+	switch n := node.(type) {
+	case *ast.AnchorNode:
+		return n.Value
+	case *ast.AliasNode:
+		return d.anchorMap[n.Value.GetToken().Value]
+	}
+	return nil
+}
+
+// anchorPointer returns the pointer decoded from the anchor which src defines or refers to,
+// so that the fields of the anchor and aliases of it share the same pointer like the encoded value.
+func (d *Decoder) anchorPointer(src ast.Node, typ reflect.Type) (reflect.Value, bool) {
+	anchored := d.anchoredNode(src)
+	if anchored == nil || d.mergePolicy != MergePolicyOverwrite {
+		return reflect.Value{}, false
+	}
+	cached, exists := d.anchorValueCache[anchorValueCacheKey{node: anchored, typ: typ}]
+	if !exists {
+		return reflect.Value{}, false
+	}
+	return cached.value, true
+}
+
+func (d *Decoder) setAnchorPointer(src ast.Node, ptr reflect.Value) {
+	anchored := d.anchoredNode(src)
+	if anchored == nil || ptr.IsNil() {
+		return
+	}
+	v := reflect.New(ptr.Type()).Elem()
+	v.Set(ptr)
+	d.anchorValueCache[anchorValueCacheKey{node: anchored, typ: ptr.Type()}] = &anchorValue{value: v}
+}
+
+// decodeGenericValue decodes src into the commonly used generic types
+// ( interface{} / map[string]interface{} / []interface{} ) without reflection.
+// It reports whether v was decoded.
+func (d *Decoder) decodeGenericValue(v interface{}, src ast.Node) bool {
+	switch vv := v.(type) {
+	case *interface{}:
+		if value := d.nodeToValue(src); value != nil {
+			*vv = value
+		}
+		return true
+	case *map[string]interface{}:
+		m, ok := d.nodeToValue(src).(map[string]interface{})
+		if !ok {
+			return false
+		}
+		*vv = m
+		return true
+	case *[]interface{}:
+		s, ok := d.nodeToValue(src).([]interface{})
+		if !ok {
+			return false
+		}
+		*vv = s
+		return true
+	}
+	return false
+}
+
+func (d *Decoder) createDecodableValue(typ reflect.Type) reflect.Value {
+	for {
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+			continue
+		}
+		break
+	}
+	return reflect.New(typ).Elem()
+}
+
+// mergeGenericValue merges src into the copy of existing map or slice held by interface{} value.
+// It reports false if existing is not map or slice, or src can't be decoded into it.
+func (d *Decoder) mergeGenericValue(existing reflect.Value, src ast.Node) (reflect.Value, bool) {
+	switch existing.Kind() {
+	case reflect.Map, reflect.Slice:
+		v := reflect.New(existing.Type()).Elem()
+		v.Set(existing)
+		if err := d.decodeValue(v, src); err != nil {
+			return reflect.Value{}, false
+		}
+		return v, true
+	}
+	return reflect.Value{}, false
+}
+
+// initDecodableValue sets the existing value of destination to v created by createDecodableValue
+// if MergePolicy isn't MergePolicyOverwrite, so the decoded value is merged into the existing value.
+func (d *Decoder) initDecodableValue(v, existing reflect.Value) {
+	if d.mergePolicy == MergePolicyOverwrite {
+		return
+	}
+	for existing.Kind() == reflect.Ptr {
+		if existing.IsNil() {
+			return
+		}
+		existing = existing.Elem()
+	}
+	v.Set(existing)
+}
+
+// keepsExistingValue reports whether existing value of destination is kept by MergePolicyFillZero.
+// Structs and maps which have values are not kept as a whole unless src is null, because they are filled recursively.
+func (d *Decoder) keepsExistingValue(existing reflect.Value, src ast.Node) bool {
+	if d.mergePolicy != MergePolicyFillZero || isZeroValue(existing) {
+		return false
+	}
+	if src.Type() == ast.NullType {
+		return true
+	}
+	for existing.Kind() == reflect.Interface {
+		existing = existing.Elem()
+	}
+	typ := existing.Type()
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Map:
+		return false
+	case reflect.Struct:
+		if typ == reflect.TypeOf(time.Time{}) {
+			return true
+		}
+		ptr := reflect.PtrTo(typ)
+		return ptr.Implements(reflect.TypeOf((*BytesUnmarshaler)(nil)).Elem()) ||
+			ptr.Implements(reflect.TypeOf((*InterfaceUnmarshaler)(nil)).Elem())
+	}
+	return true
+}
+
+func (d *Decoder) castToAssignableValue(value reflect.Value, target reflect.Type) reflect.Value {
+	if target.Kind() != reflect.Ptr {
+		return value
+	}
+	maxTryCount := 5
+	tryCount := 0
+	for {
+		if tryCount > maxTryCount {
+			return value
+		}
+		if value.Type().AssignableTo(target) {
+			break
+		}
+		value = value.Addr()
+		tryCount++
+	}
+	return value
+}
+
+// isMergeKey reports whether node is merge key ( `<<` ) processed by decoder
+func (d *Decoder) isMergeKey(node ast.Node) bool {
+	return !d.isDisabledMergeKey && node.Type() == ast.MergeKeyType
+}
+
+func (d *Decoder) keyToNodeMap(node ast.Node) (map[string]ast.Node, error) {
+	mapNode, err := d.getMapNode(node)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get map node")
+	}
+	keyToNodeMap := map[string]ast.Node{}
+	if mapNode == nil {
+		return keyToNodeMap, nil
+	}
+	mapIter := mapNode.MapRange()
+	for mapIter.Next() {
+		keyNode := mapIter.Key()
+		if d.isMergeKey(keyNode) {
+			mergeMap, err := d.keyToNodeMap(mapIter.Value())
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get keyToNodeMap by MergeKey node")
+			}
+			for k, v := range mergeMap {
+				keyToNodeMap[k] = v
+			}
+		} else {
+			key, ok := d.nodeToValue(keyNode).(string)
+			if !ok {
+				return nil, errors.Wrapf(err, "failed to decode map key")
+			}
+			keyToNodeMap[key] = mapIter.Value()
+		}
+	}
+	return keyToNodeMap, nil
+}
+
+func (d *Decoder) setDefaultValueIfConflicted(v reflect.Value, fieldMap StructFieldMap) error {
+	typ := v.Type()
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+	embeddedStructFieldMap, err := structFieldMap(typ, d.structFieldOption)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get struct field map by embedded type")
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if isIgnoredStructField(field, d.structFieldOption) {
+			continue
+		}
+		structField := embeddedStructFieldMap[field.Name]
+		if !fieldMap.isIncludedRenderName(structField.RenderName) {
+			continue
+		}
+		// if declared same key name, set default value
+		fieldValue := v.Field(i)
+		if fieldValue.CanSet() {
+			fieldValue.Set(reflect.Zero(fieldValue.Type()))
+		}
+	}
+	return nil
+}
+
+// This is a subset of the formats allowed by the regular expression
+// defined at http://yaml.org/type/timestamp.html.
+var allowedTimestampFormats = []string{
+	"2006-1-2T15:4:5.999999999Z07:00",  // RCF3339Nano with short date fields.
+	"2006-1-2t15:4:5.999999999Z07:00",  // RFC3339Nano with short date fields and lower-case "t".
+	"2006-1-2 15:4:5.999999999",        // space separated with no time zone
+	"2006-1-2 15:4:5.999999999Z07:00",  // space separated
+	"2006-1-2 15:4:5.999999999 Z07:00", // space separated with space before time zone
+	"2006-1-2T15:4:5.999999999",        // ISO8601 with no time zone
+	"2006-1-2",                         // date only
 }
 
 func (d *Decoder) castToTime(src ast.Node) (time.Time, error) {
 	if src == nil {
 		return time.Time{}, nil
 	}
-	v := d.nodeToValue(src)
+	v := d.nodeToScalarValue(src)
 	if t, ok := v.(time.Time); ok {
 		return t, nil
 	}
@@ -451,33 +1197,98 @@ func (d *Decoder) castToTime(src ast.Node) (time.Time, error) {
 	if !ok {
 		return time.Time{}, errTypeMismatch
 	}
-	for _, format := range allowedTimestampFormats {
-		t, err := time.Parse(format, s)
-		if err != nil {
-			// invalid format
-			continue
+	if s == "" {
+		return time.Time{}, nil
+	}
+	// layouts set by DecodeTimeLayouts are preferred to the timestamp formats of YAML
+	for _, formats := range [][]string{d.timeLayouts, allowedTimestampFormats} {
+		for _, format := range formats {
+			t, err := time.Parse(format, s)
+			if err != nil {
+				// invalid format
+				continue
+			}
+			return t, nil
 		}
-		return t, nil
 	}
-	return time.Time{}, nil
+	return time.Time{}, invalidTimeValueError(src, s, reflect.TypeOf(time.Time{}))
 }
 
 func (d *Decoder) decodeTime(dst reflect.Value, src ast.Node) error {
 	t, err := d.castToTime(src)
 	if err != nil {
+		if xerrors.Is(err, errTypeMismatch) {
+			return typeMismatchError(src, dst.Type())
+		}
 		return err
 	}
 	dst.Set(reflect.ValueOf(t))
 	return nil
 }
 
+// binaryValue returns the value of node tagged by `!!binary`. Anchors and aliases are looked through
+func (d *Decoder) binaryValue(node ast.Node) (ast.Node, bool) {
+	node = d.resolveAlias(node)
+	for {
+		switch n := node.(type) {
+		case *ast.AnchorNode:
+			node = n.Value
+		case *ast.TagNode:
+			return n.Value, n.Start.Value == token.BinaryTag
+		default:
+			return nil, false
+		}
+	}
+}
+
+// decodeBinary decodes base64 text of value tagged by `!!binary` into byte slice.
+// The text may be wrapped into lines ( e.g. by literal block scalar )
+func (d *Decoder) decodeBinary(dst reflect.Value, src, value ast.Node) error {
+	if _, ok := d.nodeToValue(value).(string); !ok {
+		return typeMismatchError(src, dst.Type())
+	}
+	b, err := d.binaryBytes(value)
+	if err != nil {
+		return err
+	}
+	dst.SetBytes(b)
+	return nil
+}
+
+// binaryBytes decodes base64 text of value tagged by `!!binary`. The value which is not string is invalid
+func (d *Decoder) binaryBytes(value ast.Node) ([]byte, error) {
+	s, ok := d.nodeToValue(value).(string)
+	if !ok {
+		return nil, errors.ErrSyntax(errors.CodeInvalidBinaryValue, errorToken(value), value.String())
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+	if err != nil {
+		return nil, errors.ErrSyntax(errors.CodeInvalidBinaryValue, errorToken(value), s)
+	}
+	return b, nil
+}
+
+// decodeDuration decodes the string like `1h30m` parsed by time.ParseDuration.
+// Integer is decoded as nanoseconds like the other integer types
+func (d *Decoder) decodeDuration(dst reflect.Value, src ast.Node, s string) error {
+	duration, err := time.ParseDuration(s)
+	if err != nil {
+		return invalidTimeValueError(src, s, dst.Type())
+	}
+	dst.SetInt(int64(duration))
+	return nil
+}
+
 func (d *Decoder) decodeStruct(dst reflect.Value, src ast.Node) error {
 	if src == nil {
 		return nil
 	}
 	structType := dst.Type()
 	structValue := reflect.New(structType)
-	structFieldMap, err := structFieldMap(structType)
+	if d.mergePolicy != MergePolicyOverwrite {
+		structValue.Elem().Set(dst)
+	}
+	structFieldMap, err := structFieldMap(structType, d.structFieldOption)
 	if err != nil {
 		return errors.Wrapf(err, "failed to create struct field map")
 	}
@@ -485,9 +1296,29 @@ func (d *Decoder) decodeStruct(dst reflect.Value, src ast.Node) error {
 	if err != nil {
 		return errors.Wrapf(err, "failed to get keyToNodeMap")
 	}
+	if d.disallowUnknown && src != d.inlineSource && !hasInlineMap(structType, d.structFieldOption) {
+		// keys of inline struct are checked with the keys of parent struct,
+		// and inline map receives the unknown keys
+		if err := d.checkUnknownField(src, structFieldKeys(structType, d.structFieldOption)); err != nil {
+			return err
+		}
+	}
+	if d.stats != nil && src != d.inlineSource && !hasInlineMap(structType, d.structFieldOption) {
+		d.findUnknownField(src, structFieldKeys(structType, d.structFieldOption), func(keyNode ast.Node, key string) bool {
+			d.stats.addUnknownKey(keyNode, key)
+			return true
+		})
+	}
+	inlineKeys := structFieldKeys(structType, d.structFieldOption)
+	if src == d.inlineSource {
+		// the keys decoded into the fields of parent struct are not decoded into inline map
+		for key := range d.inlineKeys {
+			inlineKeys[key] = struct{}{}
+		}
+	}
 	for i := 0; i < structType.NumField(); i++ {
 		field := structType.Field(i)
-		if isIgnoredStructField(field) {
+		if isIgnoredStructField(field, d.structFieldOption) {
 			continue
 		}
 		structField := structFieldMap[field.Name]
@@ -501,8 +1332,22 @@ func (d *Decoder) decodeStruct(dst reflect.Value, src ast.Node) error {
 				fieldValue.Set(reflect.Zero(fieldValue.Type()))
 				continue
 			}
+			if fieldValue.Type().Kind() == reflect.Ptr && !hasInlineFieldKey(fieldValue.Type(), keyToNodeMap, structFieldMap, d.structFieldOption) {
+				// keep nil pointer of embedded struct whose keys don't appear
+				continue
+			}
 			newFieldValue := d.createDecodableValue(fieldValue.Type())
-			if err := d.decodeValue(newFieldValue, src); err != nil {
+			d.initDecodableValue(newFieldValue, fieldValue)
+			parentInlineSource, parentInlineKeys := d.inlineSource, d.inlineKeys
+			d.inlineSource, d.inlineKeys = src, inlineKeys
+			var err error
+			if newFieldValue.Kind() == reflect.Map {
+				err = d.decodeMapWithoutKeys(newFieldValue, src, inlineKeys)
+			} else {
+				err = d.decodeValue(newFieldValue, src)
+			}
+			d.inlineSource, d.inlineKeys = parentInlineSource, parentInlineKeys
+			if err != nil {
 				if xerrors.Is(err, errTypeMismatch) || xerrors.Is(err, errOverflowNumber) {
 					// skip decoding if an error occurs
 					continue
@@ -518,12 +1363,35 @@ func (d *Decoder) decodeStruct(dst reflect.Value, src ast.Node) error {
 			continue
 		}
 		fieldValue := structValue.Elem().FieldByName(field.Name)
-		if fieldValue.Type().Kind() == reflect.Ptr && src.Type() == ast.NullType {
+		if d.keepsExistingValue(fieldValue, v) {
+			continue
+		}
+		if fieldValue.Type().Kind() == reflect.Ptr && v.Type() == ast.NullType {
 			// set nil value to pointer
 			fieldValue.Set(reflect.Zero(fieldValue.Type()))
 			continue
 		}
+		if structField.IsDocument && v.Type() != ast.NullType {
+			if err := d.decodeDocumentField(fieldValue, v); err != nil {
+				return errors.Wrapf(err, "failed to decode %s field as document", field.Name)
+			}
+			continue
+		}
+		if v.Type() == ast.NullType && d.nullPolicy != NullPolicyZero && !isNullableType(fieldValue.Type()) {
+			if err := d.decodeNull(fieldValue, v); err != nil {
+				return err
+			}
+			continue
+		}
+		isSharedPointer := isAnchorOrAliasField(structField) && fieldValue.Type().Kind() == reflect.Ptr
+		if isSharedPointer {
+			if ptr, exists := d.anchorPointer(v, fieldValue.Type()); exists {
+				fieldValue.Set(ptr)
+				continue
+			}
+		}
 		newFieldValue := d.createDecodableValue(fieldValue.Type())
+		d.initDecodableValue(newFieldValue, fieldValue)
 		if err := d.decodeValue(newFieldValue, v); err != nil {
 			if xerrors.Is(err, errTypeMismatch) || xerrors.Is(err, errOverflowNumber) {
 				// skip decoding if an error occurs
@@ -532,11 +1400,16 @@ func (d *Decoder) decodeStruct(dst reflect.Value, src ast.Node) error {
 			return errors.Wrapf(err, "failed to decode value")
 		}
 		fieldValue.Set(d.castToAssignableValue(newFieldValue, fieldValue.Type()))
+		if isSharedPointer {
+			d.setAnchorPointer(v, fieldValue)
+		}
 	}
 	if d.validator != nil {
 		if err := d.validator.Struct(structValue.Interface()); err != nil {
 			ev := reflect.ValueOf(err)
 			if ev.Type().Kind() == reflect.Slice {
+				fieldNames := []string{}
+				nodes := []ast.Node{}
 				for i := 0; i < ev.Len(); i++ {
 					fieldErr, ok := ev.Index(i).Interface().(FieldError)
 					if !ok {
@@ -544,11 +1417,19 @@ func (d *Decoder) decodeStruct(dst reflect.Value, src ast.Node) error {
 					}
 					fieldName := fieldErr.StructField()
 					structField := structFieldMap[fieldName]
-					node, exists := keyToNodeMap[structField.RenderName]
-					if exists {
-						// TODO: to make FieldError message cutomizable
-						return errors.ErrSyntax(fmt.Sprintf("%s", err), node.GetToken())
+					if node, exists := keyToNodeMap[structField.RenderName]; exists {
+						fieldNames = append(fieldNames, fieldName)
+						nodes = append(nodes, node)
+					}
+				}
+				if len(nodes) > 0 {
+					// TODO: to make FieldError message cutomizable
+					syntaxErr := errors.ErrSyntax(errors.CodeValidation, nodes[0].GetToken(), err)
+					// the other invalid fields are reported as related tokens
+					for idx, node := range nodes[1:] {
+						syntaxErr.AddRelated(node.GetToken(), fmt.Sprintf("invalid value of %s", fieldNames[idx+1]))
 					}
+					return syntaxErr
 				}
 			}
 		}
@@ -557,6 +1438,181 @@ func (d *Decoder) decodeStruct(dst reflect.Value, src ast.Node) error {
 	return nil
 }
 
+// structFieldKeys returns the keys of fields of struct type typ including the fields of inline structs
+func structFieldKeys(typ reflect.Type, opt structFieldOption) map[string]struct{} {
+	keys := map[string]struct{}{}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return keys
+	}
+	fieldMap, err := structFieldMap(typ, opt)
+	if err != nil {
+		// the error is reported by decoding the struct
+		return keys
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if isIgnoredStructField(field, opt) {
+			continue
+		}
+		structField := fieldMap[field.Name]
+		if structField.IsInline {
+			for key := range structFieldKeys(field.Type, opt) {
+				keys[key] = struct{}{}
+			}
+			continue
+		}
+		keys[structField.RenderName] = struct{}{}
+	}
+	return keys
+}
+
+// hasInlineMap reports whether struct type typ has inline map field including the fields of inline structs
+func hasInlineMap(typ reflect.Type, opt structFieldOption) bool {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return false
+	}
+	fieldMap, err := structFieldMap(typ, opt)
+	if err != nil {
+		// the error is reported by decoding the struct
+		return false
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if isIgnoredStructField(field, opt) || !fieldMap[field.Name].IsInline {
+			continue
+		}
+		if field.Type.Kind() == reflect.Map || hasInlineMap(field.Type, opt) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkUnknownField returns error which has the position of the first key of src not included in keys.
+// Keys merged by merge key are also checked.
+func (d *Decoder) checkUnknownField(src ast.Node, keys map[string]struct{}) error {
+	var err error
+	d.findUnknownField(src, keys, func(keyNode ast.Node, key string) bool {
+		err = errors.ErrSyntax(errors.CodeUnknownField, keyNode.GetToken(), key)
+		return false
+	})
+	return err
+}
+
+// findUnknownField calls found with the keys of src which don't exist in keys until found returns false
+func (d *Decoder) findUnknownField(src ast.Node, keys map[string]struct{}, found func(keyNode ast.Node, key string) bool) bool {
+	mapNode, err := d.getMapNode(src)
+	if err != nil || mapNode == nil {
+		// the error is reported by keyToNodeMap
+		return true
+	}
+	mapIter := mapNode.MapRange()
+	for mapIter.Next() {
+		keyNode := mapIter.Key()
+		if d.isMergeKey(keyNode) {
+			if !d.findUnknownField(mapIter.Value(), keys, found) {
+				return false
+			}
+			continue
+		}
+		key, ok := d.nodeToValue(keyNode).(string)
+		if !ok {
+			continue
+		}
+		if _, exists := keys[key]; !exists && !found(keyNode, key) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasInlineFieldKey reports whether keyToNodeMap has the key of any field of inline struct type typ including nested inline structs.
+// The keys of parentFieldMap are ignored because they are decoded into the fields of the parent struct.
+func hasInlineFieldKey(typ reflect.Type, keyToNodeMap map[string]ast.Node, parentFieldMap StructFieldMap, opt structFieldOption) bool {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return true
+	}
+	fieldMap, err := structFieldMap(typ, opt)
+	if err != nil {
+		// the error is reported by decoding the field
+		return true
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if isIgnoredStructField(field, opt) {
+			continue
+		}
+		structField := fieldMap[field.Name]
+		if structField.IsInline {
+			if hasInlineFieldKey(field.Type, keyToNodeMap, parentFieldMap, opt) {
+				return true
+			}
+			continue
+		}
+		if _, exists := keyToNodeMap[structField.RenderName]; exists && !parentFieldMap.isIncludedRenderName(structField.RenderName) {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeDocumentField stores src rendered as standalone YAML document into dst of string or []byte type.
+// Anchors referred by aliases in src are copied into the document.
+func (d *Decoder) decodeDocumentField(dst reflect.Value, src ast.Node) error {
+	if !isDocumentFieldType(dst.Type()) {
+		return xerrors.Errorf("document field must be string or []byte type but got %s", dst.Type())
+	}
+	f, err := ast.Extract(src, d.documentNode)
+	if err != nil {
+		return errors.Wrapf(err, "failed to extract node")
+	}
+	doc := f.String() + "\n"
+	if dst.Kind() == reflect.String {
+		dst.SetString(doc)
+	} else {
+		dst.SetBytes([]byte(doc))
+	}
+	return nil
+}
+
+func isDocumentFieldType(typ reflect.Type) bool {
+	return typ.Kind() == reflect.String || (typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8)
+}
+
+func isNullableType(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return true
+	}
+	return false
+}
+
+// decodeNull decodes null into non-pointer field by NullPolicy
+func (d *Decoder) decodeNull(dst reflect.Value, src ast.Node) error {
+	switch d.nullPolicy {
+	case NullPolicyError:
+		return errors.ErrSyntax(errors.CodeNullValue, src.GetToken(), dst.Type())
+	case NullPolicySetter:
+		v := reflect.New(dst.Type())
+		if setter, ok := v.Interface().(NullSetter); ok {
+			setter.SetNull()
+		}
+		dst.Set(v.Elem())
+		return nil
+	}
+	dst.Set(reflect.Zero(dst.Type()))
+	return nil
+}
+
 func (d *Decoder) decodeArray(dst reflect.Value, src ast.Node) error {
 	arrayNode, err := d.getArrayNode(src)
 	if err != nil {
@@ -568,9 +1624,16 @@ func (d *Decoder) decodeArray(dst reflect.Value, src ast.Node) error {
 	iter := arrayNode.ArrayRange()
 	arrayValue := reflect.New(dst.Type()).Elem()
 	arrayType := dst.Type()
+	if err := d.validateArrayLength(arrayNode, src, arrayType); err != nil {
+		return err
+	}
 	elemType := arrayType.Elem()
 	idx := 0
 	for iter.Next() {
+		if idx >= arrayType.Len() {
+			// ignore the elements beyond the length of array
+			break
+		}
 		v := iter.Value()
 		if elemType.Kind() == reflect.Ptr && v.Type() == ast.NullType {
 			// set nil value to pointer
@@ -592,7 +1655,57 @@ func (d *Decoder) decodeArray(dst reflect.Value, src ast.Node) error {
 	return nil
 }
 
+// validateArrayLength returns error if the length of arrayNode is different from arrayType and ArrayLengthPolicy rejects it.
+// The error points to the first element beyond the length of array, or src if sequence is shorter than array.
+func (d *Decoder) validateArrayLength(arrayNode ast.ArrayNode, src ast.Node, arrayType reflect.Type) error {
+	iter := arrayNode.ArrayRange()
+	length := iter.Len()
+	switch {
+	case length > arrayType.Len():
+		if d.arrayLengthPolicy != ArrayLengthPolicyError && d.arrayLengthPolicy != ArrayLengthPolicyZeroFill {
+			return nil
+		}
+		for i := 0; i <= arrayType.Len(); i++ {
+			iter.Next()
+		}
+		return errors.ErrSyntax(errors.CodeArrayLength, iter.Value().GetToken(), length, arrayType)
+	case length < arrayType.Len():
+		if d.arrayLengthPolicy != ArrayLengthPolicyError && d.arrayLengthPolicy != ArrayLengthPolicyTruncate {
+			return nil
+		}
+		return errors.ErrSyntax(errors.CodeArrayLength, src.GetToken(), length, arrayType)
+	}
+	return nil
+}
+
+// resolveAlias returns the anchored value if node is alias
+func (d *Decoder) resolveAlias(node ast.Node) ast.Node {
+	if alias, ok := node.(*ast.AliasNode); ok {
+		if anchor, exists := d.anchorMap[alias.Value.GetToken().Value]; exists {
+			return anchor
+		}
+	}
+	return node
+}
+
+// isScalarValue reports whether node is scalar node which may have tag or anchor
+func isScalarValue(node ast.Node) bool {
+	switch n := node.(type) {
+	case *ast.TagNode:
+		return isScalarValue(n.Value)
+	case *ast.AnchorNode:
+		return isScalarValue(n.Value)
+	case ast.ScalarNode:
+		return true
+	}
+	return false
+}
+
 func (d *Decoder) decodeSlice(dst reflect.Value, src ast.Node) error {
+	if d.isPromotedScalar && src.Type() != ast.NullType && isScalarValue(src) {
+		d.addCoercion(src, dst.Type())
+		src = &ast.SequenceNode{Start: src.GetToken(), IsFlowStyle: true, Values: []ast.Node{src}}
+	}
 	arrayNode, err := d.getArrayNode(src)
 	if err != nil {
 		return errors.Wrapf(err, "failed to get array node")
@@ -600,9 +1713,15 @@ func (d *Decoder) decodeSlice(dst reflect.Value, src ast.Node) error {
 	if arrayNode == nil {
 		return nil
 	}
+	if d.mergePolicy == MergePolicyFillZero && dst.Len() > 0 {
+		return nil
+	}
 	iter := arrayNode.ArrayRange()
 	sliceType := dst.Type()
 	sliceValue := reflect.MakeSlice(sliceType, 0, iter.Len())
+	if d.mergePolicy == MergePolicyMerge {
+		sliceValue = reflect.AppendSlice(sliceValue, dst)
+	}
 	elemType := sliceType.Elem()
 	for iter.Next() {
 		v := iter.Value()
@@ -625,7 +1744,39 @@ func (d *Decoder) decodeSlice(dst reflect.Value, src ast.Node) error {
 	return nil
 }
 
+// decodeMapSlice decodes mapping into MapSlice to keep the order of keys.
+// Nested mappings in the values are decoded into MapSlice too.
+func (d *Decoder) decodeMapSlice(dst *MapSlice, src ast.Node) error {
+	mapNode, err := d.getMapNode(src)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get map node")
+	}
+	if mapNode == nil {
+		return nil
+	}
+	useOrderedMap := d.useOrderedMap
+	d.useOrderedMap = true
+	defer func() {
+		d.useOrderedMap = useOrderedMap
+	}()
+	m := MapSlice{}
+	if d.mergePolicy != MergePolicyOverwrite {
+		m = append(m, *dst...)
+	}
+	for _, item := range d.nodeToMapSlice(mapNode.(ast.Node)) {
+		setMapItem(&m, item)
+	}
+	*dst = m
+	return nil
+}
+
 func (d *Decoder) decodeMap(dst reflect.Value, src ast.Node) error {
+	return d.decodeMapWithoutKeys(dst, src, nil)
+}
+
+// decodeMapWithoutKeys decodes src into dst except the keys included in excludedKeys.
+// It is used to decode the keys which don't match any field into inline map ( e.g. `yaml:",inline"` of map field ).
+func (d *Decoder) decodeMapWithoutKeys(dst reflect.Value, src ast.Node, excludedKeys map[string]struct{}) error {
 	mapNode, err := d.getMapNode(src)
 	if err != nil {
 		return errors.Wrapf(err, "failed to get map node")
@@ -635,22 +1786,50 @@ func (d *Decoder) decodeMap(dst reflect.Value, src ast.Node) error {
 	}
 	mapType := dst.Type()
 	mapValue := reflect.MakeMap(mapType)
+	if d.mergePolicy != MergePolicyOverwrite && !dst.IsNil() {
+		existingIter := dst.MapRange()
+		for existingIter.Next() {
+			mapValue.SetMapIndex(existingIter.Key(), existingIter.Value())
+		}
+	}
 	keyType := mapValue.Type().Key()
 	valueType := mapValue.Type().Elem()
 	mapIter := mapNode.MapRange()
 	for mapIter.Next() {
 		key := mapIter.Key()
 		value := mapIter.Value()
-		k := reflect.ValueOf(d.nodeToValue(key))
+		if d.isMergeKey(key) {
+			if err := d.decodeMergedMap(mapValue, value, excludedKeys); err != nil {
+				return errors.Wrapf(err, "failed to decode merged map")
+			}
+			continue
+		}
+		keyValue := d.nodeToValue(key)
+		if name, ok := keyValue.(string); ok && excludedKeys != nil {
+			if _, exists := excludedKeys[name]; exists {
+				continue
+			}
+		}
+		k := reflect.ValueOf(keyValue)
 		if k.IsValid() && k.Type().ConvertibleTo(keyType) {
 			k = k.Convert(keyType)
 		}
+		var existing reflect.Value
+		if k.IsValid() {
+			existing = mapValue.MapIndex(k)
+		}
+		if existing.IsValid() && d.keepsExistingValue(existing, value) {
+			continue
+		}
 		if valueType.Kind() == reflect.Ptr && value.Type() == ast.NullType {
 			// set nil value to pointer
 			mapValue.SetMapIndex(k, reflect.Zero(valueType))
 			continue
 		}
 		dstValue := d.createDecodableValue(valueType)
+		if existing.IsValid() {
+			d.initDecodableValue(dstValue, existing)
+		}
 		if err := d.decodeValue(dstValue, value); err != nil {
 			if xerrors.Is(err, errTypeMismatch) || xerrors.Is(err, errOverflowNumber) {
 				// skip decoding if an error occurs
@@ -669,6 +1848,30 @@ func (d *Decoder) decodeMap(dst reflect.Value, src ast.Node) error {
 	return nil
 }
 
+// decodeMergedMap decodes the value of merge key into mapValue.
+// The keys which already exist in mapValue are not overwritten, so the first merged mapping takes precedence
+func (d *Decoder) decodeMergedMap(mapValue reflect.Value, src ast.Node, excludedKeys map[string]struct{}) error {
+	if sequence, ok := d.resolveAlias(src).(*ast.SequenceNode); ok {
+		for _, value := range sequence.Values {
+			if err := d.decodeMergedMap(mapValue, value, excludedKeys); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	merged := reflect.New(mapValue.Type()).Elem()
+	if err := d.decodeMapWithoutKeys(merged, src, excludedKeys); err != nil {
+		return errors.Wrapf(err, "failed to decode map")
+	}
+	mergedIter := merged.MapRange()
+	for mergedIter.Next() {
+		if !mapValue.MapIndex(mergedIter.Key()).IsValid() {
+			mapValue.SetMapIndex(mergedIter.Key(), mergedIter.Value())
+		}
+	}
+	return nil
+}
+
 func (d *Decoder) fileToReader(file string) (io.Reader, error) {
 	reader, err := os.Open(file)
 	if err != nil {
@@ -726,12 +1929,34 @@ func (d *Decoder) readersUnderDirRecursive(dir string) ([]io.Reader, error) {
 	return readers, nil
 }
 
+// applySafeMode caps the limits set by options and rejects the options not allowed by SafeMode
+func (d *Decoder) applySafeMode() error {
+	if len(d.referenceReaders) > 0 || len(d.referenceFiles) > 0 || len(d.referenceDirs) > 0 {
+		return xerrors.Errorf("reference files cannot be used in safe mode")
+	}
+	capLimit := func(limit *int, max int) {
+		if *limit <= 0 || *limit > max {
+			*limit = max
+		}
+	}
+	capLimit(&d.maxAliasCount, DefaultMaxAliasCount)
+	capLimit(&d.aliasDepthLimit, DefaultAliasDepthLimit)
+	capLimit(&d.maxDepth, SafeModeMaxDepth)
+	capLimit(&d.maxDocumentSize, SafeModeMaxDocumentSize)
+	return nil
+}
+
 func (d *Decoder) resolveReference() error {
 	for _, opt := range d.opts {
 		if err := opt(d); err != nil {
 			return errors.Wrapf(err, "failed to exec option")
 		}
 	}
+	if d.isSafeMode {
+		if err := d.applySafeMode(); err != nil {
+			return err
+		}
+	}
 	for _, file := range d.referenceFiles {
 		reader, err := d.fileToReader(file)
 		if err != nil {
@@ -765,24 +1990,337 @@ func (d *Decoder) resolveReference() error {
 			return errors.Wrapf(err, "failed to decode")
 		}
 	}
+	for k, v := range d.anchorMap {
+		d.referenceAnchorMap[k] = v
+	}
 	d.isResolvedReference = true
 	return nil
 }
 
+// Reset discards the state of the previous document and resets the decoder to read from r.
+// Options and anchors resolved from reference files are kept,
+// so a Decoder can be reused for many small documents.
+func (d *Decoder) Reset(r io.Reader) {
+	d.reader = r
+	d.streamReader = nil
+	d.nextDocumentLine = ""
+	d.readLines = 0
+	d.readBytes = 0
+	d.documentIndex = 0
+	d.anchorMap = map[string]ast.Node{}
+	d.anchorValueCache = map[anchorValueCacheKey]*anchorValue{}
+	for k, v := range d.referenceAnchorMap {
+		d.anchorMap[k] = v
+	}
+}
+
+// tokenize tokenizes the source of the document read by readDocument.
+// Positions of tokens are moved to the place of the document in the whole input.
+func (d *Decoder) tokenize(src []byte) token.Tokens {
+	var opts []lexer.Option
+	if d.isLenientComment {
+		opts = append(opts, lexer.LenientComment())
+	}
+	tokens := lexer.Tokenize(string(src), opts...)
+	for _, tk := range tokens {
+		tk.Position.Line += d.documentLine
+		tk.Position.Offset += d.documentOffset
+	}
+	return tokens
+}
+
+func (d *Decoder) parseMode() parser.Mode {
+	var mode parser.Mode
+	if d.toCommentMap != nil {
+		mode |= parser.ParseComments
+	}
+	if d.isCoreSchema {
+		mode |= parser.CoreSchema
+	}
+	return mode
+}
+
 func (d *Decoder) decode(bytes []byte) (ast.Node, error) {
-	f, err := parser.ParseBytes(bytes, 0)
+	f, err := parser.Parse(d.tokenize(bytes), d.parseMode())
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to parse yaml")
 	}
-	return d.fileToNode(f), nil
+	if d.toCommentMap != nil {
+		for _, doc := range f.Docs {
+			d.toCommentMap.addDocument(doc)
+		}
+	}
+	node, err := d.fileToNode(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to collect anchors")
+	}
+	return node, nil
+}
+
+// validateRestDocuments parses the documents which are not decoded yet to report syntax errors in them,
+// because Unmarshal decodes only the first document but the whole input must be valid.
+// The rest of input is not read with DecodeUntil, which stops scanning the input.
+func (d *Decoder) validateRestDocuments() error {
+	if d.stopDecoding != nil {
+		return nil
+	}
+	for {
+		src, err := d.readDocument()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to read buffer")
+		}
+		if _, err := parser.Parse(d.tokenize(src), d.parseMode()&^parser.ParseComments); err != nil {
+			return errors.Wrapf(err, "failed to parse yaml")
+		}
+	}
+}
+
+// decodeUntilStop parses top-level mapping values of the first document one by one,
+// and stops scanning and parsing when d.stopDecoding reports true.
+// If the document is not a block mapping, all documents are parsed.
+func (d *Decoder) decodeUntilStop(bytes []byte) (ast.Node, error) {
+	entries := d.newMappingEntryReader(bytes)
+	mapping := &ast.MappingNode{Values: []*ast.MappingValueNode{}}
+	anchors := d.newAnchorCollector()
+	for {
+		entry, isMapping := entries.next()
+		if !isMapping {
+			return d.decode(bytes)
+		}
+		if entry == nil {
+			break
+		}
+		f, err := parser.Parse(entry, d.parseMode())
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse yaml")
+		}
+		if len(f.Docs) == 0 {
+			continue
+		}
+		if d.toCommentMap != nil {
+			for _, doc := range f.Docs {
+				d.toCommentMap.addDocument(doc)
+			}
+		}
+		// empty documents before the first key are parsed with the first entry
+		doc := f.Docs[len(f.Docs)-1]
+		if doc.Body == nil {
+			continue
+		}
+		var values []*ast.MappingValueNode
+		switch body := doc.Body.(type) {
+		case *ast.MappingNode:
+			values = body.Values
+		case *ast.MappingValueNode:
+			values = []*ast.MappingValueNode{body}
+		default:
+			return nil, xerrors.Errorf("unexpected top-level %s node", body.Type())
+		}
+		for _, mvnode := range values {
+			if mapping.Start == nil {
+				mapping.Start = mvnode.GetToken()
+			}
+			mapping.Values = append(mapping.Values, mvnode)
+			if err := anchors.collect(mvnode); err != nil {
+				return nil, errors.Wrapf(err, "failed to collect anchors")
+			}
+			if d.stopDecoding(mvnode.Key.GetToken().Value, d.nodeToValue(mvnode.Value)) {
+				return mapping, d.checkDuplicateKey(mapping)
+			}
+		}
+	}
+	return mapping, d.checkDuplicateKey(mapping)
+}
+
+// mappingEntryReader scans tokens of the first document on demand,
+// and splits them at the beginning of each top-level mapping value.
+// Tokens before the first key ( e.g. comments or document header ) belong to the first entry.
+type mappingEntryReader struct {
+	decoder *Decoder
+	stream  *tokenStream
+	tokens  token.Tokens // tokens scanned so far
+	idx     int          // index of the token checked next
+	start   int          // index of the first token of the current entry
+	column  int          // column of top-level keys
+	depth   int          // depth of flow collections
+	isEnd   bool
+}
+
+func (d *Decoder) newMappingEntryReader(src []byte) *mappingEntryReader {
+	stream := newTokenStream(src)
+	stream.scanner.SetLenientComment(d.isLenientComment)
+	return &mappingEntryReader{decoder: d, stream: stream}
+}
+
+// token returns the token at idx with scanning the source until it. It returns nil at the end of the source.
+// Positions of tokens are moved to the place of the document in the whole input as Decoder.tokenize does.
+func (r *mappingEntryReader) token(idx int) *token.Token {
+	for len(r.tokens) <= idx {
+		tk := r.stream.next()
+		if tk == nil {
+			return nil
+		}
+		tk.Position.Line += r.decoder.documentLine
+		tk.Position.Offset += r.decoder.documentOffset
+		r.tokens.Add(tk)
+	}
+	return r.tokens[idx]
+}
+
+// next returns tokens of the next top-level mapping value, or nil at the end of the document.
+// isMapping is false if the document is not a block mapping.
+func (r *mappingEntryReader) next() (entry token.Tokens, isMapping bool) {
+	if r.isEnd {
+		return nil, true
+	}
+	for {
+		idx := r.idx
+		tk := r.token(idx)
+		if tk == nil {
+			r.isEnd = true
+			if r.column == 0 {
+				return nil, false
+			}
+			return r.tokens[r.start:], true
+		}
+		// scan the next token to know the type of it
+		r.token(idx + 1)
+		r.idx++
+		if r.column == 0 && tk.PreviousType() == token.DirectiveType {
+			// parameters of directive
+			continue
+		}
+		switch tk.Type {
+		case token.CommentType:
+			continue
+		case token.DirectiveType, token.DocumentHeaderType:
+			if r.column == 0 {
+				continue
+			}
+			r.isEnd = true
+			return r.tokens[r.start:idx], true
+		case token.DocumentEndType:
+			r.isEnd = true
+			if r.column == 0 {
+				return nil, false
+			}
+			return r.tokens[r.start:idx], true
+		case token.MappingStartType, token.SequenceStartType:
+			r.depth++
+		case token.MappingEndType, token.SequenceEndType:
+			r.depth--
+		}
+		if r.column == 0 {
+			if tk.NextType() != token.MappingValueType {
+				return nil, false
+			}
+			r.column = tk.Position.Column
+			continue
+		}
+		isKey := r.depth == 0 && tk.Position.Column == r.column && tk.NextType() == token.MappingValueType &&
+			(tk.Prev == nil || tk.Prev.Position.Line < tk.Position.Line)
+		if isKey {
+			entry := r.tokens[r.start:idx]
+			r.start = idx
+			return entry, true
+		}
+	}
+}
+
+// readDocument reads the source of the next document from the reader.
+// The input is split at the document header ( `---` ) and the content after the document end marker ( `...` ),
+// because they can't appear in the content of a document. It returns io.EOF if there are no more documents.
+func (d *Decoder) readDocument() ([]byte, error) {
+	if d.streamReader == nil {
+		d.streamReader = bufio.NewReader(d.reader)
+	}
+	var src strings.Builder
+	src.WriteString(d.nextDocumentLine)
+	hasContent := isDocumentContentLine(d.nextDocumentLine, false)
+	hasHeader := isDocumentMarkerLine(d.nextDocumentLine, "---")
+	isEnded := false
+	d.nextDocumentLine = ""
+	for {
+		line, err := d.streamReader.ReadString('\n')
+		if line != "" {
+			isHeader := isDocumentMarkerLine(line, "---")
+			isNext := (isHeader && (hasHeader || hasContent || isEnded)) || (isEnded && isDocumentContentLine(line, hasContent))
+			if isNext {
+				d.nextDocumentLine = line
+				break
+			}
+			if isHeader {
+				hasHeader = true
+				hasContent = isDocumentContentLine(line[3:], true)
+			} else if isDocumentMarkerLine(line, "...") {
+				isEnded = true
+			} else if isDocumentContentLine(line, hasContent) {
+				hasContent = true
+			}
+			src.WriteString(line)
+			if d.maxDocumentSize > 0 && src.Len() > d.maxDocumentSize {
+				return nil, xerrors.Errorf("the size of document exceeds the limit %d bytes", d.maxDocumentSize)
+			}
+		}
+		if err == io.EOF {
+			if !hasHeader && !hasContent {
+				// only comments or directives
+				return nil, io.EOF
+			}
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read document")
+		}
+	}
+	doc := src.String()
+	d.documentLine = d.readLines
+	d.documentOffset = d.readBytes
+	d.readLines += strings.Count(doc, "\n")
+	d.readBytes += len(doc)
+	return []byte(doc), nil
+}
+
+// isDocumentMarkerLine reports whether line starts with marker ( `---` or `...` ) followed by white space
+func isDocumentMarkerLine(line, marker string) bool {
+	if !strings.HasPrefix(line, marker) {
+		return false
+	}
+	return len(line) == len(marker) || strings.ContainsRune(" \t\r\n", rune(line[len(marker)]))
+}
+
+// isDocumentContentLine reports whether line has content of document.
+// Directive is not content if it appears before content.
+func isDocumentContentLine(line string, hasContent bool) bool {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return false
+	}
+	return hasContent || !strings.HasPrefix(line, "%")
 }
 
 // Decode reads the next YAML-encoded value from its input
 // and stores it in the value pointed to by v.
+// The input can have multiple documents separated by `---`, and each call decodes the next document
+// without reading the rest of the input. v is not modified by an empty document.
+// It returns io.EOF if there are no more documents.
+//
+// Values decoded from the same alias don't share the underlying data of slices, maps and pointers
+// except the pointers of struct fields which have the anchor or alias option.
 //
 // See the documentation for Unmarshal for details about the
 // conversion of YAML into a Go value.
 func (d *Decoder) Decode(v interface{}) error {
+	defer func() {
+		d.anchorValueCache = map[anchorValueCacheKey]*anchorValue{}
+		d.aliasCount = 0
+		d.aliasDepth = 0
+		d.aliasPeakDepth = 0
+		d.valueErr = nil
+	}()
 	if !d.isResolvedReference {
 		if err := d.resolveReference(); err != nil {
 			return errors.Wrapf(err, "failed to resolve reference")
@@ -792,19 +2330,59 @@ func (d *Decoder) Decode(v interface{}) error {
 	if rv.Type().Kind() != reflect.Ptr {
 		return errors.ErrDecodeRequiredPointerType
 	}
-	bytes, err := ioutil.ReadAll(d.reader)
+	bytes, err := d.readDocument()
+	if err == io.EOF {
+		return io.EOF
+	}
 	if err != nil {
 		return errors.Wrapf(err, "failed to read buffer")
 	}
-	node, err := d.decode(bytes)
+	index := d.documentIndex
+	d.documentIndex++
+	if d.stats != nil {
+		d.stats.reset()
+	}
+	var node ast.Node
+	if d.stopDecoding != nil {
+		node, err = d.decodeUntilStop(bytes)
+	} else {
+		node, err = d.decode(bytes)
+	}
 	if err != nil {
 		return errors.Wrapf(err, "failed to decode")
 	}
+	if err := d.checkNodeLimit(node); err != nil {
+		return errors.Wrapf(err, "failed to decode")
+	}
+	d.collectStats(node)
+	if err := d.decodeNode(rv, node); err != nil {
+		return err
+	}
+	if d.documentHook != nil {
+		if err := d.documentHook(index, node, v); err != nil {
+			return errors.Wrapf(err, "failed to process document %d", index)
+		}
+	}
+	return nil
+}
+
+func (d *Decoder) decodeNode(rv reflect.Value, node ast.Node) error {
 	if node == nil {
 		return nil
 	}
+	if d.mergePolicy == MergePolicyOverwrite && d.decodeGenericValue(rv.Interface(), node) {
+		if d.valueErr != nil {
+			return errors.Wrapf(d.valueErr, "failed to decode value")
+		}
+		return nil
+	}
+	d.documentNode = node
 	if err := d.decodeValue(rv.Elem(), node); err != nil {
 		return errors.Wrapf(err, "failed to decode value")
 	}
+	if d.valueErr != nil {
+		// errors are not returned while converting node to value ( e.g. alias expansion in interface{} )
+		return errors.Wrapf(d.valueErr, "failed to decode value")
+	}
 	return nil
 }
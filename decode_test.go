@@ -3,13 +3,18 @@ package yaml_test
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"math"
+	"net"
 	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/token"
+	"golang.org/x/xerrors"
 )
 
 func TestDecoder(t *testing.T) {
@@ -321,6 +326,26 @@ func TestDecoder(t *testing.T) {
 			"v: 2015-02-24 18:19:39\n",
 			map[string]time.Time{"v": time.Date(2015, 2, 24, 18, 19, 39, 0, time.UTC)},
 		},
+		{
+			// space separate with time zone
+			"v: 2001-12-14 21:59:43.10 -05:00\n",
+			map[string]time.Time{"v": time.Date(2001, 12, 14, 21, 59, 43, .1e9, time.FixedZone("", -5*60*60))},
+		},
+		{
+			// ISO8601 no time zone
+			"v: 2015-02-24T18:19:39\n",
+			map[string]time.Time{"v": time.Date(2015, 2, 24, 18, 19, 39, 0, time.UTC)},
+		},
+
+		// Durations
+		{
+			"v: 1h30m\n",
+			map[string]time.Duration{"v": 90 * time.Minute},
+		},
+		{
+			"v: 1000\n",
+			map[string]time.Duration{"v": time.Microsecond},
+		},
 
 		// Overflow cases.
 		{
@@ -337,6 +362,31 @@ func TestDecoder(t *testing.T) {
 			map[interface{}]interface{}{"1": "\"2\""},
 		},
 
+		{
+			`"a\"b": "\t\u00e9\x41\\"`,
+			map[string]string{"a\"b": "\téA\\"},
+		},
+		{
+			"'it''s': 'a\n\n  b\n  c'",
+			map[string]string{"it's": "a\nb c"},
+		},
+		{
+			"a\"b: c'd",
+			map[string]string{"a\"b": "c'd"},
+		},
+		{
+			`{"a":1,"b":[true],"c":{"d":"e"}}`,
+			map[string]interface{}{"a": 1, "b": []interface{}{true}, "c": map[string]interface{}{"d": "e"}},
+		},
+		{
+			"{a:, b: 1}",
+			map[string]interface{}{"a": nil, "b": 1},
+		},
+		{
+			"[a:]",
+			[]map[string]interface{}{{"a": nil}},
+		},
+
 		{
 			"a: -b_c",
 			map[string]interface{}{"a": "-b_c"},
@@ -458,6 +508,14 @@ func TestDecoder(t *testing.T) {
 			"v: !!timestamp 2015-01-01",
 			map[string]time.Time{"v": time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)},
 		},
+		{
+			"v: !!binary aGVsbG8=",
+			map[string][]byte{"v": []byte("hello")},
+		},
+		{
+			"v: !!binary |\n  aGVs\n  bG8=\n",
+			map[string][]byte{"v": []byte("hello")},
+		},
 
 		// Flow sequence
 		{
@@ -623,6 +681,18 @@ func TestDecoder(t *testing.T) {
 				"a": {1, 2},
 			},
 		},
+		{
+			"a: [1,\n\t2]\n",
+			map[string][]int{
+				"a": {1, 2},
+			},
+		},
+		{
+			"a: {x: 1,\n\ty: 2}\n",
+			map[string]map[string]int{
+				"a": {"x": 1, "y": 2},
+			},
+		},
 		{
 			"a: {b: c, d: e}\n",
 			map[string]interface{}{
@@ -632,6 +702,21 @@ func TestDecoder(t *testing.T) {
 				},
 			},
 		},
+		{
+			"a:\n-\n  b: c\n-\n  d: e\n",
+			map[string][]map[string]string{
+				"a": {{"b": "c"}, {"d": "e"}},
+			},
+		},
+		{
+			"a: {b: {c: d}, e: [f, [g]]}\n",
+			map[string]interface{}{
+				"a": map[string]interface{}{
+					"b": map[string]interface{}{"c": "d"},
+					"e": []interface{}{"f", []interface{}{"g"}},
+				},
+			},
+		},
 		{
 			"a: 3s\n",
 			map[string]string{
@@ -666,7 +751,7 @@ func TestDecoder(t *testing.T) {
 		},
 		{
 			"a: \"\\0\"\n",
-			map[string]string{"a": "\\0"},
+			map[string]string{"a": "\x00"},
 		},
 		{
 			"b: 2\na: 1\nd: 4\nc: 3\nsub:\n  e: 5\n",
@@ -727,6 +812,14 @@ func TestDecoder(t *testing.T) {
 				},
 			},
 		},
+		{
+			"a: |\n  B\n\n  C\n\nb: |-\n  D\n\nc: |+\n  E\n\nd: x\n",
+			map[string]string{"a": "B\n\nC\n", "b": "D", "c": "E\n\n", "d": "x"},
+		},
+		{
+			"a: >\n  B\n  C\n\n  D\n\n\n  E\nb: >-\n  F\n\nc: >+\n\n  G\n\nd: |\n  H\n",
+			map[string]string{"a": "B C\nD\n\nE\n", "b": "F", "c": "\nG\n\n", "d": "H\n"},
+		},
 		{
 			"a: b\nc: d\n",
 			struct {
@@ -786,6 +879,10 @@ func TestDecoder(t *testing.T) {
 			"v: 1[]{},!%?&*",
 			map[string]string{"v": "1[]{},!%?&*"},
 		},
+		{
+			"v: あいうえお\nv2: かきくけこ",
+			map[string]string{"v": "あいうえお", "v2": "かきくけこ"},
+		},
 	}
 	for _, test := range tests {
 		buf := bytes.NewBufferString(test.source)
@@ -793,7 +890,9 @@ func TestDecoder(t *testing.T) {
 		typ := reflect.ValueOf(test.value).Type()
 		value := reflect.New(typ)
 		if err := dec.Decode(value.Interface()); err != nil {
-			t.Fatalf("%s: %+v", test.source, err)
+			if err != io.EOF || test.source != "" {
+				t.Fatalf("%s: %+v", test.source, err)
+			}
 		}
 		actual := fmt.Sprintf("%+v", value.Elem().Interface())
 		expect := fmt.Sprintf("%+v", test.value)
@@ -803,6 +902,17 @@ func TestDecoder(t *testing.T) {
 	}
 }
 
+func TestDecoder_BlockScalar(t *testing.T) {
+	var v interface{}
+	if err := yaml.Unmarshal([]byte("a: |-\n  1.10\nb: >-\n  true\nc: |-\n  null\nd: |-\n  10"), &v); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expect := map[string]interface{}{"a": "1.10", "b": "true", "c": "null", "d": "10"}
+	if !reflect.DeepEqual(v, expect) {
+		t.Fatalf("content of block scalar must be string: %#v", v)
+	}
+}
+
 func TestDecoder_AnchorReferenceDirs(t *testing.T) {
 	buf := bytes.NewBufferString("a: *a\n")
 	dec := yaml.NewDecoder(buf, yaml.ReferenceDirs("testdata"))
@@ -961,6 +1071,70 @@ c: true
 	}
 }
 
+func TestDecoder_InlinePointer(t *testing.T) {
+	type Inner struct {
+		C int
+	}
+	type Middle struct {
+		*Inner `yaml:",inline"`
+		B      int
+	}
+	type Outer struct {
+		*Middle `yaml:",inline"`
+		A       int
+	}
+	t.Run("multiple levels", func(t *testing.T) {
+		var v Outer
+		if err := yaml.Unmarshal([]byte("a: 1\nb: 2\nc: 3\n"), &v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if v.A != 1 || v.Middle == nil || v.B != 2 || v.Inner == nil || v.C != 3 {
+			t.Fatalf("failed to decode into embedded pointer: %+v", v)
+		}
+	})
+	t.Run("only nested key", func(t *testing.T) {
+		var v Outer
+		if err := yaml.Unmarshal([]byte("c: 3\n"), &v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if v.Middle == nil || v.Inner == nil || v.C != 3 {
+			t.Fatalf("failed to decode into embedded pointer: %+v", v)
+		}
+	})
+	t.Run("no keys of embedded struct", func(t *testing.T) {
+		var v Outer
+		if err := yaml.Unmarshal([]byte("a: 1\n"), &v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if v.A != 1 || v.Middle != nil {
+			t.Fatalf("embedded pointer must be nil: %+v", v)
+		}
+		var m Outer
+		if err := yaml.Unmarshal([]byte("b: 2\n"), &m); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if m.Middle == nil || m.B != 2 || m.Inner != nil {
+			t.Fatalf("nested embedded pointer must be nil: %+v", m)
+		}
+	})
+	t.Run("without inline", func(t *testing.T) {
+		type Embedded struct {
+			*Inner
+			B int
+		}
+		var v struct {
+			*Embedded
+			A int
+		}
+		if err := yaml.Unmarshal([]byte("a: 1\nembedded:\n  b: 2\n  inner:\n    c: 3\n"), &v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if v.A != 1 || v.Embedded == nil || v.B != 2 || v.Inner == nil || v.C != 3 {
+			t.Fatalf("failed to decode into embedded pointer: %+v", v)
+		}
+	})
+}
+
 func TestDecoder_InvalidCases(t *testing.T) {
 	const src = `---
 a:
@@ -987,3 +1161,1822 @@ a:
 	t.Logf("%s", yaml.FormatError(err, false, true))
 	t.Logf("%s", yaml.FormatError(err, true, true))
 }
+
+func TestDecoder_TypeError(t *testing.T) {
+	tests := []struct {
+		src    string
+		v      interface{}
+		code   yaml.ErrorCode
+		expect string
+	}{
+		{
+			src:    "a\n",
+			v:      new(int),
+			code:   yaml.ErrCodeTypeMismatch,
+			expect: "[1:1] cannot decode String node into int\n>  1 | a\n      ^\n",
+		},
+		{
+			src:    "300\n",
+			v:      new(uint8),
+			code:   yaml.ErrCodeOverflowNumber,
+			expect: "[1:1] 300 overflows uint8\n>  1 | 300\n      ^\n",
+		},
+		{
+			src:    "a: b\n",
+			v:      new([]int),
+			code:   yaml.ErrCodeUnexpectedNodeType,
+			expect: "[1:1] unexpected MappingValue node. Sequence node is required\n>  1 | a: b\n      ^\n",
+		},
+		{
+			src:    "24 Feb 2015\n",
+			v:      new(time.Time),
+			code:   yaml.ErrCodeInvalidTimeValue,
+			expect: "[1:1] cannot parse \"24 Feb 2015\" as time.Time\n>  1 | 24 Feb 2015\n      ^\n",
+		},
+		{
+			src:    "1 hour\n",
+			v:      new(time.Duration),
+			code:   yaml.ErrCodeInvalidTimeValue,
+			expect: "[1:1] cannot parse \"1 hour\" as time.Duration\n>  1 | 1 hour\n      ^\n",
+		},
+		{
+			src:    "!!binary a?b\n",
+			v:      new([]byte),
+			code:   yaml.ErrCodeInvalidBinaryValue,
+			expect: "[1:10] cannot decode \"a?b\" as base64\n>  1 | !!binary a?b\n               ^\n",
+		},
+		{
+			src:    "!!binary a?b\n",
+			v:      new(interface{}),
+			code:   yaml.ErrCodeInvalidBinaryValue,
+			expect: "[1:10] cannot decode \"a?b\" as base64\n>  1 | !!binary a?b\n               ^\n",
+		},
+		{
+			src:    "!!binary 123\n",
+			v:      new(interface{}),
+			code:   yaml.ErrCodeInvalidBinaryValue,
+			expect: "[1:10] cannot decode \"123\" as base64\n>  1 | !!binary 123\n               ^\n",
+		},
+		{
+			src:    "a: !!binary 123\n",
+			v:      new(struct{ A interface{} }),
+			code:   yaml.ErrCodeInvalidBinaryValue,
+			expect: "[1:13] cannot decode \"123\" as base64\n>  1 | a: !!binary 123\n                  ^\n",
+		},
+	}
+	for _, test := range tests {
+		err := yaml.Unmarshal([]byte(test.src), test.v)
+		if err == nil {
+			t.Fatalf("%q: expected error", test.src)
+		}
+		if code := yaml.ErrorCodeOf(err); code != test.code {
+			t.Fatalf("%q: unexpected code: %q", test.src, code)
+		}
+		var syntaxErr yaml.SyntaxError
+		if !xerrors.As(err, &syntaxErr) {
+			t.Fatalf("%q: failed to get SyntaxError", test.src)
+		}
+		if syntaxErr.Position() == nil || syntaxErr.Position().Line != 1 {
+			t.Fatalf("%q: unexpected position: %v", test.src, syntaxErr.Position())
+		}
+		actual := yaml.FormatError(err, false, true)
+		if actual != test.expect {
+			t.Fatalf("%q: unexpected error. expected:\n%s\nbut got:\n%s", test.src, test.expect, actual)
+		}
+	}
+}
+
+func TestUnmarshal_InvalidFollowingDocument(t *testing.T) {
+	for _, src := range []string{
+		"a: 1\n---\n- b\nc: d\n",
+		"a: 1\n---\nb: 1\n  c: 2\n",
+	} {
+		var v map[string]int
+		if err := yaml.Unmarshal([]byte(src), &v); err == nil {
+			t.Fatalf("%q: expected error", src)
+		}
+	}
+	var v map[string]int
+	if err := yaml.Unmarshal([]byte("a: 1\n---\nb: 2\n"), &v); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if !reflect.DeepEqual(v, map[string]int{"a": 1}) {
+		t.Fatalf("unexpected value: %v", v)
+	}
+}
+
+func TestDecoder_Reset(t *testing.T) {
+	dec := yaml.NewDecoder(
+		strings.NewReader("a: &x 1\nb: *x\n"),
+		yaml.ReferenceFiles("testdata/anchor.yml"),
+	)
+	var v1 struct {
+		A int
+		B int
+	}
+	if err := dec.Decode(&v1); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if v1.A != 1 || v1.B != 1 {
+		t.Fatal("failed to decode first document")
+	}
+	dec.Reset(strings.NewReader("a: *a\nb: *x\n"))
+	var v2 struct {
+		A struct {
+			B int
+			C string
+		}
+		B interface{}
+	}
+	if err := dec.Decode(&v2); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if v2.A.B != 1 || v2.A.C != "hello" {
+		t.Fatal("failed to keep anchors resolved by reference files")
+	}
+	if v2.B != nil {
+		t.Fatal("anchor defined by previous document leaked after Reset")
+	}
+}
+
+func TestDecoder_GenericValue(t *testing.T) {
+	yml := `
+a: &a
+  b: 1
+  c: [x, y]
+d:
+  <<: *a
+  e: true
+`
+	var m map[string]interface{}
+	if err := yaml.Unmarshal([]byte(yml), &m); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expect := map[string]interface{}{
+		"a": map[string]interface{}{"b": uint64(1), "c": []interface{}{"x", "y"}},
+		"d": map[string]interface{}{"b": uint64(1), "c": []interface{}{"x", "y"}, "e": true},
+	}
+	if !reflect.DeepEqual(m, expect) {
+		t.Fatalf("failed to decode map[string]interface{}: %v", m)
+	}
+	var s []interface{}
+	if err := yaml.Unmarshal([]byte("- 1\n- a\n- null\n"), &s); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if !reflect.DeepEqual(s, []interface{}{uint64(1), "a", nil}) {
+		t.Fatalf("failed to decode []interface{}: %v", s)
+	}
+	var i interface{}
+	if err := yaml.Unmarshal([]byte("a: b\n"), &i); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if !reflect.DeepEqual(i, map[string]interface{}{"a": "b"}) {
+		t.Fatalf("failed to decode interface{}: %v", i)
+	}
+}
+
+func BenchmarkDecoder_GenericValue(b *testing.B) {
+	src := []byte(strings.Repeat("- name: foo\n  value: 1\n  tags: [a, b]\n", 100))
+	for i := 0; i < b.N; i++ {
+		var v []interface{}
+		if err := yaml.Unmarshal(src, &v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecoder_RepeatedKeys(b *testing.B) {
+	var sb strings.Builder
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintf(&sb, "- apiVersion: v1\n  kind: Service\n  metadata:\n    name: svc%d\n    namespace: default\n  spec:\n    port: %d\n", i, i)
+	}
+	src := []byte(sb.String())
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var v []map[string]interface{}
+		if err := yaml.Unmarshal(src, &v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestDecoder_AliasValueCache(t *testing.T) {
+	yml := `
+base: &base
+  name: foo
+  values: [1, 2, 3]
+a: *base
+b: *base
+c: *base
+d: *base
+n: &n 10
+x: *n
+y: *n
+`
+	type T struct {
+		Name   string
+		Values []int
+	}
+	var v struct {
+		Base T
+		A    T
+		B    *T
+		C    map[string]interface{}
+		D    T
+		N    int
+		X    int
+		Y    string
+	}
+	if err := yaml.Unmarshal([]byte(yml), &v); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expect := T{Name: "foo", Values: []int{1, 2, 3}}
+	if !reflect.DeepEqual(v.Base, expect) || !reflect.DeepEqual(v.A, expect) || !reflect.DeepEqual(*v.B, expect) {
+		t.Fatalf("failed to decode alias: %+v", v)
+	}
+	if v.C["name"] != "foo" || len(v.C["values"].([]interface{})) != 3 {
+		t.Fatalf("failed to decode alias to map: %+v", v.C)
+	}
+	if v.N != 10 || v.X != 10 || v.Y != "10" {
+		t.Fatalf("failed to decode alias to scalar: %+v", v)
+	}
+	v.A.Values[0] = 100
+	v.B.Values[1] = 200
+	if !reflect.DeepEqual(v.D, expect) || !reflect.DeepEqual(v.Base, expect) {
+		t.Fatalf("aliases must not share decoded values: %+v", v)
+	}
+}
+
+func TestDecoder_AnchorAliasPointer(t *testing.T) {
+	type T struct {
+		I int
+		S string
+	}
+	t.Run("round trip", func(t *testing.T) {
+		type V struct {
+			A *T `yaml:"a,anchor"`
+			B *T `yaml:"b,anchor"`
+			C *T `yaml:"c,alias"`
+			D *T `yaml:"d,alias"`
+			E *T `yaml:"e,alias"`
+		}
+		v := V{A: &T{I: 1, S: "hello"}, B: &T{I: 2, S: "world"}}
+		v.C = v.A
+		v.D = v.A
+		bytes, err := yaml.Marshal(v)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		var decoded V
+		if err := yaml.Unmarshal(bytes, &decoded); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if decoded.A != decoded.C || decoded.A != decoded.D {
+			t.Fatalf("anchor and aliases must be decoded into the same pointer:\n%s", string(bytes))
+		}
+		if decoded.E != nil {
+			t.Fatalf("nil alias must be decoded as nil:\n%s", string(bytes))
+		}
+		if !reflect.DeepEqual(*decoded.A, *v.A) || !reflect.DeepEqual(*decoded.B, *v.B) {
+			t.Fatalf("failed to decode: %+v", decoded)
+		}
+	})
+	t.Run("alias field declared before anchor field", func(t *testing.T) {
+		var v struct {
+			C *T `yaml:"c,alias"`
+			A *T `yaml:"a,anchor"`
+			E *T `yaml:"e"`
+		}
+		src := `
+a: &a
+  i: 1
+  s: hello
+c: *a
+e: *a
+`
+		if err := yaml.Unmarshal([]byte(src), &v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if v.A != v.C {
+			t.Fatal("anchor and alias must be decoded into the same pointer")
+		}
+		if v.A == v.E {
+			t.Fatal("field without anchor or alias option must not share the pointer")
+		}
+		if !reflect.DeepEqual(*v.A, T{I: 1, S: "hello"}) || !reflect.DeepEqual(*v.E, *v.A) {
+			t.Fatalf("failed to decode: %+v", v)
+		}
+	})
+}
+
+func TestDecoder_PreserveTags(t *testing.T) {
+	sources := []string{
+		"a: !!binary gIGC\nb: !point 1\nc: !!str \"10\"\n",
+		"a: !foo\n  b: 1\n  c: 2\nd: 1\n",
+		"- !foo x\n- !bar\n  a: 1\n",
+		"a: !seq\n- 1\n- 2\n",
+	}
+	for _, src := range sources {
+		t.Run(src, func(t *testing.T) {
+			var v interface{}
+			dec := yaml.NewDecoder(strings.NewReader(src), yaml.PreserveTags(true))
+			if err := dec.Decode(&v); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			b, err := yaml.Marshal(v)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if string(b) != src {
+				t.Fatalf("unexpected output. expected:\n%s\nbut got:\n%s", src, string(b))
+			}
+		})
+	}
+	t.Run("tagged value", func(t *testing.T) {
+		var v map[string]interface{}
+		dec := yaml.NewDecoder(strings.NewReader("a: !!binary gIGC\nb: !point 1\n"), yaml.PreserveTags(true))
+		if err := dec.Decode(&v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		expected := map[string]interface{}{
+			"a": yaml.TaggedValue{Tag: "!!binary", Value: []byte{0x80, 0x81, 0x82}},
+			"b": yaml.TaggedValue{Tag: "!point", Value: uint64(1)},
+		}
+		if !reflect.DeepEqual(v, expected) {
+			t.Fatalf("unexpected value: %#v", v)
+		}
+	})
+	t.Run("typed destination", func(t *testing.T) {
+		var v struct {
+			A int
+			B string
+		}
+		dec := yaml.NewDecoder(strings.NewReader("a: !point 1\nb: !!str 2\n"), yaml.PreserveTags(true))
+		if err := dec.Decode(&v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if v.A != 1 || v.B != "2" {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+}
+
+type nullableInt struct {
+	Value  int
+	IsNull bool
+}
+
+func (n *nullableInt) SetNull() {
+	n.IsNull = true
+}
+
+func TestDecoder_DecodeNull(t *testing.T) {
+	type T struct {
+		A int
+		B nullableInt
+		C *int
+		D []int
+	}
+	src := "a: null\nb: null\nc: null\nd: null\n"
+	t.Run("zero", func(t *testing.T) {
+		var v T
+		if err := yaml.NewDecoder(strings.NewReader(src)).Decode(&v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if v.A != 0 || v.B.IsNull || v.C != nil {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+	t.Run("error", func(t *testing.T) {
+		var v T
+		err := yaml.NewDecoder(strings.NewReader(src), yaml.DecodeNull(yaml.NullPolicyError)).Decode(&v)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if code := yaml.ErrorCodeOf(err); code != yaml.ErrCodeNullValue {
+			t.Fatalf("unexpected code: %q", code)
+		}
+		if !strings.Contains(err.Error(), "[1:4] cannot decode null into int") {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		var nullable struct {
+			C *int
+			D []int
+		}
+		if err := yaml.NewDecoder(strings.NewReader("c: null\nd: null\n"), yaml.DecodeNull(yaml.NullPolicyError)).Decode(&nullable); err != nil {
+			t.Fatalf("pointer and slice should accept null: %+v", err)
+		}
+	})
+	t.Run("setter", func(t *testing.T) {
+		var v T
+		if err := yaml.NewDecoder(strings.NewReader(src), yaml.DecodeNull(yaml.NullPolicySetter)).Decode(&v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if v.A != 0 || !v.B.IsNull || v.C != nil {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+}
+
+func TestDecoder_ScalarToSlice(t *testing.T) {
+	type T struct {
+		A []string
+		B []int
+		C []string
+		D []string
+		E []interface{}
+	}
+	src := "a: foo\nb: [1, 2]\nc: !!str 1\nd: null\ne: &x bar\nf: *x\n"
+	var v T
+	if err := yaml.NewDecoder(strings.NewReader(src), yaml.ScalarToSlice(true)).Decode(&v); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expected := T{
+		A: []string{"foo"},
+		B: []int{1, 2},
+		C: []string{"1"},
+		E: []interface{}{"bar"},
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Fatalf("unexpected value: %+v", v)
+	}
+	var alias struct {
+		F []string
+	}
+	if err := yaml.NewDecoder(strings.NewReader(src), yaml.ScalarToSlice(true)).Decode(&alias); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if !reflect.DeepEqual(alias.F, []string{"bar"}) {
+		t.Fatalf("unexpected value: %+v", alias)
+	}
+	var disabled struct {
+		A []string
+	}
+	if err := yaml.NewDecoder(strings.NewReader("a: foo\n")).Decode(&disabled); err == nil {
+		t.Fatal("expected error without ScalarToSlice option")
+	}
+}
+
+func TestDecoder_DecodeArrayLength(t *testing.T) {
+	longer := "a: [1, 2, 3]\n"
+	shorter := "a:\n  - 1\n"
+	tests := []struct {
+		name     string
+		policy   yaml.ArrayLengthPolicy
+		src      string
+		expected [2]int
+		err      string
+	}{
+		{"lenient longer", yaml.ArrayLengthPolicyLenient, longer, [2]int{1, 2}, ""},
+		{"lenient shorter", yaml.ArrayLengthPolicyLenient, shorter, [2]int{1, 0}, ""},
+		{"error longer", yaml.ArrayLengthPolicyError, longer, [2]int{}, "[1:11] cannot decode sequence of 3 elements into [2]int"},
+		{"error shorter", yaml.ArrayLengthPolicyError, shorter, [2]int{}, "[2:3] cannot decode sequence of 1 elements into [2]int"},
+		{"truncate longer", yaml.ArrayLengthPolicyTruncate, longer, [2]int{1, 2}, ""},
+		{"truncate shorter", yaml.ArrayLengthPolicyTruncate, shorter, [2]int{}, "[2:3] cannot decode sequence of 1 elements into [2]int"},
+		{"zero fill longer", yaml.ArrayLengthPolicyZeroFill, longer, [2]int{}, "[1:11] cannot decode sequence of 3 elements into [2]int"},
+		{"zero fill shorter", yaml.ArrayLengthPolicyZeroFill, shorter, [2]int{1, 0}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var v struct {
+				A [2]int
+			}
+			err := yaml.NewDecoder(strings.NewReader(test.src), yaml.DecodeArrayLength(test.policy)).Decode(&v)
+			if test.err != "" {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				if code := yaml.ErrorCodeOf(err); code != yaml.ErrCodeArrayLength {
+					t.Fatalf("unexpected code: %q", code)
+				}
+				if !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if v.A != test.expected {
+				t.Fatalf("unexpected value: %v", v.A)
+			}
+		})
+	}
+}
+
+func TestDecoder_DecodeMerge(t *testing.T) {
+	type Server struct {
+		Host    string
+		Port    int
+		Tags    []string
+		Labels  map[string]string
+		Timeout *int
+	}
+	type Config struct {
+		Name    string
+		Server  Server
+		Backup  *Server
+		Options map[string]interface{}
+	}
+	timeout := 10
+	base := func() Config {
+		return Config{
+			Name: "base",
+			Server: Server{
+				Host:    "localhost",
+				Port:    80,
+				Tags:    []string{"a"},
+				Labels:  map[string]string{"env": "dev", "team": "x"},
+				Timeout: &timeout,
+			},
+			Backup:  &Server{Host: "backup"},
+			Options: map[string]interface{}{"debug": true, "nested": map[string]interface{}{"a": uint64(1)}},
+		}
+	}
+	src := `
+server:
+  port: 8080
+  tags: [b]
+  labels:
+    env: prod
+  timeout: null
+backup:
+  port: 9090
+options:
+  nested:
+    b: 2
+`
+	t.Run("overwrite", func(t *testing.T) {
+		v := base()
+		if err := yaml.NewDecoder(strings.NewReader(src)).Decode(&v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		expected := Config{
+			Server: Server{
+				Port:   8080,
+				Tags:   []string{"b"},
+				Labels: map[string]string{"env": "prod"},
+			},
+			Backup:  &Server{Port: 9090},
+			Options: map[string]interface{}{"nested": map[string]interface{}{"b": uint64(2)}},
+		}
+		if !reflect.DeepEqual(v, expected) {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+	t.Run("merge", func(t *testing.T) {
+		v := base()
+		original := base()
+		backup := v.Backup
+		if err := yaml.NewDecoder(strings.NewReader(src), yaml.DecodeMerge(yaml.MergePolicyMerge)).Decode(&v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		expected := Config{
+			Name: "base",
+			Server: Server{
+				Host:   "localhost",
+				Port:   8080,
+				Tags:   []string{"a", "b"},
+				Labels: map[string]string{"env": "prod", "team": "x"},
+			},
+			Backup: &Server{Host: "backup", Port: 9090},
+			Options: map[string]interface{}{
+				"debug":  true,
+				"nested": map[string]interface{}{"a": uint64(1), "b": uint64(2)},
+			},
+		}
+		if !reflect.DeepEqual(v, expected) {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+		if !reflect.DeepEqual(backup, original.Backup) {
+			t.Fatalf("existing pointer value is modified: %+v", backup)
+		}
+	})
+	t.Run("fill zero", func(t *testing.T) {
+		v := base()
+		if err := yaml.NewDecoder(strings.NewReader(src), yaml.DecodeMerge(yaml.MergePolicyFillZero)).Decode(&v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		expected := base()
+		expected.Backup.Port = 9090
+		expected.Options["nested"].(map[string]interface{})["b"] = uint64(2)
+		if !reflect.DeepEqual(v, expected) {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+}
+
+func TestDecoder_DecodeUntil(t *testing.T) {
+	src := `# manifest
+apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+spec: {a: b, c}
+`
+	t.Run("struct", func(t *testing.T) {
+		var v struct {
+			APIVersion string `yaml:"apiVersion"`
+			Kind       string
+			Metadata   map[string]string
+		}
+		keys := []string{}
+		dec := yaml.NewDecoder(strings.NewReader(src), yaml.DecodeUntil(func(key string, value interface{}) bool {
+			keys = append(keys, key)
+			return key == "kind"
+		}))
+		if err := dec.Decode(&v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if v.APIVersion != "v1" || v.Kind != "Service" || v.Metadata != nil {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+		if !reflect.DeepEqual(keys, []string{"apiVersion", "kind"}) {
+			t.Fatalf("unexpected keys: %v", keys)
+		}
+	})
+	t.Run("value", func(t *testing.T) {
+		var v map[string]interface{}
+		var name interface{}
+		dec := yaml.NewDecoder(strings.NewReader("---\n"+src), yaml.DecodeUntil(func(key string, value interface{}) bool {
+			if key == "metadata" {
+				name = value.(map[string]interface{})["name"]
+				return true
+			}
+			return false
+		}))
+		if err := dec.Decode(&v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if name != "foo" || len(v) != 3 {
+			t.Fatalf("unexpected value: %v %v", name, v)
+		}
+	})
+	t.Run("alias", func(t *testing.T) {
+		var v map[string]int
+		dec := yaml.NewDecoder(strings.NewReader("a: &a 1\nb: *a\nc: 2\n"), yaml.DecodeUntil(func(key string, value interface{}) bool {
+			return key == "b"
+		}))
+		if err := dec.Decode(&v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if !reflect.DeepEqual(v, map[string]int{"a": 1, "b": 1}) {
+			t.Fatalf("unexpected value: %v", v)
+		}
+	})
+	t.Run("rest is not scanned", func(t *testing.T) {
+		var large strings.Builder
+		large.WriteString("a: 1\n")
+		for i := 0; i < 2000; i++ {
+			fmt.Fprintf(&large, "b%d: [1, 2, 3]\n", i)
+		}
+		allocs := func(opts ...yaml.DecodeOption) float64 {
+			return testing.AllocsPerRun(1, func() {
+				var v map[string]interface{}
+				if err := yaml.NewDecoder(strings.NewReader(large.String()), opts...).Decode(&v); err != nil {
+					t.Fatalf("%+v", err)
+				}
+			})
+		}
+		all := allocs()
+		stopped := allocs(yaml.DecodeUntil(func(key string, value interface{}) bool {
+			return key == "a"
+		}))
+		// lines of the document are read before decoding, but the tokens after "a" must not be scanned
+		if stopped > all/10 {
+			t.Fatalf("the rest of document is scanned: %v allocations with DecodeUntil, %v without it", stopped, all)
+		}
+	})
+	t.Run("not stopped", func(t *testing.T) {
+		var v map[string]interface{}
+		if err := yaml.NewDecoder(strings.NewReader(src), yaml.DecodeUntil(func(string, interface{}) bool {
+			return false
+		})).Decode(&v); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestDecoder_Stream(t *testing.T) {
+	t.Run("documents", func(t *testing.T) {
+		src := `
+# comment
+a: 1
+---
+a: 2
+...
+%YAML 1.2
+---
+a: 3
+---
+--- {a: 4}
+`
+		dec := yaml.NewDecoder(strings.NewReader(src))
+		expected := []map[string]int{{"a": 1}, {"a": 2}, {"a": 3}, nil, {"a": 4}}
+		for idx, e := range expected {
+			var v map[string]int
+			if err := dec.Decode(&v); err != nil {
+				t.Fatalf("failed to decode document %d: %+v", idx, err)
+			}
+			if !reflect.DeepEqual(v, e) {
+				t.Fatalf("unexpected document %d: %v", idx, v)
+			}
+		}
+		var v map[string]int
+		if err := dec.Decode(&v); err != io.EOF {
+			t.Fatalf("expected io.EOF but got %v", err)
+		}
+	})
+	t.Run("empty documents", func(t *testing.T) {
+		var indices []int
+		hook := func(index int, node ast.Node, v interface{}) error {
+			indices = append(indices, index)
+			return nil
+		}
+		dec := yaml.NewDecoder(strings.NewReader("---\n---\na: 1\n---\n---\n--- # comment\nb: 2\n"), yaml.DocumentHook(hook))
+		expected := []map[string]int{nil, {"a": 1}, nil, nil, {"b": 2}}
+		for idx, e := range expected {
+			v := map[string]int{"default": 0}
+			if e == nil {
+				e = v
+			}
+			if err := dec.Decode(&v); err != nil {
+				t.Fatalf("failed to decode document %d: %+v", idx, err)
+			}
+			if !reflect.DeepEqual(v, e) {
+				t.Fatalf("unexpected document %d: %v", idx, v)
+			}
+		}
+		var v map[string]int
+		if err := dec.Decode(&v); err != io.EOF {
+			t.Fatalf("expected io.EOF but got %v", err)
+		}
+		if !reflect.DeepEqual(indices, []int{0, 1, 2, 3, 4}) {
+			t.Fatalf("unexpected indices: %v", indices)
+		}
+	})
+	t.Run("empty", func(t *testing.T) {
+		for _, src := range []string{"", "# comment\n", "\n\n"} {
+			var v interface{}
+			if err := yaml.NewDecoder(strings.NewReader(src)).Decode(&v); err != io.EOF {
+				t.Fatalf("%q: expected io.EOF but got %v", src, err)
+			}
+		}
+	})
+	t.Run("error position", func(t *testing.T) {
+		dec := yaml.NewDecoder(strings.NewReader("a: 1\n---\nb: 2\n---\nc: 1\n  d: 2\n"))
+		for i := 0; i < 2; i++ {
+			var v map[string]int
+			if err := dec.Decode(&v); err != nil {
+				t.Fatalf("%+v", err)
+			}
+		}
+		var v map[string]int
+		err := dec.Decode(&v)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if !strings.Contains(err.Error(), "[5:") {
+			t.Fatalf("unexpected error position: %v", err)
+		}
+	})
+}
+
+func TestDecoder_DocumentField(t *testing.T) {
+	src := `
+base: &base
+  size: 1
+name: app
+values:
+  image:
+    tag: v1
+  ports: [80, 443]
+  default: *base
+raw: "text"
+`
+	var v struct {
+		Name   string
+		Values string `yaml:"values,document"`
+		Raw    []byte `yaml:"raw,document"`
+		Empty  string `yaml:"empty,document"`
+	}
+	if err := yaml.Unmarshal([]byte(src), &v); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expect := "image:\n  tag: v1\nports: [80, 443]\ndefault: &base\n  size: 1\n"
+	if v.Values != expect {
+		t.Fatalf("expect = [%s], actual = [%s]", expect, v.Values)
+	}
+	if string(v.Raw) != "\"text\"\n" {
+		t.Fatalf("unexpected raw document: %q", v.Raw)
+	}
+	if v.Name != "app" || v.Empty != "" {
+		t.Fatalf("unexpected value: %+v", v)
+	}
+	var values map[string]interface{}
+	if err := yaml.Unmarshal([]byte(v.Values), &values); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if values["default"].(map[string]interface{})["size"] != uint64(1) {
+		t.Fatalf("unexpected values: %v", values)
+	}
+	t.Run("invalid type", func(t *testing.T) {
+		var v struct {
+			Values int `yaml:"values,document"`
+		}
+		if err := yaml.Unmarshal([]byte(src), &v); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestDecoder_TextUnmarshaler(t *testing.T) {
+	src := `
+level: &l info
+levels: [debug, *l]
+ip: 192.168.0.1
+optional: debug
+empty: null
+`
+	var v struct {
+		Level    Level `yaml:"level"`
+		Levels   []Level
+		IP       net.IP
+		Optional *Level
+		Empty    Level
+	}
+	if err := yaml.Unmarshal([]byte(src), &v); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if v.Level != LevelInfo || !reflect.DeepEqual(v.Levels, []Level{LevelDebug, LevelInfo}) {
+		t.Fatalf("unexpected levels: %v %v", v.Level, v.Levels)
+	}
+	if !v.IP.Equal(net.ParseIP("192.168.0.1")) {
+		t.Fatalf("unexpected ip: %v", v.IP)
+	}
+	if v.Optional == nil || *v.Optional != LevelDebug || v.Empty != LevelDebug {
+		t.Fatalf("unexpected value: %+v", v)
+	}
+	var invalid struct {
+		Level Level
+	}
+	if err := yaml.Unmarshal([]byte("level: trace\n"), &invalid); err == nil {
+		t.Fatal("expected error")
+	}
+	t.Run("source text", func(t *testing.T) {
+		src := `
+a: 1.10
+b: 0x1F
+c: 010
+d: !!str 1.10
+e: "1.10"
+f: '1.10'
+g: &g 1.10
+h: *g
+`
+		var v map[string]text
+		for _, opts := range [][]yaml.DecodeOption{nil, {yaml.PreserveTags(true)}} {
+			if err := yaml.UnmarshalWithOptions([]byte(src), &v, opts...); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			expect := map[string]text{
+				"a": "1.10", "b": "0x1F", "c": "010", "d": "1.10",
+				"e": "1.10", "f": "1.10", "g": "1.10", "h": "1.10",
+			}
+			if !reflect.DeepEqual(v, expect) {
+				t.Fatalf("unexpected value: %v", v)
+			}
+		}
+	})
+}
+
+type text string
+
+func (t *text) UnmarshalText(b []byte) error {
+	*t = text(b)
+	return nil
+}
+
+func TestDecoder_MergeKey(t *testing.T) {
+	src := `
+base: &base
+  a: 1
+  b: 2
+derived:
+  b: 3
+  <<: *base
+`
+	t.Run("enabled", func(t *testing.T) {
+		var v struct {
+			Derived map[string]int
+		}
+		if err := yaml.NewDecoder(strings.NewReader(src)).Decode(&v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if !reflect.DeepEqual(v.Derived, map[string]int{"a": 1, "b": 3}) {
+			t.Fatalf("unexpected value: %v", v.Derived)
+		}
+	})
+	t.Run("disabled", func(t *testing.T) {
+		var v struct {
+			Derived map[string]interface{}
+		}
+		if err := yaml.NewDecoder(strings.NewReader(src), yaml.MergeKey(false)).Decode(&v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		expect := map[string]interface{}{
+			"b":  uint64(3),
+			"<<": map[string]interface{}{"a": uint64(1), "b": uint64(2)},
+		}
+		if !reflect.DeepEqual(v.Derived, expect) {
+			t.Fatalf("unexpected value: %v", v.Derived)
+		}
+		var generic map[string]interface{}
+		if err := yaml.NewDecoder(strings.NewReader("<<: {a: 1}\nb: 2\n"), yaml.MergeKey(false)).Decode(&generic); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if _, exists := generic["<<"]; !exists || len(generic) != 2 {
+			t.Fatalf("unexpected value: %v", generic)
+		}
+		var s struct {
+			A int
+		}
+		if err := yaml.NewDecoder(strings.NewReader("<<: {a: 1}\n"), yaml.MergeKey(false)).Decode(&s); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if s.A != 0 {
+			t.Fatalf("merge key is processed: %+v", s)
+		}
+	})
+}
+
+func TestDecoder_CommentToMap(t *testing.T) {
+	src := `# head
+a: 1 # line
+b:
+  c: [1, 2] # flow
+seq:
+  # entry
+  - x # line of entry
+  - k: v
+  # foot of entry
+`
+	cm := yaml.CommentMap{}
+	var v map[string]interface{}
+	if err := yaml.UnmarshalWithOptions([]byte(src), &v, yaml.CommentToMap(cm)); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expected := yaml.CommentMap{
+		"$.a":      {yaml.HeadComment(" head"), yaml.LineComment(" line")},
+		"$.b.c":    {yaml.LineComment(" flow")},
+		"$.seq[0]": {yaml.HeadComment(" entry"), yaml.LineComment(" line of entry")},
+		"$.seq[1]": {yaml.FootComment(" foot of entry")},
+	}
+	if !reflect.DeepEqual(cm, expected) {
+		for path, comments := range cm {
+			for _, comment := range comments {
+				t.Logf("%s: %s %q", path, comment.Position, comment.Texts)
+			}
+		}
+		t.Fatal("unexpected comment map")
+	}
+	if v["a"] != uint64(1) {
+		t.Fatalf("unexpected value: %v", v)
+	}
+}
+
+func TestDecoder_ScalarToString(t *testing.T) {
+	type Version string
+	var v struct {
+		A string
+		B string
+		C string
+		D string
+		E Version
+		F string
+		G string
+		H *string
+	}
+	src := `
+a: 1.10
+b: 0x1F
+c: yes
+d: 007
+e: 1.0
+f: &x .inf
+g: *x
+h:
+`
+	if err := yaml.NewDecoder(strings.NewReader(src), yaml.ScalarToString(true)).Decode(&v); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if v.A != "1.10" || v.B != "0x1F" || v.C != "yes" || v.D != "007" || v.E != "1.0" || v.F != ".inf" || v.G != ".inf" {
+		t.Fatalf("unexpected value: %+v", v)
+	}
+	if v.H != nil {
+		t.Fatalf("null must not be decoded into string: %q", *v.H)
+	}
+	if err := yaml.Unmarshal([]byte(src), &v); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if v.A != "1.1" {
+		t.Fatalf("unexpected value without ScalarToString: %q", v.A)
+	}
+}
+
+func TestUnmarshal_TopLevelValue(t *testing.T) {
+	prefixes := []string{
+		"",
+		"# comment\n",
+		"---\n",
+		"%YAML 1.2\n---\n",
+		"# comment\n%YAML 1.2 # comment\n%TAG !e! tag:example.com,2000:\n---\n# comment\n",
+	}
+	for _, prefix := range prefixes {
+		t.Run(fmt.Sprintf("%q", prefix), func(t *testing.T) {
+			var seq []string
+			if err := yaml.Unmarshal([]byte(prefix+"- a\n- b"), &seq); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if !reflect.DeepEqual(seq, []string{"a", "b"}) {
+				t.Fatalf("unexpected sequence: %v", seq)
+			}
+			var i int
+			if err := yaml.Unmarshal([]byte(prefix+"42"), &i); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if i != 42 {
+				t.Fatalf("unexpected integer: %d", i)
+			}
+			var s string
+			if err := yaml.Unmarshal([]byte(prefix+"hello # comment\n"), &s); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if s != "hello" {
+				t.Fatalf("unexpected string: %q", s)
+			}
+			var v interface{}
+			if err := yaml.UnmarshalWithOptions([]byte(prefix+"- 1\n- 2\n"), &v, yaml.Strict()); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if !reflect.DeepEqual(v, []interface{}{uint64(1), uint64(2)}) {
+				t.Fatalf("unexpected value: %#v", v)
+			}
+		})
+	}
+	t.Run("type mismatch", func(t *testing.T) {
+		var s string
+		if err := yaml.Unmarshal([]byte("a: 1\n"), &s); err == nil {
+			t.Fatal("expected error")
+		}
+		var seq []string
+		if err := yaml.Unmarshal([]byte("- a: 1\n- b\n"), &seq); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if !reflect.DeepEqual(seq, []string{"b"}) {
+			t.Fatalf("unexpected sequence: %v", seq)
+		}
+		var i int
+		err := yaml.Unmarshal([]byte("%YAML 1.2\n---\n- a\n"), &i)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if msg := yaml.FormatError(err, false, true); !strings.Contains(msg, "   1 | %YAML 1.2\n   2 | ---\n>  3 | - a") {
+			t.Fatalf("unexpected error message:\n%s", msg)
+		}
+	})
+}
+
+func TestDecoder_InlineNamedFieldAndMap(t *testing.T) {
+	type Base struct {
+		A int
+		B string
+	}
+	type Middle struct {
+		Base  Base              `yaml:",inline"`
+		Extra map[string]string `yaml:",inline"`
+	}
+	type T struct {
+		Middle Middle `yaml:",inline"`
+		C      int
+	}
+	src := `
+<<: {x: merged, a: 3}
+a: 1
+b: hello
+c: 2
+d: world
+`
+	var v T
+	if err := yaml.UnmarshalWithOptions([]byte(src), &v, yaml.DisallowUnknownField()); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expected := T{
+		Middle: Middle{
+			Base:  Base{A: 1, B: "hello"},
+			Extra: map[string]string{"d": "world", "x": "merged"},
+		},
+		C: 2,
+	}
+	if !reflect.DeepEqual(expected, v) {
+		t.Fatalf("expected %+v but got %+v", expected, v)
+	}
+	t.Run("invalid type", func(t *testing.T) {
+		var v struct {
+			Extra map[int]string `yaml:",inline"`
+		}
+		if err := yaml.Unmarshal([]byte("1: a\n"), &v); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestDecoder_DisallowUnknownField(t *testing.T) {
+	type Base struct {
+		ID int
+	}
+	type T struct {
+		Base  `yaml:",inline"`
+		Name  string
+		Child struct {
+			A int
+		}
+		Ignored string `yaml:"-"`
+	}
+	tests := []struct {
+		src    string
+		expect string
+	}{
+		{
+			src:    "id: 1\nname: a\nchild:\n  a: 1\n",
+			expect: "",
+		},
+		{
+			src:    "id: 1\nnmae: a\n",
+			expect: "[2:1] unknown field \"nmae\"\n   1 | id: 1\n>  2 | nmae: a\n      ^\n",
+		},
+		{
+			src:    "child:\n  a: 1\n  b: 2\n",
+			expect: "[3:3] unknown field \"b\"\n   1 | child:\n   2 |   a: 1\n>  3 |   b: 2\n        ^\n",
+		},
+		{
+			src:    "base: &base\n  id: 1\n",
+			expect: "[1:1] unknown field \"base\"\n>  1 | base: &base\n      ^\n   2 |   id: 1",
+		},
+		{
+			src:    "ignored: a\n",
+			expect: "[1:1] unknown field \"ignored\"\n>  1 | ignored: a\n      ^\n",
+		},
+	}
+	for _, test := range tests {
+		var v T
+		err := yaml.NewDecoder(strings.NewReader(test.src), yaml.DisallowUnknownField()).Decode(&v)
+		if test.expect == "" {
+			if err != nil {
+				t.Fatalf("%q: %+v", test.src, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Fatalf("%q: expected error", test.src)
+		}
+		if code := yaml.ErrorCodeOf(err); code != yaml.ErrCodeUnknownField {
+			t.Fatalf("%q: unexpected code: %q", test.src, code)
+		}
+		if actual := yaml.FormatError(err, false, true); actual != test.expect {
+			t.Fatalf("%q: unexpected error message. expected:\n%q\nbut got:\n%q", test.src, test.expect, actual)
+		}
+		if err := yaml.Unmarshal([]byte(test.src), &v); err != nil {
+			t.Fatalf("%q: unknown field must be ignored by default: %+v", test.src, err)
+		}
+	}
+}
+
+func TestDecoder_AliasLimit(t *testing.T) {
+	laughs := `
+a: &a [lol, lol, lol, lol]
+b: &b [*a, *a, *a, *a]
+c: &c [*b, *b, *b, *b]
+d: &d [*c, *c, *c, *c]
+`
+	t.Run("count", func(t *testing.T) {
+		var v interface{}
+		err := yaml.NewDecoder(strings.NewReader(laughs), yaml.MaxAliasCount(20)).Decode(&v)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if code := yaml.ErrorCodeOf(err); code != yaml.ErrCodeExcessiveAliasing {
+			t.Fatalf("unexpected code: %q", code)
+		}
+		if err := yaml.NewDecoder(strings.NewReader(laughs), yaml.MaxAliasCount(108)).Decode(&v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if err := yaml.NewDecoder(strings.NewReader(laughs), yaml.MaxAliasCount(0)).Decode(&v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	})
+	t.Run("depth", func(t *testing.T) {
+		var v map[string][]interface{}
+		err := yaml.NewDecoder(strings.NewReader(laughs), yaml.AliasDepthLimit(2)).Decode(&v)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if code := yaml.ErrorCodeOf(err); code != yaml.ErrCodeExcessiveAliasing {
+			t.Fatalf("unexpected code: %q", code)
+		}
+		if err := yaml.NewDecoder(strings.NewReader(laughs), yaml.AliasDepthLimit(3)).Decode(&v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if len(v["d"]) != 4 {
+			t.Fatalf("failed to decode: %v", v)
+		}
+	})
+	t.Run("typed value", func(t *testing.T) {
+		type T struct {
+			A []string
+			B [][]string
+			C [][][]string
+		}
+		var v T
+		// the aliases in the cached value of *b are counted every time
+		err := yaml.NewDecoder(strings.NewReader(laughs), yaml.MaxAliasCount(23)).Decode(&v)
+		if code := yaml.ErrorCodeOf(err); code != yaml.ErrCodeExcessiveAliasing {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := yaml.NewDecoder(strings.NewReader(laughs), yaml.MaxAliasCount(24)).Decode(&v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if len(v.C) != 4 || len(v.C[3]) != 4 || len(v.C[3][3]) != 4 {
+			t.Fatalf("failed to decode: %v", v)
+		}
+		err = yaml.NewDecoder(strings.NewReader(laughs), yaml.AliasDepthLimit(1)).Decode(&T{})
+		if code := yaml.ErrorCodeOf(err); code != yaml.ErrCodeExcessiveAliasing {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	t.Run("billion laughs into typed value", func(t *testing.T) {
+		var sb strings.Builder
+		value := "lol"
+		for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i"} {
+			fmt.Fprintf(&sb, "%s: &%s [%s]\n", name, name, strings.TrimSuffix(strings.Repeat(value+", ", 10), ", "))
+			value = "*" + name
+		}
+		var v struct {
+			G [][][][][][][]string
+			I [][][][][][][][][]string
+		}
+		err := yaml.NewDecoder(strings.NewReader(sb.String()), yaml.MaxAliasCount(100), yaml.SafeMode()).Decode(&v)
+		if code := yaml.ErrorCodeOf(err); code != yaml.ErrCodeExcessiveAliasing {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestDecoder_SafeMode(t *testing.T) {
+	t.Run("depth", func(t *testing.T) {
+		var v interface{}
+		if err := yaml.UnmarshalWithOptions([]byte("a: [[1]]\n"), &v, yaml.MaxDepth(3)); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		err := yaml.UnmarshalWithOptions([]byte("a: [[1]]\n"), &v, yaml.MaxDepth(2))
+		if code := yaml.ErrorCodeOf(err); code != yaml.ErrCodeExcessiveNesting {
+			t.Fatalf("unexpected code: %q", code)
+		}
+		nested := strings.Repeat("[", yaml.SafeModeMaxDepth+1) + strings.Repeat("]", yaml.SafeModeMaxDepth+1)
+		err = yaml.UnmarshalWithOptions([]byte(nested), &v, yaml.MaxDepth(0), yaml.SafeMode())
+		if code := yaml.ErrorCodeOf(err); code != yaml.ErrCodeExcessiveNesting {
+			t.Fatalf("unexpected code: %q", code)
+		}
+	})
+	t.Run("document size", func(t *testing.T) {
+		src := "a: 1\n---\nb: " + strings.Repeat("x", 100) + "\n"
+		dec := yaml.NewDecoder(strings.NewReader(src), yaml.MaxDocumentSize(50))
+		var v map[string]interface{}
+		if err := dec.Decode(&v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if err := dec.Decode(&v); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("tag", func(t *testing.T) {
+		var v map[string]interface{}
+		if err := yaml.UnmarshalWithOptions([]byte("a: !!str 1\nb: !!binary YQ==\n"), &v, yaml.SafeMode()); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		err := yaml.UnmarshalWithOptions([]byte("a: !!str 1\nb: !foo x\n"), &v, yaml.SafeMode())
+		if code := yaml.ErrorCodeOf(err); code != yaml.ErrCodeUnsafeTag {
+			t.Fatalf("unexpected code: %q", code)
+		}
+		if err := yaml.UnmarshalWithOptions([]byte("b: !foo x\n"), &v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	})
+	t.Run("reference", func(t *testing.T) {
+		var v map[string]interface{}
+		err := yaml.UnmarshalWithOptions([]byte("a: *x\n"), &v, yaml.SafeMode(), yaml.ReferenceReaders(strings.NewReader("x: &x 1\n")))
+		if err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("alias", func(t *testing.T) {
+		laughs := "a: &a [lol, lol]\nb: &b [*a, *a]\nc: &c [*b, *b]\n"
+		var v interface{}
+		err := yaml.UnmarshalWithOptions([]byte(laughs), &v, yaml.AliasDepthLimit(0), yaml.SafeMode(), yaml.AliasDepthLimit(1))
+		if code := yaml.ErrorCodeOf(err); code != yaml.ErrCodeExcessiveAliasing {
+			t.Fatalf("unexpected code: %q", code)
+		}
+	})
+}
+
+func TestDecoder_DuplicateAnchor(t *testing.T) {
+	src := `
+a: &x 1
+b: *x
+c: &x 2
+d: *x
+`
+	t.Run("allow", func(t *testing.T) {
+		var v map[string]int
+		if err := yaml.NewDecoder(strings.NewReader(src)).Decode(&v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if v["b"] != 1 || v["d"] != 2 {
+			t.Fatalf("alias must refer to the latest definition: %v", v)
+		}
+	})
+	t.Run("error", func(t *testing.T) {
+		var v map[string]int
+		err := yaml.NewDecoder(strings.NewReader(src), yaml.DuplicateAnchor(yaml.DuplicateAnchorPolicyError)).Decode(&v)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if code := yaml.ErrorCodeOf(err); code != yaml.ErrCodeDuplicateAnchor {
+			t.Fatalf("unexpected code: %q", code)
+		}
+		if !strings.Contains(err.Error(), `[4:4] anchor "x" is already defined at [2:4]`) {
+			t.Fatalf("unexpected error message: %s", err)
+		}
+	})
+	t.Run("warn", func(t *testing.T) {
+		var warnings []string
+		warn := func(name string, first, second *token.Position) {
+			warnings = append(warnings, fmt.Sprintf("%s [%d:%d] [%d:%d]", name, first.Line, first.Column, second.Line, second.Column))
+		}
+		var v map[string]int
+		if err := yaml.NewDecoder(strings.NewReader(src), yaml.WarnDuplicateAnchor(warn)).Decode(&v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if len(warnings) != 1 || warnings[0] != "x [2:4] [4:4]" {
+			t.Fatalf("unexpected warnings: %v", warnings)
+		}
+		if v["d"] != 2 {
+			t.Fatalf("alias must refer to the latest definition: %v", v)
+		}
+	})
+	t.Run("anchors in another document", func(t *testing.T) {
+		dec := yaml.NewDecoder(strings.NewReader("a: &x 1\n---\nb: &x 2\n"), yaml.DuplicateAnchor(yaml.DuplicateAnchorPolicyError))
+		for i := 0; i < 2; i++ {
+			var v map[string]int
+			if err := dec.Decode(&v); err != nil {
+				t.Fatalf("%+v", err)
+			}
+		}
+	})
+}
+
+func TestDecoder_DecodeFieldNamer(t *testing.T) {
+	type Server struct {
+		HTTPPort int
+	}
+	type T struct {
+		APIVersion string
+		Server     Server
+		Name       string `yaml:"title"`
+	}
+	src := "api_version: v1\nserver:\n  http_port: 8080\ntitle: app\n"
+	var v T
+	if err := yaml.UnmarshalWithOptions([]byte(src), &v, yaml.DecodeFieldNamer(yaml.SnakeCase), yaml.Strict()); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if expect := (T{APIVersion: "v1", Server: Server{HTTPPort: 8080}, Name: "app"}); v != expect {
+		t.Fatalf("failed to decode. expect %#v but got %#v", expect, v)
+	}
+}
+
+func TestDecoder_DecodeJSONTag(t *testing.T) {
+	type TypeMeta struct {
+		Kind string `json:"kind"`
+	}
+	type T struct {
+		TypeMeta `json:",inline"`
+		Name     string `json:"name"`
+		Secret   string `json:"-"`
+		Image    string `yaml:"img" json:"image"`
+	}
+	src := "kind: Deployment\nname: app\nimg: nginx\n"
+	var v T
+	if err := yaml.UnmarshalWithOptions([]byte(src), &v, yaml.DecodeJSONTag(true), yaml.Strict()); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if expect := (T{TypeMeta: TypeMeta{Kind: "Deployment"}, Name: "app", Image: "nginx"}); v != expect {
+		t.Fatalf("failed to decode. expect %#v but got %#v", expect, v)
+	}
+	err := yaml.UnmarshalWithOptions([]byte("secret: s\n"), &v, yaml.DecodeJSONTag(true), yaml.DisallowUnknownField())
+	if code := yaml.ErrorCodeOf(err); code != yaml.ErrCodeUnknownField {
+		t.Fatalf("unexpected code: %q", code)
+	}
+}
+
+func TestDecoder_UseOrderedMap(t *testing.T) {
+	src := `
+z: 1
+a:
+  y: true
+  b: [x, {d: 2, c: 3}]
+base: &base
+  k: v
+  j: w
+merged:
+  j: x
+  <<: *base
+`
+	var v interface{}
+	if err := yaml.UnmarshalWithOptions([]byte(src), &v, yaml.UseOrderedMap()); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expect := yaml.MapSlice{
+		{Key: "z", Value: uint64(1)},
+		{Key: "a", Value: yaml.MapSlice{
+			{Key: "y", Value: true},
+			{Key: "b", Value: []interface{}{"x", yaml.MapSlice{{Key: "d", Value: uint64(2)}, {Key: "c", Value: uint64(3)}}}},
+		}},
+		{Key: "base", Value: yaml.MapSlice{{Key: "k", Value: "v"}, {Key: "j", Value: "w"}}},
+		{Key: "merged", Value: yaml.MapSlice{{Key: "j", Value: "w"}, {Key: "k", Value: "v"}}},
+	}
+	if !reflect.DeepEqual(v, expect) {
+		t.Fatalf("failed to decode. expect %#v but got %#v", expect, v)
+	}
+	bytes, err := yaml.Marshal(v)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if expect := "z: 1\na:\n  y: true\n  b:\n  - x\n  - d: 2\n    c: 3\n"; !strings.HasPrefix(string(bytes), expect) {
+		t.Fatalf("failed to keep the order of keys. got %q", string(bytes))
+	}
+}
+
+func TestDecoder_MapSlice(t *testing.T) {
+	src := `
+b: 1
+a:
+  d: [x, {f: 1, e: 2}]
+  c: true
+`
+	var v struct {
+		A yaml.MapSlice
+	}
+	if err := yaml.Unmarshal([]byte(src), &v); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expect := yaml.MapSlice{
+		{Key: "d", Value: []interface{}{"x", yaml.MapSlice{{Key: "f", Value: uint64(1)}, {Key: "e", Value: uint64(2)}}}},
+		{Key: "c", Value: true},
+	}
+	if !reflect.DeepEqual(v.A, expect) {
+		t.Fatalf("failed to decode. expect %#v but got %#v", expect, v.A)
+	}
+	var m yaml.MapSlice
+	if err := yaml.Unmarshal([]byte(src), &m); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	bytes, err := yaml.Marshal(m)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if expect := "b: 1\na:\n  d:\n  - x\n  - f: 1\n    e: 2\n  c: true\n"; string(bytes) != expect {
+		t.Fatalf("failed to keep the order of keys. got %q", string(bytes))
+	}
+	err = yaml.Unmarshal([]byte("- a\n"), &m)
+	if code := yaml.ErrorCodeOf(err); code != yaml.ErrCodeUnexpectedNodeType {
+		t.Fatalf("unexpected code: %q", code)
+	}
+}
+
+func TestDecoder_Stats(t *testing.T) {
+	src := `
+base: &base
+  port: 8080
+  ratio: 1.0
+server:
+  <<: *base
+  nmae: app
+  tags: web
+other: *base
+---
+server:
+  port: "80"
+`
+	type server struct {
+		Port  string
+		Ratio int
+		Tags  []string
+	}
+	type config struct {
+		Base   server
+		Server server
+		Other  server
+	}
+	messages := func(diags yaml.Diagnostics) []string {
+		var texts []string
+		for _, diag := range diags {
+			texts = append(texts, fmt.Sprintf("%d:%d %s", diag.Range.Start.Line, diag.Range.Start.Column, diag.Message))
+		}
+		return texts
+	}
+	var stats yaml.DecodeStats
+	dec := yaml.NewDecoder(strings.NewReader(src), yaml.Stats(&stats), yaml.ScalarToSlice(true))
+	var v config
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expect := map[string][]string{
+		"unknown keys": {`7:3 unknown field "nmae"`},
+		"coercions": {
+			"3:9 Integer node is converted to string",
+			"4:10 Float node is converted to int",
+			"8:9 String node is converted to []string",
+		},
+		"aliases":    {`6:7 alias "base" is expanded`, `9:8 alias "base" is expanded`},
+		"merge keys": {"6:3 merge key is applied"},
+	}
+	actual := map[string][]string{
+		"unknown keys": messages(stats.UnknownKeys),
+		"coercions":    messages(stats.Coercions),
+		"aliases":      messages(stats.Aliases),
+		"merge keys":   messages(stats.MergeKeys),
+	}
+	if !reflect.DeepEqual(actual, expect) {
+		t.Fatalf("unexpected stats: %v", actual)
+	}
+	if stats.UnknownKeys[0].Code != yaml.ErrCodeUnknownField || stats.UnknownKeys[0].Severity != yaml.SeverityWarning {
+		t.Fatalf("unexpected diagnostic: %+v", stats.UnknownKeys[0])
+	}
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if !reflect.DeepEqual(stats, yaml.DecodeStats{}) {
+		t.Fatalf("stats must be reset by each Decode: %+v", stats)
+	}
+}
+
+func TestDecoder_DocumentHook(t *testing.T) {
+	src := `
+kind: a
+value: 1
+---
+---
+kind: b
+value: 2
+`
+	type document struct {
+		Kind  string
+		Value int
+	}
+	errTooLarge := xerrors.New("value is too large")
+	var actual []string
+	hook := func(index int, node ast.Node, v interface{}) error {
+		if node == nil {
+			actual = append(actual, fmt.Sprintf("%d: empty", index))
+			return nil
+		}
+		doc := v.(*document)
+		actual = append(actual, fmt.Sprintf("%d: %s %s=%d", index, node.Type(), doc.Kind, doc.Value))
+		if doc.Value > 1 {
+			return errTooLarge
+		}
+		return nil
+	}
+	dec := yaml.NewDecoder(strings.NewReader(src), yaml.DocumentHook(hook))
+	for i := 0; i < 2; i++ {
+		var v document
+		if err := dec.Decode(&v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+	var v document
+	err := dec.Decode(&v)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !xerrors.Is(err, errTooLarge) {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+	if err := dec.Decode(&v); err != io.EOF {
+		t.Fatalf("expected io.EOF but got %v", err)
+	}
+	expected := []string{"0: Mapping a=1", "1: empty", "2: Mapping b=2"}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("expected %v but got %v", expected, actual)
+	}
+}
+
+func TestDecoder_DisallowDuplicateKey(t *testing.T) {
+	tests := []struct {
+		src    string
+		expect string
+	}{
+		{
+			src:    "a: 1\nb: 2\n",
+			expect: "",
+		},
+		{
+			src:    "1: a\n\"1\": b\n",
+			expect: "",
+		},
+		{
+			src:    "base: &base\n  a: 1\nderived:\n  a: 2\n  <<: *base\n",
+			expect: "",
+		},
+		{
+			src:    "a: 1\nb: 2\n'a': 3\n",
+			expect: `[3:1] mapping key "a" is already defined at [1:1]`,
+		},
+		{
+			src:    "a:\n  1: x\n  0x1: y\n",
+			expect: `[3:3] mapping key "1" is already defined at [2:3]`,
+		},
+	}
+	for _, test := range tests {
+		var v interface{}
+		err := yaml.UnmarshalWithOptions([]byte(test.src), &v, yaml.DisallowDuplicateKey())
+		if test.expect == "" {
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Fatalf("expected error for %q", test.src)
+		}
+		if code := yaml.ErrorCodeOf(err); code != yaml.ErrCodeDuplicateKey {
+			t.Fatalf("unexpected code: %q", code)
+		}
+		if !strings.Contains(err.Error(), test.expect) {
+			t.Fatalf("unexpected error message: %s", err)
+		}
+	}
+	t.Run("DecodeUntil", func(t *testing.T) {
+		var v map[string]int
+		err := yaml.UnmarshalWithOptions([]byte("a: 1\nb: 2\na: 3\nc: 4\n"), &v, yaml.Strict(), yaml.DecodeUntil(func(key string, value interface{}) bool {
+			return key == "c"
+		}))
+		if code := yaml.ErrorCodeOf(err); code != yaml.ErrCodeDuplicateKey {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	t.Run("non-string key", func(t *testing.T) {
+		var v map[int]string
+		if err := yaml.Unmarshal([]byte("1: a\n0x2: b\n"), &v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if !reflect.DeepEqual(map[int]string{1: "a", 2: "b"}, v) {
+			t.Fatalf("unexpected value: %v", v)
+		}
+	})
+}
+
+type fieldErrors []yaml.FieldError
+
+func (errs fieldErrors) Error() string { return "invalid fields" }
+
+type fieldError string
+
+func (e fieldError) StructField() string { return string(e) }
+
+type invalidFieldsValidator []string
+
+func (v invalidFieldsValidator) Struct(interface{}) error {
+	errs := fieldErrors{}
+	for _, field := range v {
+		errs = append(errs, fieldError(field))
+	}
+	return errs
+}
+
+func TestDecoder_ValidatorRelatedFields(t *testing.T) {
+	var v struct {
+		A int
+		B int
+		C int
+	}
+	dec := yaml.NewDecoder(strings.NewReader("a: 1\nb: 2\nc: 3\n"), yaml.Validator(invalidFieldsValidator{"A", "C"}))
+	err := dec.Decode(&v)
+	if yaml.ErrorCodeOf(err) != yaml.ErrCodeValidation {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var syntaxErr yaml.SyntaxError
+	if !xerrors.As(err, &syntaxErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pos := syntaxErr.Position(); pos.Line != 1 {
+		t.Fatalf("unexpected position: %s", pos)
+	}
+	related := syntaxErr.Related()
+	if len(related) != 1 || related[0].Token.Position.Line != 3 || related[0].Message != "invalid value of C" {
+		t.Fatalf("unexpected related tokens: %+v", related)
+	}
+}
+
+func TestDecoder_DecodeTimeLayouts(t *testing.T) {
+	var v struct {
+		T time.Time
+	}
+	if err := yaml.UnmarshalWithOptions([]byte("t: 24/02/2015\n"), &v, yaml.DecodeTimeLayouts("02/01/2006")); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if !v.T.Equal(time.Date(2015, 2, 24, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected time: %v", v.T)
+	}
+	if err := yaml.UnmarshalWithOptions([]byte("t: 2015-02-24\n"), &v, yaml.DecodeTimeLayouts("02/01/2006")); err != nil {
+		t.Fatalf("timestamp of YAML must be decoded with custom layouts: %+v", err)
+	}
+}
+
+func TestDecoder_SchemaVersion(t *testing.T) {
+	src := "a: 010\nb: 0o10\nc: 0b11\nd: 1_000\ne: 1e3\nf: +.inf\n"
+	t.Run("1.1", func(t *testing.T) {
+		var v map[string]interface{}
+		if err := yaml.UnmarshalWithOptions([]byte(src), &v, yaml.SchemaVersion("1.1")); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		expected := map[string]interface{}{
+			"a": uint64(8), "b": uint64(8), "c": uint64(3), "d": uint64(1000), "e": "1e3", "f": "+.inf",
+		}
+		if !reflect.DeepEqual(expected, v) {
+			t.Fatalf("unexpected value: %#v", v)
+		}
+	})
+	t.Run("1.2", func(t *testing.T) {
+		var v map[string]interface{}
+		if err := yaml.UnmarshalWithOptions([]byte(src), &v, yaml.SchemaVersion("1.2")); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		expected := map[string]interface{}{
+			"a": uint64(10), "b": uint64(8), "c": "0b11", "d": "1_000", "e": float64(1000), "f": math.Inf(1),
+		}
+		if !reflect.DeepEqual(expected, v) {
+			t.Fatalf("unexpected value: %#v", v)
+		}
+	})
+	t.Run("unsupported version", func(t *testing.T) {
+		var v interface{}
+		if err := yaml.UnmarshalWithOptions([]byte(src), &v, yaml.SchemaVersion("1.3")); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestDecoder_LenientComment(t *testing.T) {
+	src := "password: abc#123\nurl: http://example.com/#top # comment\n"
+	var v map[string]string
+	if err := yaml.Unmarshal([]byte(src), &v); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if v["password"] != "abc#123" || v["url"] != "http://example.com/#top" {
+		t.Fatalf("unexpected value: %v", v)
+	}
+	var lenient map[string]string
+	if err := yaml.UnmarshalWithOptions([]byte(src), &lenient, yaml.LenientComment()); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if lenient["password"] != "abc" || lenient["url"] != "http://example.com/" {
+		t.Fatalf("unexpected value with LenientComment: %v", lenient)
+	}
+}
+
+func TestDecoder_MergeKeySequence(t *testing.T) {
+	src := "a: &a {x: 1}\nb: &b {x: 2, y: 2}\nc:\n  <<: [*a, *b]\n  z: 3\n"
+	t.Run("interface", func(t *testing.T) {
+		var v map[string]interface{}
+		if err := yaml.Unmarshal([]byte(src), &v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		expected := map[string]interface{}{"x": uint64(1), "y": uint64(2), "z": uint64(3)}
+		if !reflect.DeepEqual(expected, v["c"]) {
+			t.Fatalf("unexpected value: %#v", v["c"])
+		}
+	})
+	t.Run("ordered map", func(t *testing.T) {
+		var v yaml.MapSlice
+		if err := yaml.UnmarshalWithOptions([]byte(src), &v, yaml.UseOrderedMap()); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		expected := yaml.MapSlice{{Key: "x", Value: uint64(1)}, {Key: "y", Value: uint64(2)}, {Key: "z", Value: uint64(3)}}
+		if !reflect.DeepEqual(expected, v[2].Value) {
+			t.Fatalf("unexpected value: %#v", v[2].Value)
+		}
+	})
+}
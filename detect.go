@@ -0,0 +1,131 @@
+package yaml
+
+import (
+	"io"
+
+	"github.com/goccy/go-yaml/scanner"
+	"github.com/goccy/go-yaml/token"
+)
+
+// Kind kind of the root value of document
+type Kind int
+
+const (
+	// KindEmpty document has no value
+	KindEmpty Kind = iota
+	// KindMapping root value is mapping
+	KindMapping
+	// KindSequence root value is sequence
+	KindSequence
+	// KindScalar root value is scalar
+	KindScalar
+)
+
+// String kind to text
+func (k Kind) String() string {
+	switch k {
+	case KindEmpty:
+		return "empty"
+	case KindMapping:
+		return "mapping"
+	case KindSequence:
+		return "sequence"
+	case KindScalar:
+		return "scalar"
+	}
+	return "unknown"
+}
+
+// DetectDocumentCount returns the number of documents in src without parsing them.
+// It counts documents in the same way as parser, so it is useful to route the input before decoding.
+func DetectDocumentCount(src []byte) int {
+	stream := newTokenStream(src)
+	count := 0
+	isStarted := false
+	for tk := stream.next(); tk != nil; tk = stream.next() {
+		switch tk.Type {
+		case token.CommentType:
+		case token.DirectiveType:
+			// skip parameters of directive
+			stream.next()
+		case token.DocumentHeaderType:
+			count++
+			isStarted = true
+		case token.DocumentEndType:
+			isStarted = false
+		default:
+			if !isStarted {
+				count++
+				isStarted = true
+			}
+		}
+	}
+	return count
+}
+
+// DetectKind returns the kind of the root value of the first document in src.
+// It scans only the tokens before the root value, so it is much cheaper than decoding src.
+func DetectKind(src []byte) Kind {
+	stream := newTokenStream(src)
+	for tk := stream.next(); tk != nil; tk = stream.next() {
+		switch tk.Type {
+		case token.CommentType, token.DocumentHeaderType, token.TagType:
+		case token.DirectiveType, token.AnchorType:
+			// skip parameters of directive or name of anchor
+			stream.next()
+		case token.DocumentEndType:
+			return KindEmpty
+		case token.MappingStartType, token.MappingKeyType:
+			return KindMapping
+		case token.SequenceStartType, token.SequenceEntryType:
+			return KindSequence
+		case token.LiteralType, token.FoldedType:
+			return KindScalar
+		default:
+			if next := stream.peek(); next != nil && next.Type == token.MappingValueType {
+				return KindMapping
+			}
+			return KindScalar
+		}
+	}
+	return KindEmpty
+}
+
+// tokenStream reads tokens from scanner on demand
+type tokenStream struct {
+	scanner scanner.Scanner
+	tokens  token.Tokens
+}
+
+func newTokenStream(src []byte) *tokenStream {
+	stream := &tokenStream{}
+	stream.scanner.Init(string(src))
+	return stream
+}
+
+func (s *tokenStream) fill() bool {
+	for len(s.tokens) == 0 {
+		tokens, err := s.scanner.Scan()
+		if err == io.EOF {
+			return false
+		}
+		s.tokens = tokens
+	}
+	return true
+}
+
+func (s *tokenStream) next() *token.Token {
+	if !s.fill() {
+		return nil
+	}
+	tk := s.tokens[0]
+	s.tokens = s.tokens[1:]
+	return tk
+}
+
+func (s *tokenStream) peek() *token.Token {
+	if !s.fill() {
+		return nil
+	}
+	return s.tokens[0]
+}
@@ -0,0 +1,53 @@
+package yaml_test
+
+import (
+	"testing"
+
+	"github.com/goccy/go-yaml"
+)
+
+func TestDetectDocumentCount(t *testing.T) {
+	tests := []struct {
+		source string
+		expect int
+	}{
+		{"", 0},
+		{"# comment\n", 0},
+		{"a: 1\n", 1},
+		{"---\na: 1\n", 1},
+		{"a: 1\n---\nb: 2\n", 2},
+		{"a: 1\n---\n", 2},
+		{"%YAML 1.2\n---\na: 1\n...\n---\n- b\n", 2},
+		{"a: 1\n...\nb: 2\n", 2},
+		{"a: |\n  ---\n", 1},
+	}
+	for _, test := range tests {
+		if count := yaml.DetectDocumentCount([]byte(test.source)); count != test.expect {
+			t.Fatalf("unexpected count of %q: expected %d but got %d", test.source, test.expect, count)
+		}
+	}
+}
+
+func TestDetectKind(t *testing.T) {
+	tests := []struct {
+		source string
+		expect yaml.Kind
+	}{
+		{"", yaml.KindEmpty},
+		{"---\n", yaml.KindEmpty},
+		{"a: 1\n", yaml.KindMapping},
+		{"# comment\n\"a b\": 1\n", yaml.KindMapping},
+		{"{a: 1}\n", yaml.KindMapping},
+		{"%YAML 1.2\n--- !!map &anchor\na: 1\n", yaml.KindMapping},
+		{"- a\n", yaml.KindSequence},
+		{"[a, b]\n", yaml.KindSequence},
+		{"hello\n", yaml.KindScalar},
+		{"--- |\n  text\n", yaml.KindScalar},
+		{"- a\n---\nb: 1\n", yaml.KindSequence},
+	}
+	for _, test := range tests {
+		if kind := yaml.DetectKind([]byte(test.source)); kind != test.expect {
+			t.Fatalf("unexpected kind of %q: expected %s but got %s", test.source, test.expect, kind)
+		}
+	}
+}
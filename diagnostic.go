@@ -0,0 +1,265 @@
+package yaml
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/goccy/go-yaml/internal/errors"
+	"github.com/goccy/go-yaml/token"
+	"golang.org/x/xerrors"
+)
+
+// DiagnosticSeverity severity of Diagnostic.
+// values are same as DiagnosticSeverity of Language Server Protocol
+type DiagnosticSeverity int
+
+const (
+	// SeverityError reports an error
+	SeverityError DiagnosticSeverity = 1
+	// SeverityWarning reports a warning
+	SeverityWarning DiagnosticSeverity = 2
+	// SeverityInformation reports an information
+	SeverityInformation DiagnosticSeverity = 3
+	// SeverityHint reports a hint
+	SeverityHint DiagnosticSeverity = 4
+)
+
+// String severity to text
+func (s DiagnosticSeverity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInformation:
+		return "information"
+	case SeverityHint:
+		return "hint"
+	}
+	return "unknown"
+}
+
+// MarshalJSON encode severity as text
+func (s DiagnosticSeverity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON decode severity from text
+func (s *DiagnosticSeverity) UnmarshalJSON(b []byte) error {
+	var text string
+	if err := json.Unmarshal(b, &text); err != nil {
+		return err
+	}
+	for _, severity := range []DiagnosticSeverity{SeverityError, SeverityWarning, SeverityInformation, SeverityHint} {
+		if severity.String() == text {
+			*s = severity
+			return nil
+		}
+	}
+	return xerrors.Errorf("unknown severity %q", text)
+}
+
+// DiagnosticPosition position in source. all values start from 1, and Column is counted in bytes.
+type DiagnosticPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
+}
+
+// DiagnosticRange range in source. End points the position after the last character
+type DiagnosticRange struct {
+	Start DiagnosticPosition `json:"start"`
+	End   DiagnosticPosition `json:"end"`
+}
+
+// DiagnosticRelatedInformation position related to Diagnostic ( e.g. definition of anchor )
+type DiagnosticRelatedInformation struct {
+	Range   DiagnosticRange `json:"range"`
+	Message string          `json:"message"`
+}
+
+// Diagnostic machine-readable representation of error reported by this package
+type Diagnostic struct {
+	Severity DiagnosticSeverity             `json:"severity"`
+	Code     ErrorCode                      `json:"code,omitempty"`
+	Message  string                         `json:"message"`
+	Range    DiagnosticRange                `json:"range"`
+	Related  []DiagnosticRelatedInformation `json:"related,omitempty"`
+}
+
+// Diagnostics collection of Diagnostic
+type Diagnostics []*Diagnostic
+
+// DiagnosticsOf convert error returned by this package to Diagnostics.
+// The tokens related to the error ( e.g. the first definition of duplicated key ) are reported as Related,
+// and the hint of the error is reported as another diagnostic.
+// If err doesn't have position, Range of Diagnostic is zero value.
+func DiagnosticsOf(err error) Diagnostics {
+	if err == nil {
+		return nil
+	}
+	var syntaxErr errors.SyntaxError
+	if xerrors.As(err, &syntaxErr) {
+		diag := &Diagnostic{
+			Severity: SeverityError,
+			Code:     syntaxErr.Code(),
+			Message:  syntaxErr.Message(),
+			Range:    TokenRange(syntaxErr.Token()),
+		}
+		for _, related := range syntaxErr.Related() {
+			diag.Related = append(diag.Related, DiagnosticRelatedInformation{
+				Range:   TokenRange(related.Token),
+				Message: related.Message,
+			})
+		}
+		diagnostics := Diagnostics{diag}
+		if hint := syntaxErr.Hint(); hint != "" {
+			diagnostics = append(diagnostics, &Diagnostic{
+				Severity: SeverityHint,
+				Code:     syntaxErr.Code(),
+				Message:  hint,
+				Range:    TokenRange(syntaxErr.Token()),
+			})
+		}
+		return diagnostics
+	}
+	return Diagnostics{
+		{
+			Severity: SeverityError,
+			Message:  err.Error(),
+		},
+	}
+}
+
+// TokenRange returns range of token in source
+func TokenRange(tk *token.Token) DiagnosticRange {
+	if tk == nil || tk.Position == nil {
+		return DiagnosticRange{}
+	}
+	start := DiagnosticPosition{
+		Line:   tk.Position.Line,
+		Column: tk.Position.Column,
+		Offset: tk.Position.Offset,
+	}
+	text := strings.Trim(tk.Origin, " \t\r\n")
+	if text == "" {
+		text = tk.Value
+	}
+	end := start
+	end.Offset += len(text)
+	if idx := strings.LastIndexByte(text, '\n'); idx >= 0 {
+		end.Line += strings.Count(text, "\n")
+		end.Column = len(text) - idx
+	} else {
+		end.Column += len(text)
+	}
+	return DiagnosticRange{Start: start, End: end}
+}
+
+// LSPPosition position of Language Server Protocol. values start from 0.
+// Character is counted in bytes like Column of DiagnosticPosition, which is `utf-8` position encoding of Language Server Protocol.
+// Use UTF16 to convert it for the client which supports only `utf-16` position encoding.
+type LSPPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// UTF16 converts Character counted in bytes to the number of UTF-16 code units in the line of src
+func (p LSPPosition) UTF16(src []byte) LSPPosition {
+	line := src
+	for i := 0; i < p.Line; i++ {
+		idx := bytes.IndexByte(line, '\n')
+		if idx < 0 {
+			return p
+		}
+		line = line[idx+1:]
+	}
+	if idx := bytes.IndexByte(line, '\n'); idx >= 0 {
+		line = line[:idx]
+	}
+	if p.Character < len(line) {
+		line = line[:p.Character]
+	}
+	return LSPPosition{Line: p.Line, Character: len(utf16.Encode([]rune(string(line))))}
+}
+
+// LSPRange range of Language Server Protocol
+type LSPRange struct {
+	Start LSPPosition `json:"start"`
+	End   LSPPosition `json:"end"`
+}
+
+// UTF16 converts the characters of range to the number of UTF-16 code units in src
+func (r LSPRange) UTF16(src []byte) LSPRange {
+	return LSPRange{Start: r.Start.UTF16(src), End: r.End.UTF16(src)}
+}
+
+// LSPLocation location of Language Server Protocol
+type LSPLocation struct {
+	URI   string   `json:"uri"`
+	Range LSPRange `json:"range"`
+}
+
+// LSPDiagnosticRelatedInformation related information of Language Server Protocol
+type LSPDiagnosticRelatedInformation struct {
+	Location LSPLocation `json:"location"`
+	Message  string      `json:"message"`
+}
+
+// LSPDiagnostic diagnostic of Language Server Protocol
+type LSPDiagnostic struct {
+	Range              LSPRange                          `json:"range"`
+	Severity           int                               `json:"severity"`
+	Code               string                            `json:"code,omitempty"`
+	Source             string                            `json:"source"`
+	Message            string                            `json:"message"`
+	RelatedInformation []LSPDiagnosticRelatedInformation `json:"relatedInformation,omitempty"`
+}
+
+// LSP convert to range of Language Server Protocol
+func (r DiagnosticRange) LSP() LSPRange {
+	return LSPRange{
+		Start: r.Start.lsp(),
+		End:   r.End.lsp(),
+	}
+}
+
+func (p DiagnosticPosition) lsp() LSPPosition {
+	pos := LSPPosition{Line: p.Line - 1, Character: p.Column - 1}
+	if pos.Line < 0 {
+		pos.Line = 0
+	}
+	if pos.Character < 0 {
+		pos.Character = 0
+	}
+	return pos
+}
+
+// LSP convert to diagnostic of Language Server Protocol. uri is used for related information
+func (d *Diagnostic) LSP(uri string) *LSPDiagnostic {
+	diag := &LSPDiagnostic{
+		Range:    d.Range.LSP(),
+		Severity: int(d.Severity),
+		Code:     string(d.Code),
+		Source:   "yaml",
+		Message:  d.Message,
+	}
+	for _, related := range d.Related {
+		diag.RelatedInformation = append(diag.RelatedInformation, LSPDiagnosticRelatedInformation{
+			Location: LSPLocation{URI: uri, Range: related.Range.LSP()},
+			Message:  related.Message,
+		})
+	}
+	return diag
+}
+
+// LSP convert all diagnostics to diagnostics of Language Server Protocol
+func (d Diagnostics) LSP(uri string) []*LSPDiagnostic {
+	diags := make([]*LSPDiagnostic, 0, len(d))
+	for _, diag := range d {
+		diags = append(diags, diag.LSP(uri))
+	}
+	return diags
+}
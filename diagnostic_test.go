@@ -0,0 +1,124 @@
+package yaml_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+	"golang.org/x/xerrors"
+)
+
+func TestDiagnosticsOf(t *testing.T) {
+	t.Run("syntax error", func(t *testing.T) {
+		var v interface{}
+		err := yaml.Unmarshal([]byte("a:\nb\n"), &v)
+		diags := yaml.DiagnosticsOf(err)
+		if len(diags) != 1 {
+			t.Fatalf("unexpected diagnostics: %v", diags)
+		}
+		diag := diags[0]
+		if diag.Severity != yaml.SeverityError {
+			t.Fatalf("unexpected severity: %s", diag.Severity)
+		}
+		if diag.Code != yaml.ErrCodeMissingMappingValueToken {
+			t.Fatalf("unexpected code: %s", diag.Code)
+		}
+		if diag.Message != "could not found expected ':' token" {
+			t.Fatalf("unexpected message: %s", diag.Message)
+		}
+		expectedRange := yaml.DiagnosticRange{
+			Start: yaml.DiagnosticPosition{Line: 2, Column: 1, Offset: 4},
+			End:   yaml.DiagnosticPosition{Line: 2, Column: 2, Offset: 5},
+		}
+		if diag.Range != expectedRange {
+			t.Fatalf("unexpected range: %+v", diag.Range)
+		}
+		b, err := json.Marshal(diags)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		expected := `[{"severity":"error","code":"missing-mapping-value-token","message":"could not found expected ':' token","range":{"start":{"line":2,"column":1,"offset":4},"end":{"line":2,"column":2,"offset":5}}}]`
+		if string(b) != expected {
+			t.Fatalf("unexpected json: %s", string(b))
+		}
+		var decoded yaml.Diagnostics
+		if err := json.Unmarshal(b, &decoded); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if !reflect.DeepEqual(decoded[0], diag) {
+			t.Fatalf("failed to decode json: %+v", decoded[0])
+		}
+		lsp, err := json.Marshal(diags.LSP("file:///a.yml"))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		expected = `[{"range":{"start":{"line":1,"character":0},"end":{"line":1,"character":1}},"severity":1,"code":"missing-mapping-value-token","source":"yaml","message":"could not found expected ':' token"}]`
+		if string(lsp) != expected {
+			t.Fatalf("unexpected lsp json: %s", string(lsp))
+		}
+	})
+	t.Run("hint", func(t *testing.T) {
+		var v interface{}
+		err := yaml.Unmarshal([]byte("a:\n\tb: c\n"), &v)
+		diags := yaml.DiagnosticsOf(err)
+		if len(diags) != 2 {
+			t.Fatalf("unexpected diagnostics: %v", diags)
+		}
+		if diags[1].Severity != yaml.SeverityHint || diags[1].Message != "use spaces instead of tabs for indentation" {
+			t.Fatalf("unexpected hint: %+v", diags[1])
+		}
+		if diags[1].Range != diags[0].Range {
+			t.Fatalf("unexpected range: %+v", diags[1].Range)
+		}
+	})
+	t.Run("error without position", func(t *testing.T) {
+		diags := yaml.DiagnosticsOf(xerrors.New("error"))
+		if len(diags) != 1 || diags[0].Message != "error" || diags[0].Code != "" {
+			t.Fatalf("unexpected diagnostics: %+v", diags[0])
+		}
+	})
+	t.Run("related", func(t *testing.T) {
+		var v map[string]interface{}
+		err := yaml.UnmarshalWithOptions([]byte("a: 1\nb: 2\na: 3\n"), &v, yaml.DisallowDuplicateKey())
+		diags := yaml.DiagnosticsOf(err)
+		if len(diags) != 1 || diags[0].Code != yaml.ErrCodeDuplicateKey {
+			t.Fatalf("unexpected diagnostics: %+v", diags)
+		}
+		expected := []yaml.DiagnosticRelatedInformation{
+			{
+				Range: yaml.DiagnosticRange{
+					Start: yaml.DiagnosticPosition{Line: 1, Column: 1, Offset: 1},
+					End:   yaml.DiagnosticPosition{Line: 1, Column: 2, Offset: 2},
+				},
+				Message: "first definition of the key",
+			},
+		}
+		if !reflect.DeepEqual(diags[0].Related, expected) {
+			t.Fatalf("unexpected related information: %+v", diags[0].Related)
+		}
+		lsp := diags.LSP("file:///a.yml")
+		if len(lsp[0].RelatedInformation) != 1 || lsp[0].RelatedInformation[0].Location.Range.Start.Line != 0 {
+			t.Fatalf("unexpected lsp related information: %+v", lsp[0].RelatedInformation)
+		}
+	})
+	t.Run("utf16", func(t *testing.T) {
+		src := []byte("a: 1\n日本: 😀 x\n")
+		rng := yaml.LSPRange{
+			Start: yaml.LSPPosition{Line: 1, Character: 13},
+			End:   yaml.LSPPosition{Line: 1, Character: 14},
+		}
+		expected := yaml.LSPRange{
+			Start: yaml.LSPPosition{Line: 1, Character: 7},
+			End:   yaml.LSPPosition{Line: 1, Character: 8},
+		}
+		if actual := rng.UTF16(src); actual != expected {
+			t.Fatalf("unexpected range: %+v", actual)
+		}
+	})
+	t.Run("nil", func(t *testing.T) {
+		if diags := yaml.DiagnosticsOf(nil); diags != nil {
+			t.Fatalf("unexpected diagnostics: %v", diags)
+		}
+	})
+}
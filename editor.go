@@ -0,0 +1,723 @@
+package yaml
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/internal/errors"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/token"
+	"golang.org/x/xerrors"
+)
+
+// Editor edits YAML source by path ( e.g. `$.a.b[0]` ) with keeping comments and formatting.
+// Each operation rewrites only the lines of the target, and the other lines are kept as they are.
+// Block style mappings are supported as the target of operations.
+type Editor struct {
+	src []byte
+}
+
+// NewEditor create Editor instance for src
+func NewEditor(src []byte) (*Editor, error) {
+	if _, err := parser.ParseBytes(src, 0); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse")
+	}
+	return &Editor{src: src}, nil
+}
+
+// Bytes returns edited source
+func (e *Editor) Bytes() []byte {
+	return e.src
+}
+
+// String returns edited source as string
+func (e *Editor) String() string {
+	return string(e.src)
+}
+
+// RenameKey renames the key at path to newKey.
+// The key is rewritten in all documents which have path.
+func (e *Editor) RenameKey(path, newKey string) error {
+	segments, err := parseKeyPath(path)
+	if err != nil {
+		return err
+	}
+	file, err := parser.ParseBytes(e.src, 0)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse")
+	}
+	keyTokens := []*token.Token{}
+	for _, doc := range file.Docs {
+		mvnode, parent := findMappingValue(doc.Body, segments)
+		if mvnode == nil {
+			continue
+		}
+		if lookupMappingValue(parent, newKey) != nil {
+			return xerrors.Errorf("key %q already exists in %s", newKey, parentPath(segments))
+		}
+		keyTokens = append(keyTokens, mvnode.Key.GetToken())
+	}
+	if len(keyTokens) == 0 {
+		return xerrors.Errorf("path %s is not found", path)
+	}
+	var buf bytes.Buffer
+	last := 0
+	for _, tk := range keyTokens {
+		start, end := tokenOffsetRange(tk)
+		if start < last || end > len(e.src) {
+			return xerrors.Errorf("failed to find key at %d:%d", tk.Position.Line, tk.Position.Column)
+		}
+		buf.Write(e.src[last:start])
+		buf.WriteString(formatKey(newKey))
+		last = end
+	}
+	buf.Write(e.src[last:])
+	return e.update(buf.Bytes())
+}
+
+// MoveKey moves the key at from and its value to the path to.
+// The last element of to becomes the new key name, and missing parent mappings of to are created.
+// Comments above the key and on the lines of the value are moved together.
+func (e *Editor) MoveKey(from, to string) error {
+	fromSegments, err := parseKeyPath(from)
+	if err != nil {
+		return err
+	}
+	toSegments, err := parseKeyPath(to)
+	if err != nil {
+		return err
+	}
+	if len(fromSegments) == len(toSegments) && isPathPrefix(fromSegments, toSegments) {
+		return nil
+	}
+	if isPathPrefix(fromSegments, toSegments) {
+		return xerrors.Errorf("couldn't move %s into itself", from)
+	}
+	file, err := parser.ParseBytes(e.src, 0)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse")
+	}
+	for _, doc := range file.Docs {
+		mvnode, _ := findMappingValue(doc.Body, fromSegments)
+		if mvnode == nil {
+			continue
+		}
+		edited, err := e.moveMappingValue(doc, mvnode, toSegments)
+		if err != nil {
+			return errors.Wrapf(err, "failed to move %s to %s", from, to)
+		}
+		// the other documents are moved by the next call with updated source
+		if err := e.update(edited); err != nil {
+			return err
+		}
+		if err := e.MoveKey(from, to); err != nil && !isNotFoundPath(err) {
+			return err
+		}
+		return nil
+	}
+	return &pathNotFoundError{path: from}
+}
+
+func (e *Editor) moveMappingValue(doc *ast.Document, mvnode *ast.MappingValueNode, toSegments []*pathSegment) ([]byte, error) {
+	lines := splitLines(e.src)
+	keyTk := mvnode.Key.GetToken()
+	if !isKeyAtLineStart(mvnode, lines) {
+		return nil, xerrors.New("only the key at the beginning of line can be moved")
+	}
+	startLine, endLine := mappingValueLineRange(mvnode, lines)
+
+	parent, missing, err := findInsertionParent(doc, toSegments[:len(toSegments)-1], lines)
+	if err != nil {
+		return nil, err
+	}
+	newKey := toSegments[len(toSegments)-1].key
+	if len(missing) == 0 {
+		if exists := lookupMappingValue(parent.mapping, newKey); exists != nil && exists != mvnode {
+			return nil, xerrors.Errorf("key %q already exists", newKey)
+		}
+	}
+	if parent.insertAfterLine >= startLine && parent.insertAfterLine < endLine {
+		return nil, xerrors.New("couldn't move key into itself")
+	}
+
+	// build moved lines
+	moved, indent := missingKeyLines(parent, missing)
+	baseIndent := keyTk.Position.Column - 1
+	for idx := startLine; idx <= endLine; idx++ {
+		line := lines[idx-1]
+		if idx == keyTk.Position.Line {
+			start := keyTk.Position.Column - 1
+			_, end := tokenOffsetRange(keyTk)
+			end -= lineOffset(lines, idx)
+			line = line[:start] + formatKey(newKey) + line[end:]
+		}
+		trimmed := strings.TrimLeft(line, " ")
+		switch {
+		case strings.TrimSpace(line) == "":
+			moved = append(moved, strings.TrimLeft(line, " \t"))
+		case len(line)-len(trimmed) >= baseIndent:
+			moved = append(moved, strings.Repeat(" ", indent)+line[baseIndent:])
+		default:
+			moved = append(moved, line)
+		}
+	}
+	if last := moved[len(moved)-1]; !strings.HasSuffix(last, "\n") {
+		moved[len(moved)-1] = last + "\n"
+	}
+
+	// remove original lines, and insert moved lines
+	result := []string{}
+	insertAfter := parent.insertAfterLine
+	for idx := 1; idx <= len(lines); idx++ {
+		if idx < startLine || endLine < idx {
+			line := lines[idx-1]
+			if idx == insertAfter && !strings.HasSuffix(line, "\n") {
+				line += "\n"
+			}
+			result = append(result, line)
+		}
+		if idx == insertAfter {
+			result = append(result, moved...)
+		}
+	}
+	if insertAfter == 0 {
+		result = append(moved, result...)
+	}
+	return []byte(strings.Join(result, "")), nil
+}
+
+// SetValue replaces the value of the key at path with v encoded by Marshal.
+// The anchor of the value and the comment on the line of the key are kept.
+// The value is replaced in all documents which have path.
+func (e *Editor) SetValue(path string, v interface{}) error {
+	segments, err := parseKeyPath(path)
+	if err != nil {
+		return err
+	}
+	inline, block, err := encodeEditValue(v)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode value")
+	}
+	file, err := parser.ParseBytes(e.src, 0)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse")
+	}
+	src := e.src
+	found := false
+	// edit from the last document to keep offsets of the preceding documents
+	for idx := len(file.Docs) - 1; idx >= 0; idx-- {
+		mvnode, _ := findMappingValue(file.Docs[idx].Body, segments)
+		if mvnode == nil {
+			continue
+		}
+		if block != nil && !isKeyAtLineStart(mvnode, splitLines(src)) {
+			return xerrors.Errorf("couldn't set block value to %s", path)
+		}
+		start, end := valueOffsetRange(mvnode)
+		var buf bytes.Buffer
+		buf.Write(src[:start])
+		if block != nil {
+			indent := strings.Repeat(" ", mvnode.Key.GetToken().Position.Column-1+defaultIndentWidth)
+			for _, line := range block {
+				buf.WriteString("\n" + indent + line)
+			}
+		} else {
+			buf.WriteString(" " + inline)
+		}
+		buf.Write(src[end:])
+		src = buf.Bytes()
+		found = true
+	}
+	if !found {
+		return &pathNotFoundError{path: path}
+	}
+	return e.update(src)
+}
+
+// AddKey adds the key at path with value v encoded by Marshal.
+// The key is appended to the end of the parent mapping, and missing parent mappings are created.
+// The key is added to all documents, and it is an error if the key already exists in any of them.
+func (e *Editor) AddKey(path string, v interface{}) error {
+	segments, err := parseKeyPath(path)
+	if err != nil {
+		return err
+	}
+	inline, block, err := encodeEditValue(v)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode value")
+	}
+	file, err := parser.ParseBytes(e.src, 0)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse")
+	}
+	key := segments[len(segments)-1].key
+	lines := splitLines(e.src)
+	docs := file.Docs
+	if len(docs) == 0 {
+		docs = []*ast.Document{{}}
+	}
+	// edit from the last document to keep line numbers of the preceding documents
+	for idx := len(docs) - 1; idx >= 0; idx-- {
+		parent, missing, err := findInsertionParent(docs[idx], segments[:len(segments)-1], lines)
+		if err != nil {
+			return errors.Wrapf(err, "failed to add %s", path)
+		}
+		if len(missing) == 0 && lookupMappingValue(parent.mapping, key) != nil {
+			return xerrors.Errorf("key %q already exists in %s", key, parentPath(segments))
+		}
+		added, indent := missingKeyLines(parent, missing)
+		if block != nil {
+			added = append(added, fmt.Sprintf("%s%s:\n", strings.Repeat(" ", indent), formatKey(key)))
+			for _, line := range block {
+				added = append(added, strings.Repeat(" ", indent+parent.indentWidth)+line+"\n")
+			}
+		} else {
+			added = append(added, fmt.Sprintf("%s%s: %s\n", strings.Repeat(" ", indent), formatKey(key), inline))
+		}
+		lines = insertLines(lines, parent.insertAfterLine, added)
+	}
+	return e.update([]byte(strings.Join(lines, "")))
+}
+
+// DeleteKey deletes the key at path and its value.
+// Comments above the key and on the lines of the value are deleted together.
+// The key is deleted in all documents which have path.
+func (e *Editor) DeleteKey(path string) error {
+	segments, err := parseKeyPath(path)
+	if err != nil {
+		return err
+	}
+	file, err := parser.ParseBytes(e.src, 0)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse")
+	}
+	lines := splitLines(e.src)
+	found := false
+	// edit from the last document to keep line numbers of the preceding documents
+	for idx := len(file.Docs) - 1; idx >= 0; idx-- {
+		mvnode, _ := findMappingValue(file.Docs[idx].Body, segments)
+		if mvnode == nil {
+			continue
+		}
+		if !isKeyAtLineStart(mvnode, lines) {
+			return xerrors.New("only the key at the beginning of line can be deleted")
+		}
+		start, end := mappingValueLineRange(mvnode, lines)
+		lines = append(append([]string{}, lines[:start-1]...), lines[end:]...)
+		found = true
+	}
+	if !found {
+		return &pathNotFoundError{path: path}
+	}
+	return e.update([]byte(strings.Join(lines, "")))
+}
+
+// lookup returns the mapping value node at path in the first document which has path and the decoded value of it.
+// If path is not found, returns nil.
+func (e *Editor) lookup(path string) (*ast.MappingValueNode, interface{}, error) {
+	segments, err := parseKeyPath(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := parser.ParseBytes(e.src, 0)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to parse")
+	}
+	for _, doc := range file.Docs {
+		mvnode, _ := findMappingValue(doc.Body, segments)
+		if mvnode == nil {
+			continue
+		}
+		dec := NewDecoder(nil)
+		// resolve anchors referenced by the value
+		dec.nodeToValue(doc.Body)
+		return mvnode, dec.nodeToValue(mvnode.Value), nil
+	}
+	return nil, nil, nil
+}
+
+func (e *Editor) update(src []byte) error {
+	if _, err := parser.ParseBytes(src, 0); err != nil {
+		return errors.Wrapf(err, "edited source is invalid")
+	}
+	e.src = src
+	return nil
+}
+
+type pathNotFoundError struct {
+	path string
+}
+
+func (e *pathNotFoundError) Error() string {
+	return fmt.Sprintf("path %s is not found", e.path)
+}
+
+func isNotFoundPath(err error) bool {
+	var notFound *pathNotFoundError
+	return xerrors.As(err, &notFound)
+}
+
+type pathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// parseKeyPath parse path which points to mapping key
+func parseKeyPath(path string) ([]*pathSegment, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse path")
+	}
+	if len(segments) == 0 || segments[len(segments)-1].isIndex {
+		return nil, xerrors.Errorf("path %s doesn't point to mapping key", path)
+	}
+	return segments, nil
+}
+
+// parsePath parse path like `$.a.b[0]` or `$['a.b']`
+func parsePath(path string) ([]*pathSegment, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, xerrors.Errorf("path must start with '$': %s", path)
+	}
+	segments := []*pathSegment{}
+	src := path[1:]
+	for len(src) > 0 {
+		switch {
+		case strings.HasPrefix(src, "['"):
+			end := strings.Index(src[2:], "']")
+			if end < 0 {
+				return nil, xerrors.Errorf("unterminated quoted key in %s", path)
+			}
+			segments = append(segments, &pathSegment{key: src[2 : 2+end]})
+			src = src[2+end+2:]
+		case src[0] == '[':
+			end := strings.IndexByte(src, ']')
+			if end < 0 {
+				return nil, xerrors.Errorf("unterminated index in %s", path)
+			}
+			idx, err := strconv.Atoi(src[1:end])
+			if err != nil || idx < 0 {
+				return nil, xerrors.Errorf("invalid index %q in %s", src[1:end], path)
+			}
+			segments = append(segments, &pathSegment{index: idx, isIndex: true})
+			src = src[end+1:]
+		case src[0] == '.':
+			end := strings.IndexAny(src[1:], ".[")
+			if end < 0 {
+				end = len(src) - 1
+			}
+			key := src[1 : 1+end]
+			if key == "" {
+				return nil, xerrors.Errorf("empty key in %s", path)
+			}
+			segments = append(segments, &pathSegment{key: key})
+			src = src[1+end:]
+		default:
+			return nil, xerrors.Errorf("unexpected character %q in %s", src[0], path)
+		}
+	}
+	return segments, nil
+}
+
+func parentPath(segments []*pathSegment) string {
+	return pathText(segments[:len(segments)-1])
+}
+
+func isPathPrefix(prefix, segments []*pathSegment) bool {
+	if len(prefix) > len(segments) {
+		return false
+	}
+	for idx, seg := range prefix {
+		if *seg != *segments[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+func mappingValues(node ast.Node) []*ast.MappingValueNode {
+	switch n := ast.Unwrap(node).(type) {
+	case *ast.MappingNode:
+		return n.Values
+	case *ast.MappingValueNode:
+		return []*ast.MappingValueNode{n}
+	}
+	return nil
+}
+
+func lookupMappingValue(node ast.Node, key string) *ast.MappingValueNode {
+	for _, value := range mappingValues(node) {
+		if isKeyOf(value.Key, key) {
+			return value
+		}
+	}
+	return nil
+}
+
+// isKeyOf reports whether node is the key written as key in path.
+// Quotes and `!!str` tag of node are resolved ( e.g. `"a"` and `!!str a` are the key `a` ).
+func isKeyOf(node ast.Node, key string) bool {
+	if s, ok := ast.KeyValue(node).(string); ok {
+		return s == key
+	}
+	return ast.Unwrap(node).GetToken().Value == key
+}
+
+// findMappingValue returns mapping value node at path and the node which has it
+func findMappingValue(node ast.Node, segments []*pathSegment) (*ast.MappingValueNode, ast.Node) {
+	parent := node
+	var found *ast.MappingValueNode
+	for _, seg := range segments {
+		if parent == nil {
+			return nil, nil
+		}
+		if seg.isIndex {
+			seq, ok := ast.Unwrap(parent).(*ast.SequenceNode)
+			if !ok || seg.index >= len(seq.Values) {
+				return nil, nil
+			}
+			found = nil
+			parent = seq.Values[seg.index]
+			continue
+		}
+		found = lookupMappingValue(parent, seg.key)
+		if found == nil {
+			return nil, nil
+		}
+		node, parent = parent, found.Value
+	}
+	return found, node
+}
+
+const defaultIndentWidth = 2
+
+type insertionParent struct {
+	mapping         ast.Node
+	indent          int
+	indentWidth     int
+	insertAfterLine int
+}
+
+// findInsertionParent returns the deepest existing block mapping of segments in doc and the keys missing under it
+func findInsertionParent(doc *ast.Document, segments []*pathSegment, lines []string) (*insertionParent, []string, error) {
+	body := doc.Body
+	parent := &insertionParent{
+		mapping:     body,
+		indentWidth: defaultIndentWidth,
+	}
+	if values := mappingValues(body); len(values) > 0 {
+		parent.indent = values[0].Key.GetToken().Position.Column - 1
+		_, parent.insertAfterLine = nodeLineRange(body)
+	} else if body != nil {
+		return nil, nil, xerrors.New("document body is not mapping")
+	} else if doc.Start != nil {
+		parent.insertAfterLine = doc.Start.Position.Line
+	}
+	for idx, seg := range segments {
+		if seg.isIndex {
+			return nil, nil, xerrors.New("sequence is not supported as the parent of destination")
+		}
+		mvnode := lookupMappingValue(parent.mapping, seg.key)
+		if mvnode == nil {
+			missing := []string{}
+			for _, seg := range segments[idx:] {
+				if seg.isIndex {
+					return nil, nil, xerrors.New("sequence is not supported as the parent of destination")
+				}
+				missing = append(missing, seg.key)
+			}
+			return parent, missing, nil
+		}
+		keyIndent := mvnode.Key.GetToken().Position.Column - 1
+		switch value := ast.Unwrap(mvnode.Value).(type) {
+		case *ast.MappingNode, *ast.MappingValueNode:
+			values := mappingValues(value)
+			if values[0].Key.GetToken().Position.Line == mvnode.Key.GetToken().Position.Line {
+				return nil, nil, xerrors.New("flow style mapping is not supported")
+			}
+			parent.indentWidth = values[0].Key.GetToken().Position.Column - 1 - keyIndent
+			parent.indent = keyIndent + parent.indentWidth
+			_, parent.insertAfterLine = nodeLineRange(mvnode)
+			parent.mapping = value
+		case *ast.NullNode:
+			// only empty value can be replaced by mapping
+			line := lines[mvnode.Start.Position.Line-1]
+			rest := strings.TrimSpace(line[mvnode.Start.Position.Column:])
+			if rest != "" && !strings.HasPrefix(rest, "#") {
+				return nil, nil, xerrors.Errorf("value of %s is not mapping", seg.key)
+			}
+			parent.indent = keyIndent + parent.indentWidth
+			parent.insertAfterLine = mvnode.Start.Position.Line
+			parent.mapping = value
+		default:
+			return nil, nil, xerrors.Errorf("value of %s is not mapping", seg.key)
+		}
+	}
+	return parent, nil, nil
+}
+
+// missingKeyLines returns lines of missing parent keys and the indent of the key under them
+func missingKeyLines(parent *insertionParent, missing []string) ([]string, int) {
+	lines := []string{}
+	indent := parent.indent
+	for _, key := range missing {
+		lines = append(lines, fmt.Sprintf("%s%s:\n", strings.Repeat(" ", indent), formatKey(key)))
+		indent += parent.indentWidth
+	}
+	return lines, indent
+}
+
+// insertLines inserts added lines after the line. after is one-based, and zero means the beginning of source
+func insertLines(lines []string, after int, added []string) []string {
+	result := make([]string, 0, len(lines)+len(added))
+	result = append(result, lines[:after]...)
+	if after > 0 && !strings.HasSuffix(result[after-1], "\n") {
+		result[after-1] += "\n"
+	}
+	result = append(result, added...)
+	return append(result, lines[after:]...)
+}
+
+// mappingValueLineRange returns the first and the last line of mapping value including comments above the key
+func mappingValueLineRange(mvnode *ast.MappingValueNode, lines []string) (int, int) {
+	keyTk := mvnode.Key.GetToken()
+	startLine := keyTk.Position.Line
+	_, endLine := nodeLineRange(mvnode)
+	for startLine > 1 {
+		prev := lines[startLine-2]
+		trimmed := strings.TrimLeft(prev, " ")
+		if !strings.HasPrefix(trimmed, "#") || len(prev)-len(trimmed) != keyTk.Position.Column-1 {
+			break
+		}
+		startLine--
+	}
+	return startLine, endLine
+}
+
+// valueOffsetRange returns zero-based offset range of the value of mapping value node excluding anchor.
+// For empty value, the range is empty and points after the ':'
+func valueOffsetRange(mvnode *ast.MappingValueNode) (int, int) {
+	_, start := tokenOffsetRange(mvnode.Start)
+	value := mvnode.Value
+	if anchor, ok := value.(*ast.AnchorNode); ok {
+		_, start = tokenOffsetRange(anchor.Name.GetToken())
+		value = anchor.Value
+	}
+	valueStart, valueEnd := nodeOffsetRange(value)
+	if valueStart < start {
+		return start, start
+	}
+	return start, valueEnd
+}
+
+func isKeyAtLineStart(mvnode *ast.MappingValueNode, lines []string) bool {
+	keyTk := mvnode.Key.GetToken()
+	line := lines[keyTk.Position.Line-1]
+	return strings.TrimLeft(line[:keyTk.Position.Column-1], " ") == ""
+}
+
+// nodeSourceRange returns range of node in source
+func nodeSourceRange(node ast.Node) DiagnosticRange {
+	var rng DiagnosticRange
+	for _, tk := range ast.Tokens(node) {
+		if tk.Position == nil {
+			continue
+		}
+		r := TokenRange(tk)
+		if rng.Start.Offset == 0 || r.Start.Offset < rng.Start.Offset {
+			rng.Start = r.Start
+		}
+		if rng.End.Offset < r.End.Offset {
+			rng.End = r.End
+		}
+	}
+	return rng
+}
+
+// nodeLineRange returns the first and the last line of node
+func nodeLineRange(node ast.Node) (int, int) {
+	rng := nodeSourceRange(node)
+	return rng.Start.Line, rng.End.Line
+}
+
+// nodeOffsetRange returns zero-based offset range of node in source
+func nodeOffsetRange(node ast.Node) (int, int) {
+	rng := nodeSourceRange(node)
+	return rng.Start.Offset - 1, rng.End.Offset - 1
+}
+
+// tokenOffsetRange returns zero-based offset range of token in source
+func tokenOffsetRange(tk *token.Token) (int, int) {
+	rng := TokenRange(tk)
+	return rng.Start.Offset - 1, rng.End.Offset - 1
+}
+
+// splitLines split src into lines with keeping line feed
+func splitLines(src []byte) []string {
+	lines := strings.SplitAfter(string(src), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// lineOffset returns zero-based offset of the beginning of line
+func lineOffset(lines []string, line int) int {
+	offset := 0
+	for _, l := range lines[:line-1] {
+		offset += len(l)
+	}
+	return offset
+}
+
+func formatKey(key string) string {
+	if token.IsNeedQuoted(key) ||
+		strings.ContainsAny(key, ":#{}[],&*!|>'\"%@`\n") ||
+		strings.TrimSpace(key) != key {
+		return strconv.Quote(key)
+	}
+	return key
+}
+
+// encodeEditValue encodes v to the inline text or the lines of block
+func encodeEditValue(v interface{}) (string, []string, error) {
+	bytes, err := Marshal(v)
+	if err != nil {
+		return "", nil, err
+	}
+	text := strings.TrimRight(string(bytes), "\n")
+	if text == "" {
+		if rv := reflect.Indirect(reflect.ValueOf(v)); rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+			return "[]", nil, nil
+		}
+		return "null", nil, nil
+	}
+	file, err := parser.ParseBytes(bytes, 0)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "failed to parse encoded value")
+	}
+	if len(file.Docs) == 0 {
+		return text, nil, nil
+	}
+	switch n := ast.Unwrap(file.Docs[0].Body).(type) {
+	case *ast.MappingNode:
+		if n.IsFlowStyle {
+			return text, nil, nil
+		}
+	case *ast.SequenceNode:
+		if n.IsFlowStyle {
+			return text, nil, nil
+		}
+	case *ast.MappingValueNode:
+	default:
+		if !strings.Contains(text, "\n") {
+			return text, nil, nil
+		}
+	}
+	return "", strings.Split(text, "\n"), nil
+}
@@ -0,0 +1,378 @@
+package yaml_test
+
+import (
+	"testing"
+
+	"github.com/goccy/go-yaml"
+)
+
+func TestEditor_RenameKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		path     string
+		newKey   string
+		expected string
+	}{
+		{
+			name: "nested key",
+			src: `
+# comment
+a:
+  b: 1 # comment of b
+  c: 2
+`,
+			path:   "$.a.b",
+			newKey: "renamed",
+			expected: `
+# comment
+a:
+  renamed: 1 # comment of b
+  c: 2
+`,
+		},
+		{
+			name:     "key in sequence",
+			src:      "a:\n- b: 1\n  c: 2\n- b: 3\n",
+			path:     "$.a[1].b",
+			newKey:   "d",
+			expected: "a:\n- b: 1\n  c: 2\n- d: 3\n",
+		},
+		{
+			name:     "quoted key",
+			src:      "'a': 1\n",
+			path:     "$.a",
+			newKey:   "b: c",
+			expected: "\"b: c\": 1\n",
+		},
+		{
+			name:     "all documents",
+			src:      "a: 1\n---\nb: 2\n---\na: 3\n",
+			path:     "$.a",
+			newKey:   "c",
+			expected: "c: 1\n---\nb: 2\n---\nc: 3\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			editor, err := yaml.NewEditor([]byte(test.src))
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if err := editor.RenameKey(test.path, test.newKey); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if editor.String() != test.expected {
+				t.Fatalf("unexpected source. expected:\n%s\nbut got:\n%s", test.expected, editor.String())
+			}
+		})
+	}
+	t.Run("error", func(t *testing.T) {
+		editor, err := yaml.NewEditor([]byte("a: 1\nb: 2\n"))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if err := editor.RenameKey("$.c", "d"); err == nil {
+			t.Fatal("expected error for unknown path")
+		}
+		if err := editor.RenameKey("$.a", "b"); err == nil {
+			t.Fatal("expected error for existing key")
+		}
+	})
+}
+
+func TestEditor_MoveKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		from     string
+		to       string
+		expected string
+	}{
+		{
+			name: "move to existing mapping",
+			src: `a:
+  # comment of b
+  b:
+    c: 1 # comment of c
+    d: |
+      text
+  e: 2
+f:
+    g: 3
+`,
+			from: "$.a.b",
+			to:   "$.f.h",
+			expected: `a:
+  e: 2
+f:
+    g: 3
+    # comment of b
+    h:
+      c: 1 # comment of c
+      d: |
+        text
+`,
+		},
+		{
+			name: "move to root",
+			src: `a:
+  b: 1
+  c: 2
+d: 3
+`,
+			from: "$.a.b",
+			to:   "$.b",
+			expected: `a:
+  c: 2
+d: 3
+b: 1
+`,
+		},
+		{
+			name: "create missing parents",
+			src: `a: 1
+b: 2
+`,
+			from: "$.a",
+			to:   "$.x.y.z",
+			expected: `b: 2
+x:
+  y:
+    z: 1
+`,
+		},
+		{
+			name: "move to empty value",
+			src: `a: 1
+b:
+c: 3
+`,
+			from: "$.a",
+			to:   "$.b.a",
+			expected: `b:
+  a: 1
+c: 3
+`,
+		},
+		{
+			name:     "all documents",
+			src:      "a: 1\nb: 2\n---\na: 3\n",
+			from:     "$.a",
+			to:       "$.c",
+			expected: "b: 2\nc: 1\n---\nc: 3\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			editor, err := yaml.NewEditor([]byte(test.src))
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if err := editor.MoveKey(test.from, test.to); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if editor.String() != test.expected {
+				t.Fatalf("unexpected source. expected:\n%s\nbut got:\n%s", test.expected, editor.String())
+			}
+		})
+	}
+	t.Run("error", func(t *testing.T) {
+		editor, err := yaml.NewEditor([]byte("a:\n  b: 1\nc: 2\nd: {e: 1}\n"))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		for _, test := range []struct{ from, to string }{
+			{"$.x", "$.y"},
+			{"$.a", "$.a.b.c"},
+			{"$.a.b", "$.c"},
+			{"$.a.b", "$.c.b"},
+			{"$.a.b", "$.d.b"},
+			{"$.d.e", "$.e"},
+		} {
+			if err := editor.MoveKey(test.from, test.to); err == nil {
+				t.Fatalf("expected error for moving %s to %s", test.from, test.to)
+			}
+		}
+	})
+}
+
+func TestEditor_SetValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		path     string
+		value    interface{}
+		expected string
+	}{
+		{
+			name:     "scalar",
+			src:      "a:\n  b: 1 # comment of b\n  c: 2\n",
+			path:     "$.a.b",
+			value:    "text",
+			expected: "a:\n  b: text # comment of b\n  c: 2\n",
+		},
+		{
+			name:     "keep anchor",
+			src:      "a: &x 1\nb: *x\n",
+			path:     "$.a",
+			value:    2,
+			expected: "a: &x 2\nb: *x\n",
+		},
+		{
+			name:     "empty value",
+			src:      "a:\nb: 1\n",
+			path:     "$.a",
+			value:    true,
+			expected: "a: true\nb: 1\n",
+		},
+		{
+			name:     "block to scalar",
+			src:      "a:\n  b: 1\n  c: 2\nd: 3\n",
+			path:     "$.a",
+			value:    "x",
+			expected: "a: x\nd: 3\n",
+		},
+		{
+			name:     "scalar to block",
+			src:      "a: 1 # comment\nd: 3\n",
+			path:     "$.a",
+			value:    []int{1, 2},
+			expected: "a:\n  - 1\n  - 2 # comment\nd: 3\n",
+		},
+		{
+			name:     "all documents",
+			src:      "a: 1\n---\na: 2\n",
+			path:     "$.a",
+			value:    3,
+			expected: "a: 3\n---\na: 3\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			editor, err := yaml.NewEditor([]byte(test.src))
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if err := editor.SetValue(test.path, test.value); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if editor.String() != test.expected {
+				t.Fatalf("unexpected source. expected:\n%s\nbut got:\n%s", test.expected, editor.String())
+			}
+		})
+	}
+}
+
+func TestEditor_AddKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		path     string
+		value    interface{}
+		expected string
+	}{
+		{
+			name:     "append to mapping",
+			src:      "a:\n    b: 1 # comment of b\nc: 2\n",
+			path:     "$.a.d",
+			value:    "text",
+			expected: "a:\n    b: 1 # comment of b\n    d: text\nc: 2\n",
+		},
+		{
+			name:     "create missing parents",
+			src:      "a: 1",
+			path:     "$.b.c",
+			value:    map[string]int{"d": 1},
+			expected: "a: 1\nb:\n  c:\n    d: 1\n",
+		},
+		{
+			name:     "empty document",
+			src:      "",
+			path:     "$.a",
+			value:    1,
+			expected: "a: 1\n",
+		},
+		{
+			name:     "all documents",
+			src:      "a: 1\n---\n---\nb: 2\n",
+			path:     "$.c",
+			value:    3,
+			expected: "a: 1\nc: 3\n---\nc: 3\n---\nb: 2\nc: 3\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			editor, err := yaml.NewEditor([]byte(test.src))
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if err := editor.AddKey(test.path, test.value); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if editor.String() != test.expected {
+				t.Fatalf("unexpected source. expected:\n%s\nbut got:\n%s", test.expected, editor.String())
+			}
+		})
+	}
+	t.Run("error", func(t *testing.T) {
+		editor, err := yaml.NewEditor([]byte("a: 1\nb: 2\n"))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if err := editor.AddKey("$.a", 3); err == nil {
+			t.Fatal("expected error for existing key")
+		}
+		if err := editor.AddKey("$.a.c", 3); err == nil {
+			t.Fatal("expected error for scalar parent")
+		}
+	})
+}
+
+func TestEditor_DeleteKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		path     string
+		expected string
+	}{
+		{
+			name: "with comments",
+			src: `a:
+  # comment of b
+  b:
+    c: 1 # comment of c
+  d: 2
+e: 3
+`,
+			path:     "$.a.b",
+			expected: "a:\n  d: 2\ne: 3\n",
+		},
+		{
+			name:     "last key",
+			src:      "a: 1\nb: |\n  text\n",
+			path:     "$.b",
+			expected: "a: 1\n",
+		},
+		{
+			name:     "all documents",
+			src:      "a: 1\nb: 2\n---\na: 3\n",
+			path:     "$.a",
+			expected: "b: 2\n---\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			editor, err := yaml.NewEditor([]byte(test.src))
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if err := editor.DeleteKey(test.path); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if editor.String() != test.expected {
+				t.Fatalf("unexpected source. expected:\n%s\nbut got:\n%s", test.expected, editor.String())
+			}
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package yaml
 
 import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
@@ -8,9 +12,14 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/goccy/go-yaml/ast"
 	"github.com/goccy/go-yaml/internal/errors"
+	"github.com/goccy/go-yaml/internal/yamlpath"
+	"github.com/goccy/go-yaml/lexer"
 	"github.com/goccy/go-yaml/parser"
 	"github.com/goccy/go-yaml/printer"
 	"github.com/goccy/go-yaml/token"
@@ -24,11 +33,41 @@ const (
 
 // Encoder writes YAML values to an output stream.
 type Encoder struct {
-	writer             io.Writer
-	opts               []EncodeOption
-	indent             int
-	isFlowStyle        bool
-	anchorPtrToNameMap map[uintptr]string
+	writer              io.Writer
+	opts                []EncodeOption
+	indent              int
+	isFlowStyle         bool
+	isAppliedOptions    bool
+	anchorPtrToNameMap  map[uintptr]string
+	nodeHook            func(ast.Node) (ast.Node, error)
+	nodeMiddlewares     []func(string, ast.Node) (ast.Node, error)
+	boolFormat          *boolFormat
+	isSingleQuote       bool
+	isForcedQuote       bool
+	isLiteralStyle      bool
+	isJSON              bool
+	structFieldOption   structFieldOption
+	directives          []string
+	explicitTag         func(ast.Node) bool
+	lineBreak           string
+	flowDepth           int
+	flowSequenceWidth   int
+	commentWidth        int
+	commentColumn       int
+	commentSpaces       int
+	keyLess             func(a, b string) bool
+	timeLayout          string
+	isBinaryTag         bool
+	binaryWidth         int
+	typeStyles          map[reflect.Type]EncodeStyle
+	isMappingOnNextLine bool
+	commentMap          CommentMap
+	documentNum         int
+	isClosed            bool
+	encodingValues      map[encodingValue]struct{}
+	isAutoAnchor        bool
+	pointerCounts       map[encodingValue]int
+	autoAnchors         map[encodingValue]string
 
 	line        int
 	column      int
@@ -37,6 +76,13 @@ type Encoder struct {
 	indentLevel int
 }
 
+// encodingValue identifies the pointer, map or slice being encoded to detect cyclic data structures
+type encodingValue struct {
+	ptr uintptr
+	len int
+	typ reflect.Type
+}
+
 // NewEncoder returns a new encoder that writes to w.
 // The Encoder should be closed after use to flush all data to w.
 func NewEncoder(w io.Writer, opts ...EncodeOption) *Encoder {
@@ -44,7 +90,9 @@ func NewEncoder(w io.Writer, opts ...EncodeOption) *Encoder {
 		writer:             w,
 		opts:               opts,
 		indent:             DefaultIndentSpaces,
+		flowDepth:          -1,
 		anchorPtrToNameMap: map[uintptr]string{},
+		encodingValues:     map[encodingValue]struct{}{},
 		line:               1,
 		column:             1,
 		offset:             0,
@@ -53,31 +101,198 @@ func NewEncoder(w io.Writer, opts ...EncodeOption) *Encoder {
 
 // Close closes the encoder by writing any remaining data.
 // It does not write a stream terminating string "...".
+// Encode returns an error after Close is called.
 func (e *Encoder) Close() error {
+	e.isClosed = true
 	return nil
 }
 
 // Encode writes the YAML encoding of v to the stream.
 // If multiple items are encoded to the stream,
 // the second and subsequent document will be preceded with a "---" document separator,
-// but the first will not.
+// but the first will not. If directives are set by options, the previous document is terminated by "..." instead,
+// because directives can't follow a document which isn't terminated.
+// Anchors and aliases are not shared between documents.
+// Cyclic data structures ( e.g. a map which contains itself ) can't be encoded and an error is returned.
 //
 // See the documentation for Marshal for details about the conversion of Go values to YAML.
 func (e *Encoder) Encode(v interface{}) error {
-	for _, opt := range e.opts {
-		if err := opt(e); err != nil {
-			return errors.Wrapf(err, "failed to run option for encoder")
+	if e.isClosed {
+		return xerrors.New("encoder is already closed")
+	}
+	e.anchorPtrToNameMap = map[uintptr]string{}
+	node, err := e.EncodeToNode(v)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode to node")
+	}
+	if node == nil {
+		return nil
+	}
+	if len(e.directives) > 0 && !e.isJSON {
+		node = e.encodeDirectives(node)
+	}
+	if len(e.commentMap) > 0 && !e.isJSON {
+		node, err = e.encodeComment(node)
+		if err != nil {
+			return errors.Wrapf(err, "failed to encode comment")
+		}
+	}
+	if e.commentWidth > 0 && !e.isJSON {
+		wrapComments(node, 1, e.commentWidth)
+	}
+	var buf bytes.Buffer
+	if e.documentNum > 0 && !e.isJSON {
+		// JSON values are written line by line without separator
+		if len(e.directives) > 0 {
+			buf.WriteString("...\n")
+		} else {
+			buf.WriteString("---\n")
+		}
+	}
+	var p printer.Printer
+	printed := p.PrintNode(node)
+	if e.commentColumn > 0 || e.commentSpaces > 1 {
+		printed = alignLineComments(printed, e.commentColumn, e.commentSpaces)
+	}
+	buf.Write(printed)
+	if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteString("\n")
+	}
+	b := buf.Bytes()
+	if e.lineBreak != "" && e.lineBreak != "\n" {
+		b = bytes.Replace(b, []byte("\n"), []byte(e.lineBreak), -1)
+	}
+	if _, err := e.writer.Write(b); err != nil {
+		return errors.Wrapf(err, "failed to write document")
+	}
+	e.documentNum++
+	return nil
+}
+
+// alignLineComments aligns the line comments in text at column,
+// and at least spaces are put between the value and the comment.
+func alignLineComments(text []byte, column, spaces int) []byte {
+	if spaces < 1 {
+		spaces = 1
+	}
+	lines := bytes.Split(text, []byte("\n"))
+	for _, tk := range lexer.Tokenize(string(text)) {
+		if tk.Type != token.CommentType {
+			continue
+		}
+		line := lines[tk.Position.Line-1]
+		idx := tk.Position.Column - 1
+		value := bytes.TrimRight(line[:idx], " \t")
+		if len(value) == 0 {
+			// head or foot comment
+			continue
+		}
+		padding := column - 1 - utf8.RuneCount(value)
+		if padding < spaces {
+			padding = spaces
 		}
+		aligned := make([]byte, 0, len(line)+padding)
+		aligned = append(aligned, value...)
+		aligned = append(aligned, bytes.Repeat([]byte(" "), padding)...)
+		lines[tk.Position.Line-1] = append(aligned, line[idx:]...)
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// encodeDirectives create document which has directives set by options and body
+func (e *Encoder) encodeDirectives(body ast.Node) *ast.Document {
+	doc := &ast.Document{Body: body}
+	for _, directive := range e.directives {
+		doc.Directives = append(doc.Directives, &ast.DirectiveNode{
+			Start: token.Directive(e.pos(1)),
+			Value: ast.String(token.New(directive, directive, e.pos(2))),
+		})
+	}
+	doc.Start = token.DocumentHeader(e.pos(1))
+	return doc
+}
+
+// EncodeToNode convert v to ast.Node without writing to the stream.
+// If hook is set by NodeHook option, returns the node returned by hook.
+// The node can be rendered by printer.Printer after post-processing.
+func (e *Encoder) EncodeToNode(v interface{}) (ast.Node, error) {
+	if err := e.applyOptions(); err != nil {
+		return nil, errors.Wrapf(err, "failed to apply options")
+	}
+	if e.isAutoAnchor {
+		e.pointerCounts = map[encodingValue]int{}
+		e.autoAnchors = map[encodingValue]string{}
+		e.countPointers(reflect.ValueOf(v), map[encodingValue]struct{}{})
 	}
 	node, err := e.encodeValue(reflect.ValueOf(v), 1)
 	if err != nil {
-		return errors.Wrapf(err, "failed to encode value")
+		return nil, errors.Wrapf(err, "failed to encode value")
 	}
-	var p printer.Printer
-	e.writer.Write(p.PrintNode(node))
+	if e.keyLess != nil {
+		e.encodeAnchorOrder(node)
+	}
+	if e.flowDepth >= 0 {
+		e.encodeFlowDepth(node, 1)
+	}
+	if e.flowSequenceWidth > 0 {
+		e.encodeFlowSequence(node)
+	}
+	if e.explicitTag != nil {
+		node = e.encodeExplicitTag(node)
+	}
+	if len(e.nodeMiddlewares) > 0 {
+		node, err = e.encodeNodeMiddleware("$", node)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to run node middleware")
+		}
+		if node == nil {
+			return nil, nil
+		}
+	}
+	if e.nodeHook != nil {
+		hooked, err := e.nodeHook(node)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to run node hook")
+		}
+		node = hooked
+	}
+	if e.isJSON {
+		node, err = e.encodeJSON(node)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to encode to JSON")
+		}
+	}
+	return node, nil
+}
+
+func (e *Encoder) applyOptions() error {
+	if e.isAppliedOptions {
+		return nil
+	}
+	for _, opt := range e.opts {
+		if err := opt(e); err != nil {
+			return errors.Wrapf(err, "failed to run option for encoder")
+		}
+	}
+	e.isAppliedOptions = true
 	return nil
 }
 
+// Reset discards the state of the previous document and resets the encoder to write to w.
+// Options already applied are kept, so an Encoder can be reused for many small documents.
+func (e *Encoder) Reset(w io.Writer) {
+	e.writer = w
+	e.anchorPtrToNameMap = map[uintptr]string{}
+	e.documentNum = 0
+	e.isClosed = false
+	e.encodingValues = map[encodingValue]struct{}{}
+	e.line = 1
+	e.column = 1
+	e.offset = 0
+	e.indentNum = 0
+	e.indentLevel = 0
+}
+
 func (e *Encoder) encodeDocument(doc []byte) (ast.Node, error) {
 	f, err := parser.ParseBytes(doc, 0)
 	if err != nil {
@@ -91,6 +306,39 @@ func (e *Encoder) encodeDocument(doc []byte) (ast.Node, error) {
 	return nil, nil
 }
 
+// encodeNode encodes the copy of node held by the value ( e.g. map[string]ast.Node ) to splice it into the output.
+// Aliases in node must refer to the anchors in node. Comments of node are not copied.
+func (e *Encoder) encodeNode(node ast.Node, column int) (ast.Node, error) {
+	f, err := ast.Extract(node)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to copy node")
+	}
+	copied := f.Docs[0].Body
+	if copied == nil {
+		return e.encodeNil(), nil
+	}
+	if mv, ok := copied.(*ast.MappingValueNode); ok {
+		// mapping value is encoded as mapping like the other mappings
+		copied = &ast.MappingNode{Start: mv.Start, Values: []*ast.MappingValueNode{mv}}
+	}
+	ast.WalkAll(literalContentResetter{}, copied)
+	shiftColumn(copied, column-1)
+	if e.isFlowStyle {
+		copied = ast.ToFlowStyle(copied)
+	}
+	return copied, nil
+}
+
+// literalContentResetter clears the source text of literal content to render it by the column of the copy
+type literalContentResetter struct{}
+
+func (r literalContentResetter) Visit(node ast.Node) ast.Visitor {
+	if literal, ok := node.(*ast.LiteralNode); ok {
+		literal.Value.GetToken().Origin = ""
+	}
+	return r
+}
+
 func (e *Encoder) isInvalidValue(v reflect.Value) bool {
 	if !v.IsValid() {
 		return true
@@ -109,8 +357,49 @@ func (e *Encoder) encodeValue(v reflect.Value, column int) (ast.Node, error) {
 	if e.isInvalidValue(v) {
 		return e.encodeNil(), nil
 	}
+	if style, exists := e.typeStyles[v.Type()]; exists {
+		node, err := e.encodeValueByKind(v, column)
+		if err != nil {
+			return nil, err
+		}
+		return e.encodeStyle(node, style, column), nil
+	}
+	return e.encodeValueByKind(v, column)
+}
+
+// encodeStyle changes the style of node encoded from the value of the type registered by TypeStyle option.
+// Literal and Quoted are applied only to string node, and Literal is ignored in flow collection.
+func (e *Encoder) encodeStyle(node ast.Node, style EncodeStyle, column int) ast.Node {
+	if n, ok := node.(*ast.StringNode); ok {
+		text := stringNodeValue(n)
+		switch {
+		case style.Literal && !e.isFlowStyle && canBeLiteral(text):
+			node = e.encodeLiteral(text, column)
+		case style.Quoted:
+			node = e.encodeQuotedString(text, column, true)
+		}
+	}
+	if style.Flow {
+		node = ast.ToFlowStyle(node)
+	}
+	if style.Tag != "" {
+		if _, ok := node.(*ast.TagNode); !ok {
+			node = &ast.TagNode{
+				Start: token.New(style.Tag, style.Tag, e.pos(column)),
+				Value: node,
+			}
+		}
+	}
+	return node
+}
+
+func (e *Encoder) encodeValueByKind(v reflect.Value, column int) (ast.Node, error) {
 	if v.CanInterface() {
-		if marshaler, ok := v.Interface().(BytesMarshaler); ok {
+		if node, ok := v.Interface().(ast.Node); ok {
+			return e.encodeNode(node, column)
+		}
+		iface := marshalerValue(v)
+		if marshaler, ok := iface.(BytesMarshaler); ok {
 			doc, err := marshaler.MarshalYAML()
 			if err != nil {
 				return nil, errors.Wrapf(err, "failed to MarshalYAML")
@@ -120,13 +409,39 @@ func (e *Encoder) encodeValue(v reflect.Value, column int) (ast.Node, error) {
 				return nil, errors.Wrapf(err, "failed to encode document")
 			}
 			return node, nil
-		} else if marshaler, ok := v.Interface().(InterfaceMarshaler); ok {
+		} else if marshaler, ok := iface.(InterfaceMarshaler); ok {
 			marshalV, err := marshaler.MarshalYAML()
 			if err != nil {
 				return nil, errors.Wrapf(err, "failed to MarshalYAML")
 			}
 			return e.encodeValue(reflect.ValueOf(marshalV), column)
+		} else if t, ok := iface.(time.Time); ok {
+			return e.encodeTime(t, column), nil
+		} else if t, ok := iface.(*time.Time); ok {
+			return e.encodeTime(*t, column), nil
+		} else if duration, ok := iface.(time.Duration); ok {
+			return e.encodeString(duration.String(), column), nil
+		} else if marshaler, ok := iface.(encoding.TextMarshaler); ok {
+			text, err := marshaler.MarshalText()
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to MarshalText")
+			}
+			return e.encodeString(string(text), column), nil
+		} else if number, ok := iface.(json.Number); ok {
+			return e.encodeNumber(number, column), nil
+		}
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		key := encodingValue{ptr: v.Pointer(), typ: v.Type()}
+		if v.Kind() == reflect.Slice {
+			key.len = v.Len()
 		}
+		if _, exists := e.encodingValues[key]; exists {
+			return nil, xerrors.Errorf("cannot encode cyclic data structure: encountered a cycle via %s", v.Type())
+		}
+		e.encodingValues[key] = struct{}{}
+		defer delete(e.encodingValues, key)
 	}
 	switch v.Type().Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
@@ -136,6 +451,9 @@ func (e *Encoder) encodeValue(v reflect.Value, column int) (ast.Node, error) {
 	case reflect.Float32, reflect.Float64:
 		return e.encodeFloat(v.Float()), nil
 	case reflect.Ptr, reflect.Interface:
+		if v.Kind() == reflect.Ptr && e.pointerCounts[pointerKey(v)] > 1 {
+			return e.encodeSharedPointer(v, column)
+		}
 		return e.encodeValue(v.Elem(), column)
 	case reflect.String:
 		return e.encodeString(v.String(), column), nil
@@ -145,22 +463,159 @@ func (e *Encoder) encodeValue(v reflect.Value, column int) (ast.Node, error) {
 		if mapSlice, ok := v.Interface().(MapSlice); ok {
 			return e.encodeMapSlice(mapSlice, column)
 		}
+		if e.isBinaryTag && v.Type().Elem().Kind() == reflect.Uint8 && !v.IsNil() {
+			return e.encodeBinary(v.Bytes(), column), nil
+		}
 		return e.encodeSlice(v)
 	case reflect.Struct:
 		if v.CanInterface() {
 			if mapItem, ok := v.Interface().(MapItem); ok {
 				return e.encodeMapItem(mapItem, column)
 			}
+			if tagged, ok := v.Interface().(TaggedValue); ok {
+				return e.encodeTaggedValue(tagged, column)
+			}
 		}
 		return e.encodeStruct(v, column)
 	case reflect.Map:
-		return e.encodeMap(v, column), nil
+		return e.encodeMap(v, column)
 	default:
 		return nil, xerrors.Errorf("unknown value type %s", v.Type().String())
 	}
 	return nil, nil
 }
 
+// marshalerValue returns v as interface{}.
+// If v doesn't implement marshaler interfaces but the pointer of v does ( e.g. by pointer receiver ), returns the pointer
+func marshalerValue(v reflect.Value) interface{} {
+	iface := v.Interface()
+	if isMarshaler(iface) || v.Kind() == reflect.Ptr || !v.CanAddr() {
+		return iface
+	}
+	if ptr := v.Addr().Interface(); isMarshaler(ptr) {
+		return ptr
+	}
+	return iface
+}
+
+// encodeFieldAnchor wraps value of the field by the anchor specified by the struct tag.
+// If the value is already anchored by AutoAnchor option, the anchor is renamed.
+func (e *Encoder) encodeFieldAnchor(anchorName string, fieldValue reflect.Value, value ast.Node, column int) ast.Node {
+	if fieldValue.Kind() == reflect.Ptr {
+		key := pointerKey(fieldValue)
+		if _, exists := e.autoAnchors[key]; exists {
+			anchor, ok := value.(*ast.AnchorNode)
+			if !ok {
+				// the value is alias to the anchor at the previous occurrence
+				return value
+			}
+			anchor.Name = ast.String(token.New(anchorName, anchorName, e.pos(column)))
+			e.autoAnchors[key] = anchorName
+			e.anchorPtrToNameMap[fieldValue.Pointer()] = anchorName
+			return anchor
+		}
+		e.anchorPtrToNameMap[fieldValue.Pointer()] = anchorName
+	}
+	return &ast.AnchorNode{
+		Start: token.New("&", "&", e.pos(column)),
+		Name:  ast.String(token.New(anchorName, anchorName, e.pos(column))),
+		Value: value,
+	}
+}
+
+func pointerKey(v reflect.Value) encodingValue {
+	return encodingValue{ptr: v.Pointer(), typ: v.Type()}
+}
+
+// countPointers counts how many times each pointer in v is encoded for AutoAnchor option.
+// Values encoded by marshaler are not traversed because the encoder doesn't encode the pointers in them.
+func (e *Encoder) countPointers(v reflect.Value, visiting map[encodingValue]struct{}) {
+	if !v.IsValid() {
+		return
+	}
+	if v.CanInterface() && isMarshaler(marshalerValue(v)) {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+		key := pointerKey(v)
+		e.pointerCounts[key]++
+		if e.pointerCounts[key] > 1 {
+			// the value is already counted
+			return
+		}
+		e.countPointers(v.Elem(), visiting)
+	case reflect.Interface:
+		e.countPointers(v.Elem(), visiting)
+	case reflect.Map:
+		key := pointerKey(v)
+		if _, exists := visiting[key]; exists {
+			return
+		}
+		visiting[key] = struct{}{}
+		defer delete(visiting, key)
+		for _, k := range v.MapKeys() {
+			e.countPointers(v.MapIndex(k), visiting)
+		}
+	case reflect.Slice:
+		key := encodingValue{ptr: v.Pointer(), len: v.Len(), typ: v.Type()}
+		if _, exists := visiting[key]; exists {
+			return
+		}
+		visiting[key] = struct{}{}
+		defer delete(visiting, key)
+		for i := 0; i < v.Len(); i++ {
+			e.countPointers(v.Index(i), visiting)
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			e.countPointers(v.Index(i), visiting)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if isIgnoredStructField(v.Type().Field(i), e.structFieldOption) {
+				continue
+			}
+			e.countPointers(v.Field(i), visiting)
+		}
+	}
+}
+
+// encodeSharedPointer encodes the pointer which appears more than once in the document.
+// The value is anchored at the first time and referred by alias after that.
+func (e *Encoder) encodeSharedPointer(v reflect.Value, column int) (ast.Node, error) {
+	key := pointerKey(v)
+	if anchorName, exists := e.autoAnchors[key]; exists {
+		return &ast.AliasNode{
+			Start: token.New("*", "*", e.pos(column)),
+			Value: ast.String(token.New(anchorName, anchorName, e.pos(column))),
+		}, nil
+	}
+	value, err := e.encodeValue(v.Elem(), column)
+	if err != nil {
+		return nil, err
+	}
+	anchorName := fmt.Sprintf("id%03d", len(e.autoAnchors)+1)
+	e.autoAnchors[key] = anchorName
+	e.anchorPtrToNameMap[v.Pointer()] = anchorName
+	return &ast.AnchorNode{
+		Start: token.New("&", "&", e.pos(column)),
+		Name:  ast.String(token.New(anchorName, anchorName, e.pos(column))),
+		Value: value,
+	}, nil
+}
+
+func isMarshaler(v interface{}) bool {
+	switch v.(type) {
+	case BytesMarshaler, InterfaceMarshaler, encoding.TextMarshaler:
+		return true
+	}
+	return false
+}
+
 func (e *Encoder) pos(column int) *token.Position {
 	return &token.Position{
 		Line:        e.line,
@@ -186,6 +641,24 @@ func (e *Encoder) encodeUint(v uint64) ast.Node {
 	return ast.Integer(token.New(value, value, e.pos(e.column)))
 }
 
+// encodeNumber encodes json.Number by its text ( e.g. `1.50` ).
+// Exponent without fraction ( e.g. `1e3` ) is written with fraction because it is read as string otherwise.
+func (e *Encoder) encodeNumber(number json.Number, column int) ast.Node {
+	text := number.String()
+	if idx := strings.IndexAny(text, "eE"); idx > 0 && !strings.Contains(text[:idx], ".") {
+		text = text[:idx] + ".0" + text[idx:]
+	}
+	tk := token.New(text, text, e.pos(column))
+	switch tk.Type {
+	case token.IntegerType, token.BinaryIntegerType, token.OctetIntegerType, token.HexIntegerType:
+		return ast.Integer(tk)
+	case token.FloatType:
+		return ast.Float(tk)
+	}
+	// json.Number which isn't number ( e.g. empty string )
+	return e.encodeString(number.String(), column)
+}
+
 func (e *Encoder) encodeFloat(v float64) ast.Node {
 	if v == math.Inf(0) {
 		value := ".inf"
@@ -213,20 +686,144 @@ func (e *Encoder) encodeFloat(v float64) ast.Node {
 	return ast.Float(token.New(value, value, e.pos(e.column)))
 }
 
+// encodeTime encodes t by the layout set by EncodeTimeLayout option or RFC3339 with nanoseconds
+func (e *Encoder) encodeTime(t time.Time, column int) ast.Node {
+	layout := time.RFC3339Nano
+	if e.timeLayout != "" {
+		layout = e.timeLayout
+	}
+	v := t.Format(layout)
+	if !e.isForcedQuote && token.IsTimestamp(v) {
+		// timestamp is written without quotes to be read as timestamp
+		return ast.String(token.New(v, v, e.pos(column)))
+	}
+	return e.encodeString(v, column)
+}
+
 func (e *Encoder) encodeString(v string, column int) ast.Node {
-	if token.IsNeedQuoted(v) {
+	if e.isLiteralStyle && !e.isFlowStyle && isLiteralText(v) {
+		return e.encodeLiteral(v, column)
+	}
+	// `y` and `n` are quoted only in values because YAML 1.1 consumers read them as boolean
+	return e.encodeQuotedString(v, column, e.isForcedQuote || token.IsLegacyBool(v))
+}
+
+// encodeLiteral encodes v as literal block scalar. The chomping indicator is decided by the line breaks at the end of v
+func (e *Encoder) encodeLiteral(v string, column int) ast.Node {
+	header := "|"
+	switch {
+	case !strings.HasSuffix(v, "\n"):
+		header = "|-"
+	case strings.HasSuffix(v, "\n\n"):
+		header = "|+"
+	}
+	return &ast.LiteralNode{
+		Start: token.Literal(header, header, e.pos(column)),
+		Value: ast.String(token.New(v, "", e.pos(column+e.indent))).(*ast.StringNode),
+	}
+}
+
+// isLiteralText reports whether v is multi-line string which can be written in literal block scalar.
+// Literal cannot have the characters which must be escaped, and lines must not start with white space
+// because the indent of literal is decided by the first line which has content.
+func isLiteralText(v string) bool {
+	return strings.Contains(v, "\n") && canBeLiteral(v)
+}
+
+// canBeLiteral reports whether v can be written in literal block scalar even if v is single line.
+func canBeLiteral(v string) bool {
+	if v == "" {
+		return false
+	}
+	for _, line := range strings.Split(v, "\n") {
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			return false
+		}
+	}
+	for _, c := range v {
+		if c != '\n' && c != '\t' && !unicode.IsPrint(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeKey encodes the key of mapping. Key is quoted only if it is required even if ForceQuote option is enabled
+func (e *Encoder) encodeKey(v string, column int) ast.Node {
+	return e.encodeQuotedString(v, column, false)
+}
+
+// encodeQuotedString encodes v as quoted string if force is true or v is read as another type without quotes.
+// Single quote is used by UseSingleQuote option unless v has the characters which must be escaped ( e.g. `\n` ).
+func (e *Encoder) encodeQuotedString(v string, column int, force bool) ast.Node {
+	if !force && !token.IsNeedQuoted(v) && !(e.boolFormat != nil && isBoolText(v)) {
+		return ast.String(token.New(v, v, e.pos(column)))
+	}
+	if e.isSingleQuote && isSingleQuotable(v) {
+		v = "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	} else {
 		v = strconv.Quote(v)
 	}
 	return ast.String(token.New(v, v, e.pos(column)))
 }
 
+// isSingleQuotable reports whether v can be written in single quoted scalar which cannot have escape sequences
+func isSingleQuotable(v string) bool {
+	for _, c := range v {
+		if !unicode.IsPrint(c) {
+			return false
+		}
+	}
+	return true
+}
+
 func (e *Encoder) encodeBool(v bool) ast.Node {
-	value := fmt.Sprint(v)
-	return ast.Bool(token.New(value, value, e.pos(e.column)))
+	if e.boolFormat == nil {
+		value := fmt.Sprint(v)
+		return ast.Bool(token.New(value, value, e.pos(e.column)))
+	}
+	value := e.boolFormat.falseText
+	if v {
+		value = e.boolFormat.trueText
+	}
+	return &ast.BoolNode{
+		Token: token.New(value, value, e.pos(e.column)),
+		Value: v,
+	}
+}
+
+type boolFormat struct {
+	trueText  string
+	falseText string
+}
+
+// boolFormats formats of boolean supported by BoolFormat option
+var boolFormats = []*boolFormat{
+	{trueText: "true", falseText: "false"},
+	{trueText: "True", falseText: "False"},
+	{trueText: "TRUE", falseText: "FALSE"},
+	{trueText: "yes", falseText: "no"},
+	{trueText: "Yes", falseText: "No"},
+	{trueText: "YES", falseText: "NO"},
+	{trueText: "on", falseText: "off"},
+	{trueText: "On", falseText: "Off"},
+	{trueText: "ON", falseText: "OFF"},
+}
+
+// isBoolText reports whether s is text of boolean in one of boolFormats.
+// Such a string must be quoted when BoolFormat option is used, because the consumer reads it as boolean.
+func isBoolText(s string) bool {
+	for _, format := range boolFormats {
+		if s == format.trueText || s == format.falseText {
+			return true
+		}
+	}
+	return false
 }
 
 func (e *Encoder) encodeSlice(value reflect.Value) (ast.Node, error) {
 	sequence := ast.Sequence(token.New("-", "-", e.pos(e.column)), e.isFlowStyle)
+	sequence.IsMappingOnNextLine = e.isMappingOnNextLine
 	for i := 0; i < value.Len(); i++ {
 		node, err := e.encodeValue(value.Index(i), e.column)
 		if err != nil {
@@ -234,30 +831,442 @@ func (e *Encoder) encodeSlice(value reflect.Value) (ast.Node, error) {
 		}
 		sequence.Values = append(sequence.Values, node)
 	}
+	if len(sequence.Values) == 0 {
+		// empty sequence can be rendered only by flow style ( e.g. `[]` )
+		sequence.IsFlowStyle = true
+	}
 	return sequence, nil
 }
 
+// encodeBinary encodes b as base64 text tagged by `!!binary`.
+// The text is wrapped into literal block scalar by the width set by UseBinaryTag option
+func (e *Encoder) encodeBinary(b []byte, column int) ast.Node {
+	text := base64.StdEncoding.EncodeToString(b)
+	var value ast.Node
+	if e.binaryWidth > 0 && len(text) > e.binaryWidth && !e.isFlowStyle {
+		var lines []string
+		for len(text) > e.binaryWidth {
+			lines = append(lines, text[:e.binaryWidth])
+			text = text[e.binaryWidth:]
+		}
+		lines = append(lines, text)
+		value = e.encodeLiteral(strings.Join(lines, "\n")+"\n", column)
+	} else {
+		value = e.encodeString(text, column)
+	}
+	return &ast.TagNode{
+		Start: token.New(token.BinaryTag, token.BinaryTag, e.pos(column)),
+		Value: value,
+	}
+}
+
+func (e *Encoder) encodeTaggedValue(v TaggedValue, column int) (ast.Node, error) {
+	var value ast.Node
+	if b, ok := v.Value.([]byte); ok && v.Tag == token.BinaryTag {
+		return e.encodeBinary(b, column), nil
+	} else {
+		encoded, err := e.encodeValue(reflect.ValueOf(v.Value), column)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to encode tagged value")
+		}
+		value = encoded
+	}
+	return &ast.TagNode{
+		Start: token.New(v.Tag, v.Tag, e.pos(column)),
+		Value: value,
+	}, nil
+}
+
+// encodeExplicitTag wraps node and its descendant values by TagNode of the resolved tag if the predicate set by ExplicitTag option reports true.
+// Keys, aliases and nodes which already have tag are not wrapped.
+func (e *Encoder) encodeExplicitTag(node ast.Node) ast.Node {
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		for _, value := range n.Values {
+			value.Value = e.encodeExplicitTag(value.Value)
+		}
+	case *ast.MappingValueNode:
+		n.Value = e.encodeExplicitTag(n.Value)
+	case *ast.SequenceNode:
+		for idx, value := range n.Values {
+			n.Values[idx] = e.encodeExplicitTag(value)
+		}
+	case *ast.AnchorNode:
+		n.Value = e.encodeExplicitTag(n.Value)
+		return n
+	case *ast.TagNode, *ast.AliasNode:
+		return n
+	}
+	tag := resolvedTag(node)
+	if tag == "" || !e.explicitTag(node) {
+		return node
+	}
+	return &ast.TagNode{
+		Start: token.New(tag, tag, node.GetToken().Position),
+		Value: node,
+	}
+}
+
+// encodeJSON converts node to the node which is rendered as JSON by JSON option.
+// Aliases and merge keys are expanded, tags and comments are removed,
+// collections are changed to flow style and scalars are replaced by the text of JSON value.
+func (e *Encoder) encodeJSON(node ast.Node) (ast.Node, error) {
+	doc, err := expandAliases(&ast.Document{Body: node})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to expand aliases")
+	}
+	return e.encodeJSONValue(doc.Body)
+}
+
+func (e *Encoder) encodeJSONValue(node ast.Node) (ast.Node, error) {
+	switch n := node.(type) {
+	case nil:
+		return e.encodeNil(), nil
+	case *ast.TagNode:
+		if _, ok := n.Value.(ast.ScalarNode); ok && n.Start.Value == token.StringTag {
+			return e.encodeJSONString(n.Value.GetToken().Value), nil
+		}
+		return e.encodeJSONValue(n.Value)
+	case *ast.AnchorNode:
+		return e.encodeJSONValue(n.Value)
+	case *ast.MappingValueNode:
+		return e.encodeJSONValue(&ast.MappingNode{Start: n.Start, Values: []*ast.MappingValueNode{n}})
+	case *ast.MappingNode:
+		mapping := ast.Mapping(token.New("", "", e.pos(1)), true)
+		for _, mv := range n.Values {
+			key, err := e.encodeJSONKey(mv.Key)
+			if err != nil {
+				return nil, err
+			}
+			value, err := e.encodeJSONValue(mv.Value)
+			if err != nil {
+				return nil, err
+			}
+			mapping.Values = append(mapping.Values, &ast.MappingValueNode{
+				Start: token.New("", "", e.pos(1)),
+				Key:   key,
+				Value: value,
+			})
+		}
+		return mapping, nil
+	case *ast.SequenceNode:
+		sequence := ast.Sequence(token.New("", "", e.pos(1)), true)
+		for _, v := range n.Values {
+			value, err := e.encodeJSONValue(v)
+			if err != nil {
+				return nil, err
+			}
+			sequence.Values = append(sequence.Values, value)
+		}
+		return sequence, nil
+	case *ast.StringNode:
+		return e.encodeJSONString(stringNodeValue(n)), nil
+	case *ast.LiteralNode:
+		return e.encodeJSONString(n.Value.Value), nil
+	case *ast.NullNode:
+		return e.encodeNil(), nil
+	case *ast.BoolNode:
+		value := strconv.FormatBool(n.Value)
+		return ast.Bool(token.New(value, value, e.pos(1))), nil
+	case *ast.IntegerNode:
+		value := n.Token.Value
+		if !json.Valid([]byte(value)) {
+			// YAML specific notation ( e.g. `0x1F` or `1_000` )
+			value = fmt.Sprint(n.Value)
+		}
+		return ast.Integer(token.New(value, value, e.pos(1))), nil
+	case *ast.FloatNode:
+		value := n.Token.Value
+		if !json.Valid([]byte(value)) {
+			value = strconv.FormatFloat(n.Value, 'g', -1, 64)
+		}
+		return ast.Float(token.New(value, value, e.pos(1))), nil
+	}
+	return nil, errors.ErrSyntax(errors.CodeInvalidJSONValue, node.GetToken(), node.Type(), "value")
+}
+
+// encodeJSONKey encodes the key of mapping as JSON string. Non-string scalar keys are converted to the text ( e.g. `1` to `"1"` )
+func (e *Encoder) encodeJSONKey(node ast.Node) (ast.Node, error) {
+	switch n := node.(type) {
+	case *ast.TagNode:
+		return e.encodeJSONKey(n.Value)
+	case *ast.AnchorNode:
+		return e.encodeJSONKey(n.Value)
+	case *ast.StringNode:
+		return e.encodeJSONString(stringNodeValue(n)), nil
+	}
+	value, err := e.encodeJSONValue(node)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := value.(ast.ScalarNode); !ok {
+		return nil, errors.ErrSyntax(errors.CodeInvalidJSONValue, node.GetToken(), node.Type(), "key")
+	}
+	return e.encodeJSONString(value.GetToken().Value), nil
+}
+
+func (e *Encoder) encodeJSONString(v string) ast.Node {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	// string is always encoded successfully. invalid UTF-8 sequence is replaced by U+FFFD
+	_ = enc.Encode(v)
+	quoted := strings.TrimSuffix(buf.String(), "\n")
+	return ast.String(token.New(quoted, quoted, e.pos(1)))
+}
+
+// stringNodeValue returns the value of string node.
+// The value of string quoted by encoder is the quoted text, and plain string cannot start with quote character.
+func stringNodeValue(n *ast.StringNode) string {
+	if tk := n.GetToken(); tk.Type == token.SingleQuoteType || tk.Type == token.DoubleQuoteType {
+		return n.Value
+	}
+	switch {
+	case strings.HasPrefix(n.Value, `"`):
+		if value, err := strconv.Unquote(n.Value); err == nil {
+			return value
+		}
+	case len(n.Value) >= 2 && strings.HasPrefix(n.Value, "'") && strings.HasSuffix(n.Value, "'"):
+		return strings.ReplaceAll(n.Value[1:len(n.Value)-1], "''", "'")
+	}
+	return n.Value
+}
+
+// encodeNodeMiddleware applies the middlewares set by NodeMiddleware option to node of the path,
+// and then to the values of the returned node in document order.
+// If a middleware returns nil, the value is removed from the parent mapping or sequence.
+func (e *Encoder) encodeNodeMiddleware(path string, node ast.Node) (ast.Node, error) {
+	for _, middleware := range e.nodeMiddlewares {
+		converted, err := middleware(path, node)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to convert node at %s", path)
+		}
+		if converted == nil {
+			return nil, nil
+		}
+		node = converted
+	}
+	switch n := ast.Unwrap(node).(type) {
+	case *ast.MappingNode:
+		values := make([]*ast.MappingValueNode, 0, len(n.Values))
+		for _, value := range n.Values {
+			converted, err := e.encodeNodeMiddleware(yamlpath.AppendKey(path, keyText(value.Key)), value.Value)
+			if err != nil {
+				return nil, err
+			}
+			if converted == nil {
+				continue
+			}
+			value.Value = converted
+			values = append(values, value)
+		}
+		n.Values = values
+		if len(n.Values) == 0 {
+			// empty mapping cannot be rendered by block style
+			n.IsFlowStyle = true
+		}
+	case *ast.MappingValueNode:
+		converted, err := e.encodeNodeMiddleware(yamlpath.AppendKey(path, keyText(n.Key)), n.Value)
+		if err != nil {
+			return nil, err
+		}
+		if converted == nil {
+			converted = ast.NewNull()
+		}
+		n.Value = converted
+	case *ast.SequenceNode:
+		values := make([]ast.Node, 0, len(n.Values))
+		for idx, value := range n.Values {
+			converted, err := e.encodeNodeMiddleware(fmt.Sprintf("%s[%d]", path, idx), value)
+			if err != nil {
+				return nil, err
+			}
+			if converted == nil {
+				continue
+			}
+			values = append(values, converted)
+		}
+		n.Values = values
+		if len(n.Values) == 0 {
+			// empty sequence cannot be rendered by block style
+			n.IsFlowStyle = true
+		}
+	}
+	return node, nil
+}
+
+// encodeFlowDepth changes style of mappings and sequences deeper than the depth set by FlowDepth option to flow style.
+// depth is the depth of node ( top level mapping or sequence is 1 ).
+func (e *Encoder) encodeFlowDepth(node ast.Node, depth int) {
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		if depth > e.flowDepth {
+			ast.ToFlowStyle(n)
+			return
+		}
+		for _, value := range n.Values {
+			e.encodeFlowDepth(value.Value, depth+1)
+		}
+	case *ast.MappingValueNode:
+		e.encodeFlowDepth(n.Value, depth+1)
+	case *ast.SequenceNode:
+		if depth > e.flowDepth {
+			ast.ToFlowStyle(n)
+			return
+		}
+		for _, value := range n.Values {
+			e.encodeFlowDepth(value, depth+1)
+		}
+	case *ast.AnchorNode:
+		e.encodeFlowDepth(n.Value, depth)
+	case *ast.TagNode:
+		e.encodeFlowDepth(n.Value, depth)
+	}
+}
+
+// encodeFlowSequence change style of sequences of scalars to flow style if the width of flow style is not more than flowSequenceWidth
+func (e *Encoder) encodeFlowSequence(node ast.Node) {
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		for _, value := range n.Values {
+			e.encodeFlowSequence(value.Value)
+		}
+	case *ast.MappingValueNode:
+		e.encodeFlowSequence(n.Value)
+	case *ast.SequenceNode:
+		if n.IsFlowStyle {
+			return
+		}
+		if width, ok := flowSequenceWidth(n); ok && width <= e.flowSequenceWidth {
+			n.IsFlowStyle = true
+			return
+		}
+		for _, value := range n.Values {
+			e.encodeFlowSequence(value)
+		}
+	case *ast.AnchorNode:
+		e.encodeFlowSequence(n.Value)
+	case *ast.TagNode:
+		e.encodeFlowSequence(n.Value)
+	}
+}
+
+// flowSequenceWidth returns the width of sequence in flow style ( e.g. `[a, b, c]` ).
+// If sequence has values other than scalars, returns false
+func flowSequenceWidth(sequence *ast.SequenceNode) (int, bool) {
+	width := len("[]") + len(", ")*(len(sequence.Values)-1)
+	for _, value := range sequence.Values {
+		text, ok := flowScalarText(value)
+		if !ok {
+			return 0, false
+		}
+		width += len(text)
+	}
+	return width, true
+}
+
+// flowScalarText returns the text of scalar node. If node isn't scalar or can't be rendered in flow sequence, returns false
+func flowScalarText(node ast.Node) (string, bool) {
+	switch n := node.(type) {
+	case *ast.StringNode:
+		text := n.GetToken().Value
+		if !strings.HasPrefix(text, `"`) && strings.ContainsAny(text, ",[]{}#\n") {
+			// plain string which has flow indicators
+			return "", false
+		}
+		return text, true
+	case *ast.IntegerNode, *ast.FloatNode, *ast.BoolNode, *ast.NullNode, *ast.InfinityNode, *ast.NanNode:
+		return n.GetToken().Value, true
+	}
+	return "", false
+}
+
+// resolvedTag returns the tag resolved from type of node
+func resolvedTag(node ast.Node) string {
+	switch node.(type) {
+	case *ast.StringNode:
+		return token.StringTag
+	case *ast.IntegerNode:
+		return string(token.IntegerTag)
+	case *ast.FloatNode, *ast.InfinityNode, *ast.NanNode:
+		return token.FloatTag
+	case *ast.BoolNode:
+		return token.BooleanTag
+	case *ast.NullNode:
+		return token.NullTag
+	case *ast.MappingNode, *ast.MappingValueNode:
+		return token.MappingTag
+	case *ast.SequenceNode:
+		return token.SequenceTag
+	}
+	return ""
+}
+
+type columnShifter int
+
+func (s columnShifter) Visit(node ast.Node) ast.Visitor {
+	if tk := node.GetToken(); tk != nil && tk.Position != nil {
+		tk.Position.Column += int(s)
+	}
+	return s
+}
+
+// shiftColumn moves node and its descendants by diff columns to change the indent of them
+func shiftColumn(node ast.Node, diff int) {
+	ast.WalkAll(columnShifter(diff), node)
+}
+
+// untaggedNode returns the value of TagNode
+func untaggedNode(node ast.Node) ast.Node {
+	if tag, ok := node.(*ast.TagNode); ok {
+		return tag.Value
+	}
+	return node
+}
+
 func (e *Encoder) encodeMapItem(item MapItem, column int) (*ast.MappingValueNode, error) {
-	k := reflect.ValueOf(item.Key)
+	key, err := e.encodeMapItemKey(item.Key, column)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to encode key of MapItem")
+	}
 	v := reflect.ValueOf(item.Value)
 	value, err := e.encodeValue(v, column)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to encode MapItem")
 	}
-	if m, ok := value.(*ast.MappingNode); ok {
-		for _, value := range m.Values {
-			value.Key.GetToken().Position.Column += e.indent
-		}
+	if _, ok := ast.Unwrap(value).(*ast.MappingNode); ok {
+		shiftColumn(value, e.indent)
 	}
 	return &ast.MappingValueNode{
 		Start: token.New("", "", e.pos(column)),
-		Key:   e.encodeString(k.Interface().(string), column),
+		Key:   key,
 		Value: value,
 	}, nil
 }
 
+// encodeMapItemKey encodes key of MapItem. The key which isn't string must be scalar ( e.g. `1: a` ).
+func (e *Encoder) encodeMapItemKey(key interface{}, column int) (ast.Node, error) {
+	if k, ok := key.(string); ok {
+		return e.encodeKey(k, column), nil
+	}
+	node, err := e.encodeValue(reflect.ValueOf(key), column)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := node.(ast.ScalarNode); !ok {
+		return nil, xerrors.Errorf("unsupported key type %T of MapItem", key)
+	}
+	return node, nil
+}
+
 func (e *Encoder) encodeMapSlice(value MapSlice, column int) (ast.Node, error) {
 	node := ast.Mapping(token.New("", "", e.pos(column)), e.isFlowStyle)
+	if e.keyLess != nil {
+		value = append(MapSlice{}, value...)
+		sort.SliceStable(value, func(i, j int) bool {
+			return e.keyLess(fmt.Sprint(value[i].Key), fmt.Sprint(value[j].Key))
+		})
+	}
 	for _, item := range value {
 		value, err := e.encodeMapItem(item, column)
 		if err != nil {
@@ -265,34 +1274,45 @@ func (e *Encoder) encodeMapSlice(value MapSlice, column int) (ast.Node, error) {
 		}
 		node.Values = append(node.Values, value)
 	}
+	if len(node.Values) == 0 {
+		// empty mapping can be rendered only by flow style ( e.g. `{}` )
+		node.IsFlowStyle = true
+	}
 	return node, nil
 }
 
-func (e *Encoder) encodeMap(value reflect.Value, column int) ast.Node {
+func (e *Encoder) encodeMap(value reflect.Value, column int) (ast.Node, error) {
 	node := ast.Mapping(token.New("", "", e.pos(column)), e.isFlowStyle)
 	keys := []string{}
 	for _, k := range value.MapKeys() {
 		keys = append(keys, k.Interface().(string))
 	}
-	sort.Strings(keys)
+	if e.keyLess != nil {
+		sort.SliceStable(keys, func(i, j int) bool {
+			return e.keyLess(keys[i], keys[j])
+		})
+	} else {
+		sort.Strings(keys)
+	}
 	for _, key := range keys {
 		k := reflect.ValueOf(key)
 		v := value.MapIndex(k)
 		value, err := e.encodeValue(v, column)
 		if err != nil {
-			return nil
+			return nil, errors.Wrapf(err, "failed to encode value for map")
 		}
-		if m, ok := value.(*ast.MappingNode); ok {
-			for _, value := range m.Values {
-				value.Key.GetToken().Position.Column += e.indent
-			}
+		if _, ok := ast.Unwrap(value).(*ast.MappingNode); ok {
+			shiftColumn(value, e.indent)
 		}
 		node.Values = append(node.Values, &ast.MappingValueNode{
-			Key:   e.encodeString(k.Interface().(string), column),
+			Key:   e.encodeKey(k.Interface().(string), column),
 			Value: value,
 		})
 	}
-	return node
+	if len(node.Values) == 0 {
+		node.IsFlowStyle = true
+	}
+	return node, nil
 }
 
 // IsZeroer is used to check whether an object is zero to determine
@@ -302,7 +1322,7 @@ type IsZeroer interface {
 	IsZero() bool
 }
 
-func (e *Encoder) isZeroValue(v reflect.Value) bool {
+func isZeroValue(v reflect.Value) bool {
 	kind := v.Kind()
 	if z, ok := v.Interface().(IsZeroer); ok {
 		if (kind == reflect.Ptr || kind == reflect.Interface) && v.IsNil() {
@@ -310,6 +1330,12 @@ func (e *Encoder) isZeroValue(v reflect.Value) bool {
 		}
 		return z.IsZero()
 	}
+	if kind != reflect.Ptr && v.CanAddr() {
+		// IsZero may be defined with pointer receiver
+		if z, ok := v.Addr().Interface().(IsZeroer); ok {
+			return z.IsZero()
+		}
+	}
 	switch kind {
 	case reflect.String:
 		return len(v.String()) == 0
@@ -333,7 +1359,7 @@ func (e *Encoder) isZeroValue(v reflect.Value) bool {
 			if vt.Field(i).PkgPath != "" {
 				continue // private field
 			}
-			if !e.isZeroValue(v.Field(i)) {
+			if !isZeroValue(v.Field(i)) {
 				return false
 			}
 		}
@@ -342,63 +1368,74 @@ func (e *Encoder) isZeroValue(v reflect.Value) bool {
 	return false
 }
 
+// encodeDocumentField parses YAML document in value of string or []byte type and returns the root node of it.
+// Empty document is encoded as null.
+func (e *Encoder) encodeDocumentField(value reflect.Value, column int) (ast.Node, error) {
+	if !isDocumentFieldType(value.Type()) {
+		return nil, xerrors.Errorf("document field must be string or []byte type but got %s", value.Type())
+	}
+	var doc []byte
+	if value.Kind() == reflect.String {
+		doc = []byte(value.String())
+	} else {
+		doc = value.Bytes()
+	}
+	node, err := e.encodeDocument(doc)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to encode document")
+	}
+	if node == nil {
+		return e.encodeNil(), nil
+	}
+	// the root node of the parsed document starts at the first column
+	shiftColumn(node, column-1)
+	return node, nil
+}
+
 func (e *Encoder) encodeStruct(value reflect.Value, column int) (ast.Node, error) {
 	node := ast.Mapping(token.New("", "", e.pos(column)), e.isFlowStyle)
 	structType := value.Type()
-	structFieldMap, err := structFieldMap(structType)
+	structFieldMap, err := structFieldMap(structType, e.structFieldOption)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to get struct field map")
 	}
 	for i := 0; i < value.NumField(); i++ {
 		field := structType.Field(i)
-		if isIgnoredStructField(field) {
+		if isIgnoredStructField(field, e.structFieldOption) {
 			continue
 		}
 		fieldValue := value.FieldByName(field.Name)
 		structField := structFieldMap[field.Name]
-		if structField.IsOmitEmpty && e.isZeroValue(fieldValue) {
+		if structField.IsOmitEmpty && isZeroValue(fieldValue) {
 			// omit encoding
 			continue
 		}
-		value, err := e.encodeValue(fieldValue, column)
+		var value ast.Node
+		if structField.IsDocument {
+			value, err = e.encodeDocumentField(fieldValue, column)
+		} else {
+			value, err = e.encodeValue(fieldValue, column)
+		}
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to encode value")
 		}
-		if m, ok := value.(*ast.MappingNode); ok {
-			if !e.isFlowStyle && structField.IsFlow {
-				m.IsFlowStyle = true
-			}
-			for _, value := range m.Values {
-				value.Key.GetToken().Position.Column += e.indent
-				value.Value.GetToken().Position.Column += e.indent
-			}
-		} else if s, ok := value.(*ast.SequenceNode); ok {
-			if !e.isFlowStyle && structField.IsFlow {
-				s.IsFlowStyle = true
-			}
+		if structField.IsOmitEmpty && ast.IsEmpty(value) {
+			// value encoded by custom marshaler may be empty
+			continue
 		}
-		key := e.encodeString(structField.RenderName, column)
+		if !e.isFlowStyle && structField.IsFlow {
+			// block collection cannot be placed in flow collection, so the nested values are also flow style
+			value = ast.ToFlowStyle(value)
+		}
+		if _, ok := ast.Unwrap(value).(*ast.MappingNode); ok {
+			shiftColumn(value, e.indent)
+		}
+		key := e.encodeKey(structField.RenderName, column)
 		switch {
 		case structField.AnchorName != "":
-			anchorName := structField.AnchorName
-			if fieldValue.Kind() == reflect.Ptr {
-				e.anchorPtrToNameMap[fieldValue.Pointer()] = anchorName
-			}
-			value = &ast.AnchorNode{
-				Start: token.New("&", "&", e.pos(column)),
-				Name:  ast.String(token.New(anchorName, anchorName, e.pos(column))),
-				Value: value,
-			}
+			value = e.encodeFieldAnchor(structField.AnchorName, fieldValue, value, column)
 		case structField.IsAutoAnchor:
-			anchorName := structField.RenderName
-			if fieldValue.Kind() == reflect.Ptr {
-				e.anchorPtrToNameMap[fieldValue.Pointer()] = anchorName
-			}
-			value = &ast.AnchorNode{
-				Start: token.New("&", "&", e.pos(column)),
-				Name:  ast.String(token.New(anchorName, anchorName, e.pos(column))),
-				Value: value,
-			}
+			value = e.encodeFieldAnchor(structField.RenderName, fieldValue, value, column)
 		case structField.IsAutoAlias:
 			if fieldValue.Kind() != reflect.Ptr {
 				return nil, xerrors.Errorf(
@@ -406,6 +1443,14 @@ func (e *Encoder) encodeStruct(value reflect.Value, column int) (ast.Node, error
 					structField.FieldName,
 				)
 			}
+			if fieldValue.IsNil() {
+				// nil pointer is encoded as null because there is no anchor to refer to
+				break
+			}
+			if _, ok := value.(*ast.AnchorNode); ok && e.autoAnchors[pointerKey(fieldValue)] != "" {
+				// the first occurrence of the pointer is anchored by AutoAnchor option
+				break
+			}
 			anchorName := e.anchorPtrToNameMap[fieldValue.Pointer()]
 			if anchorName == "" {
 				return nil, xerrors.Errorf(
@@ -432,10 +1477,19 @@ func (e *Encoder) encodeStruct(value reflect.Value, column int) (ast.Node, error
 				key = ast.MergeKey(token.New("<<", "<<", e.pos(column)))
 			}
 		case structField.IsInline:
+			if value.Type() == ast.NullType {
+				// nil map or pointer has no keys to inline
+				continue
+			}
 			mapNode, ok := value.(ast.MapNode)
 			if !ok {
 				return nil, xerrors.Errorf("inline value is must be map or struct type")
 			}
+			var fieldKeys map[string]struct{}
+			if fieldValue.Kind() == reflect.Map {
+				// keys of inline map conflicting with the fields including the fields of inline structs are not encoded
+				fieldKeys = structFieldKeys(structType, e.structFieldOption)
+			}
 			mapIter := mapNode.MapRange()
 			for mapIter.Next() {
 				key := mapIter.Key()
@@ -445,8 +1499,11 @@ func (e *Encoder) encodeStruct(value reflect.Value, column int) (ast.Node, error
 					// if declared same key name, skip encoding this field
 					continue
 				}
-				key.GetToken().Position.Column -= e.indent
-				value.GetToken().Position.Column -= e.indent
+				if _, exists := fieldKeys[keyName]; exists {
+					continue
+				}
+				shiftColumn(key, -e.indent)
+				shiftColumn(value, -e.indent)
 				node.Values = append(node.Values, &ast.MappingValueNode{
 					Key:   key,
 					Value: value,
@@ -459,5 +1516,86 @@ func (e *Encoder) encodeStruct(value reflect.Value, column int) (ast.Node, error
 			Value: value,
 		})
 	}
+	if e.keyLess != nil {
+		// fields are encoded in the order of declaration to define anchors before aliases,
+		// and the order is fixed by encodeAnchorOrder after sorting
+		e.sortMappingValues(node.Values)
+	}
+	if len(node.Values) == 0 {
+		node.IsFlowStyle = true
+	}
 	return node, nil
 }
+
+// sortMappingValues sorts values by the keys with the function set by SortKeys option. Merge keys are placed first.
+func (e *Encoder) sortMappingValues(values []*ast.MappingValueNode) {
+	sort.SliceStable(values, func(i, j int) bool {
+		mi, mj := values[i].Key.Type() == ast.MergeKeyType, values[j].Key.Type() == ast.MergeKeyType
+		if mi || mj {
+			return mi && !mj
+		}
+		return e.keyLess(mappingKeyText(values[i].Key), mappingKeyText(values[j].Key))
+	})
+}
+
+// encodeAnchorOrder moves anchors before their aliases in document order, which can be reversed by SortKeys option.
+// The anchored value is swapped with the first alias because both represent the same value.
+func (e *Encoder) encodeAnchorOrder(node ast.Node) {
+	type alias struct {
+		node           *ast.AliasNode
+		set            func(ast.Node)
+		isMappingValue bool
+	}
+	anchors := map[string]struct{}{}
+	aliases := map[string]*alias{}
+	var walk func(node ast.Node, set func(ast.Node), isMappingValue bool)
+	walk = func(node ast.Node, set func(ast.Node), isMappingValue bool) {
+		switch n := node.(type) {
+		case *ast.AliasNode:
+			name := n.Value.GetToken().Value
+			if _, exists := anchors[name]; !exists && aliases[name] == nil {
+				aliases[name] = &alias{node: n, set: set, isMappingValue: isMappingValue}
+			}
+		case *ast.AnchorNode:
+			name := n.Name.GetToken().Value
+			anchors[name] = struct{}{}
+			if a := aliases[name]; a != nil {
+				diff := columnOf(a.node) - columnOf(n)
+				if _, ok := ast.Unwrap(n).(*ast.MappingNode); ok && a.isMappingValue != isMappingValue {
+					// mapping as the value of mapping is indented from the key
+					if a.isMappingValue {
+						diff += e.indent
+					} else {
+						diff -= e.indent
+					}
+				}
+				shiftColumn(n, diff)
+				shiftColumn(a.node, columnOf(n)-diff-columnOf(a.node))
+				a.set(n)
+				set(a.node)
+			}
+			walk(n.Value, func(v ast.Node) { n.Value = v }, false)
+		case *ast.TagNode:
+			walk(n.Value, func(v ast.Node) { n.Value = v }, isMappingValue)
+		case *ast.MappingNode:
+			for _, value := range n.Values {
+				walk(value, nil, false)
+			}
+		case *ast.MappingValueNode:
+			walk(n.Value, func(v ast.Node) { n.Value = v }, true)
+		case *ast.SequenceNode:
+			for idx, value := range n.Values {
+				idx := idx
+				walk(value, func(v ast.Node) { n.Values[idx] = v }, false)
+			}
+		}
+	}
+	walk(node, func(ast.Node) {}, false)
+}
+
+func mappingKeyText(key ast.Node) string {
+	if n, ok := key.(*ast.StringNode); ok {
+		return stringNodeValue(n)
+	}
+	return key.GetToken().Value
+}
@@ -2,12 +2,22 @@ package yaml_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"math"
+	"net"
+	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/printer"
+	"golang.org/x/xerrors"
 )
 
 func TestEncoder(t *testing.T) {
@@ -23,6 +33,22 @@ func TestEncoder(t *testing.T) {
 			"v: hi\n",
 			map[string]string{"v": "hi"},
 		},
+		{
+			"a: []\nb: {}\nc:\n- {}\n- {}\n",
+			map[string]interface{}{
+				"a": []int{},
+				"b": map[string]int{},
+				"c": []interface{}{struct{}{}, yaml.MapSlice{}},
+			},
+		},
+		{
+			"z:\n  y: 1\n  x:\n  - q: 1\n    p: 2\n1: a\ntrue: b\n",
+			yaml.MapSlice{
+				{Key: "z", Value: yaml.MapSlice{{Key: "y", Value: 1}, {Key: "x", Value: []interface{}{yaml.MapSlice{{Key: "q", Value: 1}, {Key: "p", Value: 2}}}}}},
+				{Key: 1, Value: "a"},
+				{Key: true, Value: "b"},
+			},
+		},
 		{
 			"v: \"true\"\n",
 			map[string]string{"v": "true"},
@@ -92,7 +118,7 @@ func TestEncoder(t *testing.T) {
 			map[string][]string{"v": {"A", "B"}},
 		},
 		{
-			"a: -\n",
+			"a: \"-\"\n",
 			map[string]string{"a": "-"},
 		},
 		{
@@ -124,7 +150,7 @@ func TestEncoder(t *testing.T) {
 			},
 		},
 		{
-			"t2: 2018-01-09T10:40:47Z\nt4: 2098-01-09T10:40:47Z\n",
+			"t2: \"2018-01-09T10:40:47Z\"\nt4: \"2098-01-09T10:40:47Z\"\n",
 			map[string]string{
 				"t2": "2018-01-09T10:40:47Z",
 				"t4": "2098-01-09T10:40:47Z",
@@ -271,6 +297,25 @@ func TestEncoder(t *testing.T) {
 				A []int "a,flow"
 			}{[]int{1, 2}},
 		},
+		{
+			"a: [{b: 1, c: [x, \"y\"]}, {b: 2, c: []}]\nd:\n- z\n",
+			struct {
+				A []map[string]interface{} `yaml:"a,flow"`
+				D []string                 `yaml:"d"`
+			}{
+				[]map[string]interface{}{
+					{"b": 1, "c": []string{"x", "y"}},
+					{"b": 2, "c": []string{}},
+				},
+				[]string{"z"},
+			},
+		},
+		{
+			"a: {b: [1, 2], c: {d: e}}\n",
+			struct {
+				A map[string]interface{} `yaml:"a,flow"`
+			}{map[string]interface{}{"b": []int{1, 2}, "c": map[string]string{"d": "e"}}},
+		},
 		{
 			"a: {b: c, d: e}\n",
 			&struct {
@@ -285,6 +330,13 @@ func TestEncoder(t *testing.T) {
 				} "a,flow"
 			}{struct{ B, D string }{"c", "e"}},
 		},
+		{
+			"t: 2015-02-24T18:19:39.12Z\nd: 1h30m0s\n",
+			struct {
+				T time.Time
+				D time.Duration
+			}{time.Date(2015, 2, 24, 18, 19, 39, .12e9, time.UTC), 90 * time.Minute},
+		},
 	}
 	for _, test := range tests {
 		var buf bytes.Buffer
@@ -463,6 +515,71 @@ c: true
 	}
 }
 
+func TestEncoder_InlineNamedFieldAndMap(t *testing.T) {
+	type base struct {
+		A int
+		B string
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	if err := enc.Encode(struct {
+		Base  base `yaml:",inline"`
+		C     bool
+		Extra map[string]interface{} `yaml:",inline"`
+		Empty map[string]int         `yaml:"empty,omitempty"`
+	}{
+		Base: base{
+			A: 1,
+			B: "hello",
+		},
+		C: true,
+		Extra: map[string]interface{}{
+			"a": 2, // conflict with the field of inline struct
+			"c": false,
+			"d": []int{1, 2},
+		},
+	}); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expect := `
+a: 1
+b: hello
+c: true
+d:
+- 1
+- 2
+`
+	actual := "\n" + buf.String()
+	if expect != actual {
+		t.Fatalf("inline marshal error: expect=[%s] actual=[%s]", expect, actual)
+	}
+	t.Run("nil map", func(t *testing.T) {
+		bytes, err := yaml.Marshal(struct {
+			A     int
+			Extra map[string]string `yaml:",inline"`
+		}{A: 1})
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if string(bytes) != "a: 1\n" {
+			t.Fatalf("unexpected output: %q", string(bytes))
+		}
+	})
+	t.Run("invalid type", func(t *testing.T) {
+		if _, err := yaml.Marshal(struct {
+			Values []string `yaml:",inline"`
+		}{}); err == nil {
+			t.Fatal("expected error")
+		}
+		if _, err := yaml.Marshal(struct {
+			A map[string]int `yaml:",inline"`
+			B map[string]int `yaml:",inline"`
+		}{}); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
 func TestEncoder_InlineAndConflictKey(t *testing.T) {
 	type base struct {
 		A int
@@ -671,3 +788,1633 @@ func Example_MarshalYAML() {
 	// a: Hello speed demon
 	// b: 100
 }
+
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+)
+
+func (l Level) MarshalText() ([]byte, error) {
+	switch l {
+	case LevelDebug:
+		return []byte("debug"), nil
+	case LevelInfo:
+		return []byte("info"), nil
+	}
+	return nil, fmt.Errorf("unknown level %d", l)
+}
+
+func (l *Level) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "debug":
+		*l = LevelDebug
+	case "info":
+		*l = LevelInfo
+	default:
+		return fmt.Errorf("unknown level %s", text)
+	}
+	return nil
+}
+
+type PtrMarshaler struct {
+	V int
+}
+
+func (m *PtrMarshaler) MarshalYAML() (interface{}, error) {
+	return fmt.Sprintf("v=%d", m.V), nil
+}
+
+func TestEncoder_Marshalers(t *testing.T) {
+	v := struct {
+		Level  Level
+		Levels []Level
+		IP     net.IP
+		Ptr    PtrMarshaler
+	}{
+		Level:  LevelInfo,
+		Levels: []Level{LevelDebug, LevelInfo},
+		IP:     net.ParseIP("192.168.0.1"),
+		Ptr:    PtrMarshaler{V: 1},
+	}
+	b, err := yaml.Marshal(&v)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expect := "level: info\nlevels:\n- debug\n- info\nip: 192.168.0.1\nptr: v=1\n"
+	if string(b) != expect {
+		t.Fatalf("expect = [%s], actual = [%s]", expect, string(b))
+	}
+	if _, err := yaml.Marshal(Level(10)); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestEncoder_Indent(t *testing.T) {
+	type C struct {
+		D int
+		E []int
+	}
+	type B struct {
+		C C
+	}
+	v := struct {
+		A map[string]interface{}
+		B B
+		L []B
+	}{
+		A: map[string]interface{}{"x": map[string]interface{}{"w": map[string]int{"z": 1}}},
+		B: B{C: C{D: 1, E: []int{1, 2}}},
+		L: []B{{C: C{D: 2, E: []int{3}}}},
+	}
+	tests := []struct {
+		spaces int
+		expect string
+	}{
+		{2, `a:
+  x:
+    w:
+      z: 1
+b:
+  c:
+    d: 1
+    e:
+    - 1
+    - 2
+l:
+- c:
+    d: 2
+    e:
+    - 3
+`},
+		{4, `a:
+    x:
+        w:
+            z: 1
+b:
+    c:
+        d: 1
+        e:
+        - 1
+        - 2
+l:
+- c:
+      d: 2
+      e:
+      - 3
+`},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := yaml.NewEncoder(&buf, yaml.Indent(test.spaces)).Encode(v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if buf.String() != test.expect {
+			t.Fatalf("unexpected output. expect:\n%s\nbut got:\n%s", test.expect, buf.String())
+		}
+	}
+}
+
+func TestEncoder_FlowSequence(t *testing.T) {
+	v := map[string]interface{}{
+		"tags":   []string{"a", "b", "c"},
+		"long":   []string{"alpha", "beta", "gamma", "delta"},
+		"mixed":  []interface{}{1, true, nil, "x y"},
+		"quoted": []string{"a,b", "c"},
+		"nested": []interface{}{[]int{1, 2}, map[string]int{"d": 3}},
+	}
+	var buf bytes.Buffer
+	if err := yaml.NewEncoder(&buf, yaml.FlowSequence(20)).Encode(v); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expect := `
+long:
+- alpha
+- beta
+- gamma
+- delta
+mixed: [1, true, null, x y]
+nested:
+- [1, 2]
+- d: 3
+quoted:
+- a,b
+- c
+tags: [a, b, c]
+`
+	if buf.String() != strings.TrimPrefix(expect, "\n") {
+		t.Fatalf("unexpected output. expect:\n%s\nbut got:\n%s", expect, buf.String())
+	}
+	var decoded map[string]interface{}
+	if err := yaml.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if fmt.Sprint(decoded) != fmt.Sprint(v) {
+		t.Fatalf("failed to decode: %v", decoded)
+	}
+	t.Run("invalid width", func(t *testing.T) {
+		if err := yaml.NewEncoder(&bytes.Buffer{}, yaml.FlowSequence(-1)).Encode(v); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestEncoder_FlowDepth(t *testing.T) {
+	v := map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": map[string]interface{}{"c": 1, "d": []int{1, 2}},
+			"e": []interface{}{"f", map[string]string{"g": "h"}},
+		},
+		"i": 1,
+	}
+	tests := []struct {
+		depth  int
+		expect string
+	}{
+		{0, "{a: {b: {c: 1, d: [1, 2]}, e: [f, {g: h}]}, i: 1}\n"},
+		{1, "a: {b: {c: 1, d: [1, 2]}, e: [f, {g: h}]}\ni: 1\n"},
+		{2, "a:\n  b: {c: 1, d: [1, 2]}\n  e: [f, {g: h}]\ni: 1\n"},
+		{3, "a:\n  b:\n    c: 1\n    d: [1, 2]\n  e:\n  - f\n  - {g: h}\ni: 1\n"},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := yaml.NewEncoder(&buf, yaml.FlowDepth(test.depth)).Encode(v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if buf.String() != test.expect {
+			t.Fatalf("unexpected output. expect:\n%s\nbut got:\n%s", test.expect, buf.String())
+		}
+		var decoded map[string]interface{}
+		if err := yaml.Unmarshal(buf.Bytes(), &decoded); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if fmt.Sprint(decoded) != fmt.Sprint(v) {
+			t.Fatalf("failed to decode flow depth %d: %v", test.depth, decoded)
+		}
+	}
+	t.Run("invalid depth", func(t *testing.T) {
+		if err := yaml.NewEncoder(&bytes.Buffer{}, yaml.FlowDepth(-1)).Encode(v); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestEncoder_InlineSequenceMap(t *testing.T) {
+	type Container struct {
+		Name  string
+		Ports []int
+	}
+	v := map[string]interface{}{
+		"containers": []Container{{Name: "a", Ports: []int{80}}, {Name: "b", Ports: []int{443}}},
+		"names":      []string{"a", "b"},
+	}
+	tests := []struct {
+		isInline bool
+		expect   string
+	}{
+		{true, "containers:\n- name: a\n  ports:\n  - 80\n- name: b\n  ports:\n  - 443\nnames:\n- a\n- b\n"},
+		{false, "containers:\n-\n  name: a\n  ports:\n  - 80\n-\n  name: b\n  ports:\n  - 443\nnames:\n- a\n- b\n"},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := yaml.NewEncoder(&buf, yaml.InlineSequenceMap(test.isInline)).Encode(v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if buf.String() != test.expect {
+			t.Fatalf("unexpected output. expect:\n%s\nbut got:\n%s", test.expect, buf.String())
+		}
+		var decoded struct {
+			Containers []Container
+		}
+		if err := yaml.Unmarshal(buf.Bytes(), &decoded); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if len(decoded.Containers) != 2 || decoded.Containers[0].Name != "a" || decoded.Containers[1].Name != "b" {
+			t.Fatalf("failed to decode: %+v", decoded)
+		}
+	}
+}
+
+func TestEncoder_Reset(t *testing.T) {
+	var buf1, buf2 bytes.Buffer
+	enc := yaml.NewEncoder(&buf1, yaml.Flow(true))
+	if err := enc.Encode(map[string]int{"a": 1}); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	enc.Reset(&buf2)
+	if err := enc.Encode(map[string]int{"b": 2}); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if buf1.String() != "{a: 1}\n" {
+		t.Fatalf("unexpected output before Reset: %s", buf1.String())
+	}
+	if buf2.String() != "{b: 2}\n" {
+		t.Fatalf("unexpected output after Reset: %s", buf2.String())
+	}
+}
+
+func TestEncoder_MultipleDocuments(t *testing.T) {
+	t.Run("separator", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		for _, v := range []interface{}{
+			map[string]int{"a": 1},
+			[]string{"b"},
+			"c",
+		} {
+			if err := enc.Encode(v); err != nil {
+				t.Fatalf("%+v", err)
+			}
+		}
+		if err := enc.Close(); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		expect := "a: 1\n---\n- b\n---\nc\n"
+		if buf.String() != expect {
+			t.Fatalf("expect = [%s], actual = [%s]", expect, buf.String())
+		}
+		if err := enc.Encode(1); err == nil {
+			t.Fatal("expected error after Close")
+		}
+	})
+	t.Run("directive", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf, yaml.VersionDirective("1.2"))
+		for _, v := range []interface{}{map[string]int{"a": 1}, map[string]int{"b": 2}} {
+			if err := enc.Encode(v); err != nil {
+				t.Fatalf("%+v", err)
+			}
+		}
+		expect := "%YAML 1.2\n---\na: 1\n...\n%YAML 1.2\n---\nb: 2\n"
+		if buf.String() != expect {
+			t.Fatalf("expect = [%s], actual = [%s]", expect, buf.String())
+		}
+	})
+	t.Run("anchor", func(t *testing.T) {
+		type T struct {
+			A int
+		}
+		var v struct {
+			A *T `yaml:"a,anchor"`
+			B *T `yaml:"b,alias"`
+		}
+		v.A = &T{A: 1}
+		v.B = v.A
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		for i := 0; i < 2; i++ {
+			if err := enc.Encode(v); err != nil {
+				t.Fatalf("%+v", err)
+			}
+		}
+		expect := "a: &a\n  a: 1\nb: *a\n---\na: &a\n  a: 1\nb: *a\n"
+		if buf.String() != expect {
+			t.Fatalf("expect = [%s], actual = [%s]", expect, buf.String())
+		}
+	})
+	t.Run("decode", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		for i := 1; i <= 3; i++ {
+			if err := enc.Encode(map[string]int{"v": i}); err != nil {
+				t.Fatalf("%+v", err)
+			}
+		}
+		dec := yaml.NewDecoder(&buf)
+		for i := 1; i <= 3; i++ {
+			var v map[string]int
+			if err := dec.Decode(&v); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if v["v"] != i {
+				t.Fatalf("unexpected document %d: %v", i, v)
+			}
+		}
+	})
+}
+
+func TestEncoder_DocumentField(t *testing.T) {
+	type T struct {
+		Name   string
+		Values string `yaml:"values,document"`
+		List   []byte `yaml:"list,document"`
+		Empty  string `yaml:"empty,document"`
+	}
+	v := struct {
+		Chart T
+	}{
+		Chart: T{
+			Name:   "app",
+			Values: "image:\n  tag: v1\nports: [80, 443]\n",
+			List:   []byte("- a\n- b\n"),
+		},
+	}
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expect := `
+chart:
+  name: app
+  values:
+    image:
+      tag: v1
+    ports: [80, 443]
+  list:
+  - a
+  - b
+  empty: null
+`
+	if string(b) != strings.TrimPrefix(expect, "\n") {
+		t.Fatalf("expect = [%s], actual = [%s]", expect, string(b))
+	}
+	if _, err := yaml.Marshal(T{Values: "a: 1\n  b: 2\n"}); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestEncoder_QuotedKey(t *testing.T) {
+	tests := []struct {
+		key    string
+		expect string
+	}{
+		{"a: b", "\"a: b\": v\n"},
+		{"#a", "\"#a\": v\n"},
+		{"- a", "\"- a\": v\n"},
+		{"-a", "-a: v\n"},
+		{"", "\"\": v\n"},
+		{" a", "\" a\": v\n"},
+		{"[a]", "\"[a]\": v\n"},
+		{"&a", "\"&a\": v\n"},
+		{"a:", "\"a:\": v\n"},
+		{"'a'", "\"'a'\": v\n"},
+		{"a\"b", "a\"b: v\n"},
+		{"a\nb", "\"a\\nb\": v\n"},
+		{"日本語", "日本語: v\n"},
+	}
+	for _, test := range tests {
+		b, err := yaml.Marshal(map[string]string{test.key: "v"})
+		if err != nil {
+			t.Fatalf("%q: %+v", test.key, err)
+		}
+		if string(b) != test.expect {
+			t.Fatalf("%q: unexpected output: %q", test.key, string(b))
+		}
+		var v map[string]string
+		if err := yaml.Unmarshal(b, &v); err != nil {
+			t.Fatalf("%q: %+v", test.key, err)
+		}
+		if len(v) != 1 || v[test.key] != "v" {
+			t.Fatalf("%q: unexpected decoded value: %v", test.key, v)
+		}
+	}
+}
+
+func TestEncoder_Cycle(t *testing.T) {
+	type Node struct {
+		Name string
+		Next *Node
+	}
+	t.Run("pointer", func(t *testing.T) {
+		v := &Node{Name: "a"}
+		v.Next = &Node{Name: "b", Next: v}
+		if _, err := yaml.Marshal(v); err == nil || !strings.Contains(err.Error(), "cycle via *yaml_test.Node") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	t.Run("pointer with AutoAnchor", func(t *testing.T) {
+		v := &Node{Name: "a"}
+		v.Next = v
+		if _, err := yaml.MarshalWithOptions(v, yaml.AutoAnchor(true)); err == nil || !strings.Contains(err.Error(), "cycle via *yaml_test.Node") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	t.Run("map", func(t *testing.T) {
+		v := map[string]interface{}{}
+		v["a"] = []interface{}{v}
+		if _, err := yaml.Marshal(v); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("slice", func(t *testing.T) {
+		v := []interface{}{nil}
+		v[0] = v
+		if _, err := yaml.Marshal(v); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("shared", func(t *testing.T) {
+		shared := &Node{Name: "c"}
+		v := []*Node{shared, {Name: "d", Next: shared}}
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		expect := "- name: c\n  next: null\n- name: d\n  next:\n    name: c\n    next: null\n"
+		if string(b) != expect {
+			t.Fatalf("expect = [%s], actual = [%s]", expect, string(b))
+		}
+	})
+}
+
+func TestEncoder_NodeHook(t *testing.T) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf, yaml.NodeHook(func(node ast.Node) (ast.Node, error) {
+		mapping, ok := node.(*ast.MappingNode)
+		if !ok {
+			return nil, fmt.Errorf("unexpected node type %T", node)
+		}
+		// move key c to the top
+		sort.SliceStable(mapping.Values, func(i, j int) bool {
+			return mapping.Values[i].Key.GetToken().Value == "c"
+		})
+		return mapping, nil
+	}))
+	if err := enc.Encode(map[string]int{"a": 1, "b": 2, "c": 3}); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expect := "c: 3\na: 1\nb: 2\n"
+	if buf.String() != expect {
+		t.Fatalf("unexpected output. expect:\n%s\nbut got:\n%s", expect, buf.String())
+	}
+	t.Run("error", func(t *testing.T) {
+		enc := yaml.NewEncoder(&buf, yaml.NodeHook(func(node ast.Node) (ast.Node, error) {
+			return nil, fmt.Errorf("hook error")
+		}))
+		if err := enc.Encode(1); err == nil {
+			t.Fatal("expected error from hook")
+		}
+	})
+}
+
+func TestEncoder_NodeMiddleware(t *testing.T) {
+	type account struct {
+		Name     string
+		Password string
+		Roles    []string
+	}
+	v := struct {
+		Accounts []account
+		Debug    bool
+	}{
+		Accounts: []account{
+			{Name: "alice", Password: "secret", Roles: []string{"admin", "internal"}},
+			{Name: "bob", Password: "secret2", Roles: []string{"user"}},
+		},
+		Debug: true,
+	}
+	var paths []string
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf,
+		yaml.NodeMiddleware(func(path string, node ast.Node) (ast.Node, error) {
+			paths = append(paths, path)
+			return node, nil
+		}),
+		yaml.NodeMiddleware(func(path string, node ast.Node) (ast.Node, error) {
+			switch {
+			case strings.HasSuffix(path, ".password"):
+				return ast.NewString("***"), nil
+			case path == "$.debug":
+				return nil, nil
+			}
+			if s, ok := node.(*ast.StringNode); ok && s.Value == "internal" {
+				return nil, nil
+			}
+			return node, nil
+		}),
+	)
+	if err := enc.Encode(v); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expect := `
+accounts:
+- name: alice
+  password: "***"
+  roles:
+  - admin
+- name: bob
+  password: "***"
+  roles:
+  - user
+`
+	if actual := "\n" + buf.String(); actual != expect {
+		t.Fatalf("unexpected output. expect:%s\nbut got:%s", expect, actual)
+	}
+	expectedPaths := []string{
+		"$",
+		"$.accounts",
+		"$.accounts[0]",
+		"$.accounts[0].name",
+		"$.accounts[0].password",
+		"$.accounts[0].roles",
+		"$.accounts[0].roles[0]",
+		"$.accounts[0].roles[1]",
+		"$.accounts[1]",
+		"$.accounts[1].name",
+		"$.accounts[1].password",
+		"$.accounts[1].roles",
+		"$.accounts[1].roles[0]",
+		"$.debug",
+	}
+	if !reflect.DeepEqual(expectedPaths, paths) {
+		t.Fatalf("unexpected paths: %v", paths)
+	}
+	t.Run("error", func(t *testing.T) {
+		_, err := yaml.MarshalWithOptions(v, yaml.NodeMiddleware(func(path string, node ast.Node) (ast.Node, error) {
+			if path == "$.accounts[1].name" {
+				return nil, fmt.Errorf("middleware error")
+			}
+			return node, nil
+		}))
+		if err == nil {
+			t.Fatal("expected error from middleware")
+		}
+	})
+}
+
+func TestEncoder_EncodeToNode(t *testing.T) {
+	enc := yaml.NewEncoder(nil)
+	node, err := enc.EncodeToNode(struct {
+		A int
+		B []string
+	}{A: 1, B: []string{"x", "y"}})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	mapping, ok := node.(*ast.MappingNode)
+	if !ok {
+		t.Fatalf("unexpected node type %T", node)
+	}
+	if len(mapping.Values) != 2 {
+		t.Fatalf("unexpected number of values: %d", len(mapping.Values))
+	}
+	var p printer.Printer
+	expect := "a: 1\nb:\n- x\n- \"y\"\n"
+	if actual := string(p.PrintNode(node)); actual != expect {
+		t.Fatalf("unexpected output. expect:\n%s\nbut got:\n%s", expect, actual)
+	}
+}
+
+func TestEncoder_BoolFormat(t *testing.T) {
+	v := struct {
+		A bool
+		B bool
+		C string
+		D string
+	}{A: true, B: false, C: "no", D: "yes"}
+	tests := []struct {
+		trueText  string
+		falseText string
+		expect    string
+	}{
+		{"true", "false", "a: true\nb: false\nc: \"no\"\nd: \"yes\"\n"},
+		{"yes", "no", "a: yes\nb: no\nc: \"no\"\nd: \"yes\"\n"},
+		{"ON", "OFF", "a: ON\nb: OFF\nc: \"no\"\nd: \"yes\"\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.trueText, func(t *testing.T) {
+			var buf bytes.Buffer
+			enc := yaml.NewEncoder(&buf, yaml.BoolFormat(test.trueText, test.falseText))
+			if err := enc.Encode(v); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if buf.String() != test.expect {
+				t.Fatalf("unexpected output. expect:\n%s\nbut got:\n%s", test.expect, buf.String())
+			}
+		})
+	}
+	t.Run("unsupported format", func(t *testing.T) {
+		enc := yaml.NewEncoder(&bytes.Buffer{}, yaml.BoolFormat("yes", "off"))
+		if err := enc.Encode(v); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestEncoder_LineBreak(t *testing.T) {
+	v := map[string]interface{}{"a": 1, "b": []string{"c", "d"}}
+	tests := []struct {
+		terminator string
+		expect     string
+	}{
+		{"\n", "a: 1\nb:\n- c\n- d\n"},
+		{"\r\n", "a: 1\r\nb:\r\n- c\r\n- d\r\n"},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf, yaml.LineBreak(test.terminator))
+		if err := enc.Encode(v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if buf.String() != test.expect {
+			t.Fatalf("unexpected output. expect:\n%q\nbut got:\n%q", test.expect, buf.String())
+		}
+	}
+	t.Run("unsupported terminator", func(t *testing.T) {
+		enc := yaml.NewEncoder(&bytes.Buffer{}, yaml.LineBreak("\r"))
+		if err := enc.Encode(v); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestEncoder_CommentColumn(t *testing.T) {
+	v := yaml.MapSlice{
+		{Key: "a", Value: 1},
+		{Key: "long_key_name", Value: "x"},
+		{Key: "c", Value: []int{1, 2}},
+		{Key: "d", Value: yaml.MapSlice{{Key: "e", Value: "日本"}}},
+		{Key: "f", Value: "g # h"},
+	}
+	cm := yaml.CommentMap{
+		"$.a":             []*yaml.Comment{yaml.LineComment(" first")},
+		"$.long_key_name": []*yaml.Comment{yaml.LineComment(" long")},
+		"$.c[1]":          []*yaml.Comment{yaml.LineComment(" entry")},
+		"$.d":             []*yaml.Comment{yaml.LineComment(" map")},
+		"$.d.e":           []*yaml.Comment{yaml.LineComment(" nested")},
+	}
+	tests := []struct {
+		name     string
+		opts     []yaml.EncodeOption
+		expected string
+	}{
+		{
+			name:     "without comments",
+			opts:     []yaml.EncodeOption{yaml.CommentColumn(10), yaml.CommentSpaces(2)},
+			expected: "a: 1\nlong_key_name: x\nc:\n- 1\n- 2\nd:\n  e: 日本\nf: \"g # h\"\n",
+		},
+		{
+			name:     "column",
+			opts:     []yaml.EncodeOption{yaml.WithComment(cm), yaml.CommentColumn(10)},
+			expected: "a: 1     # first\nlong_key_name: x # long\nc:\n- 1\n- 2      # entry\nd:       # map\n  e: 日本  # nested\nf: \"g # h\"\n",
+		},
+		{
+			name:     "spaces",
+			opts:     []yaml.EncodeOption{yaml.WithComment(cm), yaml.CommentSpaces(2)},
+			expected: "a: 1  # first\nlong_key_name: x  # long\nc:\n- 1\n- 2  # entry\nd:  # map\n  e: 日本  # nested\nf: \"g # h\"\n",
+		},
+		{
+			name:     "column and spaces",
+			opts:     []yaml.EncodeOption{yaml.WithComment(cm), yaml.CommentColumn(10), yaml.CommentSpaces(2)},
+			expected: "a: 1     # first\nlong_key_name: x  # long\nc:\n- 1\n- 2      # entry\nd:       # map\n  e: 日本  # nested\nf: \"g # h\"\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := yaml.MarshalWithOptions(v, test.opts...)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if string(b) != test.expected {
+				t.Fatalf("failed to align comments. expected:\n%s\nbut got:\n%s", test.expected, string(b))
+			}
+		})
+	}
+	if _, err := yaml.MarshalWithOptions(v, yaml.CommentColumn(-1)); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := yaml.MarshalWithOptions(v, yaml.CommentSpaces(0)); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestEncoder_Directives(t *testing.T) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf,
+		yaml.VersionDirective("1.2"),
+		yaml.TagDirective("!e!", "tag:example.com,2000:app/"),
+	)
+	if err := enc.Encode(map[string]int{"a": 1}); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expect := "%YAML 1.2\n%TAG !e! tag:example.com,2000:app/\n---\na: 1\n"
+	if buf.String() != expect {
+		t.Fatalf("unexpected output. expect:\n%s\nbut got:\n%s", expect, buf.String())
+	}
+	var v map[string]int
+	if err := yaml.Unmarshal(buf.Bytes(), &v); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if v["a"] != 1 {
+		t.Fatalf("unexpected decoded value: %v", v)
+	}
+	t.Run("invalid directive", func(t *testing.T) {
+		for _, opt := range []yaml.EncodeOption{
+			yaml.VersionDirective("1"),
+			yaml.TagDirective("e", "tag:example.com,2000:"),
+			yaml.TagDirective("!e!", ""),
+		} {
+			if err := yaml.NewEncoder(&bytes.Buffer{}, opt).Encode(1); err == nil {
+				t.Fatal("expected error")
+			}
+		}
+	})
+}
+
+func TestEncoder_ExplicitTag(t *testing.T) {
+	type T struct {
+		A string
+		B string
+		C int
+		D map[string]int
+		E []string
+	}
+	v := T{A: "10", B: "hello", C: 1, D: map[string]int{"x": 1}, E: []string{"true"}}
+	t.Run("ambiguous strings", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf, yaml.ExplicitTag(func(node ast.Node) bool {
+			// strings quoted to be distinguished from other types
+			s, ok := node.(*ast.StringNode)
+			return ok && strings.HasPrefix(s.Value, `"`)
+		}))
+		if err := enc.Encode(v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		expect := "a: !!str \"10\"\nb: hello\nc: 1\nd:\n  x: 1\ne:\n- !!str \"true\"\n"
+		if buf.String() != expect {
+			t.Fatalf("unexpected output. expect:\n%s\nbut got:\n%s", expect, buf.String())
+		}
+	})
+	t.Run("all nodes", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf, yaml.ExplicitTag(func(ast.Node) bool { return true }))
+		if err := enc.Encode(v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		expect := "!!map\na: !!str \"10\"\nb: !!str hello\nc: !!int 1\nd: !!map\n  x: !!int 1\ne: !!seq\n- !!str \"true\"\n"
+		if buf.String() != expect {
+			t.Fatalf("unexpected output. expect:\n%s\nbut got:\n%s", expect, buf.String())
+		}
+		var decoded T
+		if err := yaml.Unmarshal(buf.Bytes(), &decoded); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if !reflect.DeepEqual(v, decoded) {
+			t.Fatalf("unexpected decoded value: %+v", decoded)
+		}
+	})
+}
+
+func TestEncoder_Quote(t *testing.T) {
+	v := map[string]string{
+		"a":   "yes",
+		"b":   "on",
+		"c":   "1.0",
+		"d":   "012",
+		"e":   "190:20:30",
+		"f":   "hello",
+		"g":   "it's\n",
+		"h":   "y",
+		"i":   "N",
+		"j":   "2001-12-14",
+		"k":   "2001-12-14t21:59:43.10-05:00",
+		"yes": "no",
+	}
+	tests := []struct {
+		name   string
+		opts   []yaml.EncodeOption
+		expect string
+	}{
+		{
+			"default",
+			nil,
+			"a: \"yes\"\nb: \"on\"\nc: \"1.0\"\nd: \"012\"\ne: \"190:20:30\"\nf: hello\ng: \"it's\\n\"\n" +
+				"h: \"y\"\ni: \"N\"\nj: \"2001-12-14\"\nk: \"2001-12-14t21:59:43.10-05:00\"\n\"yes\": \"no\"\n",
+		},
+		{
+			"single quote",
+			[]yaml.EncodeOption{yaml.UseSingleQuote(true)},
+			"a: 'yes'\nb: 'on'\nc: '1.0'\nd: '012'\ne: '190:20:30'\nf: hello\ng: \"it's\\n\"\n" +
+				"h: 'y'\ni: 'N'\nj: '2001-12-14'\nk: '2001-12-14t21:59:43.10-05:00'\n'yes': 'no'\n",
+		},
+		{
+			"force quote",
+			[]yaml.EncodeOption{yaml.ForceQuote(true)},
+			"a: \"yes\"\nb: \"on\"\nc: \"1.0\"\nd: \"012\"\ne: \"190:20:30\"\nf: \"hello\"\ng: \"it's\\n\"\n" +
+				"h: \"y\"\ni: \"N\"\nj: \"2001-12-14\"\nk: \"2001-12-14t21:59:43.10-05:00\"\n\"yes\": \"no\"\n",
+		},
+		{
+			"force single quote",
+			[]yaml.EncodeOption{yaml.ForceQuote(true), yaml.UseSingleQuote(true)},
+			"a: 'yes'\nb: 'on'\nc: '1.0'\nd: '012'\ne: '190:20:30'\nf: 'hello'\ng: \"it's\\n\"\n" +
+				"h: 'y'\ni: 'N'\nj: '2001-12-14'\nk: '2001-12-14t21:59:43.10-05:00'\n'yes': 'no'\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := yaml.NewEncoder(&buf, test.opts...).Encode(v); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if buf.String() != test.expect {
+				t.Fatalf("unexpected output. expect:\n%s\nbut got:\n%s", test.expect, buf.String())
+			}
+			var decoded map[string]string
+			if err := yaml.Unmarshal(buf.Bytes(), &decoded); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if !reflect.DeepEqual(v, decoded) {
+				t.Fatalf("failed to decode. expect %v but got %v", v, decoded)
+			}
+		})
+	}
+}
+
+func TestEncoder_LiteralStyle(t *testing.T) {
+	type T struct {
+		A string
+		B string
+		C string
+		D []string
+		E map[string]string
+		F string
+		G string
+	}
+	v := T{
+		A: "a\nb\n",
+		B: "a\n\nb",
+		C: "c\n\n",
+		D: []string{"d\ne", "f"},
+		E: map[string]string{"g": "h\ni\n"},
+		F: " j\nk",
+		G: "l\n  m\n\tn",
+	}
+	t.Run("block style", func(t *testing.T) {
+		b, err := yaml.MarshalWithOptions(v, yaml.UseLiteralStyleIfMultiline(true))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		expect := `
+a: |
+  a
+  b
+b: |-
+  a
+
+  b
+c: |+
+  c
+
+d:
+- |-
+  d
+  e
+- f
+e:
+  g: |
+    h
+    i
+f: " j\nk"
+g: "l\n  m\n\tn"
+`
+		if string(b) != strings.TrimPrefix(expect, "\n") {
+			t.Fatalf("unexpected output. expect:\n%s\nbut got:\n%s", expect, string(b))
+		}
+		var decoded T
+		if err := yaml.Unmarshal(b, &decoded); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if !reflect.DeepEqual(v, decoded) {
+			t.Fatalf("failed to decode. expect %#v but got %#v", v, decoded)
+		}
+	})
+	t.Run("flow style", func(t *testing.T) {
+		b, err := yaml.MarshalWithOptions(v, yaml.UseLiteralStyleIfMultiline(true), yaml.FlowDepth(1))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		expect := "a: |\n  a\n  b\nb: |-\n  a\n\n  b\nc: |+\n  c\n\nd: [\"d\\ne\", f]\ne: {g: \"h\\ni\\n\"}\nf: \" j\\nk\"\ng: \"l\\n  m\\n\\tn\"\n"
+		if string(b) != expect {
+			t.Fatalf("unexpected output. expect:\n%s\nbut got:\n%s", expect, string(b))
+		}
+	})
+}
+
+func TestEncoder_CoreSchemaString(t *testing.T) {
+	v := map[string]string{}
+	for _, s := range []string{"1e3", "1E3", "-1.5e-3", "0o17", "0x1F", "010", ".inf", "-.Inf", ".NaN", "Null", "TRUE", "~"} {
+		v[s] = s
+	}
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	for _, version := range []string{"1.1", "1.2"} {
+		var decoded map[string]interface{}
+		if err := yaml.UnmarshalWithOptions(b, &decoded, yaml.SchemaVersion(version)); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		for key, value := range decoded {
+			if value != key {
+				t.Fatalf("%s: string must be quoted to be read as string: %s", version, string(b))
+			}
+		}
+		if len(decoded) != len(v) {
+			t.Fatalf("%s: unexpected value: %v", version, decoded)
+		}
+	}
+}
+
+func TestEncoder_CommentWidth(t *testing.T) {
+	v := yaml.MapSlice{
+		{Key: "a", Value: yaml.MapSlice{{Key: "b", Value: []int{1, 2}}}},
+		{Key: "c", Value: "d"},
+	}
+	cm := yaml.CommentMap{
+		"$":        []*yaml.Comment{yaml.HeadComment(" this document describes the configuration")},
+		"$.a.b":    []*yaml.Comment{yaml.HeadComment(" the list of numbers used by the application")},
+		"$.a.b[1]": []*yaml.Comment{yaml.HeadComment(" second number is important")},
+		"$.c": []*yaml.Comment{
+			yaml.LineComment(" line comment is not wrapped even if it is long"),
+			yaml.FootComment(" foot comment of c is wrapped", "#  indented   text"),
+		},
+	}
+	b, err := yaml.MarshalWithOptions(v, yaml.WithComment(cm), yaml.CommentWidth(24))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expected := `# this document
+# describes the
+# configuration
+a:
+  # the list of numbers
+  # used by the
+  # application
+  b:
+  - 1
+  # second number is
+  # important
+  - 2
+c: d # line comment is not wrapped even if it is long
+# foot comment of c is
+# wrapped
+##  indented   text
+`
+	if string(b) != expected {
+		t.Fatalf("failed to wrap comments. got:\n%s", string(b))
+	}
+	if _, err := yaml.MarshalWithOptions(v, yaml.CommentWidth(-1)); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestEncoder_SortKeys(t *testing.T) {
+	type meta struct {
+		Name  string
+		Label string
+	}
+	type inner struct {
+		X *meta   `yaml:"x"`
+		L []*meta `yaml:"l"`
+	}
+	type object struct {
+		Kind       string
+		APIVersion string `yaml:"apiVersion"`
+		Meta       meta   `yaml:",inline"`
+		Spec       *meta  `yaml:"spec,anchor=s"`
+		Copy       *meta  `yaml:"copy,alias=s"`
+		Extra      yaml.MapSlice
+	}
+	m := &meta{Name: "n", Label: "l"}
+	obj := object{
+		Kind:       "Pod",
+		APIVersion: "v1",
+		Meta:       meta{Name: "x", Label: "y"},
+		Spec:       m,
+		Copy:       m,
+		Extra:      yaml.MapSlice{{Key: "z", Value: 1}, {Key: "a", Value: 2}},
+	}
+	t.Run("sorted", func(t *testing.T) {
+		b, err := yaml.MarshalWithOptions(obj, yaml.SortKeys(true))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		expected := `apiVersion: v1
+copy: &s
+  label: l
+  name: "n"
+extra:
+  a: 2
+  z: 1
+kind: Pod
+label: "y"
+name: x
+spec: *s
+`
+		if string(b) != expected {
+			t.Fatalf("failed to sort keys. got:\n%s", string(b))
+		}
+	})
+	t.Run("less function", func(t *testing.T) {
+		order := map[string]int{"apiVersion": -2, "kind": -1}
+		b, err := yaml.MarshalWithOptions(obj, yaml.SortKeysFunc(func(a, b string) bool {
+			if order[a] != order[b] {
+				return order[a] < order[b]
+			}
+			return a < b
+		}))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if !strings.HasPrefix(string(b), "apiVersion: v1\nkind: Pod\ncopy: &s\n") {
+			t.Fatalf("failed to sort keys. got:\n%s", string(b))
+		}
+	})
+	t.Run("auto anchor", func(t *testing.T) {
+		type document struct {
+			Z []*meta `yaml:"z"`
+			B *meta   `yaml:"b"`
+			A inner   `yaml:"a"`
+		}
+		m2 := &meta{Name: "n2", Label: "l2"}
+		b, err := yaml.MarshalWithOptions(document{Z: []*meta{m2}, B: m, A: inner{X: m2, L: []*meta{m}}}, yaml.SortKeys(true), yaml.AutoAnchor(true))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		expected := `a:
+  l:
+  - &id002
+    label: l
+    name: "n"
+  x: &id001
+    label: l2
+    name: n2
+b: *id002
+z:
+- *id001
+`
+		if string(b) != expected {
+			t.Fatalf("failed to place anchors before aliases. got:\n%s", string(b))
+		}
+	})
+	t.Run("not sorted", func(t *testing.T) {
+		b, err := yaml.MarshalWithOptions(obj.Extra, yaml.SortKeys(false))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if string(b) != "z: 1\na: 2\n" {
+			t.Fatalf("unexpected output: %s", string(b))
+		}
+	})
+}
+
+type testScript string
+
+type testTags []string
+
+type testVersion string
+
+func TestEncoder_TypeStyle(t *testing.T) {
+	type job struct {
+		Name    string
+		Run     testScript
+		Steps   []testScript
+		Tags    testTags
+		Version testVersion
+		Token   string
+	}
+	v := job{
+		Name:    "build",
+		Run:     "make\nmake test\n",
+		Steps:   []testScript{"go vet", "a\nb"},
+		Tags:    testTags{"a", "b"},
+		Version: "1.10",
+		Token:   "xyz",
+	}
+	b, err := yaml.MarshalWithOptions(v,
+		yaml.TypeStyle(testScript(""), yaml.EncodeStyle{Literal: true}),
+		yaml.TypeStyle(testTags(nil), yaml.EncodeStyle{Flow: true, Tag: "!tags"}),
+		yaml.TypeStyle(testVersion(""), yaml.EncodeStyle{Quoted: true}),
+	)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expected := `name: build
+run: |
+  make
+  make test
+steps:
+- |-
+  go vet
+- |-
+  a
+  b
+tags: !tags [a, b]
+version: "1.10"
+token: xyz
+`
+	if string(b) != expected {
+		t.Fatalf("failed to encode with TypeStyle. expected:[%s] but got [%s]", expected, string(b))
+	}
+	t.Run("flow context", func(t *testing.T) {
+		b, err := yaml.MarshalWithOptions(v, yaml.Flow(true), yaml.TypeStyle(testScript(""), yaml.EncodeStyle{Literal: true}))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if strings.Contains(string(b), "|") {
+			t.Fatalf("literal must not be placed in flow collection: %s", string(b))
+		}
+	})
+	t.Run("nil value", func(t *testing.T) {
+		if _, err := yaml.MarshalWithOptions(v, yaml.TypeStyle(nil, yaml.EncodeStyle{})); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestEncoder_UseBinaryTag(t *testing.T) {
+	v := struct {
+		A []byte
+		B []byte
+		C []byte
+	}{[]byte("hello"), []byte("the quick brown fox"), nil}
+	b, err := yaml.MarshalWithOptions(v, yaml.UseBinaryTag(12))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expected := `a: !!binary aGVsbG8=
+b: !!binary |
+  dGhlIHF1aWNr
+  IGJyb3duIGZv
+  eA==
+c: []
+`
+	if string(b) != expected {
+		t.Fatalf("failed to encode with UseBinaryTag. expected:[%s] but got [%s]", expected, string(b))
+	}
+	var decoded struct {
+		A []byte
+		B []byte
+	}
+	if err := yaml.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if string(decoded.A) != "hello" || string(decoded.B) != "the quick brown fox" {
+		t.Fatalf("unexpected decoded value: %q %q", decoded.A, decoded.B)
+	}
+	if _, err := yaml.MarshalWithOptions(v, yaml.UseBinaryTag(-1)); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestEncoder_EncodeTimeLayout(t *testing.T) {
+	at := time.Date(2015, 2, 24, 18, 19, 39, 0, time.UTC)
+	v := struct {
+		T time.Time
+		P *time.Time
+	}{at, &at}
+	b, err := yaml.MarshalWithOptions(v, yaml.EncodeTimeLayout("2006-01-02"))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expected := "t: 2015-02-24\np: 2015-02-24\n"
+	if string(b) != expected {
+		t.Fatalf("failed to encode with EncodeTimeLayout. expected:[%s] but got [%s]", expected, string(b))
+	}
+	if _, err := yaml.MarshalWithOptions(v, yaml.EncodeTimeLayout("")); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestEncoder_ASTNode(t *testing.T) {
+	parse := func(src string) ast.Node {
+		f, err := parser.ParseBytes([]byte(src), 0)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		return f.Docs[0].Body
+	}
+	spec := parse("a: 1\nb:\n  c: &x [1, 2]\n  d: *x\n  s: |\n    line1\n    line2\n")
+	specText := spec.String()
+	v := struct {
+		Kind  string
+		Spec  ast.Node
+		Items []ast.Node
+		Extra map[string]ast.Node
+	}{
+		Kind:  "Pod",
+		Spec:  spec,
+		Items: []ast.Node{parse("x: 1\ny: 2"), parse("- a\n- b"), parse("k: v")},
+		Extra: map[string]ast.Node{"m": parse("n:\n  o: p\n")},
+	}
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expected := `kind: Pod
+spec:
+  a: 1
+  b:
+    c: &x [1, 2]
+    d: *x
+    s: |
+      line1
+      line2
+items:
+- x: 1
+  y: 2
+- - a
+  - b
+- k: v
+extra:
+  m:
+    n:
+      o: p
+`
+	if string(b) != expected {
+		t.Fatalf("failed to encode ast.Node. expected:[%s] but got [%s]", expected, string(b))
+	}
+	if spec.String() != specText {
+		t.Fatalf("spliced node must not be modified: %s", spec.String())
+	}
+	t.Run("flow", func(t *testing.T) {
+		b, err := yaml.MarshalWithOptions(map[string]ast.Node{"a": parse("b:\n  - c\n")}, yaml.Flow(true))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if expected := "{a: {b: [c]}}\n"; string(b) != expected {
+			t.Fatalf("expected %q but got %q", expected, string(b))
+		}
+	})
+	t.Run("undefined anchor", func(t *testing.T) {
+		if _, err := yaml.Marshal(map[string]ast.Node{"a": parse("*x")}); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestEncoder_JSON(t *testing.T) {
+	type T struct {
+		A string                 `yaml:"a"`
+		B []int                  `yaml:"b"`
+		C map[string]interface{} `yaml:"c"`
+		D *T                     `yaml:"d"`
+		E float64                `yaml:"e"`
+		F bool                   `yaml:"f"`
+	}
+	shared := map[string]interface{}{"x": 1}
+	v := T{
+		A: "say \"hi\"\n\x01\t<'>",
+		B: []int{1, 2},
+		C: map[string]interface{}{"k": "'q'", "n": nil, "s": shared, "t": shared, "l": "a\nb\n"},
+		E: 1.5,
+		F: true,
+	}
+	t.Run("valid json", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf,
+			yaml.JSON(),
+			yaml.AutoAnchor(true),
+			yaml.UseSingleQuote(true),
+			yaml.UseLiteralStyleIfMultiline(true),
+		)
+		if err := enc.Encode(v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if err := enc.Encode([]interface{}{1, "y", nil}); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		expect := `{"a": "say \"hi\"\n\u0001\t<'>", "b": [1, 2], "c": {"k": "'q'", "l": "a\nb\n", "n": null, "s": {"x": 1}, "t": {"x": 1}}, "d": null, "e": 1.5, "f": true}
+[1, "y", null]
+`
+		if buf.String() != expect {
+			t.Fatalf("unexpected output. expect:\n%s\nbut got:\n%s", expect, buf.String())
+		}
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		var fromJSON, fromYAML T
+		if err := json.Unmarshal([]byte(lines[0]), &fromJSON); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if err := yaml.Unmarshal([]byte(lines[0]), &fromYAML); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		// numbers in interface{} are decoded to different types, so compare typed fields only
+		if fromJSON.A != v.A || fromYAML.A != v.A || !reflect.DeepEqual(fromJSON.B, fromYAML.B) || fromJSON.E != fromYAML.E {
+			t.Fatalf("failed to decode. json %#v yaml %#v", fromJSON, fromYAML)
+		}
+	})
+	t.Run("invalid utf8", func(t *testing.T) {
+		b, err := yaml.MarshalWithOptions(map[string]string{"a": "b\xffc"}, yaml.JSON())
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if !json.Valid(b) {
+			t.Fatalf("invalid json: %s", string(b))
+		}
+		if expect := "{\"a\": \"b\ufffdc\"}\n"; string(b) != expect {
+			t.Fatalf("unexpected output. expect %q but got %q", expect, string(b))
+		}
+	})
+	t.Run("infinity", func(t *testing.T) {
+		if _, err := yaml.MarshalWithOptions(math.Inf(1), yaml.JSON()); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestEncoder_EncodeFieldNamer(t *testing.T) {
+	type T struct {
+		APIVersion   string
+		UserID       int
+		HTTPServer   string
+		MaxRetries   int    `yaml:",omitempty"`
+		TaggedField  string `yaml:"tagged"`
+		SkippedField string `yaml:"-"`
+	}
+	v := T{APIVersion: "v1", UserID: 1, HTTPServer: "a", MaxRetries: 3, TaggedField: "b"}
+	tests := []struct {
+		namer  func(string) string
+		expect string
+	}{
+		{yaml.SnakeCase, "api_version: v1\nuser_id: 1\nhttp_server: a\nmax_retries: 3\ntagged: b\n"},
+		{yaml.KebabCase, "api-version: v1\nuser-id: 1\nhttp-server: a\nmax-retries: 3\ntagged: b\n"},
+		{yaml.CamelCase, "apiVersion: v1\nuserID: 1\nhttpServer: a\nmaxRetries: 3\ntagged: b\n"},
+		{nil, "apiversion: v1\nuserid: 1\nhttpserver: a\nmaxretries: 3\ntagged: b\n"},
+	}
+	for _, test := range tests {
+		b, err := yaml.MarshalWithOptions(v, yaml.EncodeFieldNamer(test.namer))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if string(b) != test.expect {
+			t.Fatalf("unexpected output. expect:\n%s\nbut got:\n%s", test.expect, string(b))
+		}
+	}
+}
+
+func TestEncoder_EncodeJSONTag(t *testing.T) {
+	type TypeMeta struct {
+		Kind string `json:"kind,omitempty"`
+	}
+	type Status struct {
+		Ready bool `json:"ready"`
+	}
+	type T struct {
+		TypeMeta `json:",inline"`
+		Status
+		Name     string `json:"name"`
+		Replicas int    `json:"replicas,omitempty"`
+		Secret   string `json:"-"`
+		Image    string `yaml:"img" json:"image"`
+		Port     int
+	}
+	v := T{TypeMeta: TypeMeta{Kind: "Deployment"}, Status: Status{Ready: true}, Name: "app", Secret: "s", Image: "nginx", Port: 80}
+	b, err := yaml.MarshalWithOptions(v, yaml.EncodeJSONTag(true))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expect := `
+kind: Deployment
+ready: true
+name: app
+img: nginx
+port: 80
+`
+	if string(b) != strings.TrimPrefix(expect, "\n") {
+		t.Fatalf("unexpected output. expect:\n%s\nbut got:\n%s", expect, string(b))
+	}
+	b, err = yaml.Marshal(v)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if !strings.Contains(string(b), "secret: s") {
+		t.Fatalf("json tag is used without option:\n%s", string(b))
+	}
+}
+
+func TestEncoder_WithComment(t *testing.T) {
+	v := map[string]interface{}{
+		"a": 1,
+		"b": map[string]interface{}{
+			"c": "d",
+		},
+		"e": []interface{}{"f", map[string]int{"g": 2}},
+	}
+	cm := yaml.CommentMap{
+		"$":        {yaml.HeadComment(" document")},
+		"$.a":      {yaml.LineComment(" line")},
+		"$.b":      {yaml.HeadComment(" head", " of b"), yaml.FootComment(" foot")},
+		"$.b.c":    {yaml.LineComment(" nested")},
+		"$.e[0]":   {yaml.HeadComment(" entry")},
+		"$.e[1].g": {yaml.HeadComment(" key in entry")},
+	}
+	b, err := yaml.MarshalWithOptions(v, yaml.WithComment(cm))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expected := `# document
+a: 1 # line
+# head
+# of b
+b:
+  c: d # nested
+# foot
+e:
+# entry
+- f
+-
+  # key in entry
+  g: 2
+`
+	if string(b) != expected {
+		t.Fatalf("unexpected output. expected:\n%s\nbut got:\n%s", expected, string(b))
+	}
+
+	_, err = yaml.MarshalWithOptions(v, yaml.WithComment(yaml.CommentMap{"$.x": {yaml.LineComment(" x")}}))
+	if !xerrors.Is(err, yaml.ErrNotFoundNode) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// unsetPort is zero value when the port is not set, because 0 is valid port number
+type unsetPort struct {
+	Number int
+	IsSet  bool
+}
+
+func (p *unsetPort) IsZero() bool {
+	return !p.IsSet
+}
+
+func TestEncoder_OmitEmpty(t *testing.T) {
+	type T struct {
+		Ptr    *int              `yaml:"ptr,omitempty"`
+		Str    string            `yaml:"str,omitempty"`
+		Slice  []int             `yaml:"slice,omitempty"`
+		Map    map[string]string `yaml:"map,omitempty"`
+		Int    int               `yaml:"int,omitempty"`
+		Uint   uint              `yaml:"uint,omitempty"`
+		Float  float64           `yaml:"float,omitempty"`
+		Bool   bool              `yaml:"bool,omitempty"`
+		Time   time.Time         `yaml:"time,omitempty"`
+		Port   unsetPort         `yaml:"port,omitempty"`
+		Always int               `yaml:"always"`
+	}
+	bytes, err := yaml.Marshal(&T{})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if string(bytes) != "always: 0\n" {
+		t.Fatalf("zero values must be omitted: %q", string(bytes))
+	}
+	zero := 0
+	bytes, err = yaml.Marshal(&T{
+		Ptr:   &zero,
+		Slice: []int{0},
+		Port:  unsetPort{Number: 0, IsSet: true},
+	})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expect := `
+ptr: 0
+slice:
+- 0
+port:
+  number: 0
+  isset: true
+always: 0
+`
+	if actual := "\n" + string(bytes); actual != expect {
+		t.Fatalf("unexpected output. expect:%s\nbut got:%s", expect, actual)
+	}
+}
+
+type emptyMarshaler struct {
+	Values   []string
+	IsHidden bool
+}
+
+func (m emptyMarshaler) MarshalYAML() (interface{}, error) {
+	if m.IsHidden {
+		return []string{}, nil
+	}
+	return m.Values, nil
+}
+
+func TestEncoder_OmitEmptyMarshaler(t *testing.T) {
+	v := struct {
+		A emptyMarshaler `yaml:"a,omitempty"`
+		B emptyMarshaler `yaml:"b,omitempty"`
+		C emptyMarshaler `yaml:"c"`
+	}{
+		A: emptyMarshaler{Values: []string{"x"}, IsHidden: true},
+		B: emptyMarshaler{Values: []string{"x"}},
+		C: emptyMarshaler{Values: []string{"x"}, IsHidden: true},
+	}
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expected := "b:\n- x\nc: []\n"
+	if string(b) != expected {
+		t.Fatalf("unexpected output. expected:\n%s\nbut got:\n%s", expected, string(b))
+	}
+}
+
+func TestEncoder_AutoAnchor(t *testing.T) {
+	type T struct {
+		I int
+		S string
+	}
+	shared := &T{I: 1, S: "hello"}
+	tagged := &T{I: 2, S: "world"}
+	n := 10
+	v := struct {
+		A *T
+		B *T
+		L []*T
+		M map[string]*T
+		N *int
+		O *int
+		X *T `yaml:"x,anchor"`
+		Y *T `yaml:"y,alias"`
+		Z *T
+	}{
+		A: shared,
+		B: shared,
+		L: []*T{shared, {I: 3}},
+		M: map[string]*T{"k": shared},
+		N: &n,
+		O: &n,
+		X: tagged,
+		Y: tagged,
+		Z: &T{I: 4},
+	}
+	b, err := yaml.MarshalWithOptions(v, yaml.AutoAnchor(true))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expected := `
+a: &id001
+  i: 1
+  s: hello
+b: *id001
+l:
+- *id001
+- i: 3
+  s: ""
+m:
+  k: *id001
+n: &id002 10
+o: *id002
+x: &x
+  i: 2
+  s: world
+y: *x
+z:
+  i: 4
+  s: ""
+`
+	if string(b) != strings.TrimPrefix(expected, "\n") {
+		t.Fatalf("unexpected output. expected:\n%s\nbut got:\n%s", expected, string(b))
+	}
+	b, err = yaml.Marshal(v)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if strings.Contains(string(b), "id001") {
+		t.Fatalf("anchor must not be emitted without AutoAnchor option:\n%s", string(b))
+	}
+}
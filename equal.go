@@ -0,0 +1,323 @@
+package yaml
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/internal/errors"
+	"github.com/goccy/go-yaml/internal/yamlpath"
+	"github.com/goccy/go-yaml/parser"
+	"golang.org/x/xerrors"
+)
+
+// EqualOption functional option type for Equal and Diff
+type EqualOption func(e *equality)
+
+// StrictKeyOrder compare the order of mapping keys in Equal. By default, the order is ignored
+func StrictKeyOrder(isStrict bool) EqualOption {
+	return func(e *equality) {
+		e.isStrictKeyOrder = isStrict
+	}
+}
+
+// StrictTag compare tags of values in Equal ( e.g. `!foo 1` is not equal to `1` ).
+// By default, tags are used only to resolve values ( e.g. `!!str 1` is equal to `"1"` )
+func StrictTag(isStrict bool) EqualOption {
+	return func(e *equality) {
+		e.isStrictTag = isStrict
+	}
+}
+
+// Subset ignore mapping keys which exist only in the second source,
+// so Equal reports whether the first source is a subset of the second source
+func Subset(isSubset bool) EqualOption {
+	return func(e *equality) {
+		e.isSubset = isSubset
+	}
+}
+
+// Difference difference between two sources found by Diff
+type Difference struct {
+	// Document index of the document which has the difference
+	Document int
+	// Path path of the different value ( e.g. `$.a.b[0]` )
+	Path string
+	// Expected node in the first source. It is nil if the value exists only in the second source
+	Expected ast.Node
+	// Actual node in the second source. It is nil if the value exists only in the first source
+	Actual ast.Node
+	// Message description of the difference
+	Message string
+}
+
+// Equal reports whether a and b represent the same documents.
+// Formatting ( e.g. indent, style of collections and quotes of strings ), comments, order of mapping keys
+// and representation by anchors, aliases and merge keys are ignored.
+// It is useful to compare generated YAML in tests or to detect drift of configuration.
+func Equal(a, b []byte, opts ...EqualOption) (bool, error) {
+	diffs, err := Diff(a, b, opts...)
+	if err != nil {
+		return false, err
+	}
+	return len(diffs) == 0, nil
+}
+
+// Diff returns the differences between expected and actual in document order.
+// Values are compared in the same way as Equal, and nodes of the differences have positions in the sources.
+// Values referred by aliases have positions of the anchored values.
+func Diff(expected, actual []byte, opts ...EqualOption) ([]*Difference, error) {
+	e := &equality{decoder: NewDecoder(nil)}
+	for _, opt := range opts {
+		opt(e)
+	}
+	docsA, err := expandedDocuments(expected)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse first source")
+	}
+	docsB, err := expandedDocuments(actual)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse second source")
+	}
+	for idx := range docsA {
+		e.document = idx
+		if idx >= len(docsB) {
+			e.addDifference("$", docsA[idx].Body, nil, "document doesn't exist")
+			continue
+		}
+		e.compare("$", docsA[idx].Body, docsB[idx].Body)
+	}
+	for idx := len(docsA); idx < len(docsB); idx++ {
+		e.document = idx
+		e.addDifference("$", nil, docsB[idx].Body, "unexpected document")
+	}
+	return e.diffs, nil
+}
+
+// expandedDocuments parses src and expands aliases and merge keys of each document
+func expandedDocuments(src []byte) ([]*ast.Document, error) {
+	f, err := parser.ParseBytes(src, 0)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse")
+	}
+	docs := make([]*ast.Document, 0, len(f.Docs))
+	for _, doc := range f.Docs {
+		expanded, err := expandAliases(doc)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to expand aliases")
+		}
+		docs = append(docs, expanded)
+	}
+	return docs, nil
+}
+
+// expandAliases expands aliases and merge keys of doc by ast.ExpandAliases.
+// The expansion exceeding the limit is reported as the error of ErrCodeExcessiveAliasing like decoding.
+func expandAliases(doc *ast.Document) (*ast.Document, error) {
+	expanded, err := ast.ExpandAliases(doc)
+	if err != nil {
+		var limitErr *ast.AliasLimitError
+		if xerrors.As(err, &limitErr) {
+			return nil, errors.ErrSyntax(errors.CodeExcessiveAliasing, limitErr.Alias.GetToken(), limitErr.Kind, limitErr.Limit)
+		}
+		return nil, err
+	}
+	return expanded, nil
+}
+
+type equality struct {
+	decoder          *Decoder
+	isStrictKeyOrder bool
+	isStrictTag      bool
+	isSubset         bool
+	document         int
+	diffs            []*Difference
+}
+
+func (e *equality) addDifference(path string, a, b ast.Node, format string, args ...interface{}) {
+	e.diffs = append(e.diffs, &Difference{
+		Document: e.document,
+		Path:     path,
+		Expected: a,
+		Actual:   b,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (e *equality) compare(path string, a, b ast.Node) {
+	if e.isStrictTag && nodeTag(a) != nodeTag(b) {
+		e.addDifference(path, a, b, "expected tag %s but got %s", tagText(nodeTag(a)), tagText(nodeTag(b)))
+		return
+	}
+	kindA := nodeKind(a)
+	kindB := nodeKind(b)
+	if kindA != kindB {
+		e.addDifference(path, a, b, "expected %s but got %s", kindA, kindB)
+		return
+	}
+	switch kindA {
+	case KindMapping:
+		e.compareMapping(path, a, b)
+	case KindSequence:
+		e.compareSequence(path, a, b)
+	default:
+		e.compareScalar(path, a, b)
+	}
+}
+
+type mappingEntry struct {
+	key   interface{}
+	node  *ast.MappingValueNode
+	value ast.Node
+}
+
+func (e *equality) compareMapping(path string, a, b ast.Node) {
+	entriesA := e.mappingEntries(a)
+	entriesB := e.mappingEntries(b)
+	keyToIndex := map[interface{}]int{}
+	for idx, entryB := range entriesB {
+		if isComparableKey(entryB.key) {
+			keyToIndex[entryB.key] = idx
+		}
+	}
+	found := make([]bool, len(entriesB))
+	isSameKeys := true
+	for _, entryA := range entriesA {
+		keyPath := yamlpath.AppendKey(path, fmt.Sprint(entryA.key))
+		idx := indexOfKey(keyToIndex, entriesB, entryA.key)
+		if idx < 0 {
+			e.addDifference(keyPath, entryA.node, nil, "key %s doesn't exist", formatScalar(entryA.key))
+			isSameKeys = false
+			continue
+		}
+		found[idx] = true
+		e.compare(keyPath, entryA.value, entriesB[idx].value)
+	}
+	for idx, entryB := range entriesB {
+		if found[idx] {
+			continue
+		}
+		isSameKeys = false
+		if !e.isSubset {
+			keyPath := yamlpath.AppendKey(path, fmt.Sprint(entryB.key))
+			e.addDifference(keyPath, nil, entryB.node, "unexpected key %s", formatScalar(entryB.key))
+		}
+	}
+	if !e.isStrictKeyOrder || !isSameKeys {
+		return
+	}
+	for idx := range entriesA {
+		if !reflect.DeepEqual(entriesA[idx].key, entriesB[idx].key) {
+			e.addDifference(path, a, b, "expected key %s at %d but got %s", formatScalar(entriesA[idx].key), idx, formatScalar(entriesB[idx].key))
+			return
+		}
+	}
+}
+
+func (e *equality) mappingEntries(node ast.Node) []*mappingEntry {
+	entries := []*mappingEntry{}
+	switch n := untaggedNode(node).(type) {
+	case *ast.MappingNode:
+		for _, value := range n.Values {
+			entries = append(entries, &mappingEntry{
+				key:   e.decoder.nodeToScalarValue(value.Key),
+				node:  value,
+				value: value.Value,
+			})
+		}
+	case *ast.MappingValueNode:
+		entries = append(entries, &mappingEntry{
+			key:   e.decoder.nodeToScalarValue(n.Key),
+			node:  n,
+			value: n.Value,
+		})
+	}
+	return entries
+}
+
+// indexOfKey returns index of the entry which has key. If key doesn't exist, returns -1
+func indexOfKey(keyToIndex map[interface{}]int, entries []*mappingEntry, key interface{}) int {
+	if isComparableKey(key) {
+		if idx, exists := keyToIndex[key]; exists {
+			return idx
+		}
+		return -1
+	}
+	// e.g. []byte decoded from !!binary key
+	for idx, entry := range entries {
+		if reflect.DeepEqual(entry.key, key) {
+			return idx
+		}
+	}
+	return -1
+}
+
+func isComparableKey(key interface{}) bool {
+	return key == nil || reflect.TypeOf(key).Comparable()
+}
+
+func (e *equality) compareSequence(path string, a, b ast.Node) {
+	valuesA := untaggedNode(a).(*ast.SequenceNode).Values
+	valuesB := untaggedNode(b).(*ast.SequenceNode).Values
+	for idx, value := range valuesA {
+		indexPath := fmt.Sprintf("%s[%d]", path, idx)
+		if idx >= len(valuesB) {
+			e.addDifference(indexPath, value, nil, "element doesn't exist")
+			continue
+		}
+		e.compare(indexPath, value, valuesB[idx])
+	}
+	for idx := len(valuesA); idx < len(valuesB); idx++ {
+		e.addDifference(fmt.Sprintf("%s[%d]", path, idx), nil, valuesB[idx], "unexpected element")
+	}
+}
+
+func (e *equality) compareScalar(path string, a, b ast.Node) {
+	valueA := e.decoder.nodeToScalarValue(a)
+	valueB := e.decoder.nodeToScalarValue(b)
+	if floatA, ok := valueA.(float64); ok && math.IsNaN(floatA) {
+		if floatB, ok := valueB.(float64); ok && math.IsNaN(floatB) {
+			return
+		}
+	} else if reflect.DeepEqual(valueA, valueB) {
+		return
+	}
+	e.addDifference(path, a, b, "expected %s but got %s", formatScalar(valueA), formatScalar(valueB))
+}
+
+// nodeKind returns the kind of node in expanded document. Empty value is treated as null scalar
+func nodeKind(node ast.Node) Kind {
+	switch untaggedNode(node).(type) {
+	case *ast.MappingNode, *ast.MappingValueNode:
+		return KindMapping
+	case *ast.SequenceNode:
+		return KindSequence
+	}
+	return KindScalar
+}
+
+// nodeTag returns the tag of node. If node has no tag, returns empty string
+func nodeTag(node ast.Node) string {
+	if tag, ok := node.(*ast.TagNode); ok {
+		return tag.Start.Value
+	}
+	return ""
+}
+
+func tagText(tag string) string {
+	if tag == "" {
+		return "no tag"
+	}
+	return tag
+}
+
+func formatScalar(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string, []byte:
+		return fmt.Sprintf("%q", v)
+	}
+	return fmt.Sprint(v)
+}
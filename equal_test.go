@@ -0,0 +1,118 @@
+package yaml_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+)
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		a      string
+		b      string
+		opts   []yaml.EqualOption
+		expect bool
+	}{
+		{"a: 1\nb: [x, y]\n", "b:\n- x\n- y\na: 1\n", nil, true},
+		{"a: 1\nb: 2\n", "b: 2\na: 1\n", []yaml.EqualOption{yaml.StrictKeyOrder(true)}, false},
+		{"a: 1\nb: 2\n", "a: 1\nb: 2 # comment\n", []yaml.EqualOption{yaml.StrictKeyOrder(true)}, true},
+		{"a: {b: c}\n", "a:\n    b: 'c'\n", nil, true},
+		{"a: 1\n", "a: \"1\"\n", nil, false},
+		{"a: 1\n", "a: !!str 1\n", nil, false},
+		{"a: \"1\"\n", "a: !!str 1\n", nil, true},
+		{"a: \"1\"\n", "a: !!str 1\n", []yaml.EqualOption{yaml.StrictTag(true)}, false},
+		{"a: !foo 1\n", "a: 1\n", nil, true},
+		{"a: !foo 1\n", "a: 1\n", []yaml.EqualOption{yaml.StrictTag(true)}, false},
+		{"a: &x {b: 1}\nc: *x\n", "a: {b: 1}\nc: {b: 1}\n", nil, true},
+		{"base: &base {a: 1, b: 2}\nd:\n  <<: *base\n  b: 3\n", "base: {a: 1, b: 2}\nd: {a: 1, b: 3}\n", nil, true},
+		{"a: null\n", "a:\n", nil, true},
+		{"a: .nan\n", "a: .NaN\n", nil, true},
+		{"a: [1, 2]\n", "a: [2, 1]\n", nil, false},
+		{"a: [1]\n", "a: {b: 1}\n", nil, false},
+		{"a: 1\n", "a: 1\nb: 2\n", nil, false},
+		{"a: 1\n---\nb: 2\n", "a: 1\n", nil, false},
+		{"a: 1\n---\nb: 2\n", "---\na: 1\n---\nb: 2\n", nil, true},
+	}
+	for _, test := range tests {
+		equal, err := yaml.Equal([]byte(test.a), []byte(test.b), test.opts...)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if equal != test.expect {
+			t.Fatalf("unexpected result of %q and %q: %t", test.a, test.b, equal)
+		}
+	}
+	t.Run("invalid source", func(t *testing.T) {
+		if _, err := yaml.Equal([]byte("a: *x\n"), []byte("a: 1\n")); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("excessive aliasing", func(t *testing.T) {
+		src := []byte(`a: &a ["lol","lol","lol","lol","lol","lol","lol","lol","lol","lol"]
+b: &b [*a,*a,*a,*a,*a,*a,*a,*a,*a,*a]
+c: &c [*b,*b,*b,*b,*b,*b,*b,*b,*b,*b]
+d: &d [*c,*c,*c,*c,*c,*c,*c,*c,*c,*c]
+e: &e [*d,*d,*d,*d,*d,*d,*d,*d,*d,*d]
+f: &f [*e,*e,*e,*e,*e,*e,*e,*e,*e,*e]
+g: &g [*f,*f,*f,*f,*f,*f,*f,*f,*f,*f]
+`)
+		_, err := yaml.Equal(src, src)
+		if code := yaml.ErrorCodeOf(err); code != yaml.ErrCodeExcessiveAliasing {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := yaml.Normalize(src); yaml.ErrorCodeOf(err) != yaml.ErrCodeExcessiveAliasing {
+			t.Fatalf("unexpected error of Normalize: %v", err)
+		}
+	})
+	t.Run("deep aliasing", func(t *testing.T) {
+		var b strings.Builder
+		b.WriteString("a0: &a0 x\n")
+		for i := 1; i <= yaml.DefaultAliasDepthLimit+1; i++ {
+			fmt.Fprintf(&b, "a%d: &a%d [*a%d]\n", i, i, i-1)
+		}
+		_, err := yaml.Equal([]byte(b.String()), []byte(b.String()))
+		if code := yaml.ErrorCodeOf(err); code != yaml.ErrCodeExcessiveAliasing {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestDiff(t *testing.T) {
+	expected := "a: &x {b: 1}\nc: [1, 2]\nd: *x\n---\ne: 1\n"
+	actual := "a: {b: 1}\nc: [1, 3]\nd: {b: 2}\nf: g\n"
+	diffs, err := yaml.Diff([]byte(expected), []byte(actual))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	type difference struct {
+		document int
+		path     string
+		message  string
+	}
+	expectedDiffs := []difference{
+		{0, "$.c[1]", "expected 2 but got 3"},
+		{0, "$.d.b", "expected 1 but got 2"},
+		{0, "$.f", `unexpected key "f"`},
+		{1, "$", "document doesn't exist"},
+	}
+	if len(diffs) != len(expectedDiffs) {
+		t.Fatalf("unexpected number of differences: %d", len(diffs))
+	}
+	for idx, diff := range diffs {
+		actualDiff := difference{diff.Document, diff.Path, diff.Message}
+		if actualDiff != expectedDiffs[idx] {
+			t.Fatalf("unexpected difference: expected %+v but got %+v", expectedDiffs[idx], actualDiff)
+		}
+	}
+	t.Run("subset", func(t *testing.T) {
+		equal, err := yaml.Equal([]byte("a: {b: 1}\n"), []byte("a: {b: 1, c: 2}\nd: 3\n"), yaml.Subset(true))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if !equal {
+			t.Fatal("expected subset")
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package yaml
+
+import (
+	"github.com/goccy/go-yaml/internal/errors"
+	"golang.org/x/xerrors"
+)
+
+// ErrorCode stable identifier for the kind of error returned by this package.
+// Codes and the message formats bound to them are kept across versions,
+// so tools can rely on them instead of matching error strings.
+type ErrorCode = errors.Code
+
+const (
+	// ErrCodeInvalidFlowMappingValue the value in flow mapping is not mapping value ( e.g. `{a: b, c}` )
+	ErrCodeInvalidFlowMappingValue = errors.CodeInvalidFlowMappingValue
+	// ErrCodeInvalidKeyName the key spreads over multiple lines
+	ErrCodeInvalidKeyName = errors.CodeInvalidKeyName
+	// ErrCodeUndefinedKey the mapping value has no key
+	ErrCodeUndefinedKey = errors.CodeUndefinedKey
+	// ErrCodeNonScalarKey the key is not scalar value
+	ErrCodeNonScalarKey = errors.CodeNonScalarKey
+	// ErrCodeMissingMappingValueToken the key is not followed by ':'
+	ErrCodeMissingMappingValueToken = errors.CodeMissingMappingValueToken
+	// ErrCodeUndefinedAnchorName the anchor has no name
+	ErrCodeUndefinedAnchorName = errors.CodeUndefinedAnchorName
+	// ErrCodeUndefinedAnchorValue the anchor has no value
+	ErrCodeUndefinedAnchorValue = errors.CodeUndefinedAnchorValue
+	// ErrCodeUndefinedAliasName the alias has no name
+	ErrCodeUndefinedAliasName = errors.CodeUndefinedAliasName
+	// ErrCodeDocumentNotStarted the directive is not followed by document header ( `---` )
+	ErrCodeDocumentNotStarted = errors.CodeDocumentNotStarted
+	// ErrCodeRequiredStringToken the literal or folded block has no string value
+	ErrCodeRequiredStringToken = errors.CodeRequiredStringToken
+	// ErrCodeValidation the value is rejected by StructValidator
+	ErrCodeValidation = errors.CodeValidation
+	// ErrCodeNullValue null is decoded into non-pointer field with NullPolicyError
+	ErrCodeNullValue = errors.CodeNullValue
+	// ErrCodeTabIndentation the tab character is used for indentation
+	ErrCodeTabIndentation = errors.CodeTabIndentation
+	// ErrCodeArrayLength the length of sequence is different from the destination array with ArrayLengthPolicy
+	ErrCodeArrayLength = errors.CodeArrayLength
+	// ErrCodeTypeMismatch the scalar cannot be decoded into the destination type ( e.g. `a: foo` for int )
+	ErrCodeTypeMismatch = errors.CodeTypeMismatch
+	// ErrCodeOverflowNumber the number overflows the destination type ( e.g. `a: 256` for uint8 )
+	ErrCodeOverflowNumber = errors.CodeOverflowNumber
+	// ErrCodeUnexpectedNodeType the kind of node is different from the destination ( e.g. sequence for map )
+	ErrCodeUnexpectedNodeType = errors.CodeUnexpectedNodeType
+	// ErrCodeUnknownField the key doesn't exist in the destination struct with DisallowUnknownField
+	ErrCodeUnknownField = errors.CodeUnknownField
+	// ErrCodeUnexpectedValue the value follows the value of document without document header ( e.g. "a:\n  b: c\n d" )
+	ErrCodeUnexpectedValue = errors.CodeUnexpectedValue
+	// ErrCodeExcessiveAliasing the expansion of aliases exceeds the limit set by MaxAliasCount or AliasDepthLimit
+	ErrCodeExcessiveAliasing = errors.CodeExcessiveAliasing
+	// ErrCodeDuplicateAnchor the anchor is defined twice in one document with DuplicateAnchorPolicyError
+	ErrCodeDuplicateAnchor = errors.CodeDuplicateAnchor
+	// ErrCodeDuplicateKey the key is defined twice in one mapping with DisallowDuplicateKey
+	ErrCodeDuplicateKey = errors.CodeDuplicateKey
+	// ErrCodeExcessiveNesting the mappings and sequences are nested deeper than the limit set by MaxDepth
+	ErrCodeExcessiveNesting = errors.CodeExcessiveNesting
+	// ErrCodeUnsafeTag the tag is not defined by YAML ( e.g. `!foo` ) with SafeMode
+	ErrCodeUnsafeTag = errors.CodeUnsafeTag
+	// ErrCodeInvalidJSONValue the value or key cannot be represented by JSON ( e.g. `.inf` ) with JSON option or YAMLToJSON
+	ErrCodeInvalidJSONValue = errors.CodeInvalidJSONValue
+	// ErrCodeUnexpectedFlowToken the token can't be a value of flow collection ( e.g. `}` in `[a}]` )
+	ErrCodeUnexpectedFlowToken = errors.CodeUnexpectedFlowToken
+	// ErrCodeInvalidTimeValue the string cannot be parsed as time.Time or time.Duration ( e.g. `1 hour` )
+	ErrCodeInvalidTimeValue = errors.CodeInvalidTimeValue
+	// ErrCodeInvalidBinaryValue the value tagged by `!!binary` isn't base64 text
+	ErrCodeInvalidBinaryValue = errors.CodeInvalidBinaryValue
+	// ErrCodeInvalidDirective the directive is malformed or `%YAML` has the unsupported version
+	ErrCodeInvalidDirective = errors.CodeInvalidDirective
+	// ErrCodeUndefinedTagHandle the named tag handle ( e.g. `!e!` ) isn't defined by `%TAG` directive
+	ErrCodeUndefinedTagHandle = errors.CodeUndefinedTagHandle
+)
+
+// SyntaxError error which has code and the position in source.
+// Use xerrors.As to get it from the error returned by this package,
+// and FormatError to print the source with the position annotated.
+type SyntaxError = errors.SyntaxError
+
+// ErrorRelatedToken token related to SyntaxError ( e.g. the first definition of duplicated key )
+type ErrorRelatedToken = errors.RelatedToken
+
+// ErrorCodes returns all error codes
+func ErrorCodes() []ErrorCode {
+	return errors.Codes()
+}
+
+// ErrorCodeOf returns the code of error returned by this package.
+// If err has no code, returns empty code.
+func ErrorCodeOf(err error) ErrorCode {
+	var coder interface {
+		Code() errors.Code
+	}
+	if xerrors.As(err, &coder) {
+		return coder.Code()
+	}
+	return errors.CodeUnknown
+}
@@ -0,0 +1,97 @@
+package yaml
+
+import (
+	"bytes"
+	"fmt"
+	"hash"
+	"sort"
+	"strconv"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/internal/errors"
+)
+
+// Hash writes the semantic content of node to h, so nodes which are equal by Equal produce the same hash.
+// Formatting, comments and representation by anchors, aliases and merge keys don't affect the hash.
+// Order of mapping keys is ignored unless StrictKeyOrder option is enabled, and tags are hashed only if StrictTag option is enabled.
+// It is useful to make cache keys or to detect changes of configuration.
+// If node is not *ast.Document, aliases in node must refer to the anchors defined in node.
+func Hash(node ast.Node, h hash.Hash, opts ...EqualOption) error {
+	e := &equality{decoder: NewDecoder(nil)}
+	for _, opt := range opts {
+		opt(e)
+	}
+	doc, ok := node.(*ast.Document)
+	if !ok {
+		doc = &ast.Document{Body: node}
+	}
+	expanded, err := expandAliases(doc)
+	if err != nil {
+		return errors.Wrapf(err, "failed to expand aliases")
+	}
+	if _, err := h.Write(e.appendCanonical(nil, expanded.Body)); err != nil {
+		return errors.Wrapf(err, "failed to write to hash")
+	}
+	return nil
+}
+
+// appendCanonical appends the representation of node which doesn't depend on formatting to b.
+// Each part is prefixed by its length, so different values never have the same representation.
+func (e *equality) appendCanonical(b []byte, node ast.Node) []byte {
+	if e.isStrictTag {
+		b = appendCanonicalText(b, nodeTag(node))
+	}
+	switch nodeKind(node) {
+	case KindMapping:
+		entries := e.mappingEntries(node)
+		pairs := make([][]byte, 0, len(entries))
+		for _, entry := range entries {
+			pair := appendCanonicalScalar(nil, entry.key)
+			pairs = append(pairs, e.appendCanonical(pair, entry.value))
+		}
+		if !e.isStrictKeyOrder {
+			// keys are unique and have prefix-free representation, so pairs are sorted by keys
+			sort.Slice(pairs, func(i, j int) bool {
+				return bytes.Compare(pairs[i], pairs[j]) < 0
+			})
+		}
+		b = append(b, 'm')
+		b = strconv.AppendInt(b, int64(len(pairs)), 10)
+		for _, pair := range pairs {
+			b = append(b, pair...)
+		}
+		return b
+	case KindSequence:
+		values := untaggedNode(node).(*ast.SequenceNode).Values
+		b = append(b, 's')
+		b = strconv.AppendInt(b, int64(len(values)), 10)
+		for _, value := range values {
+			b = e.appendCanonical(b, value)
+		}
+		return b
+	}
+	return appendCanonicalScalar(b, e.decoder.nodeToScalarValue(node))
+}
+
+func appendCanonicalScalar(b []byte, v interface{}) []byte {
+	b = appendCanonicalText(b, fmt.Sprintf("%T", v))
+	switch value := v.(type) {
+	case nil:
+		return b
+	case float64:
+		if value == 0 {
+			// -0.0 is equal to 0.0
+			value = 0
+		}
+		return appendCanonicalText(b, strconv.FormatFloat(value, 'g', -1, 64))
+	case []byte:
+		return appendCanonicalText(b, string(value))
+	}
+	return appendCanonicalText(b, fmt.Sprint(v))
+}
+
+func appendCanonicalText(b []byte, text string) []byte {
+	b = strconv.AppendInt(b, int64(len(text)), 10)
+	b = append(b, ':')
+	return append(b, text...)
+}
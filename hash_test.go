@@ -0,0 +1,66 @@
+package yaml_test
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+func hashOf(t *testing.T, src string, opts ...yaml.EqualOption) string {
+	t.Helper()
+	f, err := parser.ParseBytes([]byte(src), 0)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	h := sha256.New()
+	if err := yaml.Hash(f.Docs[0], h, opts...); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return string(h.Sum(nil))
+}
+
+func TestHash(t *testing.T) {
+	tests := []struct {
+		a      string
+		b      string
+		opts   []yaml.EqualOption
+		expect bool
+	}{
+		{"a: 1\nb: [x, y]\n", "b:\n- x\n- 'y'\na: 1 # comment\n", nil, true},
+		{"a: 1\nb: 2\n", "b: 2\na: 1\n", []yaml.EqualOption{yaml.StrictKeyOrder(true)}, false},
+		{"a: &x {b: 1}\nc: *x\n", "a: {b: 1}\nc: {b: 1}\n", nil, true},
+		{"base: &base {a: 1}\nd:\n  <<: *base\n  b: 2\n", "base: {a: 1}\nd: {b: 2, a: 1}\n", nil, true},
+		{"a: 1\n", "a: \"1\"\n", nil, false},
+		{"a: \"1\"\n", "a: !!str 1\n", nil, true},
+		{"a: !foo 1\n", "a: 1\n", nil, true},
+		{"a: !foo 1\n", "a: 1\n", []yaml.EqualOption{yaml.StrictTag(true)}, false},
+		{"a: [b, c]\n", "a: [bc]\n", nil, false},
+		{"a: {b: c}\n", "a: [b, c]\n", nil, false},
+		{"a: .nan\n", "a: .NaN\n", nil, true},
+		{"a:\n", "a: null\n", nil, true},
+		{"a: ''\n", "a: null\n", nil, false},
+		{"a: -0.0\n", "a: 0.0\n", nil, true},
+	}
+	for _, test := range tests {
+		equal := hashOf(t, test.a, test.opts...) == hashOf(t, test.b, test.opts...)
+		if equal != test.expect {
+			t.Fatalf("unexpected result of hash of %q and %q: %t", test.a, test.b, equal)
+		}
+	}
+	t.Run("node in document", func(t *testing.T) {
+		f, err := parser.ParseBytes([]byte("a: &x {b: 1}\nc: *x\n"), 0)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		body := f.Docs[0].Body.(*ast.MappingNode)
+		if err := yaml.Hash(body, sha256.New()); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if err := yaml.Hash(body.Values[1], sha256.New()); err == nil {
+			t.Fatal("expected error for alias which refers to anchor outside of node")
+		}
+	})
+}
@@ -0,0 +1,159 @@
+package errors
+
+import "fmt"
+
+// Code stable identifier for the kind of error.
+// Codes and the message formats bound to them are part of the API,
+// so existing codes must not be renamed and their messages must not be changed.
+type Code string
+
+const (
+	// CodeUnknown code for errors not created by this package
+	CodeUnknown Code = ""
+	// CodeInvalidFlowMappingValue code for the value in flow mapping which is not mapping value
+	CodeInvalidFlowMappingValue Code = "invalid-flow-mapping-value"
+	// CodeInvalidKeyName code for the key spread over multiple lines
+	CodeInvalidKeyName Code = "invalid-key-name"
+	// CodeUndefinedKey code for the mapping value without key
+	CodeUndefinedKey Code = "undefined-key"
+	// CodeNonScalarKey code for the key which is not scalar
+	CodeNonScalarKey Code = "non-scalar-key"
+	// CodeMissingMappingValueToken code for the key without ':'
+	CodeMissingMappingValueToken Code = "missing-mapping-value-token"
+	// CodeUndefinedAnchorName code for the anchor without name
+	CodeUndefinedAnchorName Code = "undefined-anchor-name"
+	// CodeUndefinedAnchorValue code for the anchor without value
+	CodeUndefinedAnchorValue Code = "undefined-anchor-value"
+	// CodeUndefinedAliasName code for the alias without name
+	CodeUndefinedAliasName Code = "undefined-alias-name"
+	// CodeDocumentNotStarted code for the directive not followed by document header
+	CodeDocumentNotStarted Code = "document-not-started"
+	// CodeRequiredStringToken code for the literal or folded block without string value
+	CodeRequiredStringToken Code = "required-string-token"
+	// CodeValidation code for the error reported by StructValidator
+	CodeValidation Code = "validation"
+	// CodeNullValue code for the null rejected by the non-pointer destination
+	CodeNullValue Code = "null-value"
+	// CodeTabIndentation code for the tab character used for indentation
+	CodeTabIndentation Code = "tab-indentation"
+	// CodeArrayLength code for the sequence whose length is different from the destination array
+	CodeArrayLength Code = "array-length"
+	// CodeTypeMismatch code for the scalar which cannot be decoded into the destination type
+	CodeTypeMismatch Code = "type-mismatch"
+	// CodeOverflowNumber code for the number which overflows the destination type
+	CodeOverflowNumber Code = "overflow-number"
+	// CodeUnexpectedNodeType code for the node whose kind is different from the destination ( e.g. sequence for map )
+	CodeUnexpectedNodeType Code = "unexpected-node-type"
+	// CodeUnknownField code for the key which doesn't exist in the destination struct
+	CodeUnknownField Code = "unknown-field"
+	// CodeUnexpectedValue code for the value following the value of document at the lower indent ( e.g. "a:\n  b: c\n d" )
+	CodeUnexpectedValue Code = "unexpected-value"
+	// CodeExcessiveAliasing code for the alias whose expansion exceeds the limit of the number or the depth
+	CodeExcessiveAliasing Code = "excessive-aliasing"
+	// CodeDuplicateAnchor code for the anchor defined twice in one document
+	CodeDuplicateAnchor Code = "duplicate-anchor"
+	// CodeDuplicateKey code for the key defined twice in one mapping
+	CodeDuplicateKey Code = "duplicate-key"
+	// CodeExcessiveNesting code for the mapping or sequence nested deeper than the limit
+	CodeExcessiveNesting Code = "excessive-nesting"
+	// CodeUnsafeTag code for the tag rejected in safe mode
+	CodeUnsafeTag Code = "unsafe-tag"
+	// CodeInvalidJSONValue code for the value or key which JSON cannot represent ( e.g. `.inf` )
+	CodeInvalidJSONValue Code = "invalid-json-value"
+	// CodeUnexpectedFlowToken code for the token which can't be a value of flow collection ( e.g. `}` in `[a}]` )
+	CodeUnexpectedFlowToken Code = "unexpected-flow-token"
+	// CodeInvalidTimeValue code for the string which cannot be parsed as time.Time or time.Duration ( e.g. `1 hour` )
+	CodeInvalidTimeValue Code = "invalid-time-value"
+	// CodeInvalidBinaryValue code for the value tagged by `!!binary` which isn't base64 text
+	CodeInvalidBinaryValue Code = "invalid-binary-value"
+	// CodeInvalidDirective code for the malformed directive or the unsupported version of `%YAML`
+	CodeInvalidDirective Code = "invalid-directive"
+	// CodeUndefinedTagHandle code for the named tag handle ( e.g. `!e!` ) which isn't defined by `%TAG`
+	CodeUndefinedTagHandle Code = "undefined-tag-handle"
+)
+
+var codeToMessageFormat = map[Code]string{
+	CodeInvalidFlowMappingValue:  "failed to parse flow mapping value node",
+	CodeInvalidKeyName:           "unexpected key name",
+	CodeUndefinedKey:             "unexpected mapping 'key'. key is undefined",
+	CodeNonScalarKey:             "unexpected mapping 'key', key is not scalar value",
+	CodeMissingMappingValueToken: "could not found expected ':' token",
+	CodeUndefinedAnchorName:      "unexpected anchor. anchor name is undefined",
+	CodeUndefinedAnchorValue:     "unexpected anchor. anchor value is undefined",
+	CodeUndefinedAliasName:       "unexpected alias. alias name is undefined",
+	CodeDocumentNotStarted:       "unexpected directive value. document not started",
+	CodeRequiredStringToken:      "unexpected token. required string token",
+	CodeValidation:               "%s",
+	CodeNullValue:                "cannot decode null into %s",
+	CodeTabIndentation:           "unexpected tab character. tabs cannot be used for indentation",
+	CodeArrayLength:              "cannot decode sequence of %d elements into %s",
+	CodeTypeMismatch:             "cannot decode %s node into %s",
+	CodeOverflowNumber:           "%s overflows %s",
+	CodeUnexpectedNodeType:       "unexpected %s node. %s node is required",
+	CodeUnknownField:             "unknown field %q",
+	CodeUnexpectedValue:          "unexpected %s node. it is not allowed after the %s node at [%d:%d] in this context",
+	CodeExcessiveAliasing:        "excessive aliasing. the %s of alias expansions exceeds the limit %d",
+	CodeDuplicateAnchor:          "anchor %q is already defined at [%d:%d]",
+	CodeDuplicateKey:             "mapping key %q is already defined at [%d:%d]",
+	CodeExcessiveNesting:         "excessive nesting. the depth of mappings and sequences exceeds the limit %d",
+	CodeUnsafeTag:                "tag %s is not allowed in safe mode",
+	CodeInvalidJSONValue:         "%s %s cannot be represented by JSON",
+	CodeUnexpectedFlowToken:      "unexpected %q %s",
+	CodeInvalidTimeValue:         "cannot parse %q as %s",
+	CodeInvalidBinaryValue:       "cannot decode %q as base64",
+	CodeInvalidDirective:         "invalid %%%s directive: %s",
+	CodeUndefinedTagHandle:       "tag handle %q is not defined by %%TAG directive",
+}
+
+// Codes returns all codes defined by this package
+func Codes() []Code {
+	return []Code{
+		CodeInvalidFlowMappingValue,
+		CodeInvalidKeyName,
+		CodeUndefinedKey,
+		CodeNonScalarKey,
+		CodeMissingMappingValueToken,
+		CodeUndefinedAnchorName,
+		CodeUndefinedAnchorValue,
+		CodeUndefinedAliasName,
+		CodeDocumentNotStarted,
+		CodeRequiredStringToken,
+		CodeValidation,
+		CodeNullValue,
+		CodeTabIndentation,
+		CodeArrayLength,
+		CodeTypeMismatch,
+		CodeOverflowNumber,
+		CodeUnexpectedNodeType,
+		CodeUnknownField,
+		CodeUnexpectedValue,
+		CodeExcessiveAliasing,
+		CodeDuplicateAnchor,
+		CodeDuplicateKey,
+		CodeExcessiveNesting,
+		CodeUnsafeTag,
+		CodeInvalidJSONValue,
+		CodeUnexpectedFlowToken,
+		CodeInvalidTimeValue,
+		CodeInvalidBinaryValue,
+		CodeInvalidDirective,
+		CodeUndefinedTagHandle,
+	}
+}
+
+// MessageFormat returns format string of message bound to code
+func (c Code) MessageFormat() string {
+	return codeToMessageFormat[c]
+}
+
+// Message build message by MessageFormat and arguments
+func (c Code) Message(args ...interface{}) string {
+	format, exists := codeToMessageFormat[c]
+	if !exists {
+		return string(c)
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
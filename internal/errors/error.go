@@ -3,6 +3,7 @@ package errors
 import (
 	"bytes"
 	"fmt"
+	"strings"
 
 	"github.com/goccy/go-yaml/printer"
 	"github.com/goccy/go-yaml/token"
@@ -28,11 +29,13 @@ func Wrapf(err error, msg string, args ...interface{}) error {
 	}
 }
 
-// ErrSyntax create syntax error instance with message and token
-func ErrSyntax(msg string, tk *token.Token) *syntaxError {
+// ErrSyntax create syntax error instance with code and token.
+// message is built from the format bound to code and args
+func ErrSyntax(code Code, tk *token.Token, args ...interface{}) *syntaxError {
 	return &syntaxError{
 		baseError: &baseError{},
-		msg:       msg,
+		code:      code,
+		msg:       code.Message(args...),
 		token:     tk,
 		frame:     xerrors.Caller(1),
 	}
@@ -141,6 +144,10 @@ func (e *wrapError) Format(state fmt.State, verb rune) {
 	xerrors.FormatError(e, &wrapState{org: state}, verb)
 }
 
+func (e *wrapError) Unwrap() error {
+	return e.nextErr
+}
+
 func (e *wrapError) Error() string {
 	var buf bytes.Buffer
 	e.PrettyPrint(&Sink{&buf}, defaultColorize, defaultIncludeSource)
@@ -149,9 +156,79 @@ func (e *wrapError) Error() string {
 
 type syntaxError struct {
 	*baseError
-	msg   string
-	token *token.Token
-	frame xerrors.Frame
+	code    Code
+	msg     string
+	token   *token.Token
+	hint    string
+	related []*RelatedToken
+	err     error
+	frame   xerrors.Frame
+}
+
+// RelatedToken token related to the error ( e.g. the first definition of duplicated key )
+type RelatedToken struct {
+	Token   *token.Token
+	Message string
+}
+
+// SetHint sets the hint built by fn to the syntax error in err to help users to fix the source.
+// It does nothing if err has no syntax error or the hint is already set.
+func SetHint(err error, fn func(SyntaxError) string) {
+	var syntaxErr *syntaxError
+	if !xerrors.As(err, &syntaxErr) || syntaxErr.hint != "" {
+		return
+	}
+	syntaxErr.hint = fn(syntaxErr)
+}
+
+// Wrap set err as the cause of error. the cause is found by xerrors.Is and xerrors.As
+func (e *syntaxError) Wrap(err error) *syntaxError {
+	e.err = err
+	return e
+}
+
+// AddRelated adds the token related to the error with the message describing the relation
+func (e *syntaxError) AddRelated(tk *token.Token, msg string) *syntaxError {
+	e.related = append(e.related, &RelatedToken{Token: tk, Message: msg})
+	return e
+}
+
+// Unwrap returns the cause of error
+func (e *syntaxError) Unwrap() error {
+	return e.err
+}
+
+// Code returns stable identifier of error
+func (e *syntaxError) Code() Code {
+	return e.code
+}
+
+// Message returns message without position and source
+func (e *syntaxError) Message() string {
+	return e.msg
+}
+
+// Hint returns the suggestion to fix the source. returns empty string if there is no hint
+func (e *syntaxError) Hint() string {
+	return e.hint
+}
+
+// Related returns the tokens related to the error. returns nil if there is no related token
+func (e *syntaxError) Related() []*RelatedToken {
+	return e.related
+}
+
+// Token returns token where error occurred
+func (e *syntaxError) Token() *token.Token {
+	return e.token
+}
+
+// Position returns position where error occurred. returns nil if the token has no position
+func (e *syntaxError) Position() *token.Position {
+	if e.token == nil {
+		return nil
+	}
+	return e.token.Position
 }
 
 func (e *syntaxError) PrettyPrint(p xerrors.Printer, colored, inclSource bool) error {
@@ -167,11 +244,24 @@ func (e *syntaxError) FormatError(p xerrors.Printer) error {
 		inclSource = mp.inclSource
 	}
 
-	pos := fmt.Sprintf("[%d:%d] ", e.token.Position.Line, e.token.Position.Column)
-	msg := pp.PrintErrorMessage(fmt.Sprintf("%s%s", pos, e.msg), colored)
+	pos := e.Position()
+	if pos == nil {
+		// synthetic node has no position and source
+		p.Print(pp.PrintErrorMessage(e.msg, colored))
+		return nil
+	}
+	msg := pp.PrintErrorMessage(fmt.Sprintf("[%d:%d] %s", pos.Line, pos.Column, e.msg), colored)
 	if inclSource {
 		msg += "\n" + pp.PrintErrorToken(e.token, colored)
 	}
+	if e.hint != "" {
+		// source printed by PrintErrorToken ends with new line
+		if strings.HasSuffix(msg, "\n") {
+			msg += "hint: " + e.hint + "\n"
+		} else {
+			msg += "\nhint: " + e.hint
+		}
+	}
 	p.Print(msg)
 
 	if e.verb == 'v' && e.state.Flag('+') {
@@ -182,6 +272,17 @@ func (e *syntaxError) FormatError(p xerrors.Printer) error {
 	return nil
 }
 
+// SyntaxError interface of error which has code and position
+type SyntaxError interface {
+	error
+	Code() Code
+	Message() string
+	Hint() string
+	Related() []*RelatedToken
+	Token() *token.Token
+	Position() *token.Position
+}
+
 type PrettyPrinter interface {
 	PrettyPrint(xerrors.Printer, bool, bool) error
 }
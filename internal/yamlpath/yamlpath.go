@@ -0,0 +1,15 @@
+package yamlpath
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AppendKey appends key to YAMLPath text ( e.g. `$.a` ).
+// The key which has the characters of YAMLPath syntax is quoted ( e.g. `$['a.b']` ).
+func AppendKey(path, key string) string {
+	if strings.ContainsAny(key, ".[]'$ ") {
+		return fmt.Sprintf("%s['%s']", path, key)
+	}
+	return fmt.Sprintf("%s.%s", path, key)
+}
@@ -0,0 +1,168 @@
+package yaml
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/internal/errors"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/printer"
+	"golang.org/x/xerrors"
+)
+
+// YAMLToJSON converts the first document in src to JSON in the same way as JSON option of Encoder.
+// It converts the parsed nodes without decoding into Go values,
+// so the order of keys and the text of numbers ( e.g. `1.50` ) are kept and errors have the position in src.
+// Aliases and merge keys are expanded up to DefaultMaxAliasCount expansions. Empty document is converted to `null`.
+func YAMLToJSON(src []byte) ([]byte, error) {
+	f, err := parser.ParseBytes(src, 0)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse yaml")
+	}
+	var body ast.Node
+	for _, doc := range f.Docs {
+		if doc.Body != nil {
+			body = doc.Body
+			break
+		}
+	}
+	if body != nil {
+		counter := &aliasExpansionCounter{anchors: map[string]int{}}
+		ast.WalkAll(counter, body)
+		if counter.err != nil {
+			return nil, counter.err
+		}
+	}
+	node, err := NewEncoder(nil, JSON()).encodeJSON(body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to convert to JSON")
+	}
+	var p printer.Printer
+	b := p.PrintNode(node)
+	if !bytes.HasSuffix(b, []byte("\n")) {
+		b = append(b, '\n')
+	}
+	return b, nil
+}
+
+// aliasExpansionCounter counts aliases expanded by ast.ExpandAliases including the aliases in the expanded values,
+// and reports error at the alias which exceeds DefaultMaxAliasCount.
+type aliasExpansionCounter struct {
+	anchors map[string]int // number of expansions in the anchored value
+	count   int
+	err     error
+}
+
+func (c *aliasExpansionCounter) Visit(node ast.Node) ast.Visitor {
+	if c.err != nil {
+		return nil
+	}
+	switch n := node.(type) {
+	case *ast.AnchorNode:
+		count := c.count
+		ast.WalkAll(c, n.Value)
+		c.anchors[n.Name.GetToken().Value] = c.count - count
+		return nil
+	case *ast.AliasNode:
+		c.count += 1 + c.anchors[n.Value.GetToken().Value]
+		if c.count > DefaultMaxAliasCount {
+			c.err = errors.ErrSyntax(errors.CodeExcessiveAliasing, n.GetToken(), "number", DefaultMaxAliasCount)
+		}
+		return nil
+	}
+	return c
+}
+
+// JSONToYAML converts JSON in src to YAML of block style.
+// The order of keys and the text of numbers ( e.g. `1.50` ) are kept. Syntax error has the position in src ( e.g. `[2:5]` ).
+// Empty input is converted to `null`.
+func JSONToYAML(src []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+	v, err := decodeJSONValue(dec)
+	if err != nil && err != io.EOF {
+		return nil, jsonSyntaxError(src, err)
+	}
+	if err == nil {
+		if _, err := dec.Token(); err != io.EOF {
+			if err != nil {
+				return nil, jsonSyntaxError(src, err)
+			}
+			return nil, xerrors.New("invalid JSON: unexpected value after top-level value")
+		}
+	}
+	b, err := Marshal(v)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to convert to YAML")
+	}
+	return b, nil
+}
+
+// decodeJSONValue decodes the next value of dec. Objects are decoded into MapSlice to keep the order of keys.
+func decodeJSONValue(dec *json.Decoder) (interface{}, error) {
+	tk, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tk {
+	case json.Delim('{'):
+		m := MapSlice{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, noEOF(err)
+			}
+			m = append(m, MapItem{Key: key, Value: value})
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, noEOF(err)
+		}
+		return m, nil
+	case json.Delim('['):
+		s := []interface{}{}
+		for dec.More() {
+			value, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, noEOF(err)
+			}
+			s = append(s, value)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, noEOF(err)
+		}
+		return s, nil
+	}
+	return tk, nil
+}
+
+// noEOF converts io.EOF in the middle of value to io.ErrUnexpectedEOF
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// jsonSyntaxError adds the position in src ( e.g. `[2:5]` ) to the syntax error returned by encoding/json
+func jsonSyntaxError(src []byte, err error) error {
+	syntaxErr, ok := err.(*json.SyntaxError)
+	if !ok {
+		return xerrors.Errorf("invalid JSON: %w", err)
+	}
+	offset := int(syntaxErr.Offset)
+	if offset > len(src) {
+		offset = len(src)
+	}
+	line := bytes.Count(src[:offset], []byte("\n")) + 1
+	column := offset - bytes.LastIndexByte(src[:offset], '\n') - 1
+	if column < 1 {
+		column = 1
+	}
+	return xerrors.Errorf("[%d:%d] invalid JSON: %s", line, column, syntaxErr.Error())
+}
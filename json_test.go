@@ -0,0 +1,116 @@
+package yaml_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+)
+
+func TestYAMLToJSON(t *testing.T) {
+	tests := []struct {
+		source string
+		expect string
+	}{
+		{
+			source: "b: 1.50\na: [0x1F, 1_000, ~, true]\n",
+			expect: `{"b": 1.50, "a": [31, 1000, null, true]}`,
+		},
+		{
+			source: "a: &x {k: v}\nb: *x\nc:\n  <<: *x\n  l: w\n",
+			expect: `{"a": {"k": "v"}, "b": {"k": "v"}, "c": {"k": "v", "l": "w"}}`,
+		},
+		{
+			source: "1: |\n  a\n  b\n2: 'it''s'\n3: \"\\t\\u0001\"\n",
+			expect: `{"1": "a\nb\n", "2": "it's", "3": "\t\u0001"}`,
+		},
+		{
+			source: "- a: 1\n  b: [x, y]\n- !!str 2\n",
+			expect: `[{"a": 1, "b": ["x", "y"]}, "2"]`,
+		},
+		{
+			source: "",
+			expect: "null",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			b, err := yaml.YAMLToJSON([]byte(test.source))
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if string(b) != test.expect+"\n" {
+				t.Fatalf("unexpected output. expect %s but got %s", test.expect, string(b))
+			}
+		})
+	}
+	t.Run("error", func(t *testing.T) {
+		_, err := yaml.YAMLToJSON([]byte("a:\n  b: .inf\n"))
+		if code := yaml.ErrorCodeOf(err); code != yaml.ErrCodeInvalidJSONValue {
+			t.Fatalf("unexpected code: %q", code)
+		}
+		if !strings.Contains(err.Error(), "[2:6]") {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		laughs := "a0: &a0 [lol]\n"
+		for i := 1; i <= 10; i++ {
+			laughs += fmt.Sprintf("a%[1]d: &a%[1]d [*a%[2]d, *a%[2]d, *a%[2]d, *a%[2]d]\n", i, i-1)
+		}
+		_, err = yaml.YAMLToJSON([]byte(laughs))
+		if code := yaml.ErrorCodeOf(err); code != yaml.ErrCodeExcessiveAliasing {
+			t.Fatalf("unexpected code: %q", code)
+		}
+	})
+}
+
+func TestJSONToYAML(t *testing.T) {
+	src := `{"b": 1.50, "a": [1e3, -0, null, true, "1", "yes", ""], "c": {}, "d": [], "1": {"x": "a\nb"}}`
+	b, err := yaml.JSONToYAML([]byte(src))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expect := `
+b: 1.50
+a:
+- 1.0e3
+- -0
+- null
+- true
+- "1"
+- "yes"
+- ""
+c: {}
+d: []
+"1":
+  x: "a\nb"
+`
+	if string(b) != strings.TrimPrefix(expect, "\n") {
+		t.Fatalf("unexpected output. expect:\n%s\nbut got:\n%s", expect, string(b))
+	}
+	j, err := yaml.YAMLToJSON(b)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if expect := strings.Replace(src, "1e3", "1.0e3", 1) + "\n"; string(j) != expect {
+		t.Fatalf("failed to convert back. expect %s but got %s", expect, string(j))
+	}
+	t.Run("error", func(t *testing.T) {
+		for _, test := range []struct {
+			source string
+			expect string
+		}{
+			{source: "{\n  \"a\": 1,\n  \"b\": x\n}", expect: "[3:8]"},
+			{source: `{"a": 1`, expect: "unexpected end"},
+			{source: `{"a": 1} {}`, expect: "after top-level value"},
+		} {
+			_, err := yaml.JSONToYAML([]byte(test.source))
+			if err == nil {
+				t.Fatalf("expected error for %q", test.source)
+			}
+			if !strings.Contains(err.Error(), test.expect) {
+				t.Fatalf("unexpected error: %s", err)
+			}
+		}
+	})
+}
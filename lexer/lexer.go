@@ -7,10 +7,23 @@ import (
 	"github.com/goccy/go-yaml/token"
 )
 
+// Option option for Tokenize
+type Option func(*scanner.Scanner)
+
+// LenientComment makes '#' start a comment even if it follows non-space character ( e.g. `abc#123` is tokenized as `abc` )
+func LenientComment() Option {
+	return func(s *scanner.Scanner) {
+		s.SetLenientComment(true)
+	}
+}
+
 // Tokenize split to token instances from string
-func Tokenize(src string) token.Tokens {
+func Tokenize(src string, opts ...Option) token.Tokens {
 	var s scanner.Scanner
 	s.Init(src)
+	for _, opt := range opts {
+		opt(&s)
+	}
 	var tokens token.Tokens
 	for {
 		subTokens, err := s.Scan()
@@ -1,10 +1,13 @@
 package lexer_test
 
 import (
+	"reflect"
 	"strings"
 	"testing"
+	"unsafe"
 
 	"github.com/goccy/go-yaml/lexer"
+	"github.com/goccy/go-yaml/token"
 )
 
 func TestTokenize(t *testing.T) {
@@ -59,3 +62,178 @@ func TestTokenize(t *testing.T) {
 		lexer.Tokenize(src).Dump()
 	}
 }
+
+func TestTokenize_FlowMappingValue(t *testing.T) {
+	tests := []struct {
+		src    string
+		expect []token.Type
+	}{
+		{
+			src: `{"a":1}`,
+			expect: []token.Type{
+				token.MappingStartType, token.DoubleQuoteType, token.MappingValueType, token.IntegerType, token.MappingEndType,
+			},
+		},
+		{
+			src: `{'a':[1]}`,
+			expect: []token.Type{
+				token.MappingStartType, token.SingleQuoteType, token.MappingValueType,
+				token.SequenceStartType, token.IntegerType, token.SequenceEndType, token.MappingEndType,
+			},
+		},
+		{
+			src: `{a:, b:}`,
+			expect: []token.Type{
+				token.MappingStartType, token.StringType, token.MappingValueType, token.CollectEntryType,
+				token.StringType, token.MappingValueType, token.MappingEndType,
+			},
+		},
+		{
+			src: `[a:]`,
+			expect: []token.Type{
+				token.SequenceStartType, token.StringType, token.MappingValueType, token.SequenceEndType,
+			},
+		},
+		{
+			// ':' followed by plain character is a part of plain scalar
+			src: `[a:1, http://example.com]`,
+			expect: []token.Type{
+				token.SequenceStartType, token.StringType, token.CollectEntryType, token.StringType, token.SequenceEndType,
+			},
+		},
+		{
+			// JSON-like key is allowed only in flow context
+			src: "a:1\n",
+			expect: []token.Type{
+				token.StringType,
+			},
+		},
+	}
+	for _, test := range tests {
+		tokens := lexer.Tokenize(test.src)
+		actual := make([]token.Type, 0, len(tokens))
+		for _, tk := range tokens {
+			actual = append(actual, tk.Type)
+		}
+		if !reflect.DeepEqual(actual, test.expect) {
+			t.Fatalf("%s: unexpected tokens: %v", test.src, actual)
+		}
+	}
+}
+
+func TestTokenize_FlowBracketInScalar(t *testing.T) {
+	tests := []struct {
+		src    string
+		expect []token.Type
+	}{
+		{
+			src: `[http://example.com/a[1], b]`,
+			expect: []token.Type{
+				token.SequenceStartType, token.StringType, token.CollectEntryType, token.StringType, token.SequenceEndType,
+			},
+		},
+		{
+			src: `{url: http://example.com/{id}}`,
+			expect: []token.Type{
+				token.MappingStartType, token.StringType, token.MappingValueType, token.StringType, token.MappingEndType,
+			},
+		},
+		{
+			src: `[a{b,c}d, e]`,
+			expect: []token.Type{
+				token.SequenceStartType, token.StringType, token.CollectEntryType, token.StringType, token.SequenceEndType,
+			},
+		},
+		{
+			src: `{a: [b}]}`,
+			expect: []token.Type{
+				token.MappingStartType, token.StringType, token.MappingValueType, token.SequenceStartType,
+				token.StringType, token.MappingEndType, token.SequenceEndType, token.MappingEndType,
+			},
+		},
+		{
+			// ',' outside of flow collection is a part of plain scalar
+			src: "a: b, c\n",
+			expect: []token.Type{
+				token.StringType, token.MappingValueType, token.StringType,
+			},
+		},
+	}
+	for _, test := range tests {
+		tokens := lexer.Tokenize(test.src)
+		actual := make([]token.Type, 0, len(tokens))
+		for _, tk := range tokens {
+			actual = append(actual, tk.Type)
+		}
+		if !reflect.DeepEqual(actual, test.expect) {
+			t.Fatalf("%s: unexpected tokens: %v", test.src, actual)
+		}
+	}
+}
+
+func TestTokenize_Comment(t *testing.T) {
+	tests := []struct {
+		src     string
+		values  []string
+		lenient []string
+	}{
+		{
+			src:     "password: abc#123\n",
+			values:  []string{"password", ":", "abc#123"},
+			lenient: []string{"password", ":", "abc", "123"},
+		},
+		{
+			src:     "url: http://example.com/#fragment # comment\n",
+			values:  []string{"url", ":", "http://example.com/#fragment", " comment"},
+			lenient: []string{"url", ":", "http://example.com/", "fragment # comment"},
+		},
+		{
+			src:     "a: b #comment\n# c\n",
+			values:  []string{"a", ":", "b", "comment", " c"},
+			lenient: []string{"a", ":", "b", "comment", " c"},
+		},
+	}
+	values := func(tokens token.Tokens) []string {
+		v := make([]string, 0, len(tokens))
+		for _, tk := range tokens {
+			v = append(v, tk.Value)
+		}
+		return v
+	}
+	for _, test := range tests {
+		if actual := values(lexer.Tokenize(test.src)); !reflect.DeepEqual(actual, test.values) {
+			t.Fatalf("%q: unexpected tokens: %q", test.src, actual)
+		}
+		if actual := values(lexer.Tokenize(test.src, lexer.LenientComment())); !reflect.DeepEqual(actual, test.lenient) {
+			t.Fatalf("%q: unexpected tokens with LenientComment: %q", test.src, actual)
+		}
+	}
+}
+
+func TestTokenize_InternedValues(t *testing.T) {
+	stringData := func(s string) uintptr {
+		return (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+	}
+	data := map[string]uintptr{}
+	for _, tk := range lexer.Tokenize("- name: a\n  kind: x\n- name: b\n  kind: x\n---\nname: c\n") {
+		if tk.Value != "name" && tk.Value != "x" {
+			continue
+		}
+		if d, exists := data[tk.Value]; exists && d != stringData(tk.Value) {
+			t.Fatalf("value %q is not shared", tk.Value)
+		}
+		data[tk.Value] = stringData(tk.Value)
+	}
+	if len(data) != 2 {
+		t.Fatalf("unexpected values: %v", data)
+	}
+}
+
+func BenchmarkTokenize_BlockScalar(b *testing.B) {
+	src := "cert: |\n" + strings.Repeat("  MIIDdzCCAl+gAwIBAgIEAgAAuTANBgkqhkiG9w0BAQUFADBaMQswCQYDVQQGEwJJ\n", 1000) +
+		"script: 'echo \"" + strings.Repeat("hello world ", 1000) + "\"'\n"
+	b.SetBytes(int64(len(src)))
+	for i := 0; i < b.N; i++ {
+		lexer.Tokenize(src)
+	}
+}
@@ -0,0 +1,343 @@
+// Package lsp provides building blocks for YAML language server.
+// All positions and ranges are zero-based as defined by Language Server Protocol,
+// and character offsets are counted in bytes.
+package lsp
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/internal/errors"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/token"
+)
+
+// Position position in document
+type Position = yaml.LSPPosition
+
+// Range range in document
+type Range = yaml.LSPRange
+
+// SymbolKind kind of DocumentSymbol. values are same as SymbolKind of Language Server Protocol
+type SymbolKind int
+
+const (
+	// SymbolKindString kind of string value
+	SymbolKindString SymbolKind = 15
+	// SymbolKindNumber kind of number value
+	SymbolKindNumber SymbolKind = 16
+	// SymbolKindBoolean kind of boolean value
+	SymbolKindBoolean SymbolKind = 17
+	// SymbolKindArray kind of sequence
+	SymbolKindArray SymbolKind = 18
+	// SymbolKindObject kind of mapping
+	SymbolKindObject SymbolKind = 19
+	// SymbolKindKey kind of alias or merge key
+	SymbolKindKey SymbolKind = 20
+	// SymbolKindNull kind of null value
+	SymbolKindNull SymbolKind = 21
+)
+
+// DocumentSymbol outline of keys in document
+type DocumentSymbol struct {
+	Name           string            `json:"name"`
+	Detail         string            `json:"detail,omitempty"`
+	Kind           SymbolKind        `json:"kind"`
+	Range          Range             `json:"range"`
+	SelectionRange Range             `json:"selectionRange"`
+	Children       []*DocumentSymbol `json:"children,omitempty"`
+}
+
+// Hover information shown when cursor is over the node
+type Hover struct {
+	Contents string `json:"contents"`
+	Range    Range  `json:"range"`
+}
+
+// FoldingRange range which can be folded
+type FoldingRange struct {
+	StartLine int    `json:"startLine"`
+	EndLine   int    `json:"endLine"`
+	Kind      string `json:"kind,omitempty"`
+}
+
+// Document parsed YAML document
+type Document struct {
+	file    *ast.File
+	anchors map[*ast.AliasNode]*ast.AnchorNode // anchor referenced by alias
+}
+
+// Parse parse source and create Document
+func Parse(src []byte) (*Document, error) {
+	file, err := parser.ParseBytes(src, 0)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse")
+	}
+	return NewDocument(file), nil
+}
+
+// NewDocument create Document from parsed file
+func NewDocument(file *ast.File) *Document {
+	d := &Document{
+		file:    file,
+		anchors: map[*ast.AliasNode]*ast.AnchorNode{},
+	}
+	// alias refers to the closest anchor defined before it in the same document
+	for _, info := range ast.AnchorUsage(file) {
+		for _, alias := range info.Aliases {
+			d.anchors[alias] = info.Anchor
+		}
+	}
+	return d
+}
+
+type nodeCollector struct {
+	nodes []ast.Node
+}
+
+func (c *nodeCollector) Visit(node ast.Node) ast.Visitor {
+	c.nodes = append(c.nodes, node)
+	return c
+}
+
+func (d *Document) nodes() []ast.Node {
+	c := &nodeCollector{}
+	for _, doc := range d.file.Docs {
+		if doc.Body != nil {
+			ast.WalkAll(c, doc.Body)
+		}
+	}
+	return c.nodes
+}
+
+// Symbols returns outline of keys in document
+func (d *Document) Symbols() []*DocumentSymbol {
+	symbols := []*DocumentSymbol{}
+	for _, doc := range d.file.Docs {
+		if doc.Body != nil {
+			symbols = append(symbols, d.childSymbols(doc.Body)...)
+		}
+	}
+	return symbols
+}
+
+func (d *Document) childSymbols(node ast.Node) []*DocumentSymbol {
+	switch n := ast.Unwrap(node).(type) {
+	case *ast.MappingNode:
+		symbols := []*DocumentSymbol{}
+		for _, value := range n.Values {
+			symbols = append(symbols, d.mappingValueSymbol(value))
+		}
+		return symbols
+	case *ast.MappingValueNode:
+		return []*DocumentSymbol{d.mappingValueSymbol(n)}
+	case *ast.SequenceNode:
+		symbols := []*DocumentSymbol{}
+		for idx, value := range n.Values {
+			symbols = append(symbols, &DocumentSymbol{
+				Name:           strconv.Itoa(idx),
+				Detail:         detail(value),
+				Kind:           symbolKind(value),
+				Range:          nodeRange(value),
+				SelectionRange: nodeRange(value),
+				Children:       d.childSymbols(value),
+			})
+		}
+		return symbols
+	}
+	return nil
+}
+
+func (d *Document) mappingValueSymbol(n *ast.MappingValueNode) *DocumentSymbol {
+	return &DocumentSymbol{
+		Name:           n.Key.GetToken().Value,
+		Detail:         detail(n.Value),
+		Kind:           symbolKind(n.Value),
+		Range:          nodeRange(n),
+		SelectionRange: nodeRange(n.Key),
+		Children:       d.childSymbols(n.Value),
+	}
+}
+
+func symbolKind(node ast.Node) SymbolKind {
+	switch ast.Unwrap(node).(type) {
+	case *ast.MappingNode, *ast.MappingValueNode:
+		return SymbolKindObject
+	case *ast.SequenceNode:
+		return SymbolKindArray
+	case *ast.IntegerNode, *ast.FloatNode, *ast.InfinityNode, *ast.NanNode:
+		return SymbolKindNumber
+	case *ast.BoolNode:
+		return SymbolKindBoolean
+	case *ast.NullNode:
+		return SymbolKindNull
+	case *ast.AliasNode, *ast.MergeKeyNode:
+		return SymbolKindKey
+	}
+	return SymbolKindString
+}
+
+func detail(node ast.Node) string {
+	switch n := ast.Unwrap(node).(type) {
+	case *ast.MappingNode, *ast.MappingValueNode, *ast.SequenceNode:
+		return ""
+	case *ast.LiteralNode:
+		return n.Value.GetToken().Value
+	case *ast.AliasNode:
+		return n.String()
+	case nil:
+		return ""
+	default:
+		return n.GetToken().Value
+	}
+}
+
+// Hover returns information of the node at position.
+// For alias, returns value of the anchor referenced by alias.
+// For key, returns the value of key.
+// If there is no information at position, returns nil.
+func (d *Document) Hover(pos Position) *Hover {
+	if alias := d.aliasAt(pos); alias != nil {
+		anchor, exists := d.anchors[alias]
+		if !exists {
+			return &Hover{
+				Contents: fmt.Sprintf("anchor '%s' is undefined", alias.Value.GetToken().Value),
+				Range:    nodeRange(alias),
+			}
+		}
+		return &Hover{
+			Contents: codeBlock(anchor.Value.String()),
+			Range:    nodeRange(alias),
+		}
+	}
+	node := d.nodeAt(pos)
+	if node == nil {
+		return nil
+	}
+	for _, n := range d.nodes() {
+		mvnode, ok := n.(*ast.MappingValueNode)
+		if !ok || mvnode.Key != node {
+			continue
+		}
+		if _, ok := ast.Unwrap(mvnode.Value).(*ast.AliasNode); ok {
+			return d.Hover(nodeRange(mvnode.Value).Start)
+		}
+		return &Hover{
+			Contents: codeBlock(mvnode.Value.String()),
+			Range:    nodeRange(mvnode.Key),
+		}
+	}
+	return nil
+}
+
+func codeBlock(src string) string {
+	return fmt.Sprintf("```yaml\n%s\n```", src)
+}
+
+// Definition returns range of anchor name referenced by alias at position.
+// If there is no alias at position or anchor is undefined, returns nil.
+func (d *Document) Definition(pos Position) *Range {
+	alias := d.aliasAt(pos)
+	if alias == nil {
+		return nil
+	}
+	anchor, exists := d.anchors[alias]
+	if !exists {
+		return nil
+	}
+	rng := nodeRange(anchor.Name)
+	return &rng
+}
+
+// FoldingRanges returns ranges of mapping values and sequence entries spreading over multiple lines
+func (d *Document) FoldingRanges() []*FoldingRange {
+	ranges := []*FoldingRange{}
+	add := func(node ast.Node) {
+		rng := nodeRange(node)
+		if rng.End.Line > rng.Start.Line {
+			ranges = append(ranges, &FoldingRange{
+				StartLine: rng.Start.Line,
+				EndLine:   rng.End.Line,
+				Kind:      "region",
+			})
+		}
+	}
+	for _, node := range d.nodes() {
+		switch n := node.(type) {
+		case *ast.MappingValueNode:
+			add(n)
+		case *ast.SequenceNode:
+			for _, value := range n.Values {
+				add(value)
+			}
+		}
+	}
+	return ranges
+}
+
+func (d *Document) aliasAt(pos Position) *ast.AliasNode {
+	var found *ast.AliasNode
+	for _, node := range d.nodes() {
+		alias, ok := node.(*ast.AliasNode)
+		if !ok {
+			continue
+		}
+		if contains(nodeRange(alias), pos) {
+			found = alias
+		}
+	}
+	return found
+}
+
+// nodeAt returns the innermost scalar node at position
+func (d *Document) nodeAt(pos Position) ast.Node {
+	for _, node := range d.nodes() {
+		if _, ok := node.(ast.ScalarNode); !ok {
+			continue
+		}
+		if contains(tokenRange(node.GetToken()), pos) {
+			return node
+		}
+	}
+	return nil
+}
+
+func nodeRange(node ast.Node) Range {
+	var rng Range
+	found := false
+	for _, tk := range ast.Tokens(node) {
+		if tk.Position == nil {
+			continue
+		}
+		r := tokenRange(tk)
+		if !found {
+			rng = r
+			found = true
+			continue
+		}
+		if less(r.Start, rng.Start) {
+			rng.Start = r.Start
+		}
+		if less(rng.End, r.End) {
+			rng.End = r.End
+		}
+	}
+	return rng
+}
+
+func tokenRange(tk *token.Token) Range {
+	return yaml.TokenRange(tk).LSP()
+}
+
+func less(a, b Position) bool {
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	return a.Character < b.Character
+}
+
+func contains(rng Range, pos Position) bool {
+	return !less(pos, rng.Start) && !less(rng.End, pos)
+}
@@ -0,0 +1,262 @@
+package lsp_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-yaml/lexer"
+	"github.com/goccy/go-yaml/lsp"
+)
+
+const src = `a: &x
+  b: 1
+  c:
+  - d
+  - e: true
+f: *x
+g: *y
+`
+
+func parse(t *testing.T) *lsp.Document {
+	t.Helper()
+	doc, err := lsp.Parse([]byte(src))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return doc
+}
+
+func rng(startLine, startChar, endLine, endChar int) lsp.Range {
+	return lsp.Range{
+		Start: lsp.Position{Line: startLine, Character: startChar},
+		End:   lsp.Position{Line: endLine, Character: endChar},
+	}
+}
+
+func rngPtr(startLine, startChar, endLine, endChar int) *lsp.Range {
+	r := rng(startLine, startChar, endLine, endChar)
+	return &r
+}
+
+func TestDocument_Symbols(t *testing.T) {
+	expected := []*lsp.DocumentSymbol{
+		{
+			Name:           "a",
+			Kind:           lsp.SymbolKindObject,
+			Range:          rng(0, 0, 4, 11),
+			SelectionRange: rng(0, 0, 0, 1),
+			Children: []*lsp.DocumentSymbol{
+				{
+					Name:           "b",
+					Detail:         "1",
+					Kind:           lsp.SymbolKindNumber,
+					Range:          rng(1, 2, 1, 6),
+					SelectionRange: rng(1, 2, 1, 3),
+				},
+				{
+					Name:           "c",
+					Kind:           lsp.SymbolKindArray,
+					Range:          rng(2, 2, 4, 11),
+					SelectionRange: rng(2, 2, 2, 3),
+					Children: []*lsp.DocumentSymbol{
+						{
+							Name:           "0",
+							Detail:         "d",
+							Kind:           lsp.SymbolKindString,
+							Range:          rng(3, 4, 3, 5),
+							SelectionRange: rng(3, 4, 3, 5),
+						},
+						{
+							Name:           "1",
+							Kind:           lsp.SymbolKindObject,
+							Range:          rng(4, 4, 4, 11),
+							SelectionRange: rng(4, 4, 4, 11),
+							Children: []*lsp.DocumentSymbol{
+								{
+									Name:           "e",
+									Detail:         "true",
+									Kind:           lsp.SymbolKindBoolean,
+									Range:          rng(4, 4, 4, 11),
+									SelectionRange: rng(4, 4, 4, 5),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:           "f",
+			Detail:         "*x",
+			Kind:           lsp.SymbolKindKey,
+			Range:          rng(5, 0, 5, 5),
+			SelectionRange: rng(5, 0, 5, 1),
+		},
+		{
+			Name:           "g",
+			Detail:         "*y",
+			Kind:           lsp.SymbolKindKey,
+			Range:          rng(6, 0, 6, 5),
+			SelectionRange: rng(6, 0, 6, 1),
+		},
+	}
+	if actual := parse(t).Symbols(); !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("unexpected symbols: %+v", actual)
+	}
+}
+
+func TestDocument_Hover(t *testing.T) {
+	anchorValue := "```yaml\n  b: 1\n  c:\n  - d\n  - e: true\n```"
+	tests := []struct {
+		name     string
+		pos      lsp.Position
+		expected *lsp.Hover
+	}{
+		{
+			name:     "alias",
+			pos:      lsp.Position{Line: 5, Character: 4},
+			expected: &lsp.Hover{Contents: anchorValue, Range: rng(5, 3, 5, 5)},
+		},
+		{
+			name:     "undefined alias",
+			pos:      lsp.Position{Line: 6, Character: 4},
+			expected: &lsp.Hover{Contents: "anchor 'y' is undefined", Range: rng(6, 3, 6, 5)},
+		},
+		{
+			name:     "key",
+			pos:      lsp.Position{Line: 1, Character: 2},
+			expected: &lsp.Hover{Contents: "```yaml\n1\n```", Range: rng(1, 2, 1, 3)},
+		},
+		{
+			name:     "key of alias",
+			pos:      lsp.Position{Line: 5, Character: 0},
+			expected: &lsp.Hover{Contents: anchorValue, Range: rng(5, 3, 5, 5)},
+		},
+		{
+			name: "nothing",
+			pos:  lsp.Position{Line: 10, Character: 0},
+		},
+	}
+	doc := parse(t)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := doc.Hover(test.pos); !reflect.DeepEqual(test.expected, actual) {
+				t.Fatalf("unexpected hover: %+v", actual)
+			}
+		})
+	}
+}
+
+func TestDocument_Definition(t *testing.T) {
+	doc := parse(t)
+	actual := doc.Definition(lsp.Position{Line: 5, Character: 4})
+	if actual == nil || *actual != rng(0, 4, 0, 5) {
+		t.Fatalf("unexpected definition: %v", actual)
+	}
+	if actual := doc.Definition(lsp.Position{Line: 6, Character: 4}); actual != nil {
+		t.Fatalf("unexpected definition of undefined anchor: %v", actual)
+	}
+	if actual := doc.Definition(lsp.Position{Line: 1, Character: 2}); actual != nil {
+		t.Fatalf("unexpected definition of key: %v", actual)
+	}
+}
+
+func TestDocument_MultipleDocuments(t *testing.T) {
+	doc, err := lsp.Parse([]byte("a: &x 1\nb: *x\nc: &x 2\nd: *x\n---\ne: &x 3\nf: *x\n---\ng: *x\n"))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	tests := []struct {
+		name       string
+		pos        lsp.Position
+		definition *lsp.Range
+		hover      string
+	}{
+		{name: "first definition", pos: lsp.Position{Line: 1, Character: 4}, definition: rngPtr(0, 4, 0, 5), hover: "```yaml\n1\n```"},
+		{name: "redefinition", pos: lsp.Position{Line: 3, Character: 4}, definition: rngPtr(2, 4, 2, 5), hover: "```yaml\n2\n```"},
+		{name: "next document", pos: lsp.Position{Line: 6, Character: 4}, definition: rngPtr(5, 4, 5, 5), hover: "```yaml\n3\n```"},
+		{name: "undefined in document", pos: lsp.Position{Line: 8, Character: 4}, hover: "anchor 'x' is undefined"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := doc.Definition(test.pos); !reflect.DeepEqual(test.definition, actual) {
+				t.Fatalf("unexpected definition: %v", actual)
+			}
+			if actual := doc.Hover(test.pos); actual == nil || actual.Contents != test.hover {
+				t.Fatalf("unexpected hover: %+v", actual)
+			}
+		})
+	}
+}
+
+func TestDocument_FoldingRanges(t *testing.T) {
+	expected := []*lsp.FoldingRange{
+		{StartLine: 0, EndLine: 4, Kind: "region"},
+		{StartLine: 2, EndLine: 4, Kind: "region"},
+	}
+	if actual := parse(t).FoldingRanges(); !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("unexpected folding ranges: %+v", actual)
+	}
+}
+
+func TestSemanticTokens(t *testing.T) {
+	src := `%YAML 1.2
+---
+a: |
+  foo
+  bar
+b: &x !!str c
+d: *x # comment
+e: [1, true, "s"]
+`
+	type semanticToken struct {
+		text      string
+		typ       lsp.SemanticTokenType
+		modifiers lsp.SemanticTokenModifier
+	}
+	expected := []semanticToken{
+		{"%YAML 1.2", lsp.SemanticTokenDirective, 0},
+		{"a", lsp.SemanticTokenKey, 0},
+		{"foo", lsp.SemanticTokenString, 0},
+		{"bar", lsp.SemanticTokenString, 0},
+		{"b", lsp.SemanticTokenKey, 0},
+		{"&x", lsp.SemanticTokenAnchor, lsp.SemanticTokenModifierDeclaration},
+		{"!!str", lsp.SemanticTokenTag, 0},
+		{"c", lsp.SemanticTokenString, 0},
+		{"d", lsp.SemanticTokenKey, 0},
+		{"*x", lsp.SemanticTokenAlias, 0},
+		{"# comment", lsp.SemanticTokenComment, 0},
+		{"e", lsp.SemanticTokenKey, 0},
+		{"1", lsp.SemanticTokenNumber, 0},
+		{"true", lsp.SemanticTokenKeyword, 0},
+		{`"s"`, lsp.SemanticTokenString, 0},
+	}
+	lines := strings.Split(src, "\n")
+	tokens := lsp.SemanticTokens(lexer.Tokenize(src))
+	if len(tokens) != len(expected) {
+		t.Fatalf("unexpected number of tokens: %d", len(tokens))
+	}
+	for idx, tk := range tokens {
+		if tk.Range.Start.Line != tk.Range.End.Line {
+			t.Fatalf("token spreads over multiple lines: %+v", tk.Range)
+		}
+		actual := semanticToken{
+			text:      lines[tk.Range.Start.Line][tk.Range.Start.Character:tk.Range.End.Character],
+			typ:       tk.Type,
+			modifiers: tk.Modifiers,
+		}
+		if actual != expected[idx] {
+			t.Fatalf("unexpected token: expected %+v but got %+v", expected[idx], actual)
+		}
+	}
+	data := lsp.EncodeSemanticTokens(tokens[:3])
+	expectedData := []uint32{
+		0, 0, 9, uint32(lsp.SemanticTokenDirective), 0,
+		2, 0, 1, uint32(lsp.SemanticTokenKey), 0,
+		1, 2, 3, uint32(lsp.SemanticTokenString), 0,
+	}
+	if !reflect.DeepEqual(data, expectedData) {
+		t.Fatalf("unexpected encoded tokens: %v", data)
+	}
+}
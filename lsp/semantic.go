@@ -0,0 +1,202 @@
+package lsp
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml/token"
+)
+
+// SemanticTokenType type of SemanticToken
+type SemanticTokenType int
+
+const (
+	// SemanticTokenKey mapping key
+	SemanticTokenKey SemanticTokenType = iota
+	// SemanticTokenAnchor anchor ( e.g. `&a` )
+	SemanticTokenAnchor
+	// SemanticTokenAlias alias ( e.g. `*a` )
+	SemanticTokenAlias
+	// SemanticTokenTag tag ( e.g. `!!str` )
+	SemanticTokenTag
+	// SemanticTokenDirective directive ( e.g. `%YAML 1.2` )
+	SemanticTokenDirective
+	// SemanticTokenString string value
+	SemanticTokenString
+	// SemanticTokenNumber number value
+	SemanticTokenNumber
+	// SemanticTokenKeyword boolean or null value
+	SemanticTokenKeyword
+	// SemanticTokenComment comment
+	SemanticTokenComment
+)
+
+// SemanticTokenTypes legend of token types. index of the legend is value of SemanticTokenType
+var SemanticTokenTypes = []string{
+	"key",
+	"anchor",
+	"alias",
+	"tag",
+	"directive",
+	"string",
+	"number",
+	"keyword",
+	"comment",
+}
+
+// String token type to text
+func (t SemanticTokenType) String() string {
+	if int(t) < len(SemanticTokenTypes) {
+		return SemanticTokenTypes[t]
+	}
+	return "unknown"
+}
+
+// SemanticTokenModifier bit set of modifiers of SemanticToken
+type SemanticTokenModifier uint32
+
+const (
+	// SemanticTokenModifierDeclaration declaration of anchor
+	SemanticTokenModifierDeclaration SemanticTokenModifier = 1 << iota
+)
+
+// SemanticTokenModifiers legend of token modifiers. index of the legend is bit position of SemanticTokenModifier
+var SemanticTokenModifiers = []string{
+	"declaration",
+}
+
+// SemanticToken classified token. Range never spreads over multiple lines
+type SemanticToken struct {
+	Range     Range
+	Type      SemanticTokenType
+	Modifiers SemanticTokenModifier
+}
+
+// SemanticTokens classify tokens for syntax highlighting.
+// Tokens spreading over multiple lines ( e.g. literal block ) are split into each line.
+// Returned tokens are sorted by position.
+func SemanticTokens(tokens token.Tokens) []*SemanticToken {
+	semanticTokens := []*SemanticToken{}
+	add := func(start, end *token.Token, typ SemanticTokenType, modifiers SemanticTokenModifier) {
+		for _, rng := range lineRanges(start, end) {
+			semanticTokens = append(semanticTokens, &SemanticToken{
+				Range:     rng,
+				Type:      typ,
+				Modifiers: modifiers,
+			})
+		}
+	}
+	for idx := 0; idx < len(tokens); idx++ {
+		tk := tokens[idx]
+		if tk.Position == nil {
+			continue
+		}
+		switch tk.Type {
+		case token.CommentType:
+			add(tk, tk, SemanticTokenComment, 0)
+		case token.AnchorType, token.AliasType:
+			end := tk
+			if idx+1 < len(tokens) && tokens[idx+1].Type == token.StringType {
+				idx++
+				end = tokens[idx]
+			}
+			if tk.Type == token.AnchorType {
+				add(tk, end, SemanticTokenAnchor, SemanticTokenModifierDeclaration)
+			} else {
+				add(tk, end, SemanticTokenAlias, 0)
+			}
+		case token.TagType:
+			add(tk, tk, SemanticTokenTag, 0)
+		case token.DirectiveType:
+			end := tk
+			for idx+1 < len(tokens) && tokens[idx+1].Position.Line == tk.Position.Line {
+				idx++
+				end = tokens[idx]
+			}
+			add(tk, end, SemanticTokenDirective, 0)
+		case token.MergeKeyType:
+			add(tk, tk, SemanticTokenKey, 0)
+		case token.StringType, token.SingleQuoteType, token.DoubleQuoteType,
+			token.IntegerType, token.BinaryIntegerType, token.OctetIntegerType, token.HexIntegerType,
+			token.FloatType, token.InfinityType, token.NanType,
+			token.BoolType, token.NullType:
+			if tk.NextType() == token.MappingValueType {
+				add(tk, tk, SemanticTokenKey, 0)
+				continue
+			}
+			add(tk, tk, scalarTokenType(tk), 0)
+		}
+	}
+	sort.SliceStable(semanticTokens, func(i, j int) bool {
+		return less(semanticTokens[i].Range.Start, semanticTokens[j].Range.Start)
+	})
+	return semanticTokens
+}
+
+func scalarTokenType(tk *token.Token) SemanticTokenType {
+	switch tk.Type {
+	case token.IntegerType, token.BinaryIntegerType, token.OctetIntegerType, token.HexIntegerType,
+		token.FloatType, token.InfinityType, token.NanType:
+		return SemanticTokenNumber
+	case token.BoolType, token.NullType:
+		return SemanticTokenKeyword
+	}
+	return SemanticTokenString
+}
+
+// lineRanges returns ranges of each line from start token to end token
+func lineRanges(start, end *token.Token) []Range {
+	if start != end {
+		// tokens on the same line
+		return []Range{{
+			Start: tokenRange(start).Start,
+			End:   tokenRange(end).End,
+		}}
+	}
+	text := strings.Trim(start.Origin, " \t\r\n")
+	if text == "" {
+		text = start.Value
+	}
+	pos := tokenRange(start).Start
+	ranges := []Range{}
+	for idx, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		character := pos.Character
+		if idx > 0 {
+			trimmed := strings.TrimLeft(line, " \t")
+			character = len(line) - len(trimmed)
+			line = trimmed
+		}
+		if line != "" {
+			ranges = append(ranges, Range{
+				Start: Position{Line: pos.Line + idx, Character: character},
+				End:   Position{Line: pos.Line + idx, Character: character + len(line)},
+			})
+		}
+	}
+	return ranges
+}
+
+// EncodeSemanticTokens encode tokens to the relative format of Language Server Protocol.
+// each token is represented by five integers ( deltaLine, deltaStartChar, length, tokenType, tokenModifiers ).
+func EncodeSemanticTokens(tokens []*SemanticToken) []uint32 {
+	data := make([]uint32, 0, len(tokens)*5)
+	var prev Position
+	for _, tk := range tokens {
+		start := tk.Range.Start
+		deltaLine := start.Line - prev.Line
+		deltaChar := start.Character
+		if deltaLine == 0 {
+			deltaChar = start.Character - prev.Character
+		}
+		data = append(data,
+			uint32(deltaLine),
+			uint32(deltaChar),
+			uint32(tk.Range.End.Character-start.Character),
+			uint32(tk.Type),
+			uint32(tk.Modifiers),
+		)
+		prev = start
+	}
+	return data
+}
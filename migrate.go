@@ -0,0 +1,331 @@
+package yaml
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/internal/errors"
+	"github.com/goccy/go-yaml/lexer"
+	"github.com/goccy/go-yaml/token"
+	"golang.org/x/xerrors"
+)
+
+// MigrationChangeKind kind of MigrationChange
+type MigrationChangeKind int
+
+const (
+	// MigrationRenameKey key is renamed or moved
+	MigrationRenameKey MigrationChangeKind = iota
+	// MigrationChangeType value is converted to another type
+	MigrationChangeType
+	// MigrationSplitValue value is split into multiple keys
+	MigrationSplitValue
+	// MigrationMergeValues values of multiple keys are merged into one key
+	MigrationMergeValues
+	// MigrationSetDefault missing key is added with default value
+	MigrationSetDefault
+)
+
+// String change kind to text
+func (k MigrationChangeKind) String() string {
+	switch k {
+	case MigrationRenameKey:
+		return "rename-key"
+	case MigrationChangeType:
+		return "change-type"
+	case MigrationSplitValue:
+		return "split-value"
+	case MigrationMergeValues:
+		return "merge-values"
+	case MigrationSetDefault:
+		return "set-default"
+	}
+	return "unknown"
+}
+
+// MigrationChange change applied by MigrationRule
+type MigrationChange struct {
+	Kind MigrationChangeKind
+	// Path path of the key which the rule is applied to
+	Path string
+	// Position position of the key in the source which the rule is applied to.
+	// The source is the output of the preceding rules, so the position of the later rule may differ from the original source.
+	// nil if the key is added by the rule
+	Position *token.Position
+	// Message description of the change
+	Message string
+}
+
+// String change to text
+func (c *MigrationChange) String() string {
+	if c.Position == nil {
+		return c.Message
+	}
+	return fmt.Sprintf("[%d:%d] %s", c.Position.Line, c.Position.Column, c.Message)
+}
+
+// MigrationRule rule to migrate a document.
+// Apply is called for each document in source with Editor which has only the document,
+// and returns changes applied to it.
+type MigrationRule interface {
+	Apply(*Editor) ([]*MigrationChange, error)
+}
+
+// MigrationRuleFunc function as MigrationRule
+type MigrationRuleFunc func(*Editor) ([]*MigrationChange, error)
+
+// Apply call f(e)
+func (f MigrationRuleFunc) Apply(e *Editor) ([]*MigrationChange, error) {
+	return f(e)
+}
+
+// Migration migrates YAML source by rules with keeping comments and formatting.
+// It is useful to migrate configuration files of application whose schema has changed.
+type Migration struct {
+	rules []MigrationRule
+}
+
+// NewMigration create Migration instance. rules are applied in order
+func NewMigration(rules ...MigrationRule) *Migration {
+	return &Migration{rules: rules}
+}
+
+// Migrate applies rules to each document in src, and returns migrated source and applied changes.
+// If src is a stream of multiple documents, positions of changes are in the whole stream which the rule is applied to.
+func (m *Migration) Migrate(src []byte) ([]byte, []*MigrationChange, error) {
+	editors := []*Editor{}
+	for _, doc := range splitDocuments(src) {
+		editor, err := NewEditor(doc)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to create editor")
+		}
+		editors = append(editors, editor)
+	}
+	changes := []*MigrationChange{}
+	for _, rule := range m.rules {
+		line, offset := 0, 0
+		for _, editor := range editors {
+			before := editor.Bytes()
+			applied, err := rule.Apply(editor)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "failed to migrate")
+			}
+			for _, change := range applied {
+				if change.Position != nil {
+					pos := *change.Position
+					pos.Line += line
+					pos.Offset += offset
+					change.Position = &pos
+				}
+				changes = append(changes, change)
+			}
+			line += strings.Count(string(before), "\n")
+			offset += len(before)
+		}
+	}
+	migrated := []byte{}
+	for _, editor := range editors {
+		migrated = append(migrated, editor.Bytes()...)
+	}
+	return migrated, changes, nil
+}
+
+// splitDocuments splits src at the beginning of each document.
+// Comments before the first document header belong to the first document.
+func splitDocuments(src []byte) [][]byte {
+	lines := splitLines(src)
+	docs := [][]byte{}
+	start := 0
+	hasContent := false
+	for _, tk := range lexer.Tokenize(string(src)) {
+		switch tk.Type {
+		case token.CommentType:
+		case token.DocumentHeaderType:
+			if hasContent {
+				offset := lineOffset(lines, tk.Position.Line)
+				docs = append(docs, src[start:offset])
+				start = offset
+			}
+			hasContent = true
+		default:
+			hasContent = true
+		}
+	}
+	return append(docs, src[start:])
+}
+
+// RenameKeyRule create MigrationRule which renames the key at from to to ( e.g. for deprecated key ).
+// If the parent of to is different from from, the key is moved to the parent.
+// The rule does nothing if from doesn't exist.
+func RenameKeyRule(from, to string) MigrationRule {
+	return MigrationRuleFunc(func(e *Editor) ([]*MigrationChange, error) {
+		mvnode, _, err := e.lookup(from)
+		if err != nil || mvnode == nil {
+			return nil, err
+		}
+		fromSegments, err := parseKeyPath(from)
+		if err != nil {
+			return nil, err
+		}
+		toSegments, err := parseKeyPath(to)
+		if err != nil {
+			return nil, err
+		}
+		if len(fromSegments) == len(toSegments) && isPathPrefix(fromSegments[:len(fromSegments)-1], toSegments) {
+			// rename in place to keep the order of keys
+			err = e.RenameKey(from, toSegments[len(toSegments)-1].key)
+		} else {
+			err = e.MoveKey(from, to)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to rename %s to %s", from, to)
+		}
+		return []*MigrationChange{{
+			Kind:     MigrationRenameKey,
+			Path:     from,
+			Position: mvnode.Key.GetToken().Position,
+			Message:  fmt.Sprintf("renamed %s to %s", from, to),
+		}}, nil
+	})
+}
+
+// ChangeTypeRule create MigrationRule which converts the value of the key at path by convert ( e.g. from string to integer ).
+// The rule does nothing if path doesn't exist or the converted value is equal to the original value.
+func ChangeTypeRule(path string, convert func(interface{}) (interface{}, error)) MigrationRule {
+	return MigrationRuleFunc(func(e *Editor) ([]*MigrationChange, error) {
+		mvnode, value, err := e.lookup(path)
+		if err != nil || mvnode == nil {
+			return nil, err
+		}
+		converted, err := convert(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to convert value of %s", path)
+		}
+		if reflect.DeepEqual(value, converted) {
+			return nil, nil
+		}
+		if err := e.SetValue(path, converted); err != nil {
+			return nil, errors.Wrapf(err, "failed to set value of %s", path)
+		}
+		return []*MigrationChange{{
+			Kind:     MigrationChangeType,
+			Path:     path,
+			Position: mvnode.Key.GetToken().Position,
+			Message:  fmt.Sprintf("changed type of %s from %T to %T", path, value, converted),
+		}}, nil
+	})
+}
+
+// SplitValueRule create MigrationRule which splits the value of the key at from into the keys at to.
+// split must return the same number of values as to, and the key at from is deleted.
+// The rule does nothing if from doesn't exist.
+func SplitValueRule(from string, to []string, split func(interface{}) ([]interface{}, error)) MigrationRule {
+	return MigrationRuleFunc(func(e *Editor) ([]*MigrationChange, error) {
+		mvnode, value, err := e.lookup(from)
+		if err != nil || mvnode == nil {
+			return nil, err
+		}
+		values, err := split(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to split value of %s", from)
+		}
+		if len(values) != len(to) {
+			return nil, xerrors.Errorf("value of %s is split into %d values, but expected %d", from, len(values), len(to))
+		}
+		if err := e.DeleteKey(from); err != nil {
+			return nil, errors.Wrapf(err, "failed to delete %s", from)
+		}
+		for idx, path := range to {
+			if err := e.AddKey(path, values[idx]); err != nil {
+				return nil, errors.Wrapf(err, "failed to add %s", path)
+			}
+		}
+		return []*MigrationChange{{
+			Kind:     MigrationSplitValue,
+			Path:     from,
+			Position: mvnode.Key.GetToken().Position,
+			Message:  fmt.Sprintf("split %s into %s", from, strings.Join(to, ", ")),
+		}}, nil
+	})
+}
+
+// MergeValuesRule create MigrationRule which merges the values of the keys at from into the key at to.
+// merge receives values in the order of from, and the value of missing key is nil.
+// The keys at from are deleted. The rule does nothing if none of from exists.
+func MergeValuesRule(from []string, to string, merge func([]interface{}) (interface{}, error)) MigrationRule {
+	return MigrationRuleFunc(func(e *Editor) ([]*MigrationChange, error) {
+		values := make([]interface{}, len(from))
+		existing := []string{}
+		var pos *token.Position
+		for idx, path := range from {
+			mvnode, value, err := e.lookup(path)
+			if err != nil {
+				return nil, err
+			}
+			if mvnode == nil {
+				continue
+			}
+			if pos == nil {
+				pos = mvnode.Key.GetToken().Position
+			}
+			values[idx] = value
+			existing = append(existing, path)
+		}
+		if len(existing) == 0 {
+			return nil, nil
+		}
+		merged, err := merge(values)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to merge values into %s", to)
+		}
+		for _, path := range existing {
+			if err := e.DeleteKey(path); err != nil {
+				return nil, errors.Wrapf(err, "failed to delete %s", path)
+			}
+		}
+		if err := e.AddKey(to, merged); err != nil {
+			return nil, errors.Wrapf(err, "failed to add %s", to)
+		}
+		return []*MigrationChange{{
+			Kind:     MigrationMergeValues,
+			Path:     existing[0],
+			Position: pos,
+			Message:  fmt.Sprintf("merged %s into %s", strings.Join(existing, ", "), to),
+		}}, nil
+	})
+}
+
+// SetDefaultRule create MigrationRule which adds the key at path with value if the key doesn't exist.
+// If the value of the key is null ( e.g. `key:` ), it is replaced with value.
+func SetDefaultRule(path string, value interface{}) MigrationRule {
+	return MigrationRuleFunc(func(e *Editor) ([]*MigrationChange, error) {
+		mvnode, _, err := e.lookup(path)
+		if err != nil {
+			return nil, err
+		}
+		if mvnode != nil {
+			if !ast.IsNull(mvnode.Value) {
+				return nil, nil
+			}
+			if err := e.SetValue(path, value); err != nil {
+				return nil, errors.Wrapf(err, "failed to set %s", path)
+			}
+			return []*MigrationChange{{
+				Kind:     MigrationSetDefault,
+				Path:     path,
+				Position: mvnode.Key.GetToken().Position,
+				Message:  fmt.Sprintf("set default value to %s", path),
+			}}, nil
+		}
+		if err := e.AddKey(path, value); err != nil {
+			return nil, errors.Wrapf(err, "failed to add %s", path)
+		}
+		return []*MigrationChange{{
+			Kind:    MigrationSetDefault,
+			Path:    path,
+			Message: fmt.Sprintf("added %s with default value", path),
+		}}, nil
+	})
+}
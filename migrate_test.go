@@ -0,0 +1,143 @@
+package yaml_test
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+)
+
+func TestMigration(t *testing.T) {
+	src := `
+# server settings
+server:
+  # listen address
+  addr: "localhost:8080"
+  timeout: "30" # seconds
+# deprecated
+user_name: goccy
+first_name: go
+last_name: ccy
+`
+	migration := yaml.NewMigration(
+		yaml.RenameKeyRule("$.user_name", "$.user.name"),
+		yaml.ChangeTypeRule("$.server.timeout", func(v interface{}) (interface{}, error) {
+			s, ok := v.(string)
+			if !ok {
+				return v, nil
+			}
+			return strconv.Atoi(s)
+		}),
+		yaml.SplitValueRule("$.server.addr", []string{"$.server.host", "$.server.port"}, func(v interface{}) ([]interface{}, error) {
+			parts := strings.SplitN(fmt.Sprint(v), ":", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("invalid address %v", v)
+			}
+			port, err := strconv.Atoi(parts[1])
+			if err != nil {
+				return nil, err
+			}
+			return []interface{}{parts[0], port}, nil
+		}),
+		yaml.MergeValuesRule([]string{"$.first_name", "$.last_name"}, "$.user.full_name", func(values []interface{}) (interface{}, error) {
+			return fmt.Sprintf("%v %v", values[0], values[1]), nil
+		}),
+		yaml.SetDefaultRule("$.server.debug", false),
+		yaml.SetDefaultRule("$.server.timeout", 60),
+	)
+	migrated, changes, err := migration.Migrate([]byte(src))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expected := `
+# server settings
+server:
+  timeout: 30 # seconds
+  host: localhost
+  port: 8080
+  debug: false
+user:
+  # deprecated
+  name: goccy
+  full_name: go ccy
+`
+	if string(migrated) != expected {
+		t.Fatalf("unexpected source. expected:\n%s\nbut got:\n%s", expected, string(migrated))
+	}
+	expectedChanges := []string{
+		"rename-key [8:1] renamed $.user_name to $.user.name",
+		"change-type [6:3] changed type of $.server.timeout from string to int",
+		"split-value [5:3] split $.server.addr into $.server.host, $.server.port",
+		// $.first_name is at the line 7 of the source renamed by the first rule
+		"merge-values [7:1] merged $.first_name, $.last_name into $.user.full_name",
+		"set-default added $.server.debug with default value",
+	}
+	if len(changes) != len(expectedChanges) {
+		t.Fatalf("unexpected number of changes: %v", changes)
+	}
+	for idx, change := range changes {
+		if actual := fmt.Sprintf("%s %s", change.Kind, change); actual != expectedChanges[idx] {
+			t.Fatalf("unexpected change. expected %q but got %q", expectedChanges[idx], actual)
+		}
+	}
+}
+
+func TestMigration_MultipleDocuments(t *testing.T) {
+	src := "# comment\n---\na: 1\n---\nb: 2\na: 3\n"
+	migration := yaml.NewMigration(
+		yaml.RenameKeyRule("$.a", "$.c"),
+		yaml.SetDefaultRule("$.c", 0),
+	)
+	migrated, changes, err := migration.Migrate([]byte(src))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expected := "# comment\n---\nc: 1\n---\nb: 2\nc: 3\n"
+	if string(migrated) != expected {
+		t.Fatalf("unexpected source. expected:\n%s\nbut got:\n%s", expected, string(migrated))
+	}
+	if len(changes) != 2 {
+		t.Fatalf("unexpected number of changes: %v", changes)
+	}
+	for idx, expected := range []struct {
+		line, column, offset int
+	}{{3, 1, 15}, {6, 1, 29}} {
+		pos := changes[idx].Position
+		if pos.Line != expected.line || pos.Column != expected.column || pos.Offset != expected.offset {
+			t.Fatalf("unexpected position of %s: %s", changes[idx], pos)
+		}
+	}
+}
+
+func TestMigration_Error(t *testing.T) {
+	migration := yaml.NewMigration(
+		yaml.SplitValueRule("$.a", []string{"$.b", "$.c"}, func(v interface{}) ([]interface{}, error) {
+			return []interface{}{v}, nil
+		}),
+	)
+	if _, _, err := migration.Migrate([]byte("a: 1\n")); err == nil {
+		t.Fatal("expected error for wrong number of split values")
+	}
+}
+
+func TestMigration_SetDefaultToNull(t *testing.T) {
+	src := "a:\nb: null # comment\nc: 1\n"
+	migration := yaml.NewMigration(
+		yaml.SetDefaultRule("$.a", 1),
+		yaml.SetDefaultRule("$.b", "x"),
+		yaml.SetDefaultRule("$.c", 2),
+	)
+	migrated, changes, err := migration.Migrate([]byte(src))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expected := "a: 1\nb: x # comment\nc: 1\n"
+	if string(migrated) != expected {
+		t.Fatalf("unexpected source. expected:\n%s\nbut got:\n%s", expected, string(migrated))
+	}
+	if len(changes) != 2 {
+		t.Fatalf("unexpected number of changes: %v", changes)
+	}
+}
@@ -0,0 +1,70 @@
+package yaml
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/goccy/go-yaml/internal/errors"
+	"github.com/goccy/go-yaml/token"
+)
+
+// Normalize returns the canonical form of src.
+// Aliases and merge keys are expanded, keys of mappings are sorted and scalars are rendered in the canonical text
+// ( e.g. `0x10` becomes `16` and `'a'` becomes `a` ), so sources which have the same content produce the same bytes.
+// Keys which have the same text but different types ( e.g. `1` and `'1'` ) are kept as different keys.
+// It is intended for golden files and content hashing. Comments and formatting of src are not preserved.
+// Tags which are needed to resolve the value ( e.g. custom tags or `!!binary` ) are kept, and documents are separated by `---`.
+func Normalize(src []byte) ([]byte, error) {
+	docs, err := expandedDocuments(src)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse")
+	}
+	d := NewDecoder(nil)
+	d.isPreservedTag = true
+	// keys which have the same text but different types ( e.g. `1` and `'1'` ) must not be merged
+	d.useOrderedMap = true
+	d.isTypedMapKey = true
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	for _, doc := range docs {
+		if err := enc.Encode(normalizedValue(d.nodeToValue(doc.Body))); err != nil {
+			return nil, errors.Wrapf(err, "failed to encode document")
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// normalizedValue removes tags which are already reflected in the type of the value ( e.g. `!!str` or `!!int` )
+func normalizedValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case TaggedValue:
+		switch value.Tag {
+		case string(token.IntegerTag), token.FloatTag, token.NullTag, token.BooleanTag,
+			token.SequenceTag, token.MappingTag, token.StringTag:
+			return normalizedValue(value.Value)
+		}
+		return TaggedValue{Tag: value.Tag, Value: normalizedValue(value.Value)}
+	case MapSlice:
+		m := make(MapSlice, 0, len(value))
+		for _, item := range value {
+			m = append(m, MapItem{Key: normalizedValue(item.Key), Value: normalizedValue(item.Value)})
+		}
+		// sorted by the text of key, and by the type of key if the texts are the same
+		sort.SliceStable(m, func(i, j int) bool {
+			ki, kj := fmt.Sprint(m[i].Key), fmt.Sprint(m[j].Key)
+			if ki != kj {
+				return ki < kj
+			}
+			return fmt.Sprintf("%T", m[i].Key) < fmt.Sprintf("%T", m[j].Key)
+		})
+		return m
+	case []interface{}:
+		s := make([]interface{}, 0, len(value))
+		for _, v := range value {
+			s = append(s, normalizedValue(v))
+		}
+		return s
+	}
+	return v
+}
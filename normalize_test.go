@@ -0,0 +1,60 @@
+package yaml_test
+
+import (
+	"testing"
+
+	"github.com/goccy/go-yaml"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected string
+	}{
+		{
+			"b: 0x10\na: 'x'\n",
+			"a: x\nb: 16\n",
+		},
+		{
+			"a: &x {d: 1, c: 2}\nb: *x\ne:\n  <<: *x\n  c: 3\n",
+			"a:\n  c: 2\n  d: 1\nb:\n  c: 2\n  d: 1\ne:\n  c: 3\n  d: 1\n",
+		},
+		{
+			"a: !!str 1\nb: !foo 2\nc: !!binary aGVsbG8=\nd: [1, ~, 1.50]\n",
+			"a: \"1\"\nb: !foo 2\nc: !!binary aGVsbG8=\nd:\n- 1\n- null\n- 1.5\n",
+		},
+		{
+			"a: []\nb: {}\n",
+			"a: []\nb: {}\n",
+		},
+		{
+			"a: 1 # comment\n---\n- x\n",
+			"a: 1\n---\n- x\n",
+		},
+		{
+			"1: a\n'1': b\ntrue: c\n'true': d\n",
+			"\"1\": b\n1: a\ntrue: c\n\"true\": d\n",
+		},
+	}
+	for _, test := range tests {
+		normalized, err := yaml.Normalize([]byte(test.source))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if string(normalized) != test.expected {
+			t.Fatalf("unexpected normalized output of %q: %q", test.source, string(normalized))
+		}
+		renormalized, err := yaml.Normalize(normalized)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if string(renormalized) != test.expected {
+			t.Fatalf("normalized output is changed by normalizing again: %q", string(renormalized))
+		}
+	}
+	t.Run("invalid source", func(t *testing.T) {
+		if _, err := yaml.Normalize([]byte("a: *x\n")); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
@@ -1,6 +1,16 @@
 package yaml
 
-import "io"
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/token"
+	"golang.org/x/xerrors"
+)
 
 // DecodeOption functional option type for Decoder
 type DecodeOption func(d *Decoder) error
@@ -37,6 +47,215 @@ func RecursiveDir(isRecursive bool) DecodeOption {
 	}
 }
 
+// PreserveTags decode tagged node to TaggedValue which has the tag when the destination is interface{}.
+// It is useful to re-encode decoded values without losing the tags.
+func PreserveTags(isPreserved bool) DecodeOption {
+	return func(d *Decoder) error {
+		d.isPreservedTag = isPreserved
+		return nil
+	}
+}
+
+// MergeKey process merge key ( `<<` ) which merges the mappings into the mapping having the key. It is enabled by default.
+// If isEnabled is false, `<<` is decoded as a normal key ( e.g. for documents whose keys are literal data like HTML snippets ).
+func MergeKey(isEnabled bool) DecodeOption {
+	return func(d *Decoder) error {
+		d.isDisabledMergeKey = !isEnabled
+		return nil
+	}
+}
+
+// DecodeNull set policy to decode null into non-pointer struct field
+func DecodeNull(policy NullPolicy) DecodeOption {
+	return func(d *Decoder) error {
+		d.nullPolicy = policy
+		return nil
+	}
+}
+
+// ScalarToSlice decode a scalar into the slice which has only the scalar ( e.g. `tags: foo` into []string{"foo"} ).
+// It is useful for the configuration which accepts both a value and a list of values. null is not decoded into slice.
+func ScalarToSlice(isPromoted bool) DecodeOption {
+	return func(d *Decoder) error {
+		d.isPromotedScalar = isPromoted
+		return nil
+	}
+}
+
+// ScalarToString decode a scalar into string by the text in source ( e.g. `version: 1.10` into "1.10" instead of "1.1" ).
+// Numbers, booleans and the other non-string scalars keep their notation ( e.g. `0x1F`, `yes` or `.inf` ).
+// null is not decoded into string.
+func ScalarToString(isCoerced bool) DecodeOption {
+	return func(d *Decoder) error {
+		d.isCoercedToString = isCoerced
+		return nil
+	}
+}
+
+// DisallowUnknownField causes the Decoder to return an error when the destination is a struct
+// and the input contains keys which do not match any non-ignored, exported fields in the destination.
+// The error has ErrCodeUnknownField code and the position of the key.
+func DisallowUnknownField() DecodeOption {
+	return func(d *Decoder) error {
+		d.disallowUnknown = true
+		return nil
+	}
+}
+
+// DisallowDuplicateKey causes the Decoder to return an error when a mapping has the same key twice.
+// Keys are compared as the spec defines ( e.g. `a` and `"a"` are the same key, but `1` and `"1"` are not ) by ast.KeyEqual.
+// The error has ErrCodeDuplicateKey code and the positions of both keys.
+func DisallowDuplicateKey() DecodeOption {
+	return func(d *Decoder) error {
+		d.disallowDuplicateKey = true
+		return nil
+	}
+}
+
+// Strict enable all the strict checks of decoding. Currently it's DisallowUnknownField and DisallowDuplicateKey.
+func Strict() DecodeOption {
+	return func(d *Decoder) error {
+		d.disallowUnknown = true
+		d.disallowDuplicateKey = true
+		return nil
+	}
+}
+
+// DecodeArrayLength set policy to decode sequence into array whose length is different from the sequence
+func DecodeArrayLength(policy ArrayLengthPolicy) DecodeOption {
+	return func(d *Decoder) error {
+		d.arrayLengthPolicy = policy
+		return nil
+	}
+}
+
+// DecodeMerge set policy to decode into destination which already has values
+func DecodeMerge(policy MergePolicy) DecodeOption {
+	return func(d *Decoder) error {
+		d.mergePolicy = policy
+		return nil
+	}
+}
+
+// DecodeJSONTag use json tag of struct field if the field has no yaml tag ( e.g. `json:"name,omitempty"` ),
+// so the types defined for encoding/json ( e.g. Kubernetes API types ) can be decoded without yaml tags.
+// Embedded struct without tag is inlined like encoding/json.
+func DecodeJSONTag(useJSONTag bool) DecodeOption {
+	return func(d *Decoder) error {
+		d.structFieldOption.useJSONTag = useJSONTag
+		return nil
+	}
+}
+
+// UseOrderedMap decodes mapping into MapSlice instead of map[string]interface{}
+// for interface{} value, so the order of keys survives decoding and re-encoding.
+func UseOrderedMap() DecodeOption {
+	return func(d *Decoder) error {
+		d.useOrderedMap = true
+		return nil
+	}
+}
+
+// Stats collects the unknown keys, the coercions of scalars, the expanded aliases and the applied merge keys
+// of each decoded document into stats, so the documents can be audited before enabling strict options.
+func Stats(stats *DecodeStats) DecodeOption {
+	return func(d *Decoder) error {
+		if stats == nil {
+			return xerrors.New("DecodeStats must not be nil")
+		}
+		d.stats = stats
+		return nil
+	}
+}
+
+// DecodeFieldNamer converts the name of struct field to the key by namer if the field has no name in the tag
+// ( e.g. SnakeCase for `APIVersion` to `api_version` ), instead of lowercasing the name.
+func DecodeFieldNamer(namer func(string) string) DecodeOption {
+	return func(d *Decoder) error {
+		d.structFieldOption.fieldNamer = namer
+		return nil
+	}
+}
+
+// SchemaVersion selects the rule to resolve the type of plain scalars by YAML version ( `1.1` or `1.2` ).
+// `1.2` resolves them by the core schema ( e.g. `010` is 10 and `0b1` is string ), see token.ToCoreSchema.
+// `1.1` is the default rule which reads `010` as octal and supports `0b1` and `1_000` too.
+func SchemaVersion(version string) DecodeOption {
+	return func(d *Decoder) error {
+		switch version {
+		case "1.1":
+			d.isCoreSchema = false
+		case "1.2":
+			d.isCoreSchema = true
+		default:
+			return xerrors.Errorf("unsupported schema version: %s", version)
+		}
+		return nil
+	}
+}
+
+// LenientComment starts a comment by '#' even if it follows non-space character ( e.g. `abc#123` is decoded as `abc` ).
+// By default, '#' starts a comment only at the beginning of line or after white space as the spec requires,
+// so the option is for the legacy files which rely on the old behavior.
+func LenientComment() DecodeOption {
+	return func(d *Decoder) error {
+		d.isLenientComment = true
+		return nil
+	}
+}
+
+// DecodeTimeLayouts decodes time.Time from the string of layouts ( e.g. `time.RFC1123` ).
+// layouts are tried in order before the timestamp formats of YAML.
+func DecodeTimeLayouts(layouts ...string) DecodeOption {
+	return func(d *Decoder) error {
+		d.timeLayouts = append(d.timeLayouts, layouts...)
+		return nil
+	}
+}
+
+// DocumentHook calls hook after each document in the stream is decoded
+// with the index of the document, its root node and the value passed to Decode.
+// The root node is nil if the document is empty.
+// It is useful to log, validate or route documents ( e.g. by `kind` field ) without wrapping the loop of Decode.
+// If hook returns error, Decode returns it.
+func DocumentHook(hook func(index int, node ast.Node, v interface{}) error) DecodeOption {
+	return func(d *Decoder) error {
+		d.documentHook = hook
+		return nil
+	}
+}
+
+// DuplicateAnchor set policy to decode the document which defines the same anchor name twice
+func DuplicateAnchor(policy DuplicateAnchorPolicy) DecodeOption {
+	return func(d *Decoder) error {
+		d.duplicateAnchorPolicy = policy
+		return nil
+	}
+}
+
+// WarnDuplicateAnchor calls warn for each anchor defined twice in one document
+// with the positions of the first definition and the redefinition.
+// The redefinition shadows the first definition like DuplicateAnchorPolicyAllow.
+func WarnDuplicateAnchor(warn func(name string, first, second *token.Position)) DecodeOption {
+	return func(d *Decoder) error {
+		d.duplicateAnchorPolicy = DuplicateAnchorPolicyWarn
+		d.warnDuplicateAnchor = warn
+		return nil
+	}
+}
+
+// DecodeUntil stop decoding when stop reports true.
+// stop is called with each top-level key of the document and its value in document order,
+// and the keys after it are neither parsed nor decoded.
+// It is useful to read a few fields ( e.g. `apiVersion` ) from many large files.
+// If the document is not a block mapping, stop is not called.
+func DecodeUntil(stop func(key string, value interface{}) bool) DecodeOption {
+	return func(d *Decoder) error {
+		d.stopDecoding = stop
+		return nil
+	}
+}
+
 // Validator set StructValidator instance to Decoder
 func Validator(v StructValidator) DecodeOption {
 	return func(d *Decoder) error {
@@ -45,6 +264,68 @@ func Validator(v StructValidator) DecodeOption {
 	}
 }
 
+// CommentToMap collect comments in the document into cm keyed by YAMLPath ( e.g. `$.a.b[0]` ) of the commented value.
+// Comments of the mapping value are keyed by the path of the key, and comments of the document are keyed by `$`.
+func CommentToMap(cm CommentMap) DecodeOption {
+	return func(d *Decoder) error {
+		if cm == nil {
+			return xerrors.New("CommentMap must not be nil")
+		}
+		d.toCommentMap = cm
+		return nil
+	}
+}
+
+// MaxAliasCount limits the number of alias expansions in a document to reject the document
+// which expands exponentially by aliases ( e.g. "billion laughs" attack ). Default is DefaultMaxAliasCount.
+// The aliases in the anchored value are counted every time the alias is expanded.
+// If count is 0 or negative, the number is not limited.
+func MaxAliasCount(count int) DecodeOption {
+	return func(d *Decoder) error {
+		d.maxAliasCount = count
+		return nil
+	}
+}
+
+// AliasDepthLimit limits the depth of nested alias expansion ( e.g. alias in the value of the anchor referred by alias ).
+// Default is DefaultAliasDepthLimit. If depth is 0 or negative, the depth is not limited.
+func AliasDepthLimit(depth int) DecodeOption {
+	return func(d *Decoder) error {
+		d.aliasDepthLimit = depth
+		return nil
+	}
+}
+
+// MaxDepth limits the depth of nested mappings and sequences in a document ( e.g. `a: [[1]]` has depth 3 ).
+// If depth is 0 or negative, the depth is not limited. It is the default.
+func MaxDepth(depth int) DecodeOption {
+	return func(d *Decoder) error {
+		d.maxDepth = depth
+		return nil
+	}
+}
+
+// MaxDocumentSize limits the size of each document in bytes. Decode returns error without reading the rest of the input
+// when the document exceeds the limit. If size is 0 or negative, the size is not limited. It is the default.
+func MaxDocumentSize(size int) DecodeOption {
+	return func(d *Decoder) error {
+		d.maxDocumentSize = size
+		return nil
+	}
+}
+
+// SafeMode enables all the restrictions for untrusted input like `safe_load` of PyYAML.
+// The limits of MaxAliasCount, AliasDepthLimit, MaxDepth and MaxDocumentSize are capped at
+// DefaultMaxAliasCount, DefaultAliasDepthLimit, SafeModeMaxDepth and SafeModeMaxDocumentSize, but the stricter limits are kept.
+// Tags not defined by YAML ( e.g. `!foo` ) are rejected, and Decode returns error if reference files or readers are passed.
+// The restrictions are applied regardless of the order of options.
+func SafeMode() DecodeOption {
+	return func(d *Decoder) error {
+		d.isSafeMode = true
+		return nil
+	}
+}
+
 // EncodeOption functional option type for Encoder
 type EncodeOption func(e *Encoder) error
 
@@ -63,3 +344,314 @@ func Flow(isFlowStyle bool) EncodeOption {
 		return nil
 	}
 }
+
+// InlineSequenceMap render the first key of mapping in sequence on the line of `-` ( e.g. "- a: 1\n  b: 2" ).
+// It is the default style. If isInline is false, the mapping starts at the next line ( e.g. "-\n  a: 1\n  b: 2" ).
+func InlineSequenceMap(isInline bool) EncodeOption {
+	return func(e *Encoder) error {
+		e.isMappingOnNextLine = !isInline
+		return nil
+	}
+}
+
+// FlowDepth encoding mappings and sequences deeper than depth by flow style.
+// Top level mapping or sequence is at depth 1, so FlowDepth(1) encodes only top level by block style.
+// It is useful to render deeply nested values compactly.
+func FlowDepth(depth int) EncodeOption {
+	return func(e *Encoder) error {
+		if depth < 0 {
+			return xerrors.Errorf("invalid flow depth %d", depth)
+		}
+		e.flowDepth = depth
+		return nil
+	}
+}
+
+// FlowSequence encoding sequences of scalars by flow style ( e.g. `tags: [a, b, c]` )
+// if the width of the flow style is not more than maxWidth. Longer sequences are encoded by block style.
+// If maxWidth is 0, all sequences are encoded by the style specified by the other options.
+func FlowSequence(maxWidth int) EncodeOption {
+	return func(e *Encoder) error {
+		if maxWidth < 0 {
+			return xerrors.Errorf("invalid max width %d of flow sequence", maxWidth)
+		}
+		e.flowSequenceWidth = maxWidth
+		return nil
+	}
+}
+
+// CommentWidth wraps head and foot comments longer than width at spaces ( e.g. `# a b` to `# a\n# b` ).
+// Wrapped lines keep `#` and the indentation of the comment. Line comments aren't wrapped.
+// If width is 0, comments are written as they are.
+func CommentWidth(width int) EncodeOption {
+	return func(e *Encoder) error {
+		if width < 0 {
+			return xerrors.Errorf("invalid width %d of comment", width)
+		}
+		e.commentWidth = width
+		return nil
+	}
+}
+
+// SortKeys sorts the keys of all mappings including the fields of struct and the items of MapSlice
+// in ascending order, so the output doesn't depend on the order of declaration or construction.
+// Keys of map are always sorted. Merge keys ( `<<` ) are placed first.
+func SortKeys(isSorted bool) EncodeOption {
+	return func(e *Encoder) error {
+		if isSorted {
+			e.keyLess = func(a, b string) bool { return a < b }
+		} else {
+			e.keyLess = nil
+		}
+		return nil
+	}
+}
+
+// SortKeysFunc sorts the keys of all mappings like SortKeys by less which reports whether key a must be placed before key b
+// ( e.g. to place `apiVersion` and `kind` first ).
+func SortKeysFunc(less func(a, b string) bool) EncodeOption {
+	return func(e *Encoder) error {
+		if less == nil {
+			return xerrors.New("less function must not be nil")
+		}
+		e.keyLess = less
+		return nil
+	}
+}
+
+// UseBinaryTag encodes byte slice as base64 text tagged by `!!binary` instead of the sequence of integers.
+// If width is positive, the text longer than width is wrapped into literal block scalar ( e.g. "!!binary |\n  aGVs\n  bG8=\n" ).
+func UseBinaryTag(width int) EncodeOption {
+	return func(e *Encoder) error {
+		if width < 0 {
+			return xerrors.Errorf("width of binary must not be negative: %d", width)
+		}
+		e.isBinaryTag = true
+		e.binaryWidth = width
+		return nil
+	}
+}
+
+// EncodeTimeLayout encodes time.Time by layout instead of time.RFC3339Nano ( e.g. `2006-01-02` for date only ).
+func EncodeTimeLayout(layout string) EncodeOption {
+	return func(e *Encoder) error {
+		if layout == "" {
+			return xerrors.New("time layout must not be empty")
+		}
+		e.timeLayout = layout
+		return nil
+	}
+}
+
+// TypeStyle encodes all values of the type of v by style,
+// so the fields of the type don't need the tag for each of them ( e.g. `TypeStyle(Script(""), EncodeStyle{Literal: true})` ).
+// The style is applied to the value encoded by the marshaler of the type too.
+func TypeStyle(v interface{}, style EncodeStyle) EncodeOption {
+	return func(e *Encoder) error {
+		if v == nil {
+			return xerrors.New("value of TypeStyle must not be nil")
+		}
+		if e.typeStyles == nil {
+			e.typeStyles = map[reflect.Type]EncodeStyle{}
+		}
+		e.typeStyles[reflect.TypeOf(v)] = style
+		return nil
+	}
+}
+
+// BoolFormat set text of boolean values for the consumer which accepts only specific format ( e.g. `yes` and `no` ).
+// Supported pairs are true/false, yes/no and on/off in lowercase, title case or uppercase ( e.g. `Yes` and `No` ).
+// Strings which have the same text as any of the supported pairs are quoted.
+func BoolFormat(trueText, falseText string) EncodeOption {
+	return func(e *Encoder) error {
+		for _, format := range boolFormats {
+			if format.trueText == trueText && format.falseText == falseText {
+				e.boolFormat = format
+				return nil
+			}
+		}
+		return xerrors.Errorf("unsupported bool format %s/%s", trueText, falseText)
+	}
+}
+
+// LineBreak set line terminator of output. Supported terminators are "\n" ( default ) and "\r\n".
+func LineBreak(terminator string) EncodeOption {
+	return func(e *Encoder) error {
+		if terminator != "\n" && terminator != "\r\n" {
+			return xerrors.Errorf("unsupported line terminator %q", terminator)
+		}
+		e.lineBreak = terminator
+		return nil
+	}
+}
+
+// CommentColumn aligns line comments at column ( e.g. `a: 1     # comment` for 10 ).
+// The comment following the value which reaches column is written after the spaces specified by CommentSpaces.
+// If column is 0, line comments aren't aligned.
+func CommentColumn(column int) EncodeOption {
+	return func(e *Encoder) error {
+		if column < 0 {
+			return xerrors.Errorf("invalid column %d of comment", column)
+		}
+		e.commentColumn = column
+		return nil
+	}
+}
+
+// CommentSpaces writes line comments after at least spaces from the value ( e.g. `a: 1  # comment` for 2 ).
+// The default is 1.
+func CommentSpaces(spaces int) EncodeOption {
+	return func(e *Encoder) error {
+		if spaces < 1 {
+			return xerrors.Errorf("invalid spaces %d before comment", spaces)
+		}
+		e.commentSpaces = spaces
+		return nil
+	}
+}
+
+// VersionDirective emit `%YAML` directive with version ( e.g. `1.2` ) at the top of each document
+func VersionDirective(version string) EncodeOption {
+	return func(e *Encoder) error {
+		if !versionDirectivePattern.MatchString(version) {
+			return xerrors.Errorf("invalid version %q for %%YAML directive", version)
+		}
+		e.directives = append(e.directives, fmt.Sprintf("YAML %s", version))
+		return nil
+	}
+}
+
+// TagDirective emit `%TAG` directive which binds handle ( e.g. `!e!` ) to prefix ( e.g. `tag:example.com,2000:` )
+// at the top of each document
+func TagDirective(handle, prefix string) EncodeOption {
+	return func(e *Encoder) error {
+		if !tagHandlePattern.MatchString(handle) {
+			return xerrors.Errorf("invalid tag handle %q for %%TAG directive", handle)
+		}
+		if prefix == "" || strings.ContainsAny(prefix, " \t\r\n") {
+			return xerrors.Errorf("invalid tag prefix %q for %%TAG directive", prefix)
+		}
+		e.directives = append(e.directives, fmt.Sprintf("TAG %s %s", handle, prefix))
+		return nil
+	}
+}
+
+var (
+	versionDirectivePattern = regexp.MustCompile(`^[0-9]+\.[0-9]+$`)
+	tagHandlePattern        = regexp.MustCompile(`^!([0-9A-Za-z-]*!)?$`)
+)
+
+// ExplicitTag emit the resolved tag ( e.g. `!!str`, `!!int` or `!!map` ) of the values for which predicate reports true.
+// predicate receives the node encoded from value ( e.g. *ast.StringNode which has quoted value if needed ),
+// so the tag can be emitted only for specific kind of node.
+// Keys and aliases are not tagged.
+func ExplicitTag(predicate func(ast.Node) bool) EncodeOption {
+	return func(e *Encoder) error {
+		e.explicitTag = predicate
+		return nil
+	}
+}
+
+// UseSingleQuote quotes string by single quote instead of double quote ( e.g. `'yes'` ).
+// String which has the characters which must be escaped ( e.g. line break ) is quoted by double quote.
+func UseSingleQuote(sq bool) EncodeOption {
+	return func(e *Encoder) error {
+		e.isSingleQuote = sq
+		return nil
+	}
+}
+
+// ForceQuote quotes all string values even if they are read as string without quotes.
+// Keys of mapping are quoted only if they are read as another type without quotes.
+// Strings read as another type by YAML 1.1 consumers ( e.g. `yes`, `on`, `y`, `012` or `2001-12-14` ) are always quoted without this option,
+// but `y` and `n` are not quoted in keys.
+func ForceQuote(force bool) EncodeOption {
+	return func(e *Encoder) error {
+		e.isForcedQuote = force
+		return nil
+	}
+}
+
+// UseLiteralStyleIfMultiline encodes multi-line string by literal block scalar ( e.g. "|\n  a\n  b" ) instead of quoted string.
+// String which has the characters which must be escaped or which has a line starting with white space is quoted as before.
+func UseLiteralStyleIfMultiline(useLiteralStyle bool) EncodeOption {
+	return func(e *Encoder) error {
+		e.isLiteralStyle = useLiteralStyle
+		return nil
+	}
+}
+
+// JSON encodes values as JSON, which is also YAML ( e.g. `{"a": [1, "b"]}` ), so one encoder can write both formats.
+// Strings and keys are double quoted by the escape rules of JSON. Control characters are escaped ( e.g. `\u0000` )
+// and invalid UTF-8 sequences are replaced by U+FFFD.
+// Anchors and aliases are expanded, and tags, comments and directives are not written.
+// Values which JSON cannot represent ( e.g. infinity and NaN ) cause an error.
+func JSON() EncodeOption {
+	return func(e *Encoder) error {
+		e.isJSON = true
+		return nil
+	}
+}
+
+// EncodeJSONTag use json tag of struct field if the field has no yaml tag ( e.g. `json:"name,omitempty"` ),
+// so the types defined for encoding/json ( e.g. Kubernetes API types ) can be encoded without yaml tags.
+// Embedded struct without tag is inlined like encoding/json.
+func EncodeJSONTag(useJSONTag bool) EncodeOption {
+	return func(e *Encoder) error {
+		e.structFieldOption.useJSONTag = useJSONTag
+		return nil
+	}
+}
+
+// EncodeFieldNamer converts the name of struct field to the key by namer if the field has no name in the tag
+// ( e.g. SnakeCase for `APIVersion` to `api_version` ), instead of lowercasing the name.
+func EncodeFieldNamer(namer func(string) string) EncodeOption {
+	return func(e *Encoder) error {
+		e.structFieldOption.fieldNamer = namer
+		return nil
+	}
+}
+
+// NodeHook set hook called with ast.Node converted from value before rendering.
+// The node returned by hook is rendered instead of the original node,
+// so hook can post-process the node ( e.g. reorder keys or add anchors ).
+// If hook returns nil, nothing is written.
+func NodeHook(hook func(ast.Node) (ast.Node, error)) EncodeOption {
+	return func(e *Encoder) error {
+		e.nodeHook = hook
+		return nil
+	}
+}
+
+// NodeMiddleware adds middleware called with each value node converted from value and its path ( e.g. `$.a.b[0]` ) before rendering.
+// The root node is called with `$`, and the values of the node returned by middleware are called after that,
+// so middleware can convert nodes at any depth ( e.g. sort keys, redact secrets or add comments ).
+// If middleware returns nil, the value is removed from the parent mapping or sequence.
+// Middlewares are called in the order they are added, and they are applied before the hook set by NodeHook.
+func NodeMiddleware(middleware func(path string, node ast.Node) (ast.Node, error)) EncodeOption {
+	return func(e *Encoder) error {
+		e.nodeMiddlewares = append(e.nodeMiddlewares, middleware)
+		return nil
+	}
+}
+
+// AutoAnchor detects the pointer encoded more than once in the document,
+// and emits the anchor at the first occurrence and aliases after that ( e.g. `a: &id001 {...}` and `b: *id001` ).
+// It prevents duplicated output. The decoded values share the pointer only if the fields have anchor or alias option,
+// and the other aliases are decoded as the copies of the anchored value.
+// Cyclic data structures ( e.g. the pointer referred from its own value ) cannot be encoded even with this option.
+func AutoAnchor(isAutoAnchor bool) EncodeOption {
+	return func(e *Encoder) error {
+		e.isAutoAnchor = isAutoAnchor
+		return nil
+	}
+}
+
+// WithComment emit comments of cm at the values pointed by the paths of cm.
+// If the value at the path doesn't exist, encoding fails with ErrNotFoundNode.
+func WithComment(cm CommentMap) EncodeOption {
+	return func(e *Encoder) error {
+		e.commentMap = cm
+		return nil
+	}
+}
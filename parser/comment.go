@@ -0,0 +1,220 @@
+package parser
+
+import (
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/token"
+)
+
+// commentAttacher associates comment tokens with the nearest nodes.
+// A comment at the end of the line is LineComment of the node on that line.
+// Comment lines followed by the node with the same or deeper indent are HeadComment of the node,
+// and comment lines followed by the dedented node ( or end of document ) are FootComment of the preceding node at the indent of comment.
+type commentAttacher struct {
+	tokenNodes map[*token.Token]ast.CommentedNode // node which the token represents
+	entryNodes map[*token.Token]ast.CommentedNode // mapping value or sequence entry started by the token ( key or `-` )
+	docs       map[*token.Token]*ast.Document     // document started by DocumentHeader token
+}
+
+func newCommentAttacher(file *ast.File) *commentAttacher {
+	a := &commentAttacher{
+		tokenNodes: map[*token.Token]ast.CommentedNode{},
+		entryNodes: map[*token.Token]ast.CommentedNode{},
+		docs:       map[*token.Token]*ast.Document{},
+	}
+	for _, doc := range file.Docs {
+		if doc.Start != nil {
+			a.docs[doc.Start] = doc
+		}
+		if doc.Body != nil {
+			ast.WalkAll(a, doc.Body)
+		}
+	}
+	return a
+}
+
+// Visit collects nodes which can have comments
+func (a *commentAttacher) Visit(node ast.Node) ast.Visitor {
+	switch n := node.(type) {
+	case *ast.MappingValueNode:
+		a.tokenNodes[n.Start] = n
+		a.entryNodes[n.Key.GetToken()] = n
+	case *ast.MappingNode:
+		if n.IsFlowStyle {
+			a.tokenNodes[n.Start] = n
+			a.tokenNodes[n.End] = n
+		}
+	case *ast.SequenceNode:
+		if n.IsFlowStyle {
+			a.tokenNodes[n.Start] = n
+			a.tokenNodes[n.End] = n
+			break
+		}
+		for _, value := range n.Values {
+			v, ok := value.(ast.CommentedNode)
+			if !ok {
+				continue
+			}
+			if entry := sequenceEntryToken(firstToken(value)); entry != nil {
+				a.entryNodes[entry] = v
+			}
+		}
+	case ast.CommentedNode:
+		a.tokenNodes[n.GetToken()] = n
+	}
+	return a
+}
+
+// firstToken returns the token at the beginning of node
+func firstToken(node ast.Node) *token.Token {
+	switch n := node.(type) {
+	case *ast.MappingValueNode:
+		return n.Key.GetToken()
+	case *ast.MappingNode:
+		if !n.IsFlowStyle && len(n.Values) > 0 {
+			return n.Values[0].Key.GetToken()
+		}
+	}
+	return node.GetToken()
+}
+
+// sequenceEntryToken returns `-` token in front of tk
+func sequenceEntryToken(tk *token.Token) *token.Token {
+	if tk == nil {
+		return nil
+	}
+	for prev := tk.Prev; prev != nil; prev = prev.Prev {
+		switch prev.Type {
+		case token.CommentType:
+			continue
+		case token.SequenceEntryType:
+			return prev
+		}
+		return nil
+	}
+	return nil
+}
+
+func (a *commentAttacher) attach(file *ast.File, tokens token.Tokens) {
+	var (
+		prev      *token.Token
+		group     []*token.Token
+		entries   []*token.Token // tokens started entries in the current document
+		flowDepth int
+		doc       *ast.Document
+	)
+	if len(file.Docs) > 0 && file.Docs[0].Start == nil {
+		// document without DocumentHeader
+		doc = file.Docs[0]
+	}
+	for _, tk := range tokens {
+		if tk.Type == token.CommentType {
+			if flowDepth > 0 {
+				// comments in flow collection are not associated
+				continue
+			}
+			if len(group) == 0 && prev != nil && prev.Position.Line == tk.Position.Line {
+				a.addLineComment(prev, tk)
+				continue
+			}
+			if len(group) > 0 && tk.Position.Column < group[0].Position.Column {
+				// dedented comment line starts another group ( e.g. foot comment of outer node )
+				a.addCommentGroup(group, tk, entries, doc)
+				group = nil
+			}
+			group = append(group, tk)
+			continue
+		}
+		if len(group) > 0 {
+			a.addCommentGroup(group, tk, entries, doc)
+			group = nil
+		}
+		switch tk.Type {
+		case token.MappingStartType, token.SequenceStartType:
+			flowDepth++
+		case token.MappingEndType, token.SequenceEndType:
+			flowDepth--
+		case token.DocumentHeaderType:
+			doc = a.docs[tk]
+			entries = nil
+		}
+		if _, exists := a.entryNodes[tk]; exists && flowDepth == 0 {
+			entries = append(entries, tk)
+		}
+		prev = tk
+	}
+	if len(group) > 0 {
+		a.addCommentGroup(group, nil, entries, doc)
+	}
+}
+
+func (a *commentAttacher) addLineComment(prev, comment *token.Token) {
+	if node, exists := a.tokenNodes[prev]; exists {
+		c := node.GetComments()
+		c.LineComment = joinComment(c.LineComment, commentText(comment))
+	} else if node, exists := a.entryNodes[prev]; exists && prev.Type == token.SequenceEntryType {
+		// comment after `-` ( e.g. "- # comment\n  a: b" )
+		c := node.GetComments()
+		c.LineComment = joinComment(c.LineComment, commentText(comment))
+	} else if doc, exists := a.docs[prev]; exists {
+		doc.LineComment = joinComment(doc.LineComment, commentText(comment))
+	}
+}
+
+// addCommentGroup associates consecutive comment lines with the node by the token next to them
+func (a *commentAttacher) addCommentGroup(group []*token.Token, next *token.Token, entries []*token.Token, doc *ast.Document) {
+	texts := make([]string, 0, len(group))
+	for _, tk := range group {
+		texts = append(texts, commentText(tk))
+	}
+	text := joinComment(texts...)
+	column := group[0].Position.Column
+	if next == nil || next.Type == token.DocumentHeaderType || next.Type == token.DocumentEndType || next.Position.Column < column {
+		for i := len(entries) - 1; i >= 0; i-- {
+			if entries[i].Position.Column > column {
+				continue
+			}
+			c := a.entryNodes[entries[i]].GetComments()
+			c.FootComment = joinComment(c.FootComment, text)
+			return
+		}
+		if doc != nil {
+			doc.FootComment = joinComment(doc.FootComment, text)
+			return
+		}
+	}
+	if next == nil {
+		return
+	}
+	if node, exists := a.entryNodes[next]; exists {
+		c := node.GetComments()
+		c.HeadComment = joinComment(c.HeadComment, text)
+	} else if node, exists := a.tokenNodes[next]; exists {
+		c := node.GetComments()
+		c.HeadComment = joinComment(c.HeadComment, text)
+	} else if doc, exists := a.docs[next]; exists {
+		doc.HeadComment = joinComment(doc.HeadComment, text)
+	}
+}
+
+func commentText(tk *token.Token) string {
+	return "#" + tk.Value
+}
+
+func joinComment(comments ...string) string {
+	text := ""
+	for _, comment := range comments {
+		if comment == "" {
+			continue
+		}
+		if text != "" {
+			text += "\n"
+		}
+		text += comment
+	}
+	return text
+}
+
+// attachComments associates comment tokens with the nodes in file
+func attachComments(file *ast.File, tokens token.Tokens) {
+	newCommentAttacher(file).attach(file, tokens)
+}
@@ -1,45 +1,36 @@
 package parser
 
-import "github.com/goccy/go-yaml/token"
+import (
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/token"
+)
 
 // context context at parsing
 type context struct {
-	idx    int
-	size   int
-	tokens token.Tokens
-	mode   Mode
+	cursor     *token.Cursor
+	mode       Mode
+	arena      *ast.Arena
+	tagHandles map[string]string // tag handles defined by %TAG directives of the current document
 }
 
 func (c *context) next() bool {
-	return c.idx < c.size
+	return c.cursor.Next()
 }
 
 func (c *context) previousToken() *token.Token {
-	if c.idx > 0 {
-		return c.tokens[c.idx-1]
-	}
-	return nil
+	return c.cursor.Peek(-1)
 }
 
 func (c *context) currentToken() *token.Token {
-	if c.idx >= c.size {
-		return nil
-	}
-	return c.tokens[c.idx]
+	return c.cursor.Current()
 }
 
 func (c *context) nextToken() *token.Token {
-	if c.size > c.idx+1 {
-		return c.tokens[c.idx+1]
-	}
-	return nil
+	return c.cursor.Peek(1)
 }
 
 func (c *context) afterNextToken() *token.Token {
-	if c.size > c.idx+2 {
-		return c.tokens[c.idx+2]
-	}
-	return nil
+	return c.cursor.Peek(2)
 }
 
 func (c *context) enabledComment() bool {
@@ -47,29 +38,47 @@ func (c *context) enabledComment() bool {
 }
 
 func (c *context) progress(num int) {
-	if c.size <= c.idx+num {
-		c.idx = c.size
-	} else {
-		c.idx += num
-	}
+	c.cursor.Progress(num)
 }
 
 func newContext(tokens token.Tokens, mode Mode) *context {
-	filteredTokens := token.Tokens{}
-	if mode&ParseComments != 0 {
-		filteredTokens = tokens
-	} else {
-		for _, tk := range tokens {
-			if tk.Type == token.CommentType {
-				continue
-			}
+	if mode&CoreSchema != 0 {
+		// types are resolved on the copies so as not to change the tokens of caller
+		tokens = copyTokens(tokens)
+	}
+	filteredTokens := make(token.Tokens, 0, len(tokens))
+	for _, tk := range tokens {
+		if tk.Type == token.CommentType {
+			continue
+		}
+		if mode&CoreSchema != 0 {
+			token.ToCoreSchema(tk)
+		}
+		if mode&ParseComments != 0 {
+			// keep links to comment tokens so that comments can be reached from the other tokens
+			filteredTokens = append(filteredTokens, tk)
+		} else {
 			filteredTokens.Add(tk)
 		}
 	}
 	return &context{
-		idx:    0,
-		size:   len(filteredTokens),
-		tokens: filteredTokens,
+		cursor: token.NewCursor(filteredTokens),
 		mode:   mode,
+		arena:  ast.NewArena(),
+	}
+}
+
+// copyTokens copies tokens and links the copies in the same order.
+// The first and the last copies keep the links to the tokens out of tokens.
+func copyTokens(tokens token.Tokens) token.Tokens {
+	copied := make(token.Tokens, len(tokens))
+	for idx, tk := range tokens {
+		tkCopy := *tk
+		copied[idx] = &tkCopy
+		if idx > 0 {
+			copied[idx-1].Next = copied[idx]
+			copied[idx].Prev = copied[idx-1]
+		}
 	}
+	return copied
 }
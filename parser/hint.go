@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-yaml/internal/errors"
+	"github.com/goccy/go-yaml/token"
+)
+
+// hint returns the suggestion for the common mistakes around the token where err occurred.
+// The tokens on the line of the token and the line of the previous token are inspected,
+// because some mistakes are reported at the next line ( e.g. `a:b\nc: d` ).
+func hint(err errors.SyntaxError) string {
+	if err.Code() == errors.CodeTabIndentation {
+		return "use spaces instead of tabs for indentation"
+	}
+	tk := err.Token()
+	if tk == nil || tk.Position == nil {
+		return ""
+	}
+	tokens := lineTokens(tk)
+	if tk.Prev != nil && tk.Prev.Position != nil && tk.Prev.Position.Line != tk.Position.Line {
+		tokens = append(tokens, lineTokens(tk.Prev)...)
+	}
+	for _, t := range tokens {
+		if h := tokenHint(t); h != "" {
+			return h
+		}
+	}
+	return ""
+}
+
+// lineTokens returns the tokens on the line of tk
+func lineTokens(tk *token.Token) []*token.Token {
+	first := tk
+	for first.Prev != nil && first.Prev.Position.Line == tk.Position.Line {
+		first = first.Prev
+	}
+	var tokens []*token.Token
+	for t := first; t != nil && t.Position.Line == tk.Position.Line; t = t.Next {
+		tokens = append(tokens, t)
+	}
+	return tokens
+}
+
+func tokenHint(tk *token.Token) string {
+	switch tk.Type {
+	case token.AliasType:
+		glob := "*.go"
+		if tk.Next != nil && tk.Next.Position.Line == tk.Position.Line {
+			glob = "*" + tk.Next.Value
+		}
+		return fmt.Sprintf("'*' starts an alias. did you mean to quote this glob? ( e.g. %q )", glob)
+	case token.DirectiveType:
+		if tk.Position.Column == 1 {
+			return ""
+		}
+		return "'%' cannot start a plain scalar. quote the value containing '%'"
+	case token.StringType:
+		value := firstLine(tk)
+		if strings.HasPrefix(value, "@") || strings.HasPrefix(value, "`") {
+			return fmt.Sprintf("%q is a reserved indicator and cannot start a plain scalar. quote the value ( e.g. %q )", value[:1], value)
+		}
+		if idx := strings.Index(value, ":"); idx > 0 && idx < len(value)-1 && value[idx+1] != ' ' && !strings.HasPrefix(value[idx:], "://") {
+			return fmt.Sprintf("missing space after ':'. did you mean %q ?", value[:idx]+": "+value[idx+1:])
+		}
+	}
+	return ""
+}
+
+// firstLine returns the text of tk in the first line, because multiline plain scalar is folded into one line
+func firstLine(tk *token.Token) string {
+	origin := strings.TrimSpace(tk.Origin)
+	if origin == "" {
+		return tk.Value
+	}
+	return strings.TrimSpace(strings.SplitN(origin, "\n", 2)[0])
+}
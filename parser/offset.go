@@ -0,0 +1,251 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/internal/yamlpath"
+	"github.com/goccy/go-yaml/token"
+)
+
+// PositionKind kind of position in document
+type PositionKind int
+
+const (
+	// PositionUnknown position is not in key, value or comment
+	PositionUnknown PositionKind = iota
+	// PositionKey position is in mapping key
+	PositionKey
+	// PositionValue position is in mapping value or sequence entry
+	PositionValue
+	// PositionComment position is in comment
+	PositionComment
+)
+
+// String position kind to text
+func (k PositionKind) String() string {
+	switch k {
+	case PositionKey:
+		return "key"
+	case PositionValue:
+		return "value"
+	case PositionComment:
+		return "comment"
+	}
+	return "unknown"
+}
+
+// OffsetContext context at the offset in document
+type OffsetContext struct {
+	// Nodes enclosing nodes from outermost to innermost
+	Nodes []ast.Node
+	// Path path to the innermost node ( e.g. `$.a.b[0]` )
+	Path string
+	// Kind kind of position
+	Kind PositionKind
+}
+
+// ContextAt returns context at the offset in file.
+// offset is zero-based byte offset in source.
+// To detect comment position, file must be parsed with ParseComments mode.
+func ContextAt(file *ast.File, offset int) *OffsetContext {
+	ctx := &OffsetContext{Path: "$"}
+	for _, doc := range file.Docs {
+		if doc.Body == nil {
+			continue
+		}
+		start, end := nodeOffsetRange(doc.Body)
+		if offset < start || end < offset {
+			continue
+		}
+		ctx.walk(doc.Body, offset)
+		break
+	}
+	if len(file.Docs) > 0 && file.Docs[0].Body != nil && isCommentOffset(file.Docs[0].Body.GetToken(), offset) {
+		ctx.Kind = PositionComment
+	}
+	return ctx
+}
+
+func (c *OffsetContext) walk(node ast.Node, offset int) {
+	c.Nodes = append(c.Nodes, node)
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		for _, value := range n.Values {
+			if start, end := nodeOffsetRange(value); start <= offset && offset <= end {
+				c.walk(value, offset)
+				return
+			}
+		}
+	case *ast.MappingValueNode:
+		if start, end := nodeOffsetRange(n.Key); start <= offset && offset <= end {
+			c.Nodes = append(c.Nodes, n.Key)
+			c.Path = yamlpath.AppendKey(c.Path, n.Key.GetToken().Value)
+			c.Kind = PositionKey
+			return
+		}
+		c.Path = yamlpath.AppendKey(c.Path, n.Key.GetToken().Value)
+		c.Kind = PositionValue
+		if start, end := nodeOffsetRange(n.Value); start <= offset && offset <= end {
+			c.walk(n.Value, offset)
+		}
+	case *ast.SequenceNode:
+		for idx, value := range n.Values {
+			if start, end := nodeOffsetRange(value); start <= offset && offset <= end {
+				c.Path = fmt.Sprintf("%s[%d]", c.Path, idx)
+				c.Kind = PositionValue
+				c.walk(value, offset)
+				return
+			}
+		}
+	case *ast.AnchorNode:
+		c.walk(n.Value, offset)
+	case *ast.TagNode:
+		if n.Value != nil {
+			c.walk(n.Value, offset)
+		}
+	}
+}
+
+// isCommentOffset reports whether offset is in comment.
+// tk is used to traverse all tokens in the same token stream.
+func isCommentOffset(tk *token.Token, offset int) bool {
+	if tk == nil {
+		return false
+	}
+	for tk.Prev != nil {
+		tk = tk.Prev
+	}
+	for ; tk != nil; tk = tk.Next {
+		if tk.Type != token.CommentType || tk.Position == nil {
+			continue
+		}
+		start, end := tokenOffsetRange(tk)
+		if start <= offset && offset <= end {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeOffsetRange returns zero-based offset range of node.
+// end points the offset after the last character.
+func nodeOffsetRange(node ast.Node) (int, int) {
+	start, end := -1, -1
+	for _, tk := range ast.Tokens(node) {
+		if tk.Position == nil {
+			continue
+		}
+		s, e := tokenOffsetRange(tk)
+		if start < 0 || s < start {
+			start = s
+		}
+		if end < e {
+			end = e
+		}
+	}
+	return start, end
+}
+
+func tokenOffsetRange(tk *token.Token) (int, int) {
+	text := strings.Trim(tk.Origin, " \t\r\n")
+	if text == "" {
+		text = tk.Value
+	}
+	start := tk.Position.Offset - 1
+	return start, start + len(text)
+}
+
+// documentRanges returns the byte ranges of docs in the source of tokens.
+// The boundary of documents is the beginning of the line which has the first token of the latter document.
+// Comments between documents belong to the latter document if they are not at the same line as the end of the former document.
+func documentRanges(docs []*ast.Document, tokens token.Tokens) []ast.DocumentRange {
+	ranges := make([]ast.DocumentRange, 0, len(docs))
+	end := 0
+	for _, doc := range docs {
+		r := ast.DocumentRange{Start: end, End: end}
+		if s, e := documentOffsetRange(doc); s >= 0 {
+			r = ast.DocumentRange{Start: s, End: e}
+		}
+		ranges = append(ranges, r)
+		end = r.End
+	}
+	if len(ranges) == 0 {
+		return ranges
+	}
+	for idx := 1; idx < len(ranges); idx++ {
+		prev, cur := &ranges[idx-1], &ranges[idx]
+		for _, tk := range tokens {
+			if tk.Position == nil {
+				continue
+			}
+			// `...` after the document without `---` is not a part of Document node
+			if s, e := tokenOffsetRange(tk); tk.Type == token.DocumentEndType && prev.End <= s && e <= cur.Start {
+				prev.End = e
+			}
+		}
+		boundary := cur.Start
+		for tidx, tk := range tokens {
+			if tk.Position == nil {
+				continue
+			}
+			s, _ := tokenOffsetRange(tk)
+			if s < prev.End || cur.Start < s {
+				continue
+			}
+			if tidx > 0 && !isLineBreakBetween(tokens[tidx-1], tk) {
+				continue
+			}
+			// characters before the first token of line are spaces
+			boundary = s - (tk.Position.Column - 1)
+			break
+		}
+		prev.End, cur.Start = boundary, boundary
+	}
+	ranges[0].Start = 0
+	last := &ranges[len(ranges)-1]
+	if tk := tokens[len(tokens)-1]; tk.Position != nil {
+		_, e := tokenOffsetRange(tk)
+		if suffix := len(tk.Origin) - len(strings.TrimRight(tk.Origin, " \t\r\n")); e+suffix > last.End {
+			last.End = e + suffix
+		}
+	}
+	return ranges
+}
+
+// documentOffsetRange returns zero-based offset range of the tokens of doc. start is -1 if doc has no token.
+func documentOffsetRange(doc *ast.Document) (int, int) {
+	start, end := -1, -1
+	update := func(s, e int) {
+		if s < 0 {
+			return
+		}
+		if start < 0 || s < start {
+			start = s
+		}
+		if end < e {
+			end = e
+		}
+	}
+	for _, directive := range doc.Directives {
+		update(tokenOffsetRange(directive.Start))
+		update(nodeOffsetRange(directive.Value))
+	}
+	for _, tk := range []*token.Token{doc.Start, doc.End} {
+		if tk != nil && tk.Position != nil {
+			update(tokenOffsetRange(tk))
+		}
+	}
+	if doc.Body != nil {
+		update(nodeOffsetRange(doc.Body))
+	}
+	return start, end
+}
+
+// isLineBreakBetween reports whether there is line break between prev and next tokens
+func isLineBreakBetween(prev, next *token.Token) bool {
+	prevSuffix := prev.Origin[len(strings.TrimRight(prev.Origin, " \t\r\n")):]
+	nextPrefix := next.Origin[:len(next.Origin)-len(strings.TrimLeft(next.Origin, " \t\r\n"))]
+	return strings.Contains(prevSuffix+nextPrefix, "\n")
+}
@@ -1,8 +1,11 @@
 package parser
 
 import (
+	"fmt"
 	"io/ioutil"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/goccy/go-yaml/ast"
 	"github.com/goccy/go-yaml/internal/errors"
@@ -13,8 +16,16 @@ import (
 
 type parser struct{}
 
+// yamlTagPrefix prefix of the tags which are resolved by the secondary tag handle `!!`
+const yamlTagPrefix = "tag:yaml.org,2002:"
+
+var (
+	supportedVersionPattern = regexp.MustCompile(`^1\.[0-9]+$`)
+	tagHandlePattern        = regexp.MustCompile(`^!([0-9A-Za-z-]*!)?$`)
+)
+
 func (p *parser) parseMapping(ctx *context) (ast.Node, error) {
-	node := ast.Mapping(ctx.currentToken(), true)
+	node := ctx.arena.Mapping(ctx.currentToken(), true)
 	ctx.progress(1) // skip MappingStart token
 	for ctx.next() {
 		tk := ctx.currentToken()
@@ -24,15 +35,20 @@ func (p *parser) parseMapping(ctx *context) (ast.Node, error) {
 		} else if tk.Type == token.CollectEntryType {
 			ctx.progress(1)
 			continue
+		} else if tk.Type == token.SequenceEndType {
+			return nil, errors.ErrSyntax(errors.CodeUnexpectedFlowToken, tk, tk.Value, "in flow mapping")
 		}
 
 		value, err := p.parseToken(ctx, tk)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to parse mapping value in mapping node")
 		}
+		if value == nil {
+			return nil, errors.ErrSyntax(errors.CodeUnexpectedFlowToken, tk, tk.Value, "in flow mapping")
+		}
 		mvnode, ok := value.(*ast.MappingValueNode)
 		if !ok {
-			return nil, errors.ErrSyntax("failed to parse flow mapping value node", value.GetToken())
+			return nil, errors.ErrSyntax(errors.CodeInvalidFlowMappingValue, value.GetToken())
 		}
 		node.Values = append(node.Values, mvnode)
 		ctx.progress(1)
@@ -41,7 +57,7 @@ func (p *parser) parseMapping(ctx *context) (ast.Node, error) {
 }
 
 func (p *parser) parseSequence(ctx *context) (ast.Node, error) {
-	node := ast.Sequence(ctx.currentToken(), true)
+	node := ctx.arena.Sequence(ctx.currentToken(), true)
 	ctx.progress(1) // skip SequenceStart token
 	for ctx.next() {
 		tk := ctx.currentToken()
@@ -51,12 +67,17 @@ func (p *parser) parseSequence(ctx *context) (ast.Node, error) {
 		} else if tk.Type == token.CollectEntryType {
 			ctx.progress(1)
 			continue
+		} else if tk.Type == token.MappingEndType {
+			return nil, errors.ErrSyntax(errors.CodeUnexpectedFlowToken, tk, tk.Value, "in flow sequence")
 		}
 
 		value, err := p.parseToken(ctx, tk)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to parse sequence value in flow sequence node")
 		}
+		if value == nil {
+			return nil, errors.ErrSyntax(errors.CodeUnexpectedFlowToken, tk, tk.Value, "in flow sequence")
+		}
 		node.Values = append(node.Values, value)
 		ctx.progress(1)
 	}
@@ -65,6 +86,11 @@ func (p *parser) parseSequence(ctx *context) (ast.Node, error) {
 
 func (p *parser) parseTag(ctx *context) (ast.Node, error) {
 	node := &ast.TagNode{Start: ctx.currentToken()}
+	uri, err := p.resolveTag(ctx, node.Start)
+	if err != nil {
+		return nil, err
+	}
+	node.URI = uri
 	ctx.progress(1) // skip tag token
 	value, err := p.parseToken(ctx, ctx.currentToken())
 	if err != nil {
@@ -80,29 +106,94 @@ func (p *parser) validateMapKey(tk *token.Token) error {
 	}
 	origin := strings.TrimLeft(tk.Origin, "\n")
 	if strings.Index(origin, "\n") > 0 {
-		return errors.ErrSyntax("unexpected key name", tk)
+		return errors.ErrSyntax(errors.CodeInvalidKeyName, tk)
 	}
 	return nil
 }
 
+// validateIndent reports the tab character used for indentation.
+// Scanner reads the tab at the beginning of line as a part of the value ( e.g. `\tkey` ),
+// so it must be detected before the value is parsed as unexpected key or string.
+// Tabs in flow collection are separation spaces ( e.g. "[1,\n\t2]" ), so they are allowed.
+func (p *parser) validateIndent(tokens token.Tokens) error {
+	flowDepth := 0
+	for _, tk := range tokens {
+		switch tk.Type {
+		case token.MappingStartType, token.SequenceStartType:
+			flowDepth++
+		case token.MappingEndType, token.SequenceEndType:
+			if flowDepth > 0 {
+				flowDepth--
+			}
+		}
+		if flowDepth > 0 || tk.Type != token.StringType || !strings.HasPrefix(tk.Value, "\t") {
+			continue
+		}
+		prev := tk.Prev
+		if prev != nil && prev.Position.Line == tk.Position.Line {
+			continue
+		}
+		if prev != nil && (prev.Type == token.LiteralType || prev.Type == token.FoldedType) {
+			// tab in the content of block scalar
+			continue
+		}
+		return errors.ErrSyntax(errors.CodeTabIndentation, tk)
+	}
+	return nil
+}
+
+// isEmptyMappingValue whether the value of key is empty or not.
+// If the token next to mapping value token ends the entry of flow collection, the value is empty.
+// If the token next to mapping value token starts at the next line with the same or less indent than key,
+// it belongs to the outer node ( e.g. `a:\nb: c` ).
+func (p *parser) isEmptyMappingValue(keyTk, mvTk, vtk *token.Token) bool {
+	switch vtk.Type {
+	case token.CollectEntryType, token.MappingEndType, token.SequenceEndType:
+		// end of flow entry ( e.g. `{a:, b: c}` )
+		return true
+	}
+	if vtk.Position.Line <= mvTk.Position.Line {
+		return false
+	}
+	keyColumn := keyTk.Position.Column
+	if vtk.Position.Column > keyColumn {
+		return false
+	}
+	if vtk.Position.Column < keyColumn {
+		return true
+	}
+	switch vtk.Type {
+	case token.SequenceEntryType:
+		// sequence can have the same indent as key
+		return false
+	case token.StringType:
+		// string without mapping value token is handled as syntax error
+		return vtk.NextType() == token.MappingValueType
+	}
+	return true
+}
+
 func (p *parser) parseMappingValue(ctx *context) (ast.Node, error) {
-	key := p.parseMapKey(ctx.currentToken())
+	key := p.parseMapKey(ctx, ctx.currentToken())
 	if key == nil {
-		return nil, errors.ErrSyntax("unexpected mapping 'key'. key is undefined", ctx.currentToken())
+		return nil, errors.ErrSyntax(errors.CodeUndefinedKey, ctx.currentToken())
 	}
 	if err := p.validateMapKey(key.GetToken()); err != nil {
 		return nil, errors.Wrapf(err, "validate mapping key error")
 	}
 	if _, ok := key.(ast.ScalarNode); !ok {
-		return nil, errors.ErrSyntax("unexpected mapping 'key', key is not scalar value", key.GetToken())
+		return nil, errors.ErrSyntax(errors.CodeNonScalarKey, key.GetToken())
 	}
 	ctx.progress(1)          // progress to mapping value token
 	tk := ctx.currentToken() // get mapping value token
-	ctx.progress(1)          // progress to value token
 	var value ast.Node
-	if vtk := ctx.currentToken(); vtk == nil {
-		value = ast.Null(token.New("null", "null", tk.Position))
+	if vtk := ctx.nextToken(); vtk == nil {
+		value = ctx.arena.Null(token.New("null", "null", tk.Position))
+	} else if p.isEmptyMappingValue(key.GetToken(), tk, vtk) {
+		// the value isn't written in source, so it's rendered as empty ( see ast.NullNode.String )
+		value = ctx.arena.Null(token.New("null", "", tk.Position))
 	} else {
+		ctx.progress(1) // progress to value token
 		v, err := p.parseToken(ctx, ctx.currentToken())
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to parse mapping 'value' node")
@@ -115,21 +206,15 @@ func (p *parser) parseMappingValue(ctx *context) (ast.Node, error) {
 		if value.Type() == ast.StringType {
 			ntk := ctx.nextToken()
 			if ntk == nil || (ntk.Type != token.MappingValueType && ntk.Type != token.SequenceEntryType) {
-				return nil, errors.ErrSyntax("could not found expected ':' token", value.GetToken())
+				return nil, errors.ErrSyntax(errors.CodeMissingMappingValueToken, value.GetToken())
 			}
 		}
 	}
-	mvnode := &ast.MappingValueNode{
-		Start: tk,
-		Key:   key,
-		Value: value,
-	}
+	mvnode := ctx.arena.MappingValue(tk, key, value)
 	ntk := ctx.nextToken()
 	antk := ctx.afterNextToken()
-	node := &ast.MappingNode{
-		Start:  tk,
-		Values: []*ast.MappingValueNode{mvnode},
-	}
+	node := ctx.arena.Mapping(tk, false)
+	node.Values = append(node.Values, mvnode)
 	for antk != nil && antk.Type == token.MappingValueType &&
 		ntk.Position.Column == key.GetToken().Position.Column {
 		ctx.progress(1)
@@ -159,13 +244,15 @@ func (p *parser) parseMappingValue(ctx *context) (ast.Node, error) {
 
 func (p *parser) parseSequenceEntry(ctx *context) (ast.Node, error) {
 	tk := ctx.currentToken()
-	sequenceNode := &ast.SequenceNode{
-		Start:  tk,
-		Values: []ast.Node{},
-	}
+	sequenceNode := ctx.arena.Sequence(tk, false)
 	curColumn := tk.Position.Column
 	for tk.Type == token.SequenceEntryType {
 		ctx.progress(1) // skip sequence token
+		if ctx.currentToken() == nil {
+			// entry without value at the end of source ( e.g. `- ` )
+			sequenceNode.Values = append(sequenceNode.Values, ctx.arena.Null(token.New("null", "null", tk.Position)))
+			break
+		}
 		value, err := p.parseToken(ctx, ctx.currentToken())
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to parse sequence")
@@ -191,7 +278,7 @@ func (p *parser) parseAnchor(ctx *context) (ast.Node, error) {
 	anchor := &ast.AnchorNode{Start: tk}
 	ntk := ctx.nextToken()
 	if ntk == nil {
-		return nil, errors.ErrSyntax("unexpected anchor. anchor name is undefined", tk)
+		return nil, errors.ErrSyntax(errors.CodeUndefinedAnchorName, tk)
 	}
 	ctx.progress(1) // skip anchor token
 	name, err := p.parseToken(ctx, ctx.currentToken())
@@ -201,7 +288,7 @@ func (p *parser) parseAnchor(ctx *context) (ast.Node, error) {
 	anchor.Name = name
 	ntk = ctx.nextToken()
 	if ntk == nil {
-		return nil, errors.ErrSyntax("unexpected anchor. anchor value is undefined", ctx.currentToken())
+		return nil, errors.ErrSyntax(errors.CodeUndefinedAnchorValue, ctx.currentToken())
 	}
 	ctx.progress(1)
 	value, err := p.parseToken(ctx, ctx.currentToken())
@@ -217,7 +304,7 @@ func (p *parser) parseAlias(ctx *context) (ast.Node, error) {
 	alias := &ast.AliasNode{Start: tk}
 	ntk := ctx.nextToken()
 	if ntk == nil {
-		return nil, errors.ErrSyntax("unexpected alias. alias name is undefined", tk)
+		return nil, errors.ErrSyntax(errors.CodeUndefinedAliasName, tk)
 	}
 	ctx.progress(1) // skip alias token
 	name, err := p.parseToken(ctx, ctx.currentToken())
@@ -228,45 +315,40 @@ func (p *parser) parseAlias(ctx *context) (ast.Node, error) {
 	return alias, nil
 }
 
-func (p *parser) parseMapKey(tk *token.Token) ast.Node {
-	if node := p.parseStringValue(tk); node != nil {
-		return node
-	}
+func (p *parser) parseMapKey(ctx *context, tk *token.Token) ast.Node {
 	if tk.Type == token.MergeKeyType {
 		return ast.MergeKey(tk)
 	}
-	if tk.Type == token.NullType {
-		return ast.Null(tk)
-	}
-	return nil
+	// the type of key is resolved like the value, so `1` and `"1"` are different keys
+	return p.parseScalarValue(ctx, tk)
 }
 
-func (p *parser) parseStringValue(tk *token.Token) ast.Node {
+func (p *parser) parseStringValue(ctx *context, tk *token.Token) ast.Node {
 	switch tk.Type {
 	case token.StringType,
 		token.SingleQuoteType,
 		token.DoubleQuoteType:
-		return ast.String(tk)
+		return ctx.arena.String(tk)
 	}
 	return nil
 }
 
-func (p *parser) parseScalarValue(tk *token.Token) ast.Node {
-	if node := p.parseStringValue(tk); node != nil {
+func (p *parser) parseScalarValue(ctx *context, tk *token.Token) ast.Node {
+	if node := p.parseStringValue(ctx, tk); node != nil {
 		return node
 	}
 	switch tk.Type {
 	case token.NullType:
-		return ast.Null(tk)
+		return ctx.arena.Null(tk)
 	case token.BoolType:
-		return ast.Bool(tk)
+		return ctx.arena.Bool(tk)
 	case token.IntegerType,
 		token.BinaryIntegerType,
 		token.OctetIntegerType,
 		token.HexIntegerType:
-		return ast.Integer(tk)
+		return ctx.arena.Integer(tk)
 	case token.FloatType:
-		return ast.Float(tk)
+		return ctx.arena.Float(tk)
 	case token.InfinityType:
 		return ast.Infinity(tk)
 	case token.NanType:
@@ -275,19 +357,117 @@ func (p *parser) parseScalarValue(tk *token.Token) ast.Node {
 	return nil
 }
 
-func (p *parser) parseDirective(ctx *context) (ast.Node, error) {
+func (p *parser) parseDirective(ctx *context) (*ast.DirectiveNode, error) {
 	node := &ast.DirectiveNode{Start: ctx.currentToken()}
+	tk := ctx.nextToken()
+	if tk == nil || tk.Type != token.StringType {
+		return nil, errors.ErrSyntax(errors.CodeDocumentNotStarted, node.Start)
+	}
 	ctx.progress(1) // skip directive token
-	value, err := p.parseToken(ctx, ctx.currentToken())
+	node.Value = ctx.arena.String(tk)
+	return node, nil
+}
+
+// parseDirectives parses directives and the document following them
+func (p *parser) parseDirectives(ctx *context) (*ast.Document, error) {
+	directives := []*ast.DirectiveNode{}
+	for {
+		directive, err := p.parseDirective(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse directive")
+		}
+		directives = append(directives, directive)
+		tk := ctx.nextToken()
+		if tk == nil {
+			return nil, errors.ErrSyntax(errors.CodeDocumentNotStarted, directive.Value.GetToken())
+		}
+		ctx.progress(1)
+		if tk.Type == token.DocumentHeaderType {
+			break
+		}
+		if tk.Type != token.DirectiveType {
+			return nil, errors.ErrSyntax(errors.CodeDocumentNotStarted, tk)
+		}
+	}
+	tagHandles, err := p.validateDirectives(directives)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to parse directive value")
+		return nil, err
 	}
-	node.Value = value
-	ctx.progress(1)
-	if ctx.currentToken().Type != token.DocumentHeaderType {
-		return nil, errors.ErrSyntax("unexpected directive value. document not started", ctx.currentToken())
+	ctx.tagHandles = tagHandles
+	defer func() { ctx.tagHandles = nil }()
+	doc, err := p.parseDocument(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse document")
 	}
-	return node, nil
+	doc.Directives = directives
+	return doc, nil
+}
+
+// validateDirectives checks the version of %YAML and returns the tag handles defined by %TAG.
+// Every version of YAML 1.x is accepted and parsed as YAML 1.2 like the spec recommends, but the other major versions are rejected.
+// The directives which are reserved by the spec are ignored.
+func (p *parser) validateDirectives(directives []*ast.DirectiveNode) (map[string]string, error) {
+	tagHandles := map[string]string{}
+	hasVersion := false
+	for _, directive := range directives {
+		tk := directive.Value.GetToken()
+		params := directive.Parameters()
+		switch directive.Name() {
+		case "YAML":
+			if hasVersion {
+				return nil, errors.ErrSyntax(errors.CodeInvalidDirective, tk, "YAML", "version is already specified")
+			}
+			hasVersion = true
+			if len(params) != 1 {
+				return nil, errors.ErrSyntax(errors.CodeInvalidDirective, tk, "YAML", "version is required")
+			}
+			if !supportedVersionPattern.MatchString(params[0]) {
+				return nil, errors.ErrSyntax(errors.CodeInvalidDirective, tk, "YAML", fmt.Sprintf("unsupported version %q", params[0]))
+			}
+		case "TAG":
+			if len(params) != 2 {
+				return nil, errors.ErrSyntax(errors.CodeInvalidDirective, tk, "TAG", "handle and prefix are required")
+			}
+			handle := params[0]
+			if !tagHandlePattern.MatchString(handle) {
+				return nil, errors.ErrSyntax(errors.CodeInvalidDirective, tk, "TAG", fmt.Sprintf("invalid tag handle %q", handle))
+			}
+			if _, exists := tagHandles[handle]; exists {
+				return nil, errors.ErrSyntax(errors.CodeInvalidDirective, tk, "TAG", fmt.Sprintf("tag handle %q is already defined", handle))
+			}
+			tagHandles[handle] = params[1]
+		}
+	}
+	return tagHandles, nil
+}
+
+// resolveTag returns the full tag of tk by the tag handles ( e.g. `tag:yaml.org,2002:str` for `!!str` ).
+// The primary handle `!` and the secondary handle `!!` can be overridden by %TAG,
+// and the named handle ( e.g. `!e!` ) must be defined by %TAG.
+func (p *parser) resolveTag(ctx *context, tk *token.Token) (string, error) {
+	tag := tk.Value
+	if tag == "!" {
+		// non-specific tag
+		return "", nil
+	}
+	if strings.HasPrefix(tag, "!<") && strings.HasSuffix(tag, ">") {
+		// verbatim tag
+		return tag[2 : len(tag)-1], nil
+	}
+	handle, suffix := "!", tag[1:]
+	if idx := strings.Index(suffix, "!"); idx >= 0 {
+		handle, suffix = tag[:idx+2], suffix[idx+1:]
+	}
+	if prefix, exists := ctx.tagHandles[handle]; exists {
+		return prefix + suffix, nil
+	}
+	switch handle {
+	case "!":
+		return tag, nil
+	case "!!":
+		return yamlTagPrefix + suffix, nil
+	}
+	return "", errors.ErrSyntax(errors.CodeUndefinedTagHandle, tk, handle)
 }
 
 func (p *parser) parseLiteral(ctx *context) (ast.Node, error) {
@@ -299,7 +479,7 @@ func (p *parser) parseLiteral(ctx *context) (ast.Node, error) {
 	}
 	snode, ok := value.(*ast.StringNode)
 	if !ok {
-		return nil, errors.ErrSyntax("unexpected token. required string token", value.GetToken())
+		return nil, errors.ErrSyntax(errors.CodeRequiredStringToken, value.GetToken())
 	}
 	node.Value = snode
 	return node, nil
@@ -307,6 +487,15 @@ func (p *parser) parseLiteral(ctx *context) (ast.Node, error) {
 
 func (p *parser) parseDocument(ctx *context) (*ast.Document, error) {
 	node := &ast.Document{Start: ctx.currentToken()}
+	switch ntk := ctx.nextToken(); {
+	case ntk == nil || ntk.Type == token.DocumentHeaderType:
+		// empty document
+		return node, nil
+	case ntk.Type == token.DocumentEndType:
+		node.End = ntk
+		ctx.progress(1)
+		return node, nil
+	}
 	ctx.progress(1) // skip document header token
 	body, err := p.parseToken(ctx, ctx.currentToken())
 	if err != nil {
@@ -324,7 +513,7 @@ func (p *parser) parseToken(ctx *context, tk *token.Token) (ast.Node, error) {
 	if tk.NextType() == token.MappingValueType {
 		return p.parseMappingValue(ctx)
 	}
-	if node := p.parseScalarValue(tk); node != nil {
+	if node := p.parseScalarValue(ctx, tk); node != nil {
 		return node, nil
 	}
 	switch tk.Type {
@@ -341,20 +530,74 @@ func (p *parser) parseToken(ctx *context, tk *token.Token) (ast.Node, error) {
 	case token.AliasType:
 		return p.parseAlias(ctx)
 	case token.DirectiveType:
-		return p.parseDirective(ctx)
+		return p.parseDirectives(ctx)
 	case token.TagType:
 		return p.parseTag(ctx)
 	case token.LiteralType, token.FoldedType:
 		return p.parseLiteral(ctx)
+	case token.SequenceEndType, token.MappingEndType:
+		// the end of flow collection is consumed by parseSequence or parseMapping
+		return nil, errors.ErrSyntax(errors.CodeUnexpectedFlowToken, tk, tk.Value, "outside of flow collection")
 	}
 	return nil, nil
 }
 
+// unexpectedValueError create error for the value which follows the body of document.
+// Such a value is at the lower indent than the collection in body ( e.g. `d` of "a:\n  b: c\n d" ),
+// so it belongs to neither the collection nor a new document which must start with `---`.
+func (p *parser) unexpectedValueError(body, value ast.Node) error {
+	pos := firstToken(body).Position
+	return errors.ErrSyntax(errors.CodeUnexpectedValue, firstToken(value), nodeType(value), nodeType(body), pos.Line, pos.Column).
+		AddRelated(firstToken(body), fmt.Sprintf("%s node which the value follows", nodeType(body)))
+}
+
+// nodeType returns the type of node. Single mapping value is reported as mapping.
+func nodeType(node ast.Node) ast.NodeType {
+	if node.Type() == ast.MappingValueType {
+		return ast.MappingType
+	}
+	return node.Type()
+}
+
+type statsVisitor struct {
+	stats *ast.FileStats
+	depth int
+}
+
+func (v *statsVisitor) Visit(node ast.Node) ast.Visitor {
+	v.stats.Nodes++
+	if v.depth > v.stats.MaxDepth {
+		v.stats.MaxDepth = v.depth
+	}
+	return &statsVisitor{stats: v.stats, depth: v.depth + 1}
+}
+
+func (p *parser) collectStats(ctx *context, tokens token.Tokens, file *ast.File) {
+	for _, tk := range tokens {
+		file.Stats.Bytes += len(tk.Origin)
+	}
+	file.Stats.Tokens = len(tokens)
+	file.Stats.AllocatedBytes = ctx.arena.AllocatedBytes()
+	for _, doc := range file.Docs {
+		if doc.Body != nil {
+			ast.WalkAll(&statsVisitor{stats: &file.Stats, depth: 1}, doc.Body)
+		}
+	}
+}
+
 func (p *parser) parse(tokens token.Tokens, mode Mode) (*ast.File, error) {
+	if err := p.validateIndent(tokens); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse")
+	}
 	ctx := newContext(tokens, mode)
 	file := &ast.File{Docs: []*ast.Document{}}
+	var openDoc *ast.Document // document not terminated by `...`
 	for ctx.next() {
-		node, err := p.parseToken(ctx, ctx.currentToken())
+		tk := ctx.currentToken()
+		if tk.Type == token.DocumentEndType {
+			openDoc = nil
+		}
+		node, err := p.parseToken(ctx, tk)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to parse")
 		}
@@ -362,38 +605,83 @@ func (p *parser) parse(tokens token.Tokens, mode Mode) (*ast.File, error) {
 		if node == nil {
 			continue
 		}
-		if doc, ok := node.(*ast.Document); ok {
-			file.Docs = append(file.Docs, doc)
-		} else {
-			file.Docs = append(file.Docs, &ast.Document{Body: node})
+		doc, ok := node.(*ast.Document)
+		if !ok {
+			if openDoc != nil && openDoc.Body != nil {
+				return nil, p.unexpectedValueError(openDoc.Body, node)
+			}
+			doc = &ast.Document{Body: node}
+		}
+		file.Docs = append(file.Docs, doc)
+		openDoc = doc
+		if doc.End != nil {
+			openDoc = nil
 		}
 	}
+	if ctx.enabledComment() {
+		attachComments(file, tokens)
+	}
+	file.DocRanges = documentRanges(file.Docs, tokens)
+	if mode&CollectStats != 0 {
+		p.collectStats(ctx, tokens, file)
+	}
 	return file, nil
 }
 
 type Mode uint
 
 const (
-	ParseComments Mode = 1 << iota // parse comments and add them to AST
+	ParseComments  Mode = 1 << iota // parse comments and add them to AST
+	CoreSchema                      // resolve plain scalars by the core schema of YAML 1.2 ( see token.ToCoreSchema )
+	LenientComment                  // start a comment by '#' following non-space character ( see lexer.LenientComment )
+	CollectStats                    // collect statistics of parsing to ast.File.Stats
 )
 
 // ParseBytes parse from byte slice, and returns ast.File
 func ParseBytes(bytes []byte, mode Mode) (*ast.File, error) {
-	tokens := lexer.Tokenize(string(bytes))
-	f, err := Parse(tokens, mode)
+	var start time.Time
+	if mode&CollectStats != 0 {
+		start = time.Now()
+	}
+	var opts []lexer.Option
+	if mode&LenientComment != 0 {
+		opts = append(opts, lexer.LenientComment())
+	}
+	tokens := lexer.Tokenize(string(bytes), opts...)
+	f, err := parseTokens(tokens, mode, start)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to parse")
 	}
+	if mode&CollectStats != 0 {
+		f.Stats.Bytes = len(bytes)
+	}
+	if len(f.DocRanges) > 0 {
+		// trailing spaces of source are not included in tokens
+		f.DocRanges[len(f.DocRanges)-1].End = len(bytes)
+	}
 	return f, nil
 }
 
 // Parse parse from token instances, and returns ast.File
 func Parse(tokens token.Tokens, mode Mode) (*ast.File, error) {
+	var start time.Time
+	if mode&CollectStats != 0 {
+		start = time.Now()
+	}
+	return parseTokens(tokens, mode, start)
+}
+
+// parseTokens parses tokens, and sets the duration from start to ast.File.Stats if CollectStats mode is enabled
+func parseTokens(tokens token.Tokens, mode Mode, start time.Time) (*ast.File, error) {
 	var p parser
 	f, err := p.parse(tokens, mode)
 	if err != nil {
+		errors.SetHint(err, hint)
 		return nil, errors.Wrapf(err, "failed to parse")
 	}
+	if mode&CollectStats != 0 {
+		f.Stats.ParseDuration = time.Since(start)
+	}
 	return f, nil
 }
 
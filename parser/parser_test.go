@@ -2,13 +2,17 @@ package parser_test
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/internal/errors"
 	"github.com/goccy/go-yaml/lexer"
 	"github.com/goccy/go-yaml/parser"
 	"github.com/goccy/go-yaml/printer"
+	"github.com/goccy/go-yaml/token"
+	"golang.org/x/xerrors"
 )
 
 func TestParser(t *testing.T) {
@@ -58,6 +62,9 @@ func TestParser(t *testing.T) {
 		"       a       :          b        \n",
 		"a: b # comment\nb: c\n",
 		"---\na: b\n",
+		"---\n",
+		"a: b\n---\n",
+		"---\n---\na: b\n",
 		"a: b\n...\n",
 		"%YAML 1.2\n---\n",
 		"a: !!binary gIGC\n",
@@ -65,6 +72,8 @@ func TestParser(t *testing.T) {
 		"- !tag\n  a: b\n  c: d\n",
 		"v:\n- A\n- |-\n  B\n  C\n",
 		"v:\n- A\n- >-\n  B\n  C\n",
+		"a: [1,\n\t2]\n",
+		"a: {x: 1,\n\ty: 2}\n",
 	}
 	for _, src := range sources {
 		fmt.Printf(src)
@@ -375,6 +384,36 @@ b: 2
 c: 3
 d: 4
 ...
+`,
+		},
+		{
+			`
+"a\"b": 'it''s'
+c: "\t\x41"
+`, `
+"a\"b": 'it''s'
+c: "\tA"
+`,
+		},
+		{
+			`
+a: |
+  b
+
+  c
+d:
+  - |-
+    e
+f: g
+`, `
+a: |
+  b
+
+  c
+d:
+  - |-
+    e
+f: g
 `,
 		},
 	}
@@ -399,6 +438,8 @@ d: 4
 func TestSyntaxError(t *testing.T) {
 	sources := []string{
 		"a:\n- b\n  c: d\n  e: f\n  g: h",
+		"a:\n\tb: c",
+		"a:\n  b: c\n\td: e",
 	}
 	for _, source := range sources {
 		_, err := parser.ParseBytes([]byte(source), 0)
@@ -409,6 +450,266 @@ func TestSyntaxError(t *testing.T) {
 	}
 }
 
+func TestUnexpectedValue(t *testing.T) {
+	tests := []struct {
+		src    string
+		expect string
+	}{
+		{"a:\n  b: c\n d\n", "[3:2] unexpected String node. it is not allowed after the Mapping node at [1:1] in this context"},
+		{"a:\n  - b\n c\n", "[3:2] unexpected String node. it is not allowed after the Mapping node at [1:1] in this context"},
+		{"- a: b\n  c\n", "[2:3] unexpected String node. it is not allowed after the Sequence node at [1:1] in this context"},
+		{"a: 1\nb: |\n  x\n y: z\n", "[4:2] unexpected Mapping node. it is not allowed after the Mapping node at [1:1] in this context"},
+		{"---\na:\n  b: c\nd\n", "[4:1] unexpected String node. it is not allowed after the Mapping node at [2:1] in this context"},
+		{"a: ]\n", `[1:4] unexpected "]" outside of flow collection`},
+		{"a: [1, 2]]\n", `[1:10] unexpected "]" outside of flow collection`},
+		{"{a: [b}]}\n", `[1:7] unexpected "}" in flow sequence`},
+	}
+	for _, test := range tests {
+		_, err := parser.ParseBytes([]byte(test.src), 0)
+		if err == nil {
+			t.Fatalf("%q: expected error", test.src)
+		}
+		if !strings.HasPrefix(err.Error(), test.expect) {
+			t.Fatalf("%q: unexpected error: %s", test.src, err.Error())
+		}
+	}
+	t.Run("related", func(t *testing.T) {
+		_, err := parser.ParseBytes([]byte("---\na:\n  b: c\nd\n"), 0)
+		var syntaxErr interface {
+			Related() []*errors.RelatedToken
+		}
+		if !xerrors.As(err, &syntaxErr) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		related := syntaxErr.Related()
+		if len(related) != 1 || related[0].Token.Position.Line != 2 || related[0].Message != "Mapping node which the value follows" {
+			t.Fatalf("unexpected related tokens: %+v", related)
+		}
+	})
+	valid := []string{
+		"a:\n  b: c\n   d\n",
+		"a: 1\n...\nb: 2\n",
+		"a: 1\n---\nb: 2\n",
+		"a: |\n  x\n",
+		"a: !!binary |\n  kJCQ\n",
+		"- a\n- ",
+	}
+	for _, src := range valid {
+		if _, err := parser.ParseBytes([]byte(src), 0); err != nil {
+			t.Fatalf("%q: %+v", src, err)
+		}
+	}
+}
+
+func TestErrorHint(t *testing.T) {
+	tests := []struct {
+		src    string
+		expect string
+	}{
+		{"files: *\n", `'*' starts an alias. did you mean to quote this glob? ( e.g. "*.go" )`},
+		{"a: @foo\n  b: c\n", `"@" is a reserved indicator and cannot start a plain scalar. quote the value ( e.g. "@foo" )`},
+		{"a: %foo\n", "'%' cannot start a plain scalar. quote the value containing '%'"},
+		{"a:b\nc: d\n", `missing space after ':'. did you mean "a: b" ?`},
+		{"a:\n  b:c\n  d: e\n", `missing space after ':'. did you mean "b: c" ?`},
+		{"a:\n\tb: c\n", "use spaces instead of tabs for indentation"},
+		{"%YAML 1.2\na: b\n", ""},
+	}
+	for _, test := range tests {
+		_, err := parser.ParseBytes([]byte(test.src), 0)
+		if err == nil {
+			t.Fatalf("%q: expected error", test.src)
+		}
+		var syntaxErr interface{ Hint() string }
+		if !xerrors.As(err, &syntaxErr) {
+			t.Fatalf("%q: unexpected error type: %T", test.src, err)
+		}
+		if syntaxErr.Hint() != test.expect {
+			t.Fatalf("%q: unexpected hint: %q", test.src, syntaxErr.Hint())
+		}
+		if test.expect != "" && !strings.Contains(err.Error(), "hint: "+test.expect) {
+			t.Fatalf("%q: hint is not printed: %s", test.src, err.Error())
+		}
+	}
+}
+
+func TestFileStats(t *testing.T) {
+	src := `
+# comment
+a:
+  b:
+  - 1
+  - 2
+c: d
+`
+	f, err := parser.ParseBytes([]byte(src), parser.ParseComments|parser.CollectStats)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	stats := f.Stats
+	if stats.Bytes != len(src) {
+		t.Fatalf("unexpected bytes: %d", stats.Bytes)
+	}
+	if stats.Tokens != 12 {
+		t.Fatalf("unexpected tokens: %d", stats.Tokens)
+	}
+	// mapping, 2 mapping values, 2 keys, nested mapping value, its key, sequence, 2 integers, string value
+	if stats.Nodes != 11 {
+		t.Fatalf("unexpected nodes: %d", stats.Nodes)
+	}
+	// mapping -> mapping value -> mapping value -> sequence -> integer
+	if stats.MaxDepth != 5 {
+		t.Fatalf("unexpected max depth: %d", stats.MaxDepth)
+	}
+	if stats.AllocatedBytes == 0 {
+		t.Fatal("failed to account allocated bytes")
+	}
+	if stats.ParseDuration <= 0 {
+		t.Fatal("failed to measure parse duration")
+	}
+	f, err = parser.ParseBytes([]byte(src), parser.ParseComments)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if f.Stats != (ast.FileStats{}) {
+		t.Fatalf("stats must not be collected without CollectStats mode: %+v", f.Stats)
+	}
+}
+
+func TestDocumentRanges(t *testing.T) {
+	tests := []struct {
+		source string
+		docs   []string
+	}{
+		{
+			"a: 1\n",
+			[]string{"a: 1\n"},
+		},
+		{
+			"# head\na: é\n# lead\n---\nb: 2\n...\n# lead\n--- c\n\n",
+			[]string{"# head\na: é\n", "# lead\n---\nb: 2\n...\n", "# lead\n--- c\n\n"},
+		},
+		{
+			"%YAML 1.2\n---\n- a\n%YAML 1.2 # comment\n--- |\n  text\n...\n",
+			[]string{"%YAML 1.2\n---\n- a\n", "%YAML 1.2 # comment\n--- |\n  text\n...\n"},
+		},
+		{
+			"a: 1 # trailing\n... # trailing\n  # lead\nb: {c: d}",
+			[]string{"a: 1 # trailing\n... # trailing\n", "  # lead\nb: {c: d}"},
+		},
+		{
+			"---\n---\n",
+			[]string{"---\n", "---\n"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			f, err := parser.ParseBytes([]byte(test.source), 0)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if len(f.DocRanges) != len(f.Docs) {
+				t.Fatalf("unexpected number of ranges: %d", len(f.DocRanges))
+			}
+			docs := []string{}
+			for _, r := range f.DocRanges {
+				docs = append(docs, test.source[r.Start:r.End])
+			}
+			if !reflect.DeepEqual(docs, test.docs) {
+				t.Fatalf("unexpected documents: %q", docs)
+			}
+		})
+	}
+}
+
+func TestContextAt(t *testing.T) {
+	src := `a: b
+c:
+  d: 'x' # comment
+  e:
+  - f
+  - g: h
+`
+	tests := []struct {
+		target string
+		path   string
+		kind   parser.PositionKind
+		nodes  int
+	}{
+		{"a:", "$.a", parser.PositionKey, 3},
+		{"b\n", "$.a", parser.PositionValue, 3},
+		{"d:", "$.c.d", parser.PositionKey, 5},
+		{"'x'", "$.c.d", parser.PositionValue, 5},
+		{"comment", "$.c", parser.PositionComment, 3},
+		{"e:", "$.c.e", parser.PositionKey, 5},
+		{"f\n", "$.c.e[0]", parser.PositionValue, 6},
+		{"g:", "$.c.e[1].g", parser.PositionKey, 7},
+		{"h\n", "$.c.e[1].g", parser.PositionValue, 7},
+	}
+	f, err := parser.ParseBytes([]byte(src), parser.ParseComments)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	for _, test := range tests {
+		t.Run(test.target, func(t *testing.T) {
+			ctx := parser.ContextAt(f, strings.Index(src, test.target))
+			if ctx.Path != test.path {
+				t.Fatalf("unexpected path: %s", ctx.Path)
+			}
+			if ctx.Kind != test.kind {
+				t.Fatalf("unexpected kind: %s", ctx.Kind)
+			}
+			if len(ctx.Nodes) != test.nodes {
+				t.Fatalf("unexpected nodes: %d", len(ctx.Nodes))
+			}
+		})
+	}
+	t.Run("out of document", func(t *testing.T) {
+		ctx := parser.ContextAt(f, len(src)+10)
+		if ctx.Path != "$" || ctx.Kind != parser.PositionUnknown || len(ctx.Nodes) != 0 {
+			t.Fatalf("unexpected context: %+v", ctx)
+		}
+	})
+}
+
+func TestParseSiblingKeyOfEmptyValue(t *testing.T) {
+	tests := []struct {
+		source string
+		keys   []string
+	}{
+		{"a:\nb: c\n", []string{"a", "b"}},
+		{"- f:\n  g: h\n", []string{"f", "g"}},
+		{"a:\n  b:\n  c: d\n", []string{"a"}},
+	}
+	for _, test := range tests {
+		f, err := parser.ParseBytes([]byte(test.source), 0)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		body := f.Docs[0].Body
+		if seq, ok := body.(*ast.SequenceNode); ok {
+			body = seq.Values[0]
+		}
+		var values []*ast.MappingValueNode
+		switch n := body.(type) {
+		case *ast.MappingNode:
+			values = n.Values
+		case *ast.MappingValueNode:
+			values = []*ast.MappingValueNode{n}
+		}
+		if len(values) != len(test.keys) {
+			t.Fatalf("unexpected number of keys of %q: %d", test.source, len(values))
+		}
+		for i, key := range test.keys {
+			if values[i].Key.String() != key {
+				t.Fatalf("unexpected key of %q: %s", test.source, values[i].Key.String())
+			}
+		}
+		if len(test.keys) > 1 && values[0].Value.Type() != ast.NullType {
+			t.Fatalf("value of %s must be null but got %s", test.keys[0], values[0].Value.Type())
+		}
+	}
+}
+
 type Visitor struct {
 }
 
@@ -418,3 +719,907 @@ func (v *Visitor) Visit(node ast.Node) ast.Visitor {
 	tk.Next = nil
 	return v
 }
+
+func BenchmarkParse(b *testing.B) {
+	var sb strings.Builder
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&sb, "key%d:\n  a: %d\n  b: %d.5\n  c: true\n  d: null\n  e:\n  - x\n  - y\n", i, i, i)
+	}
+	src := []byte(sb.String())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := parser.ParseBytes(src, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestParseTabInBlockScalar(t *testing.T) {
+	f, err := parser.ParseBytes([]byte("a: |\n  \tb\nc: \"\td\"\n"), 0)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(f.Docs) != 1 {
+		t.Fatalf("unexpected number of documents: %d", len(f.Docs))
+	}
+}
+
+func TestParseDirectives(t *testing.T) {
+	src := `%YAML 1.2
+%TAG !e! tag:example.com,2000:app/ # comment
+---
+a: !e!foo 1
+---
+b: 2
+`
+	f, err := parser.ParseBytes([]byte(src), 0)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(f.Docs) != 2 {
+		t.Fatalf("unexpected number of documents: %d", len(f.Docs))
+	}
+	directives := f.Docs[0].Directives
+	if len(directives) != 2 {
+		t.Fatalf("unexpected number of directives: %d", len(directives))
+	}
+	if name := directives[0].Name(); name != "YAML" {
+		t.Fatalf("unexpected directive name: %s", name)
+	}
+	if params := directives[1].Parameters(); len(params) != 2 || params[0] != "!e!" || params[1] != "tag:example.com,2000:app/" {
+		t.Fatalf("unexpected directive parameters: %v", params)
+	}
+	if len(f.Docs[1].Directives) != 0 {
+		t.Fatalf("unexpected directives of second document: %v", f.Docs[1].Directives)
+	}
+	expected := "%YAML 1.2\n%TAG !e! tag:example.com,2000:app/\n---\na: !e!foo 1\n---\nb: 2"
+	if actual := f.String(); actual != expected {
+		t.Fatalf("unexpected output. expected:\n%s\nbut got:\n%s", expected, actual)
+	}
+	tag, ok := f.Docs[0].Body.(*ast.MappingValueNode).Value.(*ast.TagNode)
+	if !ok {
+		t.Fatalf("unexpected node: %T", f.Docs[0].Body.(*ast.MappingValueNode).Value)
+	}
+	if tag.URI != "tag:example.com,2000:app/foo" {
+		t.Fatalf("unexpected tag URI: %s", tag.URI)
+	}
+}
+
+func TestParseCoreSchemaKeepsTokens(t *testing.T) {
+	tokens := lexer.Tokenize("a: 017\n")
+	valueOf := func(mode parser.Mode) interface{} {
+		f, err := parser.Parse(tokens, mode)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		return f.Docs[0].Body.(*ast.MappingValueNode).Value.(*ast.IntegerNode).Value
+	}
+	if v := valueOf(parser.CoreSchema); v != uint64(17) {
+		t.Fatalf("unexpected value by core schema: %v", v)
+	}
+	if tokens[2].Type != token.OctetIntegerType {
+		t.Fatalf("token of caller is changed: %s", tokens[2].Type)
+	}
+	if v := valueOf(0); v != uint64(15) {
+		t.Fatalf("unexpected value: %v", v)
+	}
+}
+
+func TestParseTagURI(t *testing.T) {
+	tests := []struct {
+		src string
+		uri string
+	}{
+		{"!!str a", "tag:yaml.org,2002:str"},
+		{"!local a", "!local"},
+		{"!<tag:example.com,2000:app/foo> a", "tag:example.com,2000:app/foo"},
+		{"! a", ""},
+		{"%TAG ! tag:example.com,2000:\n--- !foo a", "tag:example.com,2000:foo"},
+		{"%TAG !! tag:example.com,2000:\n--- !!foo a", "tag:example.com,2000:foo"},
+	}
+	for _, test := range tests {
+		f, err := parser.ParseBytes([]byte(test.src), 0)
+		if err != nil {
+			t.Fatalf("%q: %+v", test.src, err)
+		}
+		tag, ok := f.Docs[0].Body.(*ast.TagNode)
+		if !ok {
+			t.Fatalf("%q: unexpected node: %T", test.src, f.Docs[0].Body)
+		}
+		if tag.URI != test.uri {
+			t.Fatalf("%q: expected tag URI %q but got %q", test.src, test.uri, tag.URI)
+		}
+	}
+}
+
+func TestParseYAMLVersion(t *testing.T) {
+	// the documents of later minor versions are parsed as YAML 1.2
+	for _, version := range []string{"1.0", "1.1", "1.2", "1.3", "1.10"} {
+		if _, err := parser.ParseBytes([]byte("%YAML "+version+"\n---\na: 1\n"), 0); err != nil {
+			t.Fatalf("%s: %+v", version, err)
+		}
+	}
+}
+
+func TestParseDirectivesError(t *testing.T) {
+	tests := []struct {
+		src    string
+		expect string
+	}{
+		{"%YAML 2.0\n---\na: 1\n", `invalid %YAML directive: unsupported version "2.0"`},
+		{"%YAML 0.9\n---\na: 1\n", `invalid %YAML directive: unsupported version "0.9"`},
+		{"%YAML 1\n---\na: 1\n", `invalid %YAML directive: unsupported version "1"`},
+		{"%YAML 1.2\n%YAML 1.2\n---\na: 1\n", "invalid %YAML directive: version is already specified"},
+		{"%TAG !e!\n---\na: 1\n", "invalid %TAG directive: handle and prefix are required"},
+		{"%TAG e! tag:example.com,2000:\n---\na: 1\n", `invalid %TAG directive: invalid tag handle "e!"`},
+		{"%TAG !e! a\n%TAG !e! b\n---\na: 1\n", `invalid %TAG directive: tag handle "!e!" is already defined`},
+		{"a: !e!foo 1\n", `tag handle "!e!" is not defined by %TAG directive`},
+		{"%TAG !e! tag:example.com,2000:\n---\na: 1\n---\nb: !e!foo 2\n", `tag handle "!e!" is not defined by %TAG directive`},
+	}
+	for _, test := range tests {
+		_, err := parser.ParseBytes([]byte(test.src), 0)
+		if err == nil {
+			t.Fatalf("%q: expected error", test.src)
+		}
+		if !strings.Contains(err.Error(), test.expect) {
+			t.Fatalf("%q: unexpected error: %s", test.src, err)
+		}
+	}
+}
+
+func TestParseComments(t *testing.T) {
+	src := `# head
+a: 1 # line
+b:
+  - x # entry
+  # head of y
+  - y
+  # foot of y
+c:
+  d: [1, 2] # flow
+# foot of c`
+	f, err := parser.ParseBytes([]byte(src), parser.ParseComments)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	values := f.Docs[0].Body.(*ast.MappingNode).Values
+	a := values[0]
+	if a.HeadComment != "# head" {
+		t.Fatalf("unexpected head comment: %q", a.HeadComment)
+	}
+	if comment := a.Value.(*ast.IntegerNode).LineComment; comment != "# line" {
+		t.Fatalf("unexpected line comment: %q", comment)
+	}
+	entries := values[1].Value.(*ast.SequenceNode).Values
+	if comment := entries[0].(*ast.StringNode).LineComment; comment != "# entry" {
+		t.Fatalf("unexpected line comment: %q", comment)
+	}
+	if comment := entries[1].(*ast.StringNode).HeadComment; comment != "# head of y" {
+		t.Fatalf("unexpected head comment: %q", comment)
+	}
+	if comment := entries[1].(*ast.StringNode).FootComment; comment != "# foot of y" {
+		t.Fatalf("unexpected foot comment: %q", comment)
+	}
+	c := values[2]
+	if c.FootComment != "# foot of c" {
+		t.Fatalf("unexpected foot comment: %q", c.FootComment)
+	}
+	if comment := c.Value.(*ast.MappingValueNode).Value.(*ast.SequenceNode).LineComment; comment != "# flow" {
+		t.Fatalf("unexpected line comment: %q", comment)
+	}
+	if actual := f.String(); actual != src {
+		t.Fatalf("unexpected output. expected:\n%s\nbut got:\n%s", src, actual)
+	}
+
+	f, err = parser.ParseBytes([]byte(src), 0)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if actual := f.String(); strings.Contains(actual, "#") {
+		t.Fatalf("comments are attached without ParseComments:\n%s", actual)
+	}
+}
+
+func TestParseCommentsInSequence(t *testing.T) {
+	tests := []string{
+		`spec:
+  containers:
+    # main container
+    - name: app
+      # image comment
+      image: nginx
+      ports:
+        # http
+        - containerPort: 80 # port
+          protocol: TCP
+        # - containerPort: 443
+    # sidecar
+    - name: sidecar
+      image: envoy
+# end`,
+		`-
+  # on next line
+  a: 1
+  b: 2
+- # after dash
+  a: 3
+  b: 4
+  # foot of mapping
+- c`,
+		`key:
+  # between key and value
+  value
+seq:
+  # between key and sequence
+  - a`,
+	}
+	for _, src := range tests {
+		f, err := parser.ParseBytes([]byte(src), parser.ParseComments)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if actual := f.String(); actual != src {
+			t.Fatalf("unexpected output. expected:\n%s\nbut got:\n%s", src, actual)
+		}
+		var p printer.Printer
+		if actual := p.PrintFile(f); actual != src {
+			t.Fatalf("unexpected printer output. expected:\n%s\nbut got:\n%s", src, actual)
+		}
+	}
+}
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		source string
+		key    string
+		expect string
+	}{
+		{
+			source: "a: 1\nb:\n  c: 2\n  d: [3]\n",
+			key:    "b",
+			expect: "c: 2\nd: [3]",
+		},
+		{
+			source: "env: &env\n  A: 1\nweb:\n  extra: *env\n  other: *env\n",
+			key:    "web",
+			expect: "extra: &env\n  A: 1\nother: *env",
+		},
+		{
+			source: "defaults: &defaults\n  image: base\nweb:\n  <<: *defaults\n  port: 80\n",
+			key:    "web",
+			expect: "<<: &defaults\n  image: base\nport: 80",
+		},
+		{
+			source: "x: &x 1\ndb:\n  - &x 2\n  - *x\n",
+			key:    "db",
+			expect: "- &x 2\n- *x",
+		},
+		{
+			source: "x: &x [1]\ny: &y\n  a: *x\nz: *y\n",
+			key:    "z",
+			expect: "&y\na: &x [1]",
+		},
+	}
+	for _, test := range tests {
+		f, err := parser.ParseBytes([]byte(test.source), 0)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		original := f.String()
+		var node ast.Node
+		for _, value := range f.Docs[0].Body.(*ast.MappingNode).Values {
+			if value.Key.GetToken().Value == test.key {
+				node = value.Value
+			}
+		}
+		extracted, err := ast.Extract(node, f.Docs[0])
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if actual := extracted.String(); actual != test.expect {
+			t.Fatalf("unexpected output. expected:\n%s\nbut got:\n%s", test.expect, actual)
+		}
+		if _, err := parser.ParseBytes([]byte(extracted.String()), 0); err != nil {
+			t.Fatalf("extracted document is invalid: %+v", err)
+		}
+		if f.String() != original {
+			t.Fatalf("source document is modified:\n%s", f.String())
+		}
+	}
+	t.Run("undefined anchor", func(t *testing.T) {
+		f, err := parser.ParseBytes([]byte("a: &x 1\nb:\n  c: *x\n"), 0)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		b := f.Docs[0].Body.(*ast.MappingNode).Values[1].Value
+		if _, err := ast.Extract(b); err == nil {
+			t.Fatal("expected error without scope")
+		}
+	})
+}
+
+func TestExpandAliases(t *testing.T) {
+	tests := []struct {
+		source string
+		expect string
+	}{
+		{
+			source: "a: &x 1\nb: *x\n",
+			expect: "a: 1\nb: 1",
+		},
+		{
+			source: "base: &base\n  a: 1\n  b: 2\nitems:\n  - *base\nc: *base\n",
+			expect: "base:\n  a: 1\n  b: 2\nitems:\n  - a: 1\n    b: 2\nc:\n  a: 1\n  b: 2",
+		},
+		{
+			source: "base: &base\n  a: 1\n  b: 2\nderived:\n  b: 3\n  <<: *base\n  c: 4\n",
+			expect: "base:\n  a: 1\n  b: 2\nderived:\n  b: 3\n  a: 1\n  c: 4",
+		},
+		{
+			source: "x: &x {a: 1}\ny: &y {a: 2, b: 2}\nz:\n  <<: [*x, *y]\n",
+			expect: "x: {a: 1}\ny: {a: 2, b: 2}\nz:\n  a: 1\n  b: 2",
+		},
+		{
+			source: "a: &x\n  b: &y\n    c: 1\nd: *y\ne: *x\n",
+			expect: "a:\n  b:\n    c: 1\nd:\n  c: 1\ne:\n  b:\n    c: 1",
+		},
+	}
+	for _, test := range tests {
+		f, err := parser.ParseBytes([]byte(test.source), 0)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		original := f.String()
+		doc, err := ast.ExpandAliases(f.Docs[0])
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if actual := doc.String(); actual != test.expect {
+			t.Fatalf("unexpected output. expected:\n%s\nbut got:\n%s", test.expect, actual)
+		}
+		if f.String() != original {
+			t.Fatalf("source document is modified:\n%s", f.String())
+		}
+	}
+	t.Run("error", func(t *testing.T) {
+		sources := []string{
+			"a: &x\n  b: *x\n",
+			"a: *x\n",
+			"a:\n  <<: 1\n",
+		}
+		for _, src := range sources {
+			f, err := parser.ParseBytes([]byte(src), 0)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if _, err := ast.ExpandAliases(f.Docs[0]); err == nil {
+				t.Fatalf("expected error for %q", src)
+			}
+		}
+	})
+}
+
+func TestExpandMergeKeys(t *testing.T) {
+	tests := []struct {
+		source string
+		expect string
+	}{
+		{
+			source: "base: &base\n  a: 1\n  b: 2\nderived:\n  b: 3\n  <<: *base\nc: *base\n",
+			expect: "base: &base\n  a: 1\n  b: 2\nderived:\n  b: 3\n  a: 1\nc: *base",
+		},
+		{
+			source: "x: &x {a: 1}\ny: &y {a: 2, b: &z 2}\nz:\n  <<: [*x, *y]\nw: *z\n",
+			expect: "x: &x {a: 1}\ny: &y {a: 2, b: &z 2}\nz:\n  a: 1\n  b: 2\nw: *z",
+		},
+		{
+			source: "base: &base\n  a: 1\n  1: 2\nderived:\n  'a': 3\n  \"1\": 4\n  <<: *base\n",
+			expect: "base: &base\n  a: 1\n  1: 2\nderived:\n  'a': 3\n  \"1\": 4\n  1: 2",
+		},
+	}
+	for _, test := range tests {
+		f, err := parser.ParseBytes([]byte(test.source), 0)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		original := f.String()
+		doc, err := ast.ExpandMergeKeys(f.Docs[0])
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if actual := doc.String(); actual != test.expect {
+			t.Fatalf("unexpected output. expected:\n%s\nbut got:\n%s", test.expect, actual)
+		}
+		if f.String() != original {
+			t.Fatalf("source document is modified:\n%s", f.String())
+		}
+	}
+	t.Run("error", func(t *testing.T) {
+		sources := []string{
+			"a:\n  <<: &m {b: 1}\nc: *m\n",
+			"a:\n  <<: *x\n",
+		}
+		for _, src := range sources {
+			f, err := parser.ParseBytes([]byte(src), 0)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if _, err := ast.ExpandMergeKeys(f.Docs[0]); err == nil {
+				t.Fatalf("expected error for %q", src)
+			}
+		}
+	})
+}
+
+func TestIsEmpty(t *testing.T) {
+	tests := []struct {
+		src     string
+		isNull  bool
+		isEmpty bool
+	}{
+		{"a:", true, true},
+		{"a: null", true, true},
+		{"a: ~", true, true},
+		{"a: &x null", true, true},
+		{"a: {}", false, true},
+		{"a: []", false, true},
+		{`a: ""`, false, true},
+		{"a: !!str ''", false, true},
+		{"a: 0", false, false},
+		{"a: false", false, false},
+		{"a: [null]", false, false},
+		{"a: {b: c}", false, false},
+		{"a: *x", false, false},
+	}
+	for _, test := range tests {
+		f, err := parser.ParseBytes([]byte(test.src), 0)
+		if err != nil {
+			t.Fatalf("%q: %+v", test.src, err)
+		}
+		value := f.Docs[0].Body.(*ast.MappingValueNode).Value
+		if ast.IsNull(value) != test.isNull {
+			t.Fatalf("%q: unexpected IsNull result", test.src)
+		}
+		if ast.IsEmpty(value) != test.isEmpty {
+			t.Fatalf("%q: unexpected IsEmpty result", test.src)
+		}
+	}
+	if !ast.IsNull(nil) || !ast.IsEmpty(nil) {
+		t.Fatal("nil node must be null and empty")
+	}
+}
+
+func TestNewNode(t *testing.T) {
+	m := ast.NewMapping(
+		ast.NewMappingValue("name", ast.NewString("true")),
+		ast.NewMappingValue("nested", ast.NewMapping(
+			ast.NewMappingValue("a", ast.NewInteger(1)),
+			ast.NewMappingValue("b", ast.NewMapping(ast.NewMappingValue("c", ast.NewFloat(2)))),
+		)),
+		ast.NewMappingValue("list", ast.NewSequence(
+			ast.NewString("x"),
+			ast.NewMapping(
+				ast.NewMappingValue("k", ast.NewBool(true)),
+				ast.NewMappingValue("m", ast.NewMapping(ast.NewMappingValue("z", nil))),
+			),
+			ast.NewSequence(ast.NewString("p"), ast.NewString("q")),
+			ast.NewSequence(),
+		)),
+		ast.NewMappingValue("empty", ast.NewMapping()),
+	)
+	m.Values[0].HeadComment = ast.NewComment(" head")
+	m.Values[0].LineComment = ast.NewComment(" line")
+	expected := `
+# head
+name: "true" # line
+nested:
+  a: 1
+  b:
+    c: 2.0
+list:
+- x
+- k: true
+  m:
+    z: null
+- - p
+  - q
+- []
+empty: {}
+`
+	actual := ast.NewDocument(m).String()
+	if actual != strings.TrimSpace(expected) {
+		t.Fatalf("unexpected output. expected:\n%s\nbut got:\n%s", expected, actual)
+	}
+	f, err := parser.ParseBytes([]byte(actual), parser.ParseComments)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if reparsed := f.String(); reparsed != actual {
+		t.Fatalf("failed to parse built document. expected:\n%s\nbut got:\n%s", actual, reparsed)
+	}
+}
+
+func TestNodeWithoutPosition(t *testing.T) {
+	str := func(v string) *ast.StringNode {
+		return &ast.StringNode{Token: &token.Token{Type: token.StringType, Value: v}, Value: v}
+	}
+	f, err := parser.ParseBytes([]byte("x:\n    y: 1\n    z:\n    - 2\n"), 0)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	parsed := f.Docs[0].Body
+	f, err = parser.ParseBytes([]byte("top:\n  child:\n    leaf: 1\n"), 0)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	top := f.Docs[0].Body.(*ast.MappingValueNode)
+	top.Value.(*ast.MappingValueNode).Value = &ast.MappingNode{
+		Values: []*ast.MappingValueNode{
+			{Key: str("a"), Value: str("b")},
+			{Key: str("parsed"), Value: parsed},
+			{
+				Key: str("seq"),
+				Value: &ast.SequenceNode{
+					Values: []ast.Node{
+						str("c"),
+						&ast.MappingNode{
+							Values: []*ast.MappingValueNode{
+								{Key: str("d"), Value: str("e")},
+								{Key: str("parsed"), Value: parsed},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	doc := &ast.Document{
+		Body: &ast.MappingNode{
+			Values: []*ast.MappingValueNode{top, {Key: str("f"), Value: str("g")}},
+		},
+	}
+	expected := `
+top:
+  child:
+    a: b
+    parsed:
+      x:
+          y: 1
+          z:
+          - 2
+    seq:
+    - c
+    - d: e
+      parsed:
+        x:
+            y: 1
+            z:
+            - 2
+f: g
+`
+	if actual := doc.String(); actual != strings.TrimSpace(expected) {
+		t.Fatalf("unexpected output. expected:\n%s\nbut got:\n%s", expected, actual)
+	}
+}
+
+func TestKeyEqual(t *testing.T) {
+	src := `
+a: 1
+'a': 2
+"\x61": 3
+1: 4
+"1": 5
+0x1: 6
+1.0: 7
+~: 8
+null: 9
+`
+	f, err := parser.ParseBytes([]byte(src), 0)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	keys := []ast.Node{}
+	for _, value := range f.Docs[0].Body.(*ast.MappingNode).Values {
+		keys = append(keys, value.Key)
+	}
+	tag := func(tag string, value ast.Node) ast.Node {
+		return &ast.TagNode{Start: token.New(tag, tag, &token.Position{}), Value: value}
+	}
+	keys = append(keys,
+		tag("!!str", ast.NewString("a")),
+		tag("!!str", ast.NewInteger(1)),
+		tag("!custom", ast.NewString("a")),
+		ast.NewSequence(ast.NewString("a"), ast.NewInteger(1)),
+		ast.NewSequence(ast.NewString("a"), ast.NewString("1")),
+	)
+	// keys which have the same group are the same key
+	groups := []int{0, 0, 0, 1, 2, 1, 3, 4, 4, 0, 2, 5, 6, 7}
+	for i := range keys {
+		for j := range keys {
+			expected := groups[i] == groups[j]
+			if actual := ast.KeyEqual(keys[i], keys[j]); actual != expected {
+				t.Errorf("expected %t for KeyEqual(%s, %s) but got %t", expected, keys[i], keys[j], actual)
+			}
+		}
+	}
+}
+
+func TestAnchorUsage(t *testing.T) {
+	src := `
+a: &x 1
+b: *x
+c: &y [*x]
+d: &x 2
+e:
+  <<: *x
+  f: *x
+---
+g: *y
+h: &z 3
+`
+	f, err := parser.ParseBytes([]byte(src), 0)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	anchors := ast.AnchorUsage(f)
+	type usage struct {
+		name    string
+		pos     string
+		aliases []string
+		unused  bool
+	}
+	var got []usage
+	for _, anchor := range anchors {
+		aliases := []string{}
+		for _, pos := range anchor.AliasPositions() {
+			aliases = append(aliases, fmt.Sprintf("%d:%d", pos.Line, pos.Column))
+		}
+		pos := anchor.Position()
+		got = append(got, usage{
+			name:    anchor.Name,
+			pos:     fmt.Sprintf("%d:%d", pos.Line, pos.Column),
+			aliases: aliases,
+			unused:  anchor.IsUnused(),
+		})
+	}
+	expected := []usage{
+		{name: "x", pos: "2:4", aliases: []string{"3:4", "4:8"}},
+		{name: "y", pos: "4:4", aliases: []string{}, unused: true},
+		{name: "x", pos: "5:4", aliases: []string{"7:7", "8:6"}},
+		{name: "z", pos: "11:4", aliases: []string{}, unused: true},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("unexpected usage: %+v", got)
+	}
+}
+
+func TestNodeKindAndStyle(t *testing.T) {
+	tests := []struct {
+		src   string
+		kind  ast.Kind
+		style ast.Style
+	}{
+		{"a: b", ast.ScalarKind, ast.PlainStyle},
+		{"a: 'b'", ast.ScalarKind, ast.SingleQuotedStyle},
+		{`a: "b"`, ast.ScalarKind, ast.DoubleQuotedStyle},
+		{"a: 1", ast.ScalarKind, ast.PlainStyle},
+		{"a: |\n  b\n", ast.ScalarKind, ast.LiteralStyle},
+		{"a: >-\n  b\n", ast.ScalarKind, ast.FoldedStyle},
+		{"a: [b]", ast.SequenceKind, ast.FlowStyle},
+		{"a:\n  - b", ast.SequenceKind, ast.PlainStyle},
+		{"a: {b: c}", ast.MappingKind, ast.FlowStyle},
+		{"a:\n  b: c\n  d: e", ast.MappingKind, ast.PlainStyle},
+		{"a: &x [b]", ast.SequenceKind, ast.FlowStyle},
+		{"a: !!str 'b'", ast.ScalarKind, ast.SingleQuotedStyle},
+		{"a: *x", ast.AliasKind, ast.PlainStyle},
+	}
+	for _, test := range tests {
+		f, err := parser.ParseBytes([]byte(test.src), 0)
+		if err != nil {
+			t.Fatalf("%q: %+v", test.src, err)
+		}
+		value := f.Docs[0].Body.(*ast.MappingValueNode).Value
+		if value.Kind() != test.kind {
+			t.Fatalf("%q: expected kind %s but got %s", test.src, test.kind, value.Kind())
+		}
+		if value.Style() != test.style {
+			t.Fatalf("%q: expected style %s but got %s", test.src, test.style, value.Style())
+		}
+	}
+}
+
+func TestNodeSetStyle(t *testing.T) {
+	src := `a: b # c
+d: 'e'
+f: |-
+  x
+  y
+g:
+  - h
+  - i: j
+k: {l: m}
+n: true
+`
+	f, err := parser.ParseBytes([]byte(src), parser.ParseComments)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	mapping := f.Docs[0].Body.(*ast.MappingNode)
+	mapping.Values[0].Value.SetStyle(ast.DoubleQuotedStyle)
+	mapping.Values[1].Value.SetStyle(ast.PlainStyle)
+	mapping.Values[2].Value.SetStyle(ast.FoldedStyle)
+	mapping.Values[3].Value.SetStyle(ast.FlowStyle)
+	mapping.Values[4].Value.SetStyle(ast.PlainStyle)
+	mapping.Values[5].Value.SetStyle(ast.DoubleQuotedStyle)
+	expected := `a: "b" # c
+d: e
+f: >-
+  x
+
+  y
+g: [h, {i: j}]
+k:
+    l: m
+n: true`
+	if actual := f.String(); actual != expected {
+		t.Fatalf("unexpected node text. expected:[%s] but got [%s]", expected, actual)
+	}
+	var p printer.Printer
+	expectedFile := `a: "b" # c
+d: e
+f: >-
+  x
+
+  y
+g:
+  - h
+  - i: j
+k: {l: m}
+n: true`
+	if actual := p.PrintFile(f); actual != expectedFile {
+		t.Fatalf("unexpected printed file. expected:[%s] but got [%s]", expectedFile, actual)
+	}
+	t.Run("string which must be quoted", func(t *testing.T) {
+		f, err := parser.ParseBytes([]byte("a: 'true'"), 0)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		value := f.Docs[0].Body.(*ast.MappingValueNode).Value
+		value.SetStyle(ast.PlainStyle)
+		if value.Style() != ast.SingleQuotedStyle {
+			t.Fatalf("quoted string must not be changed to plain style: %s", value.Style())
+		}
+	})
+	t.Run("round trip", func(t *testing.T) {
+		sources := []string{
+			"a: b c\nz: 1",
+			"a: 'b c'\nz: 1",
+			"a: \"b\\nc\\n\"\nz: 1",
+			"a: |\n  b\n  c\nz: 1",
+			"a: |-\n  b\n\n  c\nz: 1",
+			"a: |+\n\n  b\n\n\n  c\n\nz: 1",
+			"a: >\n  b\n  c\nz: 1",
+			"a: >-\n  b\n\n  c\nz: 1",
+			"a: |\n  b\n  c\n",
+			"a: >\n  b\n\n  c\n",
+		}
+		styles := []ast.Style{
+			ast.PlainStyle,
+			ast.SingleQuotedStyle,
+			ast.DoubleQuotedStyle,
+			ast.LiteralStyle,
+			ast.FoldedStyle,
+		}
+		valueOf := func(src string) interface{} {
+			f, err := parser.ParseBytes([]byte(src), 0)
+			if err != nil {
+				t.Fatalf("%q: %+v", src, err)
+			}
+			body := f.Docs[0].Body
+			if mapping, ok := body.(*ast.MappingNode); ok {
+				return mapping.Values[0].Value.(ast.ScalarNode).GetValue()
+			}
+			return body.(*ast.MappingValueNode).Value.(ast.ScalarNode).GetValue()
+		}
+		for _, src := range sources {
+			expected := valueOf(src)
+			for _, style := range styles {
+				f, err := parser.ParseBytes([]byte(src), 0)
+				if err != nil {
+					t.Fatalf("%q: %+v", src, err)
+				}
+				body := f.Docs[0].Body
+				if mapping, ok := body.(*ast.MappingNode); ok {
+					mapping.Values[0].Value.SetStyle(style)
+				} else {
+					body.(*ast.MappingValueNode).Value.SetStyle(style)
+				}
+				// the line break at the end of document is added by encoder
+				out := f.String() + "\n"
+				if actual := valueOf(out); actual != expected {
+					t.Fatalf("%q: unexpected value by %s style: expected %q but got %q from %q", src, style, expected, actual, out)
+				}
+			}
+		}
+	})
+}
+
+func TestParseMergeKeySequence(t *testing.T) {
+	sources := []string{
+		"a: &a {x: 1}\nb: &b {y: 2}\nc:\n  <<: [*a, *b]\n  z: 3",
+		"a: &a {x: 1}\nb: &b {y: 2}\nc:\n  <<:\n    - *a\n    - *b\n  z: 3",
+		"a: &a {x: 1}\nb: &b {y: 2}\nc: {<<: [*a, *b], z: 3}",
+		"a: &a {x: 1}\nb: &b {y: 2}\nc:\n- <<: [*a, *b]\n  z: 3",
+	}
+	for _, src := range sources {
+		f, err := parser.ParseBytes([]byte(src), 0)
+		if err != nil {
+			t.Fatalf("%q: %+v", src, err)
+		}
+		value := f.Docs[0].Body.(*ast.MappingNode).Values[2].Value
+		if seq, ok := value.(*ast.SequenceNode); ok {
+			value = seq.Values[0]
+		}
+		merge := value.(*ast.MappingNode).Values[0]
+		if merge.Key.Type() != ast.MergeKeyType {
+			t.Fatalf("%q: unexpected key: %s", src, merge.Key.Type())
+		}
+		bases, ok := merge.Value.(*ast.SequenceNode)
+		if !ok {
+			t.Fatalf("%q: unexpected merge value: %T", src, merge.Value)
+		}
+		names := []string{}
+		for _, base := range bases.Values {
+			alias, ok := base.(*ast.AliasNode)
+			if !ok {
+				t.Fatalf("%q: unexpected base: %T", src, base)
+			}
+			names = append(names, alias.Value.GetToken().Value)
+		}
+		if !reflect.DeepEqual(names, []string{"a", "b"}) {
+			t.Fatalf("%q: unexpected bases: %v", src, names)
+		}
+		if actual := f.String(); actual != src {
+			t.Fatalf("unexpected output. expected:\n%s\nbut got:\n%s", src, actual)
+		}
+	}
+}
+
+func TestParseEmptyMappingValue(t *testing.T) {
+	src := "a:\nb: c\nd:\n- e:\n  f: g"
+	f, err := parser.ParseBytes([]byte(src), 0)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if actual := f.String(); actual != src {
+		t.Fatalf("empty value must be rendered as in source. expected:\n%s\nbut got:\n%s", src, actual)
+	}
+	values := f.Docs[0].Body.(*ast.MappingNode).Values
+	if values[0].Value.Type() != ast.NullType {
+		t.Fatalf("unexpected value of a: %s", values[0].Value.Type())
+	}
+	expected := "{a: null, b: c, d: [{e: null, f: g}]}"
+	if actual := ast.ToFlowStyle(f.Docs[0].Body).String(); actual != expected {
+		t.Fatalf("unexpected flow style. expected:\n%s\nbut got:\n%s", expected, actual)
+	}
+}
+
+func TestUnwrapAndTokens(t *testing.T) {
+	f, err := parser.ParseBytes([]byte("a: &x !!map {b: [c, d]}"), 0)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	value := f.Docs[0].Body.(*ast.MappingValueNode).Value
+	mapping, ok := ast.Unwrap(value).(*ast.MappingNode)
+	if !ok {
+		t.Fatalf("unexpected unwrapped node: %T", ast.Unwrap(value))
+	}
+	values := []string{}
+	for _, tk := range ast.Tokens(mapping) {
+		values = append(values, tk.Value)
+	}
+	if expected := "{,},:,b,[,],c,d"; strings.Join(values, ",") != expected {
+		t.Fatalf("unexpected tokens: expected %s but got %s", expected, strings.Join(values, ","))
+	}
+}
@@ -0,0 +1,530 @@
+package yaml
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/internal/errors"
+	"github.com/goccy/go-yaml/internal/yamlpath"
+	"github.com/goccy/go-yaml/parser"
+	"golang.org/x/xerrors"
+)
+
+var (
+	// ErrNotFoundNode error returned by Path if the node at the path doesn't exist
+	ErrNotFoundNode = xerrors.New("node not found")
+)
+
+// Path represent YAMLPath ( e.g. `$.spec.containers[0].image` ) which points to the value in YAML.
+// Keys which have special characters can be quoted ( e.g. `$['a.b']` ).
+// Aliases and merge keys are followed, so the value can be found in the anchored value or the merged mapping.
+//
+// The value at the path in ast.File can be replaced or merged with another YAML.
+// The rest of the file keeps the order of keys and the indentation, so the file can be re-rendered by String of it
+// without reformatting the whole file.
+type Path struct {
+	segments []*pathSegment
+}
+
+// PathString create Path from string
+func PathString(s string) (*Path, error) {
+	segments, err := parsePath(s)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid path")
+	}
+	return &Path{segments: segments}, nil
+}
+
+// String path to text
+func (p *Path) String() string {
+	return pathText(p.segments)
+}
+
+// Read decodes the value at the path in the first document which has it from r into v
+func (p *Path) Read(r io.Reader, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Type().Kind() != reflect.Ptr {
+		return errors.ErrDecodeRequiredPointerType
+	}
+	f, err := readFile(r)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read file")
+	}
+	doc, node, err := p.filterFile(f)
+	if err != nil {
+		return errors.Wrapf(err, "failed to filter file")
+	}
+	d := NewDecoder(nil)
+	// register anchors defined before the node in the document,
+	// so that aliases refer to the closest anchor before them like decoding the whole document
+	ast.WalkAll(&precedingAnchorCollector{d: d, target: node}, doc.Body)
+	if err := d.decodeValue(rv.Elem(), node); err != nil {
+		return errors.Wrapf(err, "failed to decode value")
+	}
+	return nil
+}
+
+// ReadNode returns the node at the path in the first document which has it from r
+func (p *Path) ReadNode(r io.Reader) (ast.Node, error) {
+	f, err := readFile(r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read file")
+	}
+	node, err := p.FilterFile(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to filter file")
+	}
+	return node, nil
+}
+
+func readFile(r io.Reader) (*ast.File, error) {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read")
+	}
+	f, err := parser.ParseBytes(src, 0)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse")
+	}
+	return f, nil
+}
+
+// FilterFile returns the node at the path in the first document which has it.
+// If no document has the node, returns ErrNotFoundNode
+func (p *Path) FilterFile(f *ast.File) (ast.Node, error) {
+	_, node, err := p.filterFile(f)
+	return node, err
+}
+
+// filterFile returns the node at the path and the first document which has it
+func (p *Path) filterFile(f *ast.File) (*ast.Document, ast.Node, error) {
+	for _, doc := range f.Docs {
+		node, err := p.FilterNode(doc)
+		if xerrors.Is(err, ErrNotFoundNode) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		return doc, node, nil
+	}
+	return nil, nil, xerrors.Errorf("%s: %w", p, ErrNotFoundNode)
+}
+
+// precedingAnchorCollector registers the anchors defined before target to the decoder
+type precedingAnchorCollector struct {
+	d      *Decoder
+	target ast.Node
+	found  bool
+}
+
+func (c *precedingAnchorCollector) Visit(node ast.Node) ast.Visitor {
+	if c.found || node == c.target {
+		c.found = true
+		return nil
+	}
+	if anchor, ok := node.(*ast.AnchorNode); ok {
+		c.d.anchorMap[anchor.Name.GetToken().Value] = anchor.Value
+	}
+	return c
+}
+
+// FilterNode returns the node at the path from node which is the root of the path.
+// Aliases are resolved by the anchors defined in node. If the node doesn't exist, returns ErrNotFoundNode
+func (p *Path) FilterNode(node ast.Node) (ast.Node, error) {
+	doc, ok := node.(*ast.Document)
+	if !ok {
+		doc = &ast.Document{Body: node}
+	}
+	target, err := p.find(doc)
+	if err != nil {
+		return nil, err
+	}
+	return target.node, nil
+}
+
+// pathTarget the node found by path and the place which has it
+type pathTarget struct {
+	node ast.Node
+	set  func(ast.Node)
+	// column where the value starts if it is block style collection
+	column int
+	// isShared is true if the node is found via alias or merge key, so it is shared by the other values
+	isShared bool
+}
+
+func (p *Path) find(doc *ast.Document) (*pathTarget, error) {
+	finder := newPathFinder(doc)
+	target := &pathTarget{
+		node:   doc.Body,
+		set:    func(node ast.Node) { doc.Body = node },
+		column: 1,
+	}
+	for idx, seg := range p.segments {
+		if target.node == nil {
+			return nil, xerrors.Errorf("%s: %w", pathText(p.segments[:idx+1]), ErrNotFoundNode)
+		}
+		isShared := target.isShared || ast.Unwrap(target.node).Type() == ast.AliasType
+		parent := finder.resolve(target.node)
+		var found *pathTarget
+		if seg.isIndex {
+			if seq, ok := parent.(*ast.SequenceNode); ok && seg.index < len(seq.Values) {
+				index := seg.index
+				found = &pathTarget{
+					node:   seq.Values[index],
+					set:    func(node ast.Node) { seq.Values[index] = node },
+					column: seq.Start.Position.Column + len("- "),
+				}
+			}
+		} else if value := finder.lookup(parent, seg.key); value != nil {
+			found = &pathTarget{
+				node:     value.Value,
+				set:      func(node ast.Node) { value.Value = node },
+				column:   valueColumn(value),
+				isShared: lookupMappingValue(parent, seg.key) != value,
+			}
+		}
+		if found == nil {
+			return nil, xerrors.Errorf("%s: %w", pathText(p.segments[:idx+1]), ErrNotFoundNode)
+		}
+		found.isShared = found.isShared || isShared
+		target = found
+	}
+	return target, nil
+}
+
+// findFile returns the target in the first document which has it
+func (p *Path) findFile(f *ast.File) (*pathTarget, error) {
+	for _, doc := range f.Docs {
+		target, err := p.find(doc)
+		if xerrors.Is(err, ErrNotFoundNode) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if target.isShared {
+			return nil, xerrors.Errorf("value at %s is shared by alias or merge key", p)
+		}
+		return target, nil
+	}
+	return nil, xerrors.Errorf("%s: %w", p, ErrNotFoundNode)
+}
+
+// ReplaceWithReader replace the node at the path in dst by the first document read from src
+func (p *Path) ReplaceWithReader(dst *ast.File, src io.Reader) error {
+	f, err := readFile(src)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read file")
+	}
+	return p.ReplaceWithFile(dst, f)
+}
+
+// ReplaceWithFile replace the node at the path in dst by the first document of src
+func (p *Path) ReplaceWithFile(dst *ast.File, src *ast.File) error {
+	if len(src.Docs) == 0 {
+		return xerrors.New("source file has no document")
+	}
+	return p.ReplaceWithNode(dst, src.Docs[0])
+}
+
+// ReplaceWithNode replace the node at the path in the first document of dst which has it by the copy of node.
+// The indent of the copy is adjusted to the place of the path.
+// An error is returned if the node at the path is shared by alias or merge key
+func (p *Path) ReplaceWithNode(dst *ast.File, node ast.Node) error {
+	target, err := p.findFile(dst)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find node")
+	}
+	copied, err := copyNodeAt(node, target.column)
+	if err != nil {
+		return errors.Wrapf(err, "failed to copy node")
+	}
+	target.set(copied)
+	return nil
+}
+
+// MergeFromReader merge the first document read from src into the node at the path in dst
+func (p *Path) MergeFromReader(dst *ast.File, src io.Reader) error {
+	f, err := readFile(src)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read file")
+	}
+	return p.MergeFromFile(dst, f)
+}
+
+// MergeFromFile merge the first document of src into the node at the path in dst
+func (p *Path) MergeFromFile(dst *ast.File, src *ast.File) error {
+	if len(src.Docs) == 0 {
+		return xerrors.New("source file has no document")
+	}
+	return p.MergeFromNode(dst, src.Docs[0])
+}
+
+// MergeFromNode merge the copy of node into the node at the path in the first document of dst which has it.
+// Mappings are merged recursively. The values of existing keys are replaced and the new keys are added to the end.
+// Elements of sequence are appended to the end. The other kinds of values can't be merged
+func (p *Path) MergeFromNode(dst *ast.File, node ast.Node) error {
+	target, err := p.findFile(dst)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find node")
+	}
+	copied, err := copyNodeAt(node, 1)
+	if err != nil {
+		return errors.Wrapf(err, "failed to copy node")
+	}
+	if err := mergeNode(target, copied); err != nil {
+		return errors.Wrapf(err, "failed to merge node into %s", p)
+	}
+	return nil
+}
+
+// copyNodeAt returns the copy of node which starts at column
+func copyNodeAt(node ast.Node, column int) (ast.Node, error) {
+	f, err := ast.Extract(node)
+	if err != nil {
+		return nil, err
+	}
+	copied := f.Docs[0].Body
+	if copied == nil {
+		return nil, xerrors.New("empty document can't be used as value")
+	}
+	shiftColumn(copied, column-1)
+	return copied, nil
+}
+
+func mergeNode(target *pathTarget, src ast.Node) error {
+	dstValue := ast.Unwrap(target.node)
+	srcValue := ast.Unwrap(src)
+	if seq, ok := dstValue.(*ast.SequenceNode); ok {
+		srcSeq, ok := srcValue.(*ast.SequenceNode)
+		if !ok {
+			return xerrors.Errorf("cannot merge %s into sequence", srcValue.Type())
+		}
+		for _, value := range srcSeq.Values {
+			shiftColumn(value, seq.Start.Position.Column-srcSeq.Start.Position.Column)
+			if seq.IsFlowStyle {
+				value = ast.ToFlowStyle(value)
+			}
+			seq.Values = append(seq.Values, value)
+		}
+		return nil
+	}
+	mapping := mappingNodeOf(target)
+	if mapping == nil {
+		return xerrors.Errorf("cannot merge into %s", dstValue.Type())
+	}
+	srcValues := mappingValues(srcValue)
+	if srcValues == nil && !ast.IsEmpty(srcValue) {
+		return xerrors.Errorf("cannot merge %s into mapping", srcValue.Type())
+	}
+	column := target.column
+	if len(mapping.Values) > 0 {
+		column = mapping.Values[0].Key.GetToken().Position.Column
+	}
+	for _, value := range srcValues {
+		var existing *ast.MappingValueNode
+		for _, dstValue := range mapping.Values {
+			if ast.KeyEqual(dstValue.Key, value.Key) {
+				existing = dstValue
+				break
+			}
+		}
+		if existing == nil {
+			shiftColumn(value, column-value.Key.GetToken().Position.Column)
+			if mapping.IsFlowStyle {
+				value.Value = ast.ToFlowStyle(value.Value)
+			}
+			mapping.Values = append(mapping.Values, value)
+			continue
+		}
+		sub := &pathTarget{
+			node:   existing.Value,
+			set:    func(node ast.Node) { existing.Value = node },
+			column: valueColumn(existing),
+		}
+		if isMergeableMapping(existing.Value) && isMergeableMapping(value.Value) {
+			if err := mergeNode(sub, value.Value); err != nil {
+				return err
+			}
+			continue
+		}
+		shiftColumn(value.Value, existing.Key.GetToken().Position.Column-value.Key.GetToken().Position.Column)
+		if mapping.IsFlowStyle {
+			value.Value = ast.ToFlowStyle(value.Value)
+		}
+		sub.set(value.Value)
+	}
+	return nil
+}
+
+// mappingNodeOf returns the mapping of target to add values.
+// Mapping which has only one value is replaced by MappingNode. If target isn't mapping, returns nil
+func mappingNodeOf(target *pathTarget) *ast.MappingNode {
+	switch n := ast.Unwrap(target.node).(type) {
+	case *ast.MappingNode:
+		return n
+	case *ast.MappingValueNode:
+		mapping := &ast.MappingNode{Start: n.Start, Values: []*ast.MappingValueNode{n}}
+		target.node = replaceUnwrapped(target.node, mapping)
+		target.set(target.node)
+		return mapping
+	}
+	return nil
+}
+
+// replaceUnwrapped replaces the value of anchor or tag which has it, and returns node
+func replaceUnwrapped(node, value ast.Node) ast.Node {
+	switch n := node.(type) {
+	case *ast.AnchorNode:
+		n.Value = replaceUnwrapped(n.Value, value)
+		return n
+	case *ast.TagNode:
+		n.Value = replaceUnwrapped(n.Value, value)
+		return n
+	}
+	return value
+}
+
+func isMergeableMapping(node ast.Node) bool {
+	switch ast.Unwrap(node).(type) {
+	case *ast.MappingNode, *ast.MappingValueNode:
+		return true
+	}
+	return false
+}
+
+// valueColumn returns the column where the value of mv starts if it is block style collection
+func valueColumn(mv *ast.MappingValueNode) int {
+	if column := blockColumn(mv.Value); column > 0 {
+		return column
+	}
+	return mv.Key.GetToken().Position.Column + defaultIndentWidth
+}
+
+// blockColumn returns the column where block style collection starts. If node isn't block style collection, returns 0
+func blockColumn(node ast.Node) int {
+	switch n := ast.Unwrap(node).(type) {
+	case *ast.MappingNode:
+		if !n.IsFlowStyle && len(n.Values) > 0 {
+			return n.Values[0].Key.GetToken().Position.Column
+		}
+	case *ast.MappingValueNode:
+		return n.Key.GetToken().Position.Column
+	case *ast.SequenceNode:
+		if !n.IsFlowStyle {
+			return n.Start.Position.Column
+		}
+	}
+	return 0
+}
+
+// pathFinder finds the node at the path with resolving aliases by the anchors of the document
+type pathFinder struct {
+	anchors map[*ast.AliasNode]ast.Node
+}
+
+// newPathFinder creates pathFinder which resolves alias to the closest anchor defined before it in doc
+func newPathFinder(doc *ast.Document) *pathFinder {
+	f := &pathFinder{anchors: map[*ast.AliasNode]ast.Node{}}
+	for _, info := range ast.AnchorUsage(&ast.File{Docs: []*ast.Document{doc}}) {
+		for _, alias := range info.Aliases {
+			f.anchors[alias] = info.Anchor.Value
+		}
+	}
+	return f
+}
+
+// resolve returns the value of node without anchor, tag and alias
+func (f *pathFinder) resolve(node ast.Node) ast.Node {
+	for {
+		switch n := node.(type) {
+		case *ast.AnchorNode:
+			node = n.Value
+		case *ast.TagNode:
+			node = n.Value
+		case *ast.AliasNode:
+			anchor, exists := f.anchors[n]
+			if !exists {
+				return nil
+			}
+			node = anchor
+		default:
+			return node
+		}
+	}
+}
+
+// lookup returns mapping value of key in node. The keys defined explicitly take precedence over merged keys
+func (f *pathFinder) lookup(node ast.Node, key string) *ast.MappingValueNode {
+	values := mappingValues(f.resolve(node))
+	for _, value := range values {
+		if value.Key.Type() != ast.MergeKeyType && isKeyOf(value.Key, key) {
+			return value
+		}
+	}
+	for _, value := range values {
+		if value.Key.Type() != ast.MergeKeyType {
+			continue
+		}
+		merged := f.resolve(value.Value)
+		if seq, ok := merged.(*ast.SequenceNode); ok {
+			for _, mapping := range seq.Values {
+				if found := f.lookup(mapping, key); found != nil {
+					return found
+				}
+			}
+			continue
+		}
+		if found := f.lookup(merged, key); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func pathText(segments []*pathSegment) string {
+	path := "$"
+	for _, seg := range segments {
+		if seg.isIndex {
+			path += fmt.Sprintf("[%d]", seg.index)
+		} else {
+			path = yamlpath.AppendKey(path, seg.key)
+		}
+	}
+	return path
+}
+
+// PathBuilder represent builder for Path
+type PathBuilder struct {
+	segments []*pathSegment
+}
+
+// Root add '$' to current path
+func (b *PathBuilder) Root() *PathBuilder {
+	return &PathBuilder{segments: []*pathSegment{}}
+}
+
+// Child add '.name' to current path
+func (b *PathBuilder) Child(name string) *PathBuilder {
+	return b.add(&pathSegment{key: name})
+}
+
+// Index add '[idx]' to current path
+func (b *PathBuilder) Index(idx uint) *PathBuilder {
+	return b.add(&pathSegment{index: int(idx), isIndex: true})
+}
+
+func (b *PathBuilder) add(seg *pathSegment) *PathBuilder {
+	segments := make([]*pathSegment, 0, len(b.segments)+1)
+	segments = append(segments, b.segments...)
+	return &PathBuilder{segments: append(segments, seg)}
+}
+
+// Build build YAMLPath
+func (b *PathBuilder) Build() *Path {
+	return &Path{segments: b.segments}
+}
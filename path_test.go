@@ -0,0 +1,288 @@
+package yaml_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/parser"
+	"golang.org/x/xerrors"
+)
+
+func TestPath(t *testing.T) {
+	src := `
+defaults: &defaults
+  image: nginx:1.19
+  pull: always
+spec:
+  containers:
+  - name: web
+    <<: *defaults
+  - name: sidecar
+    image: envoy
+  "a.b": quoted
+  ref: *defaults
+`
+	tests := []struct {
+		path   string
+		expect string
+	}{
+		{"$.spec.containers[0].name", "web"},
+		{"$.spec.containers[0].image", "nginx:1.19"},
+		{"$.spec.containers[1].image", "envoy"},
+		{"$.spec['a.b']", "quoted"},
+		{"$.spec.ref.pull", "always"},
+	}
+	f, err := parser.ParseBytes([]byte(src), 0)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	for _, test := range tests {
+		path, err := yaml.PathString(test.path)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if path.String() != test.path {
+			t.Fatalf("unexpected path text: %s", path)
+		}
+		node, err := path.FilterFile(f)
+		if err != nil {
+			t.Fatalf("%s: %+v", test.path, err)
+		}
+		if node.String() != test.expect {
+			t.Fatalf("%s: expect %s but got %s", test.path, test.expect, node)
+		}
+		var v string
+		if err := path.Read(strings.NewReader(src), &v); err != nil {
+			t.Fatalf("%s: %+v", test.path, err)
+		}
+		if v != test.expect {
+			t.Fatalf("%s: expect %s but got %s", test.path, test.expect, v)
+		}
+	}
+	t.Run("builder", func(t *testing.T) {
+		path := (&yaml.PathBuilder{}).Root().Child("spec").Child("containers").Index(1).Child("name").Build()
+		if path.String() != "$.spec.containers[1].name" {
+			t.Fatalf("unexpected path: %s", path)
+		}
+		node, err := path.ReadNode(strings.NewReader(src))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if node.String() != "sidecar" {
+			t.Fatalf("unexpected node: %s", node)
+		}
+	})
+	t.Run("read struct", func(t *testing.T) {
+		path, err := yaml.PathString("$.spec.containers[0]")
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		var v struct {
+			Name  string
+			Image string
+			Pull  string
+		}
+		if err := path.Read(strings.NewReader(src), &v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if v.Name != "web" || v.Image != "nginx:1.19" || v.Pull != "always" {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+	t.Run("not found", func(t *testing.T) {
+		for _, p := range []string{"$.spec.missing", "$.spec.containers[2]", "$.spec.containers.name"} {
+			path, err := yaml.PathString(p)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if _, err := path.FilterFile(f); !xerrors.Is(err, yaml.ErrNotFoundNode) {
+				t.Fatalf("%s: unexpected error: %v", p, err)
+			}
+		}
+	})
+	t.Run("multiple documents", func(t *testing.T) {
+		path, err := yaml.PathString("$.b")
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		var v int
+		if err := path.Read(strings.NewReader("a: 1\n---\nb: 2\n"), &v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if v != 2 {
+			t.Fatalf("unexpected value: %d", v)
+		}
+	})
+	t.Run("redefined anchor", func(t *testing.T) {
+		src := `
+a: &x {v: 1}
+b: *x
+c: &x {v: 2}
+d: *x
+---
+e: &x {v: 3}
+f: *x
+`
+		for p, expect := range map[string]int{"$.b.v": 1, "$.d.v": 2, "$.f.v": 3} {
+			path, err := yaml.PathString(p)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			var v int
+			if err := path.Read(strings.NewReader(src), &v); err != nil {
+				t.Fatalf("%s: %+v", p, err)
+			}
+			if v != expect {
+				t.Fatalf("%s: expect %d but got %d", p, expect, v)
+			}
+		}
+		for p, expect := range map[string]int{"$.b": 1, "$.d": 2, "$.f": 3} {
+			path, err := yaml.PathString(p)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			var v struct{ V int }
+			if err := path.Read(strings.NewReader(src), &v); err != nil {
+				t.Fatalf("%s: %+v", p, err)
+			}
+			if v.V != expect {
+				t.Fatalf("%s: expect %d but got %d", p, expect, v.V)
+			}
+		}
+	})
+	t.Run("invalid path", func(t *testing.T) {
+		if _, err := yaml.PathString("spec.a"); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestPath_Replace(t *testing.T) {
+	src := `
+name: app
+spec:
+    replicas: 1
+    containers:
+    - name: web
+      image: nginx
+    - name: sidecar
+      image: envoy
+labels: {app: web}
+`
+	tests := []struct {
+		path   string
+		value  string
+		expect string
+	}{
+		{
+			path:   "$.spec.replicas",
+			value:  "3",
+			expect: "name: app\nspec:\n    replicas: 3\n    containers:\n    - name: web\n      image: nginx\n    - name: sidecar\n      image: envoy\nlabels: {app: web}",
+		},
+		{
+			path:   "$.spec.containers[1]",
+			value:  "name: proxy\nports:\n- 80\n",
+			expect: "name: app\nspec:\n    replicas: 1\n    containers:\n    - name: web\n      image: nginx\n    - name: proxy\n      ports:\n      - 80\nlabels: {app: web}",
+		},
+		{
+			path:   "$.name",
+			value:  "first: a\nlast: b\n",
+			expect: "name:\n  first: a\n  last: b\nspec:\n    replicas: 1\n    containers:\n    - name: web\n      image: nginx\n    - name: sidecar\n      image: envoy\nlabels: {app: web}",
+		},
+	}
+	for _, test := range tests {
+		f, err := parser.ParseBytes([]byte(src), 0)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		path, err := yaml.PathString(test.path)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if err := path.ReplaceWithReader(f, strings.NewReader(test.value)); err != nil {
+			t.Fatalf("%s: %+v", test.path, err)
+		}
+		if f.String() != test.expect {
+			t.Fatalf("%s: unexpected output. expected:\n%s\nbut got:\n%s", test.path, test.expect, f.String())
+		}
+		if _, err := parser.ParseBytes([]byte(f.String()), 0); err != nil {
+			t.Fatalf("%s: replaced file is invalid: %+v", test.path, err)
+		}
+	}
+	t.Run("shared", func(t *testing.T) {
+		f, err := parser.ParseBytes([]byte("a: &a\n  b: 1\nc: *a\n"), 0)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		path, err := yaml.PathString("$.c.b")
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if err := path.ReplaceWithReader(f, strings.NewReader("2")); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestPath_Merge(t *testing.T) {
+	src := `
+spec:
+    replicas: 1
+    template:
+        image: nginx
+    ports:
+    - 80
+labels: {app: web}
+`
+	tests := []struct {
+		path   string
+		value  string
+		expect string
+	}{
+		{
+			path:   "$.spec",
+			value:  "replicas: 2\ntemplate:\n  pull: always\nselector:\n  app: web\n",
+			expect: "spec:\n    replicas: 2\n    template:\n        image: nginx\n        pull: always\n    ports:\n    - 80\n    selector:\n      app: web\nlabels: {app: web}",
+		},
+		{
+			path:   "$.spec.ports",
+			value:  "- 443\n",
+			expect: "spec:\n    replicas: 1\n    template:\n        image: nginx\n    ports:\n    - 80\n    - 443\nlabels: {app: web}",
+		},
+		{
+			path:   "$.labels",
+			value:  "tier:\n  name: front\n",
+			expect: "spec:\n    replicas: 1\n    template:\n        image: nginx\n    ports:\n    - 80\nlabels: {app: web, tier: {name: front}}",
+		},
+	}
+	for _, test := range tests {
+		f, err := parser.ParseBytes([]byte(src), 0)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		path, err := yaml.PathString(test.path)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if err := path.MergeFromReader(f, strings.NewReader(test.value)); err != nil {
+			t.Fatalf("%s: %+v", test.path, err)
+		}
+		if f.String() != test.expect {
+			t.Fatalf("%s: unexpected output. expected:\n%s\nbut got:\n%s", test.path, test.expect, f.String())
+		}
+	}
+	t.Run("invalid", func(t *testing.T) {
+		f, err := parser.ParseBytes([]byte(src), 0)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		path, err := yaml.PathString("$.spec.ports")
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if err := path.MergeFromReader(f, strings.NewReader("a: 1\n")); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
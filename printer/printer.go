@@ -16,10 +16,20 @@ type Property struct {
 	Suffix string
 }
 
+// decorate add prefix and suffix to text. empty text is not decorated
+func (p *Property) decorate(text string) string {
+	if text == "" {
+		return text
+	}
+	return p.Prefix + text + p.Suffix
+}
+
 // PrintFunc returns property instance
 type PrintFunc func() *Property
 
-// Printer create text from token collection or ast
+// Printer create text from token collection or ast.
+// Each PrintFunc decorates the tokens of its kind ( e.g. wrap text with escape sequence for color ).
+// If PrintFunc is nil, the tokens are printed as they are.
 type Printer struct {
 	LineNumber       bool
 	LineNumberFormat func(num int) string
@@ -29,6 +39,7 @@ type Printer struct {
 	Bool             PrintFunc
 	String           PrintFunc
 	Number           PrintFunc
+	Comment          PrintFunc
 }
 
 func defaultLineNumberFormat(num int) string {
@@ -42,8 +53,7 @@ func (p *Printer) lineTexts(tk *token.Token, prop *Property) []string {
 		if p.LineNumber {
 			header = p.LineNumberFormat(tk.Position.Line + idx)
 		}
-		lineText := prop.Prefix + src + prop.Suffix
-		texts = append(texts, fmt.Sprintf("%s%s", header, lineText))
+		texts = append(texts, fmt.Sprintf("%s%s", header, prop.decorate(src)))
 	}
 	return texts
 }
@@ -81,10 +91,15 @@ func (p *Printer) property(tk *token.Token) *Property {
 		}
 		return prop
 	case token.AliasType:
-		if p.Anchor != nil {
+		if p.Alias != nil {
 			return p.Alias()
 		}
 		return prop
+	case token.CommentType:
+		if p.Comment != nil {
+			return p.Comment()
+		}
+		return prop
 	case token.StringType, token.SingleQuoteType, token.DoubleQuoteType:
 		if p.String != nil {
 			return p.String()
@@ -120,7 +135,7 @@ func (p *Printer) PrintTokens(tokens token.Tokens) string {
 			header = p.LineNumberFormat(lineNumber)
 		}
 		if len(lines) == 1 {
-			line := prop.Prefix + lines[0] + prop.Suffix
+			line := prop.decorate(lines[0])
 			if len(texts) == 0 {
 				texts = append(texts, header+line)
 				lineNumber++
@@ -133,7 +148,7 @@ func (p *Printer) PrintTokens(tokens token.Tokens) string {
 				if p.LineNumber {
 					header = p.LineNumberFormat(lineNumber)
 				}
-				line := prop.Prefix + src + prop.Suffix
+				line := prop.decorate(src)
 				if idx == 0 {
 					if len(texts) == 0 {
 						texts = append(texts, header+line)
@@ -157,13 +172,49 @@ func (p *Printer) PrintNode(node ast.Node) []byte {
 	return []byte(fmt.Sprintf("%+v\n", node))
 }
 
+// PrintFile create text from the tokens of file.
+// The source of file is printed with keeping its layout.
+// To print comments, file must be parsed with parser.ParseComments mode.
+func (p *Printer) PrintFile(file *ast.File) string {
+	tk := firstToken(file)
+	if tk == nil {
+		return ""
+	}
+	tokens := token.Tokens{}
+	for ; tk != nil; tk = tk.Next {
+		// don't use Add to keep links between tokens of file
+		tokens = append(tokens, tk)
+	}
+	return p.PrintTokens(tokens)
+}
+
+func firstToken(file *ast.File) *token.Token {
+	for _, doc := range file.Docs {
+		tk := doc.Start
+		if len(doc.Directives) > 0 {
+			tk = doc.Directives[0].GetToken()
+		} else if tk == nil && doc.Body != nil {
+			tk = doc.Body.GetToken()
+		}
+		if tk == nil {
+			continue
+		}
+		for tk.Prev != nil {
+			tk = tk.Prev
+		}
+		return tk
+	}
+	return nil
+}
+
 const escape = "\x1b"
 
 func format(attr color.Attribute) string {
 	return fmt.Sprintf("%s[%dm", escape, attr)
 }
 
-func (p *Printer) setDefaultColorSet() {
+// SetDefaultColorSet set the colors used by error message to all PrintFunc
+func (p *Printer) SetDefaultColorSet() {
 	p.Bool = func() *Property {
 		return &Property{
 			Prefix: format(color.FgHiMagenta),
@@ -200,6 +251,12 @@ func (p *Printer) setDefaultColorSet() {
 			Suffix: format(color.Reset),
 		}
 	}
+	p.Comment = func() *Property {
+		return &Property{
+			Prefix: format(color.FgHiBlack),
+			Suffix: format(color.Reset),
+		}
+	}
 }
 
 func (p *Printer) PrintErrorMessage(msg string, isColored bool) string {
@@ -247,7 +304,12 @@ func (p *Printer) PrintErrorToken(tk *token.Token, isColored bool) string {
 	trimmed := strings.TrimRight(strings.TrimRight(lastTk.Origin, " "), "\n")
 	lastTk.Origin = trimmed
 	if tk != nil {
-		tk.Origin = org[len(trimmed)+1:] + tk.Origin
+		if len(org) > len(trimmed) {
+			tk.Origin = org[len(trimmed)+1:] + tk.Origin
+		} else {
+			// line break is placed at the beginning of next token
+			tk.Origin = strings.TrimPrefix(tk.Origin, "\n")
+		}
 	}
 	p.LineNumber = true
 	p.LineNumberFormat = func(num int) string {
@@ -264,7 +326,7 @@ func (p *Printer) PrintErrorToken(tk *token.Token, isColored bool) string {
 		return fmt.Sprintf("  %2d | ", num)
 	}
 	if isColored {
-		p.setDefaultColorSet()
+		p.SetDefaultColorSet()
 	}
 	beforeSource := p.PrintTokens(tokens)
 	prefixSpaceNum := len(fmt.Sprintf("  %2d | ", 1))
@@ -0,0 +1,62 @@
+package printer_test
+
+import (
+	"testing"
+
+	"github.com/goccy/go-yaml/lexer"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/printer"
+)
+
+func TestPrinter_PrintTokens(t *testing.T) {
+	src := `anchor: &x 1
+alias: *x
+# comment
+bool: true
+str: "a"
+`
+	wrap := func(name string) printer.PrintFunc {
+		return func() *printer.Property {
+			return &printer.Property{Prefix: "<" + name + ">", Suffix: "</" + name + ">"}
+		}
+	}
+	p := printer.Printer{
+		MapKey:  wrap("key"),
+		Anchor:  wrap("anchor"),
+		Alias:   wrap("alias"),
+		Bool:    wrap("bool"),
+		String:  wrap("str"),
+		Number:  wrap("num"),
+		Comment: wrap("comment"),
+	}
+	expect := `<key>anchor</key>:<anchor> &</anchor><anchor>x</anchor><num>1</num>
+<key>alias</key>:<alias> *</alias><alias>x</alias>
+<comment># comment</comment>
+<key>bool</key>:<bool> true</bool>
+<key>str</key>:<str> "a"</str>`
+	actual := p.PrintTokens(lexer.Tokenize(src))
+	if actual != expect {
+		t.Fatalf("unexpected output. expected:\n%s\nbut got:\n%s", expect, actual)
+	}
+}
+
+func TestPrinter_PrintFile(t *testing.T) {
+	src := `---
+a: 1 # comment
+---
+b: [2, 3]
+`
+	f, err := parser.ParseBytes([]byte(src), parser.ParseComments)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	var p printer.Printer
+	if actual := p.PrintFile(f); actual+"\n" != src {
+		t.Fatalf("unexpected output:\n%s", actual)
+	}
+	p.LineNumber = true
+	expect := " 1 | ---\n 2 | a: 1 # comment\n 3 | ---\n 4 | b: [2, 3]"
+	if actual := p.PrintFile(f); actual != expect {
+		t.Fatalf("unexpected output:\n%s", actual)
+	}
+}
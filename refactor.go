@@ -0,0 +1,68 @@
+package yaml
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/goccy/go-yaml/lexer"
+	"github.com/goccy/go-yaml/token"
+	"golang.org/x/xerrors"
+)
+
+// RenameAnchor renames the anchor named oldName and all aliases referencing it to newName.
+// Only the names are rewritten, so everything else in src ( e.g. comments and indentation ) is kept as it is.
+// If src is a stream of multiple documents, the anchor is renamed in all documents.
+func RenameAnchor(src []byte, oldName, newName string) ([]byte, error) {
+	if err := validateAnchorName(newName); err != nil {
+		return nil, xerrors.Errorf("invalid anchor name %q: %w", newName, err)
+	}
+	tokens := lexer.Tokenize(string(src))
+	nameTokens := []*token.Token{}
+	isDefined := false
+	for idx, tk := range tokens {
+		if tk.Type != token.AnchorType && tk.Type != token.AliasType {
+			continue
+		}
+		if idx+1 >= len(tokens) {
+			continue
+		}
+		name := tokens[idx+1]
+		if tk.Type == token.AnchorType && name.Value == newName && oldName != newName {
+			return nil, xerrors.Errorf("anchor %q is already defined", newName)
+		}
+		if name.Value != oldName {
+			continue
+		}
+		if tk.Type == token.AnchorType {
+			isDefined = true
+		}
+		nameTokens = append(nameTokens, name)
+	}
+	if !isDefined {
+		return nil, xerrors.Errorf("anchor %q is undefined", oldName)
+	}
+	var buf bytes.Buffer
+	last := 0
+	for _, tk := range nameTokens {
+		start := tk.Position.Offset - 1
+		end := start + len(oldName)
+		if start < last || end > len(src) || string(src[start:end]) != oldName {
+			return nil, xerrors.Errorf("failed to find anchor name %q at %d:%d", oldName, tk.Position.Line, tk.Position.Column)
+		}
+		buf.Write(src[last:start])
+		buf.WriteString(newName)
+		last = end
+	}
+	buf.Write(src[last:])
+	return buf.Bytes(), nil
+}
+
+func validateAnchorName(name string) error {
+	if name == "" {
+		return xerrors.New("anchor name is empty")
+	}
+	if strings.ContainsAny(name, " \t\r\n,[]{}") {
+		return xerrors.New("anchor name must not contain whitespace and flow indicators")
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package yaml_test
+
+import (
+	"testing"
+
+	"github.com/goccy/go-yaml"
+)
+
+func TestRenameAnchor(t *testing.T) {
+	t.Run("rename", func(t *testing.T) {
+		tests := []struct {
+			src      string
+			expected string
+		}{
+			{
+				src: `
+a: &x 1 # comment
+b: *x
+c:   [*x, *xy]
+xy: &xy  2
+`,
+				expected: `
+a: &renamed 1 # comment
+b: *renamed
+c:   [*renamed, *xy]
+xy: &xy  2
+`,
+			},
+			{
+				src: `
+base: &x
+  name: 'foo'
+  list:
+  - 1
+merged:
+  <<: *x
+  name: bar
+`,
+				expected: `
+base: &renamed
+  name: 'foo'
+  list:
+  - 1
+merged:
+  <<: *renamed
+  name: bar
+`,
+			},
+			{
+				src:      "a: &x |\n  text\nb: !!str &x \"v\"\n---\nc: *x\n",
+				expected: "a: &renamed |\n  text\nb: !!str &renamed \"v\"\n---\nc: *renamed\n",
+			},
+		}
+		for _, test := range tests {
+			actual, err := yaml.RenameAnchor([]byte(test.src), "x", "renamed")
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if string(actual) != test.expected {
+				t.Fatalf("failed to rename anchor. expected:\n%s\nbut got:\n%s", test.expected, string(actual))
+			}
+		}
+	})
+	t.Run("error", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			src     string
+			oldName string
+			newName string
+		}{
+			{name: "undefined anchor", src: "a: *x\n", oldName: "x", newName: "y"},
+			{name: "already defined", src: "a: &x 1\nb: &y 2\n", oldName: "x", newName: "y"},
+			{name: "empty name", src: "a: &x 1\n", oldName: "x", newName: ""},
+			{name: "invalid name", src: "a: &x 1\n", oldName: "x", newName: "a b"},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				if _, err := yaml.RenameAnchor([]byte(test.src), test.oldName, test.newName); err == nil {
+					t.Fatal("expected error")
+				}
+			})
+		}
+	})
+}
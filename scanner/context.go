@@ -1,33 +1,39 @@
 package scanner
 
 import (
-	"strings"
+	"bytes"
 
 	"github.com/goccy/go-yaml/token"
 )
 
+const (
+	// maxInternedLength maximum length of the scanned value shared by interning.
+	// Longer values ( e.g. multi-line scalars ) are rarely repeated
+	maxInternedLength = 64
+)
+
 // Context context at scanning
 type Context struct {
 	idx         int
 	size        int
 	src         string
-	buf         []rune
-	obuf        []rune
+	buf         []byte
+	obuf        []byte
 	tokens      token.Tokens
 	isRawFolded bool
 	isLiteral   bool
 	isFolded    bool
 	literalOpt  string
+	interned    map[string]string
 }
 
-func newContext(src string) *Context {
+func newContext(src string, interned map[string]string) *Context {
 	return &Context{
-		idx:    0,
-		size:   len(src),
-		src:    src,
-		tokens: token.Tokens{},
-		buf:    make([]rune, 0, len(src)),
-		obuf:   make([]rune, 0, len(src)),
+		idx:      0,
+		size:     len(src),
+		src:      src,
+		tokens:   token.Tokens{},
+		interned: interned,
 	}
 }
 
@@ -55,11 +61,19 @@ func (c *Context) addToken(tk *token.Token) {
 }
 
 func (c *Context) addBuf(r rune) {
-	c.buf = append(c.buf, r)
+	c.buf = append(c.buf, byte(r))
+}
+
+func (c *Context) addBufString(s string) {
+	c.buf = append(c.buf, s...)
 }
 
 func (c *Context) addOriginBuf(r rune) {
-	c.obuf = append(c.obuf, r)
+	c.obuf = append(c.obuf, byte(r))
+}
+
+func (c *Context) addOriginBufString(s string) {
+	c.obuf = append(c.obuf, s...)
 }
 
 func (c *Context) isEOS() bool {
@@ -117,11 +131,92 @@ func (c *Context) nextPos() int {
 }
 
 func (c *Context) bufferedSrc() string {
-	src := strings.TrimLeft(string(c.buf), " ")
-	src = strings.TrimRight(src, " ")
+	src := bytes.Trim(c.buf, " ")
+	if c.isFolded {
+		src = fold(src)
+	}
+	if c.isLiteral || c.isFolded {
+		src = chomp(src, c.literalOpt)
+	}
+	return c.intern(src)
+}
+
+// fold folds the lines of folded scalar.
+// The line break between two lines is replaced by a space, and the line break followed by empty lines is removed
+// so that each empty line is a line break. The line breaks around more indented lines and at the end are kept.
+func fold(src []byte) []byte {
+	content := bytes.TrimRight(src, "\n")
+	lines := bytes.Split(content, []byte("\n"))
+	folded := make([]byte, 0, len(src))
+	prev := -1 // index of the previous non-empty line
+	for idx, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		switch {
+		case prev < 0:
+			// leading empty lines
+			folded = append(folded, bytes.Repeat([]byte("\n"), idx)...)
+		case isMoreIndented(lines[prev]) || isMoreIndented(line):
+			folded = append(folded, bytes.Repeat([]byte("\n"), idx-prev)...)
+		case idx-prev == 1:
+			folded = append(folded, ' ')
+		default:
+			folded = append(folded, bytes.Repeat([]byte("\n"), idx-prev-1)...)
+		}
+		folded = append(folded, line...)
+		prev = idx
+	}
+	return append(folded, src[len(content):]...)
+}
+
+func isMoreIndented(line []byte) bool {
+	return len(line) > 0 && (line[0] == ' ' || line[0] == '\t')
+}
+
+// chomp removes the line breaks at the end of literal by the chomping indicator in opt.
+// `-` strips all of them, `+` keeps all of them and the others keep only one.
+func chomp(src []byte, opt string) []byte {
+	switch opt {
+	case "+":
+		return src
+	case "-":
+		return bytes.TrimRight(src, "\n")
+	}
+	if trimmed := bytes.TrimRight(src, "\n"); len(trimmed) < len(src) {
+		return src[:len(trimmed)+1]
+	}
 	return src
 }
 
+// intern returns the string shared by all the same values.
+// Documents repeat the same keys many times ( e.g. list of resources ),
+// so sharing them reduces allocations while scanning and memory held by decoded values.
+func (c *Context) intern(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	if len(b) > maxInternedLength || c.interned == nil {
+		return string(b)
+	}
+	if s, exists := c.interned[string(b)]; exists {
+		return s
+	}
+	s := string(b)
+	c.interned[s] = s
+	return s
+}
+
+// newToken creates token of the buffered source.
+// The content of literal or folded block scalar is string even if it looks like another type ( e.g. `1.10` ).
+func (c *Context) newToken(value, org string, pos *token.Position) *token.Token {
+	tk := token.New(value, org, pos)
+	if c.isLiteral || c.isFolded {
+		tk.Type = token.StringType
+	}
+	return tk
+}
+
 func (c *Context) bufferedToken(pos *token.Position) *token.Token {
 	if c.idx == 0 {
 		return nil
@@ -130,7 +225,7 @@ func (c *Context) bufferedToken(pos *token.Position) *token.Token {
 	if len(source) == 0 {
 		return nil
 	}
-	tk := token.New(source, string(c.obuf), pos)
+	tk := c.newToken(source, c.intern(c.obuf), pos)
 	c.buf = c.buf[:0]
 	c.obuf = c.obuf[:0]
 	return tk
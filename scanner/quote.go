@@ -0,0 +1,144 @@
+package scanner
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// quotedEnd returns the index of the quote character which closes the quoted scalar.
+// In single quoted scalar, two single quotes are escaped quote. In double quoted scalar, the character after `\` is escaped.
+// If src doesn't have closing quote, returns -1
+func quotedEnd(src string, quote byte) int {
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		if quote == '"' && c == '\\' {
+			i++
+			continue
+		}
+		if c != quote {
+			continue
+		}
+		if quote == '\'' && i+1 < len(src) && src[i+1] == '\'' {
+			i++
+			continue
+		}
+		return i
+	}
+	return -1
+}
+
+// unquote returns the value of quoted scalar from the text between quotes.
+// Line breaks are folded and escape sequences are processed.
+func unquote(raw string, quote byte) string {
+	if strings.IndexByte(raw, '\n') < 0 && strings.IndexByte(raw, '\\') < 0 && strings.IndexByte(raw, '\'') < 0 {
+		return raw
+	}
+	var b strings.Builder
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case c == '\'' && quote == '\'':
+			// `''` is escaped single quote
+			b.WriteByte(c)
+			i++
+		case c == '\\' && quote == '"':
+			i = writeEscapedChar(&b, raw, i)
+		case c == ' ' || c == '\t':
+			next := i
+			for next < len(raw) && (raw[next] == ' ' || raw[next] == '\t') {
+				next++
+			}
+			if next < len(raw) && raw[next] == '\n' {
+				// trailing white spaces are removed by folding
+				i = next - 1
+				continue
+			}
+			b.WriteString(raw[i:next])
+			i = next - 1
+		case c == '\n':
+			i = foldLineBreak(&b, raw, i)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// foldLineBreak writes the folded line break at raw[idx] and returns the index before the next content.
+// A line break is folded into a space, and empty lines following it are kept as line breaks.
+func foldLineBreak(b *strings.Builder, raw string, idx int) int {
+	emptyLineNum := 0
+	next := skipWhiteSpaces(raw, idx+1)
+	for next < len(raw) && raw[next] == '\n' {
+		emptyLineNum++
+		next = skipWhiteSpaces(raw, next+1)
+	}
+	if emptyLineNum > 0 {
+		b.WriteString(strings.Repeat("\n", emptyLineNum))
+	} else {
+		b.WriteByte(' ')
+	}
+	return next - 1
+}
+
+func skipWhiteSpaces(raw string, idx int) int {
+	for idx < len(raw) && (raw[idx] == ' ' || raw[idx] == '\t') {
+		idx++
+	}
+	return idx
+}
+
+var escapedCharMap = map[byte]string{
+	'0':  "\x00",
+	'a':  "\a",
+	'b':  "\b",
+	't':  "\t",
+	'\t': "\t",
+	'n':  "\n",
+	'v':  "\v",
+	'f':  "\f",
+	'r':  "\r",
+	'e':  "\x1b",
+	' ':  " ",
+	'"':  "\"",
+	'/':  "/",
+	'\\': "\\",
+	'N':  "\u0085",
+	'_':  "\u00a0",
+	'L':  "\u2028",
+	'P':  "\u2029",
+}
+
+var escapedHexLengthMap = map[byte]int{
+	'x': 2,
+	'u': 4,
+	'U': 8,
+}
+
+// writeEscapedChar writes the character escaped by `\` at raw[idx] and returns the index of the last character of escape sequence.
+// Unknown escape sequence is written as it is.
+func writeEscapedChar(b *strings.Builder, raw string, idx int) int {
+	if idx+1 >= len(raw) {
+		b.WriteByte('\\')
+		return idx
+	}
+	c := raw[idx+1]
+	if c == '\n' {
+		// escaped line break is removed with the leading white spaces of next line
+		return skipWhiteSpaces(raw, idx+2) - 1
+	}
+	if s, exists := escapedCharMap[c]; exists {
+		b.WriteString(s)
+		return idx + 1
+	}
+	if length, exists := escapedHexLengthMap[c]; exists && idx+2+length <= len(raw) {
+		if code, err := strconv.ParseUint(raw[idx+2:idx+2+length], 16, 32); err == nil && utf8.ValidRune(rune(code)) {
+			b.WriteRune(rune(code))
+			return idx + 1 + length
+		}
+	}
+	b.WriteByte('\\')
+	b.WriteByte(c)
+	return idx + 1
+}
@@ -1,6 +1,7 @@
 package scanner
 
 import (
+	"bytes"
 	"io"
 	"strings"
 
@@ -22,26 +23,33 @@ const (
 	IndentStateKeep
 )
 
+// flowCollection collection or bracket opened in flow context
+type flowCollection struct {
+	end      rune // character which closes the collection ( `]` or `}` )
+	isScalar bool // bracket in plain scalar ( e.g. `{id}` of `[http://x/{id}]` ) which is closed as a part of the scalar
+}
+
 // Scanner holds the scanner's internal state while processing a given text.
 // It can be allocated as part of another data structure but must be initialized via Init before use.
 type Scanner struct {
-	source                string
-	sourcePos             int
-	sourceSize            int
-	line                  int
-	column                int
-	offset                int
-	prevIndentLevel       int
-	prevIndentNum         int
-	prevIndentColumn      int
-	indentLevel           int
-	indentNum             int
-	isFirstCharAtLine     bool
-	isAnchor              bool
-	isStartedFlowSequence bool
-	isStartedFlowMap      bool
-	indentState           IndentState
-	savedPos              *token.Position
+	source            string
+	sourcePos         int
+	sourceSize        int
+	line              int
+	column            int
+	offset            int
+	prevIndentLevel   int
+	prevIndentNum     int
+	prevIndentColumn  int
+	indentLevel       int
+	indentNum         int
+	isFirstCharAtLine bool
+	isAnchor          bool
+	flowStack         []flowCollection
+	indentState       IndentState
+	savedPos          *token.Position
+	interned          map[string]string
+	isLenientComment  bool
 }
 
 func (s *Scanner) pos() *token.Position {
@@ -60,8 +68,7 @@ func (s *Scanner) bufferedToken(ctx *Context) *token.Token {
 		s.savedPos = nil
 		return tk
 	}
-	trimmedSrc := strings.TrimLeft(string(ctx.buf), " ")
-	size := len(trimmedSrc)
+	size := len(bytes.TrimLeft(ctx.buf, " "))
 	return ctx.bufferedToken(&token.Position{
 		Line:        s.line,
 		Column:      s.column - size,
@@ -148,94 +155,150 @@ func (s *Scanner) breakLiteral(ctx *Context) {
 func (s *Scanner) scanQuote(ctx *Context, ch rune) (tk *token.Token, pos int) {
 	ctx.addOriginBuf(ch)
 	startIndex := ctx.idx + 1
-	ctx.progress(1)
-	for idx, c := range ctx.src[startIndex:] {
-		pos = idx + 1
-		ctx.addOriginBuf(c)
-		switch c {
-		case ch:
-			if ctx.previousChar() == '\\' {
-				continue
-			}
-			value := ctx.source(startIndex, startIndex+idx)
-			switch ch {
-			case '\'':
-				tk = token.SingleQuote(value, string(ctx.obuf), s.pos())
-			case '"':
-				tk = token.DoubleQuote(value, string(ctx.obuf), s.pos())
-			}
-			pos = len(value) + 1
-			return
-		}
+	startPos := s.pos()
+	s.progressColumn(ctx, 1) // skip quote character
+	src := ctx.src[startIndex:]
+	end := quotedEnd(src, byte(ch))
+	if end < 0 {
+		ctx.addOriginBufString(src)
+		pos = len(src)
+		return
+	}
+	ctx.addOriginBufString(src[:end+1])
+	raw := ctx.source(startIndex, startIndex+end)
+	value := unquote(raw, byte(ch))
+	switch ch {
+	case '\'':
+		tk = token.SingleQuote(value, string(ctx.obuf), startPos)
+	case '"':
+		tk = token.DoubleQuote(value, string(ctx.obuf), startPos)
 	}
+	pos = len(raw) + 1
 	return
 }
 
 func (s *Scanner) scanTag(ctx *Context) (tk *token.Token, pos int) {
 	ctx.addOriginBuf('!')
 	ctx.progress(1) // skip '!' character
-	for idx, c := range ctx.src[ctx.idx:] {
-		pos = idx + 1
-		ctx.addOriginBuf(c)
-		switch c {
-		case ' ', '\n':
-			value := ctx.source(ctx.idx-1, ctx.idx+idx)
-			tk = token.Tag(value, string(ctx.obuf), s.pos())
-			pos = len(value)
-			return
-		}
+	src := ctx.src[ctx.idx:]
+	end := strings.IndexAny(src, " \n")
+	if end < 0 {
+		ctx.addOriginBufString(src)
+		pos = len(src)
+		return
 	}
+	ctx.addOriginBufString(src[:end+1])
+	value := ctx.source(ctx.idx-1, ctx.idx+end)
+	tk = token.Tag(value, string(ctx.obuf), s.pos())
+	pos = len(value)
+	return
+}
+
+// scanDirective scans the rest of the line after '%' as value of directive ( e.g. `YAML 1.2` )
+func (s *Scanner) scanDirective(ctx *Context) (tk *token.Token, pos int) {
+	src := ctx.src[ctx.idx:]
+	end := strings.IndexByte(src, '\n')
+	if end < 0 {
+		end = len(src)
+	}
+	line := src[:end]
+	if idx := strings.Index(line, " #"); idx >= 0 {
+		// trailing comment
+		line = line[:idx]
+	}
+	value := strings.TrimRight(line, " \t\r")
+	if value == "" {
+		return nil, 0
+	}
+	origin := value
+	pos = len(value)
+	if len(line) == end && end < len(src) {
+		// line break is included in origin to keep the lines of source when printing tokens.
+		// it is consumed by caller as the end of line
+		origin = src[:end+1]
+		pos = end
+	}
+	tk = token.New(value, origin, s.pos())
+	tk.Type = token.StringType
 	return
 }
 
 func (s *Scanner) scanComment(ctx *Context) (tk *token.Token, pos int) {
 	ctx.addOriginBuf('#')
 	ctx.progress(1) // skip '#' character
-	for idx, c := range ctx.src[ctx.idx:] {
-		pos = idx + 1
-		ctx.addOriginBuf(c)
-		switch c {
-		case '\n':
-			if ctx.previousChar() == '\\' {
-				continue
-			}
-			value := ctx.source(ctx.idx, ctx.idx+idx)
-			tk = token.Comment(value, string(ctx.obuf), s.pos())
-			pos = len(value) + 1
-			return
-		}
+	src := ctx.src[ctx.idx:]
+	end := strings.IndexByte(src, '\n')
+	if end < 0 {
+		// comment at the end of source without line break
+		ctx.addOriginBufString(src)
+		tk = token.Comment(ctx.source(ctx.idx, ctx.idx+len(src)), string(ctx.obuf), s.pos())
+		pos = len(src)
+		return
 	}
+	ctx.addOriginBufString(src[:end+1])
+	value := ctx.source(ctx.idx, ctx.idx+end)
+	tk = token.Comment(value, string(ctx.obuf), s.pos())
+	pos = len(value) + 1
 	return
 }
 
 func (s *Scanner) scanLiteral(ctx *Context, c rune) {
+	if s.savedPos == nil && c != '\n' && !(s.isFirstCharAtLine && c == ' ') {
+		// save position of the first character of literal content
+		s.savedPos = s.pos()
+	}
 	if ctx.isEOS() {
-		value := ctx.bufferedSrc()
-		ctx.addToken(token.New(value, string(ctx.obuf), s.pos()))
+		pos := s.pos()
+		if s.savedPos != nil {
+			pos = s.savedPos
+			s.savedPos = nil
+		}
+		// the token is added after the last character is buffered
+		defer func() {
+			ctx.addToken(ctx.newToken(ctx.bufferedSrc(), string(ctx.obuf), pos))
+			// the content is already added as the token, so it must not be added again at the end of scanning
+			ctx.resetBuffer()
+		}()
 	}
 	if c == '\n' {
-		if ctx.isLiteral {
+		if ctx.isLiteral || ctx.isFolded {
+			// line breaks of folded scalar are folded by bufferedSrc
 			ctx.addBuf(c)
 		} else {
 			ctx.addBuf(' ')
 		}
 		s.progressLine(ctx)
+		ctx.addOriginBuf(c)
 	} else if s.isFirstCharAtLine && c == ' ' {
 		s.progressColumn(ctx, 1)
-	} else {
+		ctx.addOriginBuf(c)
+	} else if ctx.isEOS() {
 		ctx.addBuf(c)
+		ctx.addOriginBuf(c)
 		s.progressColumn(ctx, 1)
+	} else {
+		// consume characters until the end of line at once.
+		// the last character of source is left to be handled by the end of source logic.
+		src := ctx.src[ctx.idx : ctx.size-1]
+		if end := strings.IndexByte(src, '\n'); end >= 0 {
+			src = src[:end]
+		}
+		ctx.addBufString(src)
+		ctx.addOriginBufString(src)
+		s.progressColumn(ctx, len(src))
 	}
-	ctx.addOriginBuf(c)
 }
 
 func (s *Scanner) scanLiteralHeader(ctx *Context) (pos int, err error) {
 	header := ctx.currentChar()
 	ctx.addOriginBuf(header)
-	ctx.progress(1) // skip '|' or '<' character
-	for idx, c := range ctx.src[ctx.idx:] {
+	headerPos := s.pos()
+	s.progressColumn(ctx, 1) // skip '|' or '<' character
+	src := ctx.src[ctx.idx:]
+	for idx := 0; idx < len(src); idx++ {
+		c := src[idx]
 		pos = idx
-		ctx.addOriginBuf(c)
+		ctx.addOriginBuf(rune(c))
 		switch c {
 		case '\n':
 			value := ctx.source(ctx.idx, ctx.idx+idx)
@@ -244,10 +307,10 @@ func (s *Scanner) scanLiteralHeader(ctx *Context) (pos int, err error) {
 			case "", "+", "-",
 				"0", "1", "2", "3", "4", "5", "6", "7", "8", "9":
 				if header == '|' {
-					ctx.addToken(token.Literal("|"+opt, string(ctx.obuf), s.pos()))
+					ctx.addToken(token.Literal("|"+opt, string(ctx.obuf), headerPos))
 					ctx.isLiteral = true
 				} else if header == '>' {
-					ctx.addToken(token.Folded(">"+opt, string(ctx.obuf), s.pos()))
+					ctx.addToken(token.Folded(">"+opt, string(ctx.obuf), headerPos))
 					ctx.isFolded = true
 				}
 				ctx.resetBuffer()
@@ -265,6 +328,7 @@ func (s *Scanner) scanNewLine(ctx *Context, c rune) {
 	if len(ctx.buf) > 0 && s.savedPos == nil {
 		s.savedPos = s.pos()
 		s.savedPos.Column -= len(ctx.bufferedSrc())
+		s.savedPos.Offset -= len(ctx.bufferedSrc())
 	}
 	if ctx.isEOS() {
 		s.addBufferedTokenIfExists(ctx)
@@ -276,11 +340,76 @@ func (s *Scanner) scanNewLine(ctx *Context, c rune) {
 	s.progressLine(ctx)
 }
 
+// isFlowContext returns whether the scanner is in flow sequence or flow mapping
+func (s *Scanner) isFlowContext() bool {
+	return len(s.flowStack) > 0
+}
+
+// isInFlowScalarBracket returns whether the scanner is in the bracket of plain scalar in flow context.
+// Flow indicators in the bracket ( e.g. `,` of `{a,b}` ) are the characters of the scalar.
+func (s *Scanner) isInFlowScalarBracket() bool {
+	return len(s.flowStack) > 0 && s.flowStack[len(s.flowStack)-1].isScalar
+}
+
+// startFlow opens the collection or the bracket in plain scalar by c ( `[` or `{` )
+func (s *Scanner) startFlow(c rune, isScalar bool) {
+	end := ']'
+	if c == '{' {
+		end = '}'
+	}
+	s.flowStack = append(s.flowStack, flowCollection{end: end, isScalar: isScalar})
+}
+
+// endFlow closes the innermost collection or bracket by c ( `]` or `}` ) and returns whether c is a part of plain scalar.
+// Brackets in plain scalar which are not closed yet are discarded when the enclosing collection is closed.
+// Collection which is closed by the other character ( e.g. `}` of `[a}` ) is kept opened, and the parser reports the error.
+func (s *Scanner) endFlow(c rune) bool {
+	if s.isInFlowScalarBracket() && s.flowStack[len(s.flowStack)-1].end == c {
+		s.flowStack = s.flowStack[:len(s.flowStack)-1]
+		return true
+	}
+	for s.isInFlowScalarBracket() {
+		s.flowStack = s.flowStack[:len(s.flowStack)-1]
+	}
+	if s.isFlowContext() && s.flowStack[len(s.flowStack)-1].end == c {
+		s.flowStack = s.flowStack[:len(s.flowStack)-1]
+	}
+	return false
+}
+
+// isFlowMappingValue returns whether ':' which is not followed by space is mapping value in flow context.
+// ':' followed by the end of flow entry ( e.g. `{a:}` ) or placed after JSON-like key ( e.g. `{"a":1}` ) is mapping value.
+func (s *Scanner) isFlowMappingValue(ctx *Context, nc rune) bool {
+	if !s.isFlowContext() || s.isInFlowScalarBracket() {
+		return false
+	}
+	switch nc {
+	case ',', '}', ']':
+		return true
+	}
+	if ctx.bufferedSrc() != "" {
+		return false
+	}
+	idx := s.sourcePos + ctx.idx
+	if idx == 0 {
+		return false
+	}
+	switch s.source[idx-1] {
+	case '"', '\'', '}', ']':
+		return true
+	}
+	return false
+}
+
 func (s *Scanner) scan(ctx *Context) (pos int) {
 	for ctx.next() {
 		pos = ctx.nextPos()
 		c := ctx.currentChar()
-		s.updateIndent(c)
+		isBlockScalar := ctx.isLiteral || ctx.isFolded || ctx.isRawFolded
+		if !(isBlockScalar && s.isFirstCharAtLine && c == '\n') {
+			// empty line in block scalar doesn't change indent
+			s.updateIndent(c)
+		}
 		if s.isChangedToIndentStateDown() {
 			s.addBufferedTokenIfExists(ctx)
 			s.breakLiteral(ctx)
@@ -295,25 +424,44 @@ func (s *Scanner) scan(ctx *Context) (pos int) {
 			}
 		}
 		switch c {
-		case '{':
-			if ctx.bufferedSrc() == "" {
-				ctx.addOriginBuf(c)
+		case '{', '[':
+			if ctx.bufferedSrc() != "" {
+				if s.isFlowContext() {
+					// bracket in plain scalar ( e.g. `[http://x/{id}]` )
+					s.startFlow(c, true)
+				}
+				break
+			}
+			ctx.addOriginBuf(c)
+			if c == '{' {
 				ctx.addToken(token.MappingStart(string(ctx.obuf), s.pos()))
-				s.isStartedFlowMap = true
-				s.progressColumn(ctx, 1)
-				return
+			} else {
+				ctx.addToken(token.SequenceStart(string(ctx.obuf), s.pos()))
 			}
-		case '}':
-			if ctx.bufferedSrc() == "" || s.isStartedFlowMap {
-				ctx.addToken(s.bufferedToken(ctx))
-				ctx.addOriginBuf(c)
+			s.startFlow(c, false)
+			s.progressColumn(ctx, 1)
+			return
+		case '}', ']':
+			if !s.isFlowContext() && ctx.bufferedSrc() != "" {
+				// plain scalar in block context ( e.g. `a: b]` )
+				break
+			}
+			if s.endFlow(c) {
+				break
+			}
+			s.addBufferedTokenIfExists(ctx)
+			ctx.addOriginBuf(c)
+			if c == '}' {
 				ctx.addToken(token.MappingEnd(string(ctx.obuf), s.pos()))
-				s.isStartedFlowMap = false
-				s.progressColumn(ctx, 1)
-				return
+			} else {
+				ctx.addToken(token.SequenceEnd(string(ctx.obuf), s.pos()))
 			}
+			s.progressColumn(ctx, 1)
+			return
 		case '.':
 			if s.indentNum == 0 && ctx.repeatNum('.') == 3 {
+				// flow collection can't be continued to the next document
+				s.flowStack = s.flowStack[:0]
 				ctx.addToken(token.DocumentEnd(s.pos()))
 				s.progressColumn(ctx, 3)
 				pos += 2
@@ -323,12 +471,13 @@ func (s *Scanner) scan(ctx *Context) (pos int) {
 			if ctx.repeatNum('<') == 2 {
 				s.prevIndentColumn = s.column
 				ctx.addToken(token.MergeKey(string(ctx.obuf)+"<<", s.pos()))
-				s.progressColumn(ctx, 1)
+				s.progressColumn(ctx, 2)
 				pos++
 				return
 			}
 		case '-':
 			if s.indentNum == 0 && ctx.repeatNum('-') == 3 {
+				s.flowStack = s.flowStack[:0]
 				s.addBufferedTokenIfExists(ctx)
 				ctx.addToken(token.DocumentHeader(s.pos()))
 				s.progressColumn(ctx, 3)
@@ -344,7 +493,7 @@ func (s *Scanner) scan(ctx *Context) (pos int) {
 				continue
 			}
 			nc := ctx.nextChar()
-			if nc == ' ' {
+			if nc == ' ' || (ctx.bufferedSrc() == "" && (nc == '\n' || ctx.isEOS())) {
 				s.addBufferedTokenIfExists(ctx)
 				ctx.addOriginBuf(c)
 				tk := token.SequenceEntry(string(ctx.obuf), s.pos())
@@ -353,25 +502,8 @@ func (s *Scanner) scan(ctx *Context) (pos int) {
 				s.progressColumn(ctx, 1)
 				return
 			}
-		case '[':
-			if ctx.bufferedSrc() == "" {
-				ctx.addOriginBuf(c)
-				ctx.addToken(token.SequenceStart(string(ctx.obuf), s.pos()))
-				s.isStartedFlowSequence = true
-				s.progressColumn(ctx, 1)
-				return
-			}
-		case ']':
-			if ctx.bufferedSrc() == "" || s.isStartedFlowSequence {
-				s.addBufferedTokenIfExists(ctx)
-				ctx.addOriginBuf(c)
-				ctx.addToken(token.SequenceEnd(string(ctx.obuf), s.pos()))
-				s.isStartedFlowSequence = false
-				s.progressColumn(ctx, 1)
-				return
-			}
 		case ',':
-			if s.isStartedFlowSequence || s.isStartedFlowMap {
+			if s.isFlowContext() && !s.isInFlowScalarBracket() {
 				s.addBufferedTokenIfExists(ctx)
 				ctx.addOriginBuf(c)
 				ctx.addToken(token.CollectEntry(string(ctx.obuf), s.pos()))
@@ -380,7 +512,10 @@ func (s *Scanner) scan(ctx *Context) (pos int) {
 			}
 		case ':':
 			nc := ctx.nextChar()
-			if nc == ' ' || nc == '\n' || ctx.isNextEOS() {
+			if s.isInFlowScalarBracket() {
+				break
+			}
+			if nc == ' ' || nc == '\n' || ctx.isNextEOS() || s.isFlowMappingValue(ctx, nc) {
 				// mapping value
 				tk := s.bufferedToken(ctx)
 				if tk != nil {
@@ -409,6 +544,8 @@ func (s *Scanner) scan(ctx *Context) (pos int) {
 				s.progressColumn(ctx, progress)
 				if c := ctx.previousChar(); c == '\n' {
 					s.progressLine(ctx)
+				} else if c == ' ' {
+					s.progressColumn(ctx, 1)
 				}
 				pos += progress
 				return
@@ -417,6 +554,16 @@ func (s *Scanner) scan(ctx *Context) (pos int) {
 			if ctx.bufferedSrc() == "" && s.indentNum == 0 {
 				ctx.addToken(token.Directive(s.pos()))
 				s.progressColumn(ctx, 1)
+				token, progress := s.scanDirective(ctx)
+				if token != nil {
+					ctx.addToken(token)
+				}
+				s.progressColumn(ctx, progress)
+				pos += progress
+				if token != nil && strings.HasSuffix(token.Origin, "\n") {
+					s.progressLine(ctx)
+					pos++
+				}
 				return
 			}
 		case '?':
@@ -444,6 +591,10 @@ func (s *Scanner) scan(ctx *Context) (pos int) {
 				return
 			}
 		case '#':
+			if !s.isLenientComment && ctx.bufferedSrc() != "" && !isBlankChar(ctx.previousChar()) {
+				// '#' following non-space character is a part of plain scalar ( e.g. `abc#123` )
+				break
+			}
 			s.addBufferedTokenIfExists(ctx)
 			token, progress := s.scanComment(ctx)
 			ctx.addToken(token)
@@ -452,14 +603,23 @@ func (s *Scanner) scan(ctx *Context) (pos int) {
 			pos += progress
 			return
 		case '\'', '"':
-			token, progress := s.scanQuote(ctx, c)
-			ctx.addToken(token)
-			s.progressColumn(ctx, progress)
-			pos += progress
-			return
+			if ctx.bufferedSrc() == "" {
+				token, progress := s.scanQuote(ctx, c)
+				ctx.addToken(token)
+				s.progressColumn(ctx, progress)
+				pos += progress
+				return
+			}
 		case '\n':
 			s.scanNewLine(ctx, c)
 			continue
+		case '\t':
+			if s.isFlowContext() && ctx.bufferedSrc() == "" {
+				// tab before the value in flow collection is a separation space ( e.g. "[1,\n\t2]" )
+				ctx.addOriginBuf(c)
+				s.progressColumn(ctx, 1)
+				continue
+			}
 		case ' ':
 			if ctx.isSaveIndentMode() || (!s.isAnchor && !s.isFirstCharAtLine) {
 				ctx.addBuf(c)
@@ -485,6 +645,13 @@ func (s *Scanner) scan(ctx *Context) (pos int) {
 	return
 }
 
+// SetLenientComment makes '#' start a comment even if it follows non-space character ( e.g. `abc#123` is scanned as `abc` ).
+// By default, '#' starts a comment only at the beginning of line or after white space as the spec requires.
+// It is kept for the sources which are written for the old behavior.
+func (s *Scanner) SetLenientComment(enabled bool) {
+	s.isLenientComment = enabled
+}
+
 // Init prepares the scanner s to tokenize the text src by setting the scanner at the beginning of src.
 func (s *Scanner) Init(src string) {
 	s.source = src
@@ -499,6 +666,8 @@ func (s *Scanner) Init(src string) {
 	s.indentLevel = 0
 	s.indentNum = 0
 	s.isFirstCharAtLine = true
+	s.flowStack = nil
+	s.interned = map[string]string{}
 }
 
 // Scan scans the next token and returns the token collection. The source end is indicated by io.EOF.
@@ -506,8 +675,12 @@ func (s *Scanner) Scan() (token.Tokens, error) {
 	if s.sourcePos >= s.sourceSize {
 		return nil, io.EOF
 	}
-	ctx := newContext(s.source[s.sourcePos:])
+	ctx := newContext(s.source[s.sourcePos:], s.interned)
 	progress := s.scan(ctx)
 	s.sourcePos += progress
 	return ctx.tokens, nil
 }
+
+func isBlankChar(c rune) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
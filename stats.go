@@ -0,0 +1,88 @@
+package yaml
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/internal/errors"
+)
+
+// DecodeStats reports how loosely the document is written, so it can be audited
+// before enabling strict options ( e.g. DisallowUnknownField ).
+// Each field has a Diagnostic per occurrence, and all fields are reset by each Decode.
+type DecodeStats struct {
+	// UnknownKeys keys which don't exist in the destination struct
+	UnknownKeys Diagnostics
+	// Coercions scalars converted to the destination of other type ( e.g. `port: 8080` for string )
+	Coercions Diagnostics
+	// Aliases aliases expanded to the anchored values
+	Aliases Diagnostics
+	// MergeKeys merge keys ( `<<` ) applied
+	MergeKeys Diagnostics
+}
+
+func (s *DecodeStats) reset() {
+	*s = DecodeStats{}
+}
+
+func (s *DecodeStats) addUnknownKey(key ast.Node, name string) {
+	s.UnknownKeys = appendDiagnostic(s.UnknownKeys, &Diagnostic{
+		Severity: SeverityWarning,
+		Code:     errors.CodeUnknownField,
+		Message:  errors.CodeUnknownField.Message(name),
+		Range:    TokenRange(key.GetToken()),
+	})
+}
+
+func (s *DecodeStats) addCoercion(src ast.Node, typ reflect.Type) {
+	s.Coercions = appendDiagnostic(s.Coercions, &Diagnostic{
+		Severity: SeverityInformation,
+		Message:  fmt.Sprintf("%s node is converted to %s", ast.Unwrap(src).Type(), typ),
+		Range:    TokenRange(errorToken(src)),
+	})
+}
+
+// appendDiagnostic appends diag unless diags has the same one,
+// because the anchored value is decoded at each alias.
+func appendDiagnostic(diags Diagnostics, diag *Diagnostic) Diagnostics {
+	for _, d := range diags {
+		if d.Message == diag.Message && d.Range == diag.Range {
+			return diags
+		}
+	}
+	return append(diags, diag)
+}
+
+// statsCollector collects aliases and merge keys in the document
+type statsCollector struct {
+	d *Decoder
+}
+
+func (c *statsCollector) Visit(node ast.Node) ast.Visitor {
+	switch n := node.(type) {
+	case *ast.AliasNode:
+		c.d.stats.Aliases = append(c.d.stats.Aliases, &Diagnostic{
+			Severity: SeverityInformation,
+			Message:  fmt.Sprintf("alias %q is expanded", n.Value.GetToken().Value),
+			Range:    TokenRange(n.GetToken()),
+		})
+	case *ast.MappingValueNode:
+		if c.d.isMergeKey(n.Key) {
+			c.d.stats.MergeKeys = append(c.d.stats.MergeKeys, &Diagnostic{
+				Severity: SeverityInformation,
+				Message:  "merge key is applied",
+				Range:    TokenRange(n.Key.GetToken()),
+			})
+		}
+	}
+	return c
+}
+
+// collectStats collects aliases and merge keys in node to DecodeStats set by Stats option
+func (d *Decoder) collectStats(node ast.Node) {
+	if d.stats == nil || node == nil {
+		return
+	}
+	ast.WalkAll(&statsCollector{d: d}, node)
+}
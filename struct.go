@@ -3,6 +3,7 @@ package yaml
 import (
 	"reflect"
 	"strings"
+	"unicode"
 
 	"golang.org/x/xerrors"
 )
@@ -10,6 +11,8 @@ import (
 const (
 	// StructTagName tag keyword for Marshal/Unmarshal
 	StructTagName = "yaml"
+	// JSONStructTagName tag keyword used by DecodeJSONTag and EncodeJSONTag options if the field has no yaml tag
+	JSONStructTagName = "json"
 )
 
 // StructField information for each the field in structure
@@ -23,19 +26,54 @@ type StructField struct {
 	IsOmitEmpty  bool
 	IsFlow       bool
 	IsInline     bool
+	IsDocument   bool
 }
 
-func structField(field reflect.StructField) *StructField {
-	tag := field.Tag.Get(StructTagName)
+// structFieldOption options of Encoder and Decoder to read the fields of struct
+type structFieldOption struct {
+	// useJSONTag use json tag if the field has no yaml tag
+	useJSONTag bool
+	// fieldNamer converts the name of field which has no name in the tag to the key
+	fieldNamer func(string) string
+}
+
+// structTag returns the tag of field for this package.
+// If useJSONTag of opt is true and field has no yaml tag, json tag is used instead.
+// Embedded struct without tag is inlined like encoding/json in that case.
+func structTag(field reflect.StructField, opt structFieldOption) string {
+	tag, exists := field.Tag.Lookup(StructTagName)
+	if !exists && opt.useJSONTag {
+		if jsonTag, exists := field.Tag.Lookup(JSONStructTagName); exists {
+			return jsonTag
+		}
+		if field.Anonymous && indirectType(field.Type).Kind() == reflect.Struct {
+			return ",inline"
+		}
+	}
 	if tag == "" && strings.Index(string(field.Tag), ":") < 0 {
 		tag = string(field.Tag)
 	}
-	fieldName := strings.ToLower(field.Name)
+	return tag
+}
+
+func indirectType(typ reflect.Type) reflect.Type {
+	if typ.Kind() == reflect.Ptr {
+		return typ.Elem()
+	}
+	return typ
+}
+
+func structField(field reflect.StructField, opt structFieldOption) *StructField {
+	tag := structTag(field, opt)
 	options := strings.Split(tag, ",")
-	if len(options) > 0 {
-		if options[0] != "" {
-			fieldName = options[0]
-		}
+	var fieldName string
+	switch {
+	case options[0] != "":
+		fieldName = options[0]
+	case opt.fieldNamer != nil:
+		fieldName = opt.fieldNamer(field.Name)
+	default:
+		fieldName = strings.ToLower(field.Name)
 	}
 	structField := &StructField{
 		FieldName:  field.Name,
@@ -50,6 +88,8 @@ func structField(field reflect.StructField) *StructField {
 				structField.IsFlow = true
 			case opt == "inline":
 				structField.IsInline = true
+			case opt == "document":
+				structField.IsDocument = true
 			case strings.HasPrefix(opt, "anchor"):
 				anchor := strings.Split(opt, "=")
 				if len(anchor) > 1 {
@@ -71,13 +111,12 @@ func structField(field reflect.StructField) *StructField {
 	return structField
 }
 
-func isIgnoredStructField(field reflect.StructField) bool {
+func isIgnoredStructField(field reflect.StructField, opt structFieldOption) bool {
 	if field.PkgPath != "" && !field.Anonymous {
 		// private field
 		return true
 	}
-	tag := field.Tag.Get(StructTagName)
-	if tag == "-" {
+	if structTag(field, opt) == "-" {
 		return true
 	}
 	return false
@@ -94,20 +133,101 @@ func (m StructFieldMap) isIncludedRenderName(name string) bool {
 	return false
 }
 
-func structFieldMap(structType reflect.Type) (StructFieldMap, error) {
+func structFieldMap(structType reflect.Type, opt structFieldOption) (StructFieldMap, error) {
 	structFieldMap := StructFieldMap{}
 	renderNameMap := map[string]struct{}{}
+	hasInlineMap := false
 	for i := 0; i < structType.NumField(); i++ {
 		field := structType.Field(i)
-		if isIgnoredStructField(field) {
+		if isIgnoredStructField(field, opt) {
 			continue
 		}
-		structField := structField(field)
+		structField := structField(field, opt)
 		if _, exists := renderNameMap[structField.RenderName]; exists {
 			return nil, xerrors.Errorf("duplicated struct field name %s", structField.RenderName)
 		}
+		if structField.IsInline {
+			if err := validateInlineField(field, hasInlineMap); err != nil {
+				return nil, err
+			}
+			hasInlineMap = hasInlineMap || field.Type.Kind() == reflect.Map
+		}
 		structFieldMap[structField.FieldName] = structField
 		renderNameMap[structField.RenderName] = struct{}{}
 	}
 	return structFieldMap, nil
 }
+
+// validateInlineField checks the type of inline field is struct, pointer to struct or map which has string key.
+// Struct can have only one inline map because it receives all keys which don't match the other fields.
+func validateInlineField(field reflect.StructField, hasInlineMap bool) error {
+	typ := field.Type
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch {
+	case typ.Kind() == reflect.Struct:
+		return nil
+	case field.Type.Kind() == reflect.Map && field.Type.Key().Kind() == reflect.String:
+		if hasInlineMap {
+			return xerrors.Errorf("multiple inline maps in struct field %s", field.Name)
+		}
+		return nil
+	}
+	return xerrors.Errorf("inline field %s must be struct, pointer to struct or map which has string key but got %s", field.Name, field.Type)
+}
+
+// SnakeCase converts the name of struct field to snake_case ( e.g. `APIVersion` to `api_version` ).
+// It is used with EncodeFieldNamer and DecodeFieldNamer options.
+func SnakeCase(name string) string {
+	return strings.ToLower(strings.Join(splitFieldName(name), "_"))
+}
+
+// KebabCase converts the name of struct field to kebab-case ( e.g. `APIVersion` to `api-version` ).
+// It is used with EncodeFieldNamer and DecodeFieldNamer options.
+func KebabCase(name string) string {
+	return strings.ToLower(strings.Join(splitFieldName(name), "-"))
+}
+
+// CamelCase converts the name of struct field to camelCase ( e.g. `APIVersion` to `apiVersion` ).
+// Only the first word is lowercased, so the following initialisms are kept ( e.g. `UserID` to `userID` ).
+// It is used with EncodeFieldNamer and DecodeFieldNamer options.
+func CamelCase(name string) string {
+	words := splitFieldName(name)
+	if len(words) == 0 {
+		return name
+	}
+	words[0] = strings.ToLower(words[0])
+	return strings.Join(words, "")
+}
+
+// splitFieldName splits the name of struct field into words.
+// A word starts at an upper case letter following a lower case letter or digit,
+// or at the last upper case letter of an initialism followed by a lower case letter ( e.g. `HTTPServer` to `HTTP` and `Server` ).
+func splitFieldName(name string) []string {
+	runes := []rune(name)
+	var words []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+		switch {
+		case cur == '_':
+			words = append(words, string(runes[start:i]))
+			start = i + 1
+		case unicode.IsUpper(cur) && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+			words = append(words, string(runes[start:i]))
+			start = i
+		case unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	words = append(words, string(runes[start:]))
+	filtered := words[:0]
+	for _, word := range words {
+		if word != "" {
+			filtered = append(filtered, word)
+		}
+	}
+	return filtered
+}
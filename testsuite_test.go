@@ -0,0 +1,412 @@
+//go:build yamltestsuite
+// +build yamltestsuite
+
+package yaml_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/token"
+)
+
+// Conformance test by the cases of yaml-test-suite ( https://github.com/yaml/yaml-test-suite ).
+// The parser is checked by the events in test.event of each case, and the decoder is checked by the values in in.json.
+// The cases are not vendored. The skip list is made with the cases in testdata/yaml-test-suite of github.com/goccy/go-yaml v1.19.2,
+// which are named by the titles of the cases instead of the ids of the data branch ( e.g. `spec-example-2-1-sequence-of-scalars` ).
+//
+//     dir=$(go mod download -json github.com/goccy/go-yaml@v1.19.2 | jq -r .Dir)
+//     go test -tags yamltestsuite -run TestYAMLTestSuite -yaml-test-suite $dir/testdata/yaml-test-suite
+//
+// The cases which are not supported yet are listed with the reasons in testdata/yaml-test-suite/skip.txt.
+// Run with `-update` to add the failed cases to the skip list. The cases which pass are removed from it.
+
+var yamlTestSuiteDir = flag.String("yaml-test-suite", "", "directory of the data branch of yaml-test-suite")
+
+const yamlTestSuiteSkipFile = "testdata/yaml-test-suite/skip.txt"
+
+// yamlTestSuiteCase a case of yaml-test-suite.
+// A case is a directory which has in.yaml, and the cases which have multiple sources have sub directories ( e.g. `<id>/00` ).
+type yamlTestSuiteCase struct {
+	id      string
+	dir     string
+	title   string
+	isError bool
+	events  []byte // expected events of parser. nil if the case has no test.event
+	json    []byte // expected values in JSON. nil if the case has no in.json
+}
+
+func TestYAMLTestSuite(t *testing.T) {
+	if *yamlTestSuiteDir == "" {
+		t.Skip("yaml-test-suite directory is not specified by -yaml-test-suite")
+	}
+	cases, err := loadYAMLTestSuiteCases(*yamlTestSuiteDir)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	skips, err := loadYAMLTestSuiteSkips(yamlTestSuiteSkipFile)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	failures := map[string]string{}
+	passed := 0
+	for _, c := range cases {
+		c := c
+		reason := runYAMLTestSuiteCase(c)
+		if reason == "" {
+			passed++
+		} else {
+			failures[c.id] = reason
+		}
+		t.Run(c.id, func(t *testing.T) {
+			skipReason, isSkipped := skips[c.id]
+			switch {
+			case reason == "" && isSkipped:
+				t.Logf("%s: passed but listed in skip list ( %s )", c.title, skipReason)
+			case reason == "":
+			case isSkipped:
+				t.Skipf("%s: %s", c.title, skipReason)
+			default:
+				t.Errorf("%s: %s", c.title, reason)
+			}
+		})
+	}
+	t.Logf("passed %d of %d cases", passed, len(cases))
+	if *update {
+		if err := writeYAMLTestSuiteSkips(yamlTestSuiteSkipFile, skips, failures); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+}
+
+// runYAMLTestSuiteCase returns the reason of failure. If the case passes, returns empty string
+func runYAMLTestSuiteCase(c *yamlTestSuiteCase) (reason string) {
+	defer func() {
+		if err := recover(); err != nil {
+			reason = fmt.Sprintf("panic: %v", err)
+		}
+	}()
+	src, err := ioutil.ReadFile(filepath.Join(c.dir, "in.yaml"))
+	if err != nil {
+		return err.Error()
+	}
+	if !c.isError && c.events != nil {
+		f, err := parser.ParseBytes(src, 0)
+		if err != nil {
+			return fmt.Sprintf("unexpected error: %v", err)
+		}
+		expected := strings.Split(strings.TrimSpace(string(c.events)), "\n")
+		actual := yamlTestSuiteEvents(src, f)
+		for i := 0; i < len(expected) || i < len(actual); i++ {
+			var e, a string
+			if i < len(expected) {
+				e = expected[i]
+			}
+			if i < len(actual) {
+				a = actual[i]
+			}
+			if e != a {
+				return fmt.Sprintf("expected event %q but got %q at %d", e, a, i+1)
+			}
+		}
+	}
+	values, err := decodeYAMLTestSuiteSource(src)
+	if c.isError {
+		if err == nil {
+			return "expected error"
+		}
+		return ""
+	}
+	if err != nil {
+		return fmt.Sprintf("unexpected error: %v", err)
+	}
+	if c.json == nil {
+		return ""
+	}
+	expected, err := decodeJSONStream(c.json)
+	if err != nil {
+		return fmt.Sprintf("invalid in.json: %v", err)
+	}
+	actual, err := jsonCompatibleValues(values)
+	if err != nil {
+		return fmt.Sprintf("cannot convert to JSON: %v", err)
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		return fmt.Sprintf("expected %v but got %v", expected, actual)
+	}
+	return ""
+}
+
+// decodeYAMLTestSuiteSource parses src and decodes all documents in it
+func decodeYAMLTestSuiteSource(src []byte) ([]interface{}, error) {
+	if _, err := parser.ParseBytes(src, 0); err != nil {
+		return nil, err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(src))
+	values := []interface{}{}
+	for {
+		var v interface{}
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			return values, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+}
+
+// yamlTestSuiteEvents converts f parsed from src to the events in the format of test.event ( e.g. `+MAP`, `=VAL :a` )
+func yamlTestSuiteEvents(src []byte, f *ast.File) []string {
+	w := &yamlTestSuiteEventWriter{src: src, events: []string{"+STR"}}
+	for _, doc := range f.Docs {
+		if doc.Start != nil && doc.Start.Type == token.DocumentHeaderType {
+			w.events = append(w.events, "+DOC ---")
+		} else {
+			w.events = append(w.events, "+DOC")
+		}
+		if doc.Body == nil {
+			w.events = append(w.events, "=VAL :")
+		} else {
+			w.write(doc.Body, "")
+		}
+		if doc.End != nil {
+			w.events = append(w.events, "-DOC ...")
+		} else {
+			w.events = append(w.events, "-DOC")
+		}
+	}
+	return append(w.events, "-STR")
+}
+
+type yamlTestSuiteEventWriter struct {
+	src    []byte
+	events []string
+}
+
+// write appends the events of node. props is the anchor and the tag of node ( e.g. ` &a <tag:yaml.org,2002:str>` )
+func (w *yamlTestSuiteEventWriter) write(node ast.Node, props string) {
+	switch n := node.(type) {
+	case *ast.AnchorNode:
+		anchor := " &" + n.Name.GetToken().Value
+		if tag, ok := n.Value.(*ast.TagNode); ok {
+			// tag is written after anchor even if it's placed in front of anchor
+			w.write(tag.Value, anchor+yamlTestSuiteTag(tag)+props)
+			return
+		}
+		w.write(n.Value, anchor+props)
+	case *ast.TagNode:
+		if anchor, ok := n.Value.(*ast.AnchorNode); ok {
+			w.write(anchor.Value, " &"+anchor.Name.GetToken().Value+yamlTestSuiteTag(n)+props)
+			return
+		}
+		w.write(n.Value, yamlTestSuiteTag(n)+props)
+	case *ast.AliasNode:
+		w.events = append(w.events, "=ALI *"+n.Value.GetToken().Value)
+	case *ast.MappingNode:
+		if n.IsFlowStyle {
+			w.events = append(w.events, "+MAP {}"+props)
+		} else {
+			w.events = append(w.events, "+MAP"+props)
+		}
+		for _, value := range n.Values {
+			w.write(value.Key, "")
+			w.write(value.Value, "")
+		}
+		w.events = append(w.events, "-MAP")
+	case *ast.MappingValueNode:
+		w.events = append(w.events, "+MAP"+props)
+		w.write(n.Key, "")
+		w.write(n.Value, "")
+		w.events = append(w.events, "-MAP")
+	case *ast.SequenceNode:
+		if n.IsFlowStyle {
+			w.events = append(w.events, "+SEQ []"+props)
+		} else {
+			w.events = append(w.events, "+SEQ"+props)
+		}
+		for _, value := range n.Values {
+			w.write(value, "")
+		}
+		w.events = append(w.events, "-SEQ")
+	case *ast.LiteralNode:
+		w.events = append(w.events, "=VAL"+props+" "+n.Start.Value[:1]+yamlTestSuiteEscape(n.Value.Value))
+	case *ast.StringNode:
+		style := ":"
+		switch n.Token.Type {
+		case token.SingleQuoteType:
+			style = "'"
+		case token.DoubleQuoteType:
+			style = `"`
+		}
+		w.events = append(w.events, "=VAL"+props+" "+style+yamlTestSuiteEscape(n.Value))
+	case *ast.NullNode:
+		// null which isn't written in source is created at the position of `:` or `-`
+		value := ""
+		if pos := n.Token.Position; pos.Offset > 0 && bytes.HasPrefix(w.src[pos.Offset-1:], []byte(n.Token.Value)) {
+			value = n.Token.Value
+		}
+		w.events = append(w.events, "=VAL"+props+" :"+value)
+	default:
+		w.events = append(w.events, "=VAL"+props+" :"+yamlTestSuiteEscape(node.GetToken().Value))
+	}
+}
+
+func yamlTestSuiteTag(tag *ast.TagNode) string {
+	if tag.URI == "" {
+		return " <!>"
+	}
+	return " <" + tag.URI + ">"
+}
+
+var yamlTestSuiteEscaper = strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\t", "\\t", "\r", "\\r", "\b", "\\b")
+
+func yamlTestSuiteEscape(s string) string {
+	return yamlTestSuiteEscaper.Replace(s)
+}
+
+func decodeJSONStream(src []byte) ([]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(src))
+	values := []interface{}{}
+	for {
+		var v interface{}
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			return values, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+}
+
+// jsonCompatibleValues converts values to the values decoded from JSON ( e.g. integers become float64 )
+func jsonCompatibleValues(values []interface{}) ([]interface{}, error) {
+	b, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	var converted []interface{}
+	if err := json.Unmarshal(b, &converted); err != nil {
+		return nil, err
+	}
+	return converted, nil
+}
+
+func loadYAMLTestSuiteCases(root string) ([]*yamlTestSuiteCase, error) {
+	entries, err := ioutil.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	cases := []*yamlTestSuiteCase{}
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		found, err := loadYAMLTestSuiteCase(filepath.Join(root, entry.Name()), entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, found...)
+	}
+	return cases, nil
+}
+
+func loadYAMLTestSuiteCase(dir, id string) ([]*yamlTestSuiteCase, error) {
+	if _, err := os.Stat(filepath.Join(dir, "in.yaml")); os.IsNotExist(err) {
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		cases := []*yamlTestSuiteCase{}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			found, err := loadYAMLTestSuiteCase(filepath.Join(dir, entry.Name()), id+"/"+entry.Name())
+			if err != nil {
+				return nil, err
+			}
+			cases = append(cases, found...)
+		}
+		return cases, nil
+	}
+	c := &yamlTestSuiteCase{id: id, dir: dir, title: id}
+	if title, err := ioutil.ReadFile(filepath.Join(dir, "===")); err == nil {
+		c.title = strings.TrimSpace(string(title))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "error")); err == nil {
+		c.isError = true
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(dir, "test.event")); err == nil {
+		c.events = b
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(dir, "in.json")); err == nil {
+		c.json = b
+	}
+	return []*yamlTestSuiteCase{c}, nil
+}
+
+// loadYAMLTestSuiteSkips reads skip list. Each line has the id of case and the reason separated by space
+func loadYAMLTestSuiteSkips(file string) (map[string]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	skips := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, " ", 2)
+		reason := "not supported yet"
+		if len(fields) > 1 {
+			reason = strings.TrimSpace(fields[1])
+		}
+		skips[fields[0]] = reason
+	}
+	return skips, scanner.Err()
+}
+
+// writeYAMLTestSuiteSkips updates skip list by failures with keeping the reasons written by hand
+func writeYAMLTestSuiteSkips(file string, skips, failures map[string]string) error {
+	ids := make([]string, 0, len(failures))
+	for id := range failures {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var buf bytes.Buffer
+	buf.WriteString("# cases of yaml-test-suite which are not supported yet.\n")
+	buf.WriteString("# the cases are in testdata/yaml-test-suite of github.com/goccy/go-yaml v1.19.2.\n")
+	buf.WriteString("# each line has the id of case and the reason. run TestYAMLTestSuite with -update to update this file.\n")
+	for _, id := range ids {
+		reason, exists := skips[id]
+		if !exists {
+			reason = failures[id]
+			if idx := strings.IndexByte(reason, '\n'); idx >= 0 {
+				reason = reason[:idx]
+			}
+		}
+		fmt.Fprintf(&buf, "%s %s\n", id, reason)
+	}
+	return ioutil.WriteFile(file, buf.Bytes(), 0644)
+}
@@ -2,7 +2,9 @@ package token
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
+	"unicode"
 )
 
 // Character type for character
@@ -297,6 +299,15 @@ var (
 		".NAN",
 	}
 	reservedKeywordMap = map[string]func(string, string, *Position) *Token{}
+	// legacyBoolKeywords texts of boolean in YAML 1.1.
+	// They are string in YAML 1.2, but they must be quoted to be read as string by YAML 1.1 consumers.
+	// `y` and `n` are not included because they are common keys ( e.g. coordinates ). See IsLegacyBool.
+	legacyBoolKeywords = map[string]struct{}{
+		"yes": {}, "Yes": {}, "YES": {},
+		"no": {}, "No": {}, "NO": {},
+		"on": {}, "On": {}, "ON": {},
+		"off": {}, "Off": {}, "OFF": {},
+	}
 )
 
 func reservedKeywordToken(typ Type, value, org string, pos *Position) *Token {
@@ -343,6 +354,8 @@ const (
 	FloatTag = "!!float"
 	// NullTag `!!null` tag
 	NullTag = "!!null"
+	// BooleanTag `!!bool` tag
+	BooleanTag = "!!bool"
 	// SequenceTag `!!seq` tag
 	SequenceTag = "!!seq"
 	// MappingTag `!!map` tag
@@ -560,6 +573,51 @@ func getNumberStat(str string) *numStat {
 	return stat
 }
 
+var (
+	coreIntRegexp   = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	coreOctalRegexp = regexp.MustCompile(`^0o[0-7]+$`)
+	coreHexRegexp   = regexp.MustCompile(`^0x[0-9a-fA-F]+$`)
+	coreFloatRegexp = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+	coreInfRegexp   = regexp.MustCompile(`^[-+]?\.(inf|Inf|INF)$`)
+)
+
+// ToCoreSchema changes the type of plain scalar token to the type resolved by the core schema of YAML 1.2.
+// Integers with leading zeros are decimal ( e.g. `010` ), integers in octal are only written with `0o` prefix,
+// and binary integers and numbers with `_` ( e.g. `0b1` or `1_000` ) are strings.
+// Quoted scalars and the other tokens are not changed.
+func ToCoreSchema(tk *Token) {
+	switch tk.Type {
+	case StringType, IntegerType, BinaryIntegerType, OctetIntegerType, HexIntegerType,
+		FloatType, BoolType, NullType, InfinityType, NanType:
+	default:
+		return
+	}
+	tk.Type = coreSchemaType(tk.Value)
+}
+
+// coreSchemaType returns the type of plain scalar resolved by the core schema of YAML 1.2
+func coreSchemaType(value string) Type {
+	switch {
+	case value == "null" || value == "Null" || value == "NULL" || value == "~":
+		return NullType
+	case value == "true" || value == "True" || value == "TRUE" || value == "false" || value == "False" || value == "FALSE":
+		return BoolType
+	case coreIntRegexp.MatchString(value):
+		return IntegerType
+	case coreOctalRegexp.MatchString(value):
+		return OctetIntegerType
+	case coreHexRegexp.MatchString(value):
+		return HexIntegerType
+	case coreFloatRegexp.MatchString(value):
+		return FloatType
+	case coreInfRegexp.MatchString(value):
+		return InfinityType
+	case value == ".nan" || value == ".NaN" || value == ".NAN":
+		return NanType
+	}
+	return StringType
+}
+
 // IsNeedQuoted whether need quote for passed string or not
 func IsNeedQuoted(value string) bool {
 	if value == "" {
@@ -568,23 +626,98 @@ func IsNeedQuoted(value string) bool {
 	if _, exists := reservedKeywordMap[value]; exists {
 		return true
 	}
+	if _, exists := legacyBoolKeywords[value]; exists {
+		return true
+	}
 	if stat := getNumberStat(value); stat.isNum {
 		return true
 	}
-	if strings.IndexByte(value, ':') == 1 {
+	if coreSchemaType(value) != StringType {
+		// read as another type by the decoder which resolves scalars by YAML 1.2 ( e.g. `1e3` )
+		return true
+	}
+	if strings.IndexByte(value, ':') == 1 || isSexagesimal(value) {
+		return true
+	}
+	if IsTimestamp(value) {
+		// read as timestamp by YAML 1.1 consumers
 		return true
 	}
 	if strings.IndexByte(value, '#') > 0 {
 		return true
 	}
+	if strings.TrimSpace(value) != value {
+		return true
+	}
+	if isIndicatorPrefix(value) {
+		return true
+	}
+	if strings.Contains(value, ": ") || strings.HasSuffix(value, ":") {
+		return true
+	}
 	for _, c := range value {
-		if c == '\\' {
+		if c == '\\' || c == '\n' || c == '\t' || c == '\r' || !unicode.IsPrint(c) {
 			return true
 		}
 	}
 	return false
 }
 
+// timestampRegexp timestamp of YAML 1.1 ( https://yaml.org/type/timestamp.html )
+var timestampRegexp = regexp.MustCompile(`^([0-9]{4}-[0-9]{2}-[0-9]{2}|[0-9]{4}-[0-9]{1,2}-[0-9]{1,2}([Tt]|[ \t]+)[0-9]{1,2}:[0-9]{2}:[0-9]{2}(\.[0-9]*)?([ \t]*(Z|[-+][0-9]{1,2}(:[0-9]{2})?))?)$`)
+
+// IsLegacyBool whether value is boolean in YAML 1.1 including `y` and `n` ( e.g. `yes`, `off` or `Y` )
+func IsLegacyBool(value string) bool {
+	switch value {
+	case "y", "Y", "n", "N":
+		return true
+	}
+	_, exists := legacyBoolKeywords[value]
+	return exists
+}
+
+// IsTimestamp whether value is timestamp of YAML 1.1 ( e.g. `2001-12-14` or `2001-12-14t21:59:43.10-05:00` )
+func IsTimestamp(value string) bool {
+	return timestampRegexp.MatchString(value)
+}
+
+// isSexagesimal whether value is the number in base 60 of YAML 1.1 ( e.g. `190:20:30` or `1:20.5` )
+func isSexagesimal(value string) bool {
+	value = strings.TrimLeft(value, "+-")
+	parts := strings.Split(value, ":")
+	if len(parts) < 2 {
+		return false
+	}
+	last := parts[len(parts)-1]
+	if idx := strings.IndexByte(last, '.'); idx >= 0 {
+		if strings.Trim(last[idx+1:], "0123456789_") != "" {
+			return false
+		}
+		parts[len(parts)-1] = last[:idx]
+	}
+	for idx, part := range parts {
+		if part == "" || strings.Trim(part, "0123456789_") != "" {
+			return false
+		}
+		if idx > 0 && len(part) > 2 {
+			return false
+		}
+	}
+	return true
+}
+
+// isIndicatorPrefix whether value starts with indicator which cannot be the first character of plain scalar.
+// '-', '?' and ':' can start plain scalar if followed by non-space character ( e.g. `-x` ).
+func isIndicatorPrefix(value string) bool {
+	switch value[0] {
+	case '!', '&', '*', '{', '}', '[', ']', ',', '#', '|', '>', '@', '`', '"', '\'', '%':
+		return true
+	case '-', '?', ':':
+		return len(value) == 1 || value[1] == ' '
+	}
+	return false
+}
+
 // New create reserved keyword token or number token and other string token
 func New(value string, org string, pos *Position) *Token {
 	fn := reservedKeywordMap[value]
@@ -687,6 +820,57 @@ func (t *Tokens) Add(tks ...*Token) {
 	}
 }
 
+// Cursor is a reader of token collection with lookahead.
+// It indexes into the collection directly, so creating Cursor doesn't copy tokens.
+type Cursor struct {
+	tokens Tokens
+	idx    int
+}
+
+// NewCursor create cursor that points to the first token of tokens
+func NewCursor(tokens Tokens) *Cursor {
+	return &Cursor{tokens: tokens}
+}
+
+// Next reports whether the cursor points to a token
+func (c *Cursor) Next() bool {
+	return c.idx < len(c.tokens)
+}
+
+// Index returns the index of the token pointed by the cursor
+func (c *Cursor) Index() int {
+	return c.idx
+}
+
+// Len returns the number of tokens
+func (c *Cursor) Len() int {
+	return len(c.tokens)
+}
+
+// Current returns the token pointed by the cursor. returns nil if the cursor reached the end
+func (c *Cursor) Current() *Token {
+	return c.Peek(0)
+}
+
+// Peek returns the token at n tokens ahead from the cursor ( negative n looks behind ).
+// returns nil if the position is out of range
+func (c *Cursor) Peek(n int) *Token {
+	idx := c.idx + n
+	if idx < 0 || idx >= len(c.tokens) {
+		return nil
+	}
+	return c.tokens[idx]
+}
+
+// Progress moves the cursor forward by num tokens
+func (c *Cursor) Progress(num int) {
+	if len(c.tokens) <= c.idx+num {
+		c.idx = len(c.tokens)
+	} else {
+		c.idx += num
+	}
+}
+
 // Dump dump all token structures for debugging
 func (t Tokens) Dump() {
 	for _, tk := range t {
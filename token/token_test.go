@@ -95,7 +95,81 @@ func TestIsNeedQuoted(t *testing.T) {
 	if !token.IsNeedQuoted("\\0") {
 		t.Fatal("failed to quoted judge for escaped token")
 	}
+	for _, v := range []string{"2001-12-14", "2001-12-14 21:59:43.10 -5", "2001-12-14t21:59:43.10-05:00"} {
+		if !token.IsNeedQuoted(v) {
+			t.Fatalf("failed to quoted judge for timestamp: %s", v)
+		}
+	}
+	for _, v := range []string{"1e3", "1E3", "0o17", "0x1F", ".inf", "Null"} {
+		if !token.IsNeedQuoted(v) {
+			t.Fatalf("failed to quoted judge for scalar resolved by core schema: %s", v)
+		}
+	}
 	if token.IsNeedQuoted("Hello World") {
 		t.Fatal("failed to unquoted judge")
 	}
 }
+
+func TestToCoreSchema(t *testing.T) {
+	tests := []struct {
+		value string
+		typ   token.Type
+	}{
+		{"010", token.IntegerType},
+		{"+12", token.IntegerType},
+		{"0o17", token.OctetIntegerType},
+		{"0x1F", token.HexIntegerType},
+		{"-0x1F", token.StringType},
+		{"0b11", token.StringType},
+		{"1_000", token.StringType},
+		{"1e3", token.FloatType},
+		{"-.5", token.FloatType},
+		{"+.inf", token.InfinityType},
+		{".NaN", token.NanType},
+		{"True", token.BoolType},
+		{"yes", token.StringType},
+		{"~", token.NullType},
+	}
+	for _, test := range tests {
+		tk := token.New(test.value, test.value, &token.Position{})
+		token.ToCoreSchema(tk)
+		if tk.Type != test.typ {
+			t.Fatalf("%q: expected %s but got %s", test.value, test.typ, tk.Type)
+		}
+	}
+	quoted := token.DoubleQuote("010", `"010"`, &token.Position{})
+	token.ToCoreSchema(quoted)
+	if quoted.Type != token.DoubleQuoteType {
+		t.Fatalf("quoted token must not be changed: %s", quoted.Type)
+	}
+}
+
+func TestCursor(t *testing.T) {
+	pos := &token.Position{}
+	tokens := token.Tokens{
+		token.New("a", "a", pos),
+		token.MappingValue(pos),
+		token.New("b", "b", pos),
+	}
+	cursor := token.NewCursor(tokens)
+	if !cursor.Next() {
+		t.Fatal("cursor must point to the first token")
+	}
+	if cursor.Peek(-1) != nil {
+		t.Fatal("failed to look behind the first token")
+	}
+	if cursor.Current() != tokens[0] || cursor.Peek(1) != tokens[1] || cursor.Peek(2) != tokens[2] {
+		t.Fatal("failed to look ahead")
+	}
+	if cursor.Peek(3) != nil {
+		t.Fatal("failed to look ahead over the last token")
+	}
+	cursor.Progress(2)
+	if cursor.Index() != 2 || cursor.Current() != tokens[2] || cursor.Peek(-1) != tokens[1] {
+		t.Fatal("failed to progress cursor")
+	}
+	cursor.Progress(10)
+	if cursor.Next() || cursor.Current() != nil || cursor.Index() != cursor.Len() {
+		t.Fatal("failed to progress cursor over the last token")
+	}
+}
@@ -0,0 +1,90 @@
+package yaml
+
+import (
+	"fmt"
+	"sort"
+
+	"golang.org/x/xerrors"
+)
+
+var (
+	// ErrSkipChildren error returned by WalkFunc to skip walking the children of the value
+	ErrSkipChildren = xerrors.New("skip children")
+)
+
+// WalkFunc is the type of the function called by Walk for each value.
+// The returned value replaces v in the tree, so fn returns v as it is to keep the value.
+// If fn returns ErrSkipChildren, the returned value is kept but its children are not walked.
+type WalkFunc func(path *Path, v interface{}) (interface{}, error)
+
+// Walk calls fn for each value in v decoded into interface{} ( e.g. map[string]interface{}, []interface{} or MapSlice )
+// with the YAMLPath of the value, and returns v which has the values replaced by fn.
+// A mapping or a sequence is passed to fn before its children, and the children of the value returned by fn are walked.
+// Keys of mappings are walked in sorted order except MapSlice which is walked in its order.
+// Mappings and sequences in v are updated in place.
+func Walk(v interface{}, fn WalkFunc) (interface{}, error) {
+	return walkValue([]*pathSegment{}, v, fn)
+}
+
+func walkValue(segments []*pathSegment, v interface{}, fn WalkFunc) (interface{}, error) {
+	replaced, err := fn(&Path{segments: segments}, v)
+	if err != nil {
+		if xerrors.Is(err, ErrSkipChildren) {
+			return replaced, nil
+		}
+		return nil, err
+	}
+	switch value := replaced.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child, err := walkValue(childSegments(segments, &pathSegment{key: k}), value[k], fn)
+			if err != nil {
+				return nil, err
+			}
+			value[k] = child
+		}
+	case map[interface{}]interface{}:
+		keys := make([]interface{}, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		for _, k := range keys {
+			child, err := walkValue(childSegments(segments, &pathSegment{key: fmt.Sprint(k)}), value[k], fn)
+			if err != nil {
+				return nil, err
+			}
+			value[k] = child
+		}
+	case MapSlice:
+		for idx, item := range value {
+			child, err := walkValue(childSegments(segments, &pathSegment{key: fmt.Sprint(item.Key)}), item.Value, fn)
+			if err != nil {
+				return nil, err
+			}
+			value[idx].Value = child
+		}
+	case []interface{}:
+		for idx, elem := range value {
+			child, err := walkValue(childSegments(segments, &pathSegment{index: idx, isIndex: true}), elem, fn)
+			if err != nil {
+				return nil, err
+			}
+			value[idx] = child
+		}
+	}
+	return replaced, nil
+}
+
+func childSegments(segments []*pathSegment, seg *pathSegment) []*pathSegment {
+	children := make([]*pathSegment, 0, len(segments)+1)
+	children = append(children, segments...)
+	return append(children, seg)
+}
@@ -0,0 +1,118 @@
+package yaml_test
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+	"golang.org/x/xerrors"
+)
+
+func TestWalk(t *testing.T) {
+	src := `
+name: app
+env:
+  HOME: ${HOME}
+  "a.b": ${HOME}/bin
+servers:
+- host: example.com
+  port: 80
+- host: ${HOME}
+`
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(src), &v); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	var paths []string
+	replaced, err := yaml.Walk(v, func(path *yaml.Path, v interface{}) (interface{}, error) {
+		paths = append(paths, path.String())
+		if s, ok := v.(string); ok {
+			return os.Expand(s, func(string) string { return "/root" }), nil
+		}
+		return v, nil
+	})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expectedPaths := []string{
+		"$",
+		"$.env",
+		"$.env.HOME",
+		"$.env['a.b']",
+		"$.name",
+		"$.servers",
+		"$.servers[0]",
+		"$.servers[0].host",
+		"$.servers[0].port",
+		"$.servers[1]",
+		"$.servers[1].host",
+	}
+	if !reflect.DeepEqual(expectedPaths, paths) {
+		t.Fatalf("unexpected paths: %q", paths)
+	}
+	expected := map[string]interface{}{
+		"name": "app",
+		"env": map[string]interface{}{
+			"HOME": "/root",
+			"a.b":  "/root/bin",
+		},
+		"servers": []interface{}{
+			map[string]interface{}{"host": "example.com", "port": uint64(80)},
+			map[string]interface{}{"host": "/root"},
+		},
+	}
+	if !reflect.DeepEqual(expected, replaced) {
+		t.Fatalf("unexpected value: %#v", replaced)
+	}
+
+	t.Run("replace container", func(t *testing.T) {
+		replaced, err := yaml.Walk(map[string]interface{}{"a": "x"}, func(path *yaml.Path, v interface{}) (interface{}, error) {
+			switch path.String() {
+			case "$.a":
+				return []interface{}{"y", "z"}, nil
+			case "$.a[1]":
+				return "w", nil
+			}
+			return v, nil
+		})
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		expected := map[string]interface{}{"a": []interface{}{"y", "w"}}
+		if !reflect.DeepEqual(expected, replaced) {
+			t.Fatalf("unexpected value: %#v", replaced)
+		}
+	})
+	t.Run("skip children", func(t *testing.T) {
+		v := yaml.MapSlice{
+			{Key: "a", Value: []interface{}{"x"}},
+			{Key: "b", Value: "y"},
+		}
+		var paths []string
+		if _, err := yaml.Walk(v, func(path *yaml.Path, v interface{}) (interface{}, error) {
+			paths = append(paths, path.String())
+			if path.String() == "$.a" {
+				return v, yaml.ErrSkipChildren
+			}
+			return v, nil
+		}); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if strings.Join(paths, ",") != "$,$.a,$.b" {
+			t.Fatalf("unexpected paths: %q", paths)
+		}
+	})
+	t.Run("error", func(t *testing.T) {
+		errStop := xerrors.New("stop")
+		if _, err := yaml.Walk([]interface{}{1, 2}, func(path *yaml.Path, v interface{}) (interface{}, error) {
+			if path.String() == "$[1]" {
+				return nil, errStop
+			}
+			return v, nil
+		}); !xerrors.Is(err, errStop) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
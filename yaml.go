@@ -2,6 +2,7 @@ package yaml
 
 import (
 	"bytes"
+	"io"
 
 	"github.com/goccy/go-yaml/internal/errors"
 	"golang.org/x/xerrors"
@@ -33,19 +34,112 @@ type InterfaceUnmarshaler interface {
 	UnmarshalYAML(func(interface{}) error) error
 }
 
+// NullSetter interface may be implemented by types to customize their
+// behavior when null is decoded into them with NullPolicySetter.
+type NullSetter interface {
+	SetNull()
+}
+
+// NullPolicy policy to decode null into non-pointer struct field.
+// Fields of pointer, interface, map and slice type are always set to nil.
+type NullPolicy int
+
+const (
+	// NullPolicyZero leaves zero value in the field. This is the default policy
+	NullPolicyZero NullPolicy = iota
+	// NullPolicyError returns error
+	NullPolicyError
+	// NullPolicySetter calls SetNull if the field implements NullSetter, otherwise leaves zero value
+	NullPolicySetter
+)
+
+// MergePolicy policy to decode into destination which already has values ( e.g. apply overrides from the second file onto struct ).
+type MergePolicy int
+
+const (
+	// MergePolicyOverwrite replaces destination by decoded value, so the fields which don't appear in source become zero value.
+	// This is the default policy
+	MergePolicyOverwrite MergePolicy = iota
+	// MergePolicyMerge keeps the values of destination which don't appear in source.
+	// Structs and maps are merged recursively, slices are appended and the other values are overwritten
+	MergePolicyMerge
+	// MergePolicyFillZero sets only the values which are zero value in destination.
+	// Structs and maps are filled recursively
+	MergePolicyFillZero
+)
+
+// ArrayLengthPolicy policy to decode sequence into array whose length is different from the sequence
+type ArrayLengthPolicy int
+
+const (
+	// ArrayLengthPolicyLenient ignores the elements beyond the length of array
+	// and leaves zero value in the rest of array. This is the default policy
+	ArrayLengthPolicyLenient ArrayLengthPolicy = iota
+	// ArrayLengthPolicyError returns error if the lengths are different
+	ArrayLengthPolicyError
+	// ArrayLengthPolicyTruncate ignores the elements beyond the length of array,
+	// but returns error if sequence is shorter than array
+	ArrayLengthPolicyTruncate
+	// ArrayLengthPolicyZeroFill leaves zero value in the rest of array,
+	// but returns error if sequence is longer than array
+	ArrayLengthPolicyZeroFill
+)
+
+// DuplicateAnchorPolicy policy to decode the document which defines the same anchor name twice
+type DuplicateAnchorPolicy int
+
+const (
+	// DuplicateAnchorPolicyAllow allows redefinition of anchor as the spec allows,
+	// and the alias after the second definition refers to it. This is the default policy
+	DuplicateAnchorPolicyAllow DuplicateAnchorPolicy = iota
+	// DuplicateAnchorPolicyError returns error with the positions of both definitions
+	DuplicateAnchorPolicyError
+	// DuplicateAnchorPolicyWarn allows redefinition like DuplicateAnchorPolicyAllow,
+	// and calls the function set by WarnDuplicateAnchor with the positions of both definitions
+	DuplicateAnchorPolicyWarn
+)
+
 // MapItem is an item in a MapSlice.
 type MapItem struct {
 	Key, Value interface{}
 }
 
+// TaggedValue is a value with the tag of the node ( e.g. `!!binary` or `!custom` ).
+// Decoder creates TaggedValue from tagged node for interface{} value when PreserveTags option is enabled,
+// and Encoder emits the tag with the value, so the tag survives decoding and re-encoding.
+type TaggedValue struct {
+	Tag   string
+	Value interface{}
+}
+
+// EncodeStyle preferred style of the values of the type registered by TypeStyle option.
+type EncodeStyle struct {
+	// Flow encodes mapping and sequence by flow style ( e.g. `[a, b]` )
+	Flow bool
+	// Literal encodes string by literal block scalar ( e.g. `|` ) even if it is single line
+	Literal bool
+	// Quoted encodes string by quoted scalar even if it can be written without quotes
+	Quoted bool
+	// Tag adds the tag to the value ( e.g. `!script` )
+	Tag string
+}
+
 // MapSlice encodes and decodes as a YAML map.
-// The order of keys is preserved when encoding and decoding.
+// The order of keys is preserved when encoding and decoding,
+// and nested mappings in the values are decoded into MapSlice too.
+// The key which isn't string is encoded as scalar ( e.g. `1: a` ).
 type MapSlice []MapItem
 
 // Marshal serializes the value provided into a YAML document. The structure
 // of the generated document will reflect the structure of the value itself.
 // Maps and pointers (to struct, string, int, etc) are accepted as the in value.
 //
+// Values implementing BytesMarshaler or InterfaceMarshaler control their own YAML representation.
+// Otherwise, values implementing encoding.TextMarshaler ( e.g. net.IP ) are marshalled as strings.
+// Marshaler methods with pointer receiver are used if the value is addressable ( e.g. the field of pointer to struct ).
+// Values of ast.Node ( e.g. map[string]ast.Node ) are copied into the output as they are.
+// time.Time is marshalled in RFC3339 format ( see EncodeTimeLayout ) and time.Duration is marshalled as the string like `1h30m0s`.
+//
 // Struct fields are only marshalled if they are exported (have an upper case
 // first letter), and are marshalled using the field name lowercased as the
 // default key. Custom keys may be defined via the "yaml" name in the field
@@ -71,8 +165,10 @@ type MapSlice []MapItem
 //
 //     inline       Inline the field, which must be a struct or a map,
 //                  causing all of its fields or keys to be processed as if
-//                  they were part of the outer struct. For maps, keys must
-//                  not conflict with the yaml keys of other struct fields.
+//                  they were part of the outer struct. For maps, keys
+//                  conflicting with the yaml keys of other struct fields are
+//                  not marshaled, and the keys which don't match any field
+//                  are unmarshaled into the map. Only one inline map is allowed.
 //
 //     anchor       Marshal with anchor. If want to define anchor name explicitly, use anchor=name style.
 //                  Otherwise, if used 'anchor' name only, used the field name lowercased as the anchor name
@@ -81,6 +177,10 @@ type MapSlice []MapItem
 //                  Otherwise, If omitted alias name and the field type is pointer type,
 //                  assigned anchor name automatically from same pointer address.
 //
+//     document     Embed the YAML document held by the field of string or []byte type
+//                  as the value instead of the string ( e.g. values passed to another tool ).
+//                  Unmarshal stores the value rendered as YAML document into the field.
+//
 // In addition, if the key is "-", the field is ignored.
 //
 // For example:
@@ -93,8 +193,13 @@ type MapSlice []MapItem
 //     yaml.Marshal(&T{F: 1}} // Returns "a: 1\nb: 0\n"
 //
 func Marshal(v interface{}) ([]byte, error) {
+	return MarshalWithOptions(v)
+}
+
+// MarshalWithOptions serializes the value provided into a YAML document with EncodeOptions.
+func MarshalWithOptions(v interface{}, opts ...EncodeOption) ([]byte, error) {
 	var buf bytes.Buffer
-	enc := NewEncoder(&buf)
+	enc := NewEncoder(&buf, opts...)
 	if err := enc.Encode(v); err != nil {
 		return nil, errors.Wrapf(err, "failed to marshal")
 	}
@@ -103,6 +208,7 @@ func Marshal(v interface{}) ([]byte, error) {
 
 // Unmarshal decodes the first document found within the in byte slice
 // and assigns decoded values into the out value.
+// The following documents are not decoded, but a syntax error in them is reported.
 //
 // Struct fields are only unmarshalled if they are exported (have an
 // upper case first letter), and are unmarshalled using the field name
@@ -112,6 +218,11 @@ func Marshal(v interface{}) ([]byte, error) {
 // used to tweak the marshalling process (see Marshal).
 // Conflicting names result in a runtime error.
 //
+// Values implementing BytesUnmarshaler or InterfaceUnmarshaler control their own decoding.
+// Otherwise, scalars are decoded by UnmarshalText if the value implements encoding.TextUnmarshaler.
+// time.Time is decoded from the timestamp of YAML ( e.g. `2001-12-14t21:59:43.10-05:00` ) or the layouts set by DecodeTimeLayouts,
+// and time.Duration is decoded from the string parsed by time.ParseDuration ( e.g. `1h30m` ) or the integer of nanoseconds.
+//
 // For example:
 //
 //     type T struct {
@@ -125,8 +236,21 @@ func Marshal(v interface{}) ([]byte, error) {
 // supported tag options.
 //
 func Unmarshal(data []byte, v interface{}) error {
-	dec := NewDecoder(bytes.NewBuffer(data))
+	return UnmarshalWithOptions(data, v)
+}
+
+// UnmarshalWithOptions decodes the first document found within the in byte slice with DecodeOptions
+// and assigns decoded values into the out value.
+func UnmarshalWithOptions(data []byte, v interface{}, opts ...DecodeOption) error {
+	dec := NewDecoder(bytes.NewBuffer(data), opts...)
 	if err := dec.Decode(v); err != nil {
+		if err == io.EOF {
+			// empty input leaves v unchanged
+			return nil
+		}
+		return errors.Wrapf(err, "failed to unmarshal")
+	}
+	if err := dec.validateRestDocuments(); err != nil {
 		return errors.Wrapf(err, "failed to unmarshal")
 	}
 	return nil
@@ -139,6 +263,9 @@ func Unmarshal(data []byte, v interface{}) error {
 // If the third argument `inclSource` is true, the error message will
 // contain snippets of the YAML source that was used.
 func FormatError(e error, colored, inclSource bool) string {
+	if e == nil {
+		return ""
+	}
 	var pp errors.PrettyPrinter
 	if xerrors.As(e, &pp) {
 		var buf bytes.Buffer
@@ -1,12 +1,20 @@
 package yaml_test
 
 import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/goccy/go-yaml"
 	"golang.org/x/xerrors"
 )
 
+var update = flag.Bool("update", false, "update golden files")
+
 func TestMarshal(t *testing.T) {
 	var v struct {
 		A int
@@ -201,3 +209,135 @@ b:
 		t.Fatal("failed to UnmarshalYAML")
 	}
 }
+
+type goldenFieldError string
+
+func (e goldenFieldError) StructField() string { return string(e) }
+
+type goldenValidationErrors []goldenFieldError
+
+func (e goldenValidationErrors) Error() string { return "a must be positive" }
+
+type goldenValidator struct{}
+
+func (goldenValidator) Struct(v interface{}) error {
+	if reflect.Indirect(reflect.ValueOf(v)).FieldByName("A").Int() <= 0 {
+		return goldenValidationErrors{"A"}
+	}
+	return nil
+}
+
+// unmarshalGolden returns the function to decode the source of golden test into the value created by newValue with options
+func unmarshalGolden(newValue func() interface{}, opts ...yaml.DecodeOption) func([]byte) error {
+	return func(src []byte) error {
+		return yaml.UnmarshalWithOptions(src, newValue(), opts...)
+	}
+}
+
+var (
+	// errorGoldenDecoders decodes the source of the code which isn't reported by decoding into interface{} with default options
+	errorGoldenDecoders = map[yaml.ErrorCode]func([]byte) error{
+		yaml.ErrCodeValidation: unmarshalGolden(func() interface{} { return &struct{ A int }{} }, yaml.Validator(goldenValidator{})),
+		yaml.ErrCodeNullValue:  unmarshalGolden(func() interface{} { return &struct{ A int }{} }, yaml.DecodeNull(yaml.NullPolicyError)),
+		yaml.ErrCodeArrayLength: unmarshalGolden(func() interface{} {
+			return &struct{ A [2]int }{}
+		}, yaml.DecodeArrayLength(yaml.ArrayLengthPolicyError)),
+		yaml.ErrCodeTypeMismatch:       unmarshalGolden(func() interface{} { return new(int) }),
+		yaml.ErrCodeOverflowNumber:     unmarshalGolden(func() interface{} { return new(uint8) }),
+		yaml.ErrCodeUnexpectedNodeType: unmarshalGolden(func() interface{} { return &struct{ A map[string]int }{} }),
+		yaml.ErrCodeUnknownField:       unmarshalGolden(func() interface{} { return &struct{ A int }{} }, yaml.DisallowUnknownField()),
+		yaml.ErrCodeDuplicateAnchor: unmarshalGolden(func() interface{} {
+			return new(interface{})
+		}, yaml.DuplicateAnchor(yaml.DuplicateAnchorPolicyError)),
+		yaml.ErrCodeDuplicateKey:       unmarshalGolden(func() interface{} { return new(interface{}) }, yaml.DisallowDuplicateKey()),
+		yaml.ErrCodeExcessiveNesting:   unmarshalGolden(func() interface{} { return new(interface{}) }, yaml.MaxDepth(2)),
+		yaml.ErrCodeUnsafeTag:          unmarshalGolden(func() interface{} { return new(interface{}) }, yaml.SafeMode()),
+		yaml.ErrCodeInvalidTimeValue:   unmarshalGolden(func() interface{} { return &struct{ A time.Duration }{} }),
+		yaml.ErrCodeInvalidBinaryValue: unmarshalGolden(func() interface{} { return &struct{ A []byte }{} }),
+		yaml.ErrCodeInvalidJSONValue: func(src []byte) error {
+			_, err := yaml.YAMLToJSON(src)
+			return err
+		},
+	}
+	// errorGoldenExclusions codes without golden file because no source text causes them
+	errorGoldenExclusions = map[yaml.ErrorCode]string{
+		yaml.ErrCodeNonScalarKey:        "the parser creates only scalar nodes for mapping keys",
+		yaml.ErrCodeRequiredStringToken: "the scanner always creates string token for the content of literal and folded blocks",
+	}
+)
+
+func TestErrorGolden(t *testing.T) {
+	// testdata/error/<code>.input is the source which causes error of <code>.
+	// run `go test -run TestErrorGolden -update` to update golden files after adding a new code.
+	// every code needs the source unless it is listed in errorGoldenExclusions.
+	files, err := filepath.Glob(filepath.Join("testdata", "error", "*.input"))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(files) == 0 {
+		t.Fatal("failed to find sources")
+	}
+	tested := map[yaml.ErrorCode]struct{}{}
+	for _, file := range files {
+		code := yaml.ErrorCode(strings.TrimSuffix(filepath.Base(file), ".input"))
+		tested[code] = struct{}{}
+		t.Run(string(code), func(t *testing.T) {
+			src, err := ioutil.ReadFile(file)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			decode, exists := errorGoldenDecoders[code]
+			if !exists {
+				decode = unmarshalGolden(func() interface{} { return new(interface{}) })
+			}
+			unmarshalErr := decode(src)
+			if unmarshalErr == nil {
+				t.Fatal("expected error")
+			}
+			if actual := yaml.ErrorCodeOf(unmarshalErr); actual != code {
+				t.Fatalf("unexpected code: %q", actual)
+			}
+			actual := yaml.FormatError(unmarshalErr, false, true)
+			golden := strings.TrimSuffix(file, ".input") + ".golden"
+			if *update {
+				if err := ioutil.WriteFile(golden, []byte(actual), 0644); err != nil {
+					t.Fatalf("%+v", err)
+				}
+			}
+			expected, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if actual != string(expected) {
+				t.Fatalf("unexpected error message.\nexpected:\n%s\nactual:\n%s", string(expected), actual)
+			}
+			if unmarshalErr.Error() != actual {
+				t.Fatalf("Error() and FormatError are different:\n%s", unmarshalErr.Error())
+			}
+		})
+	}
+	for _, code := range yaml.ErrorCodes() {
+		_, isTested := tested[code]
+		_, isExcluded := errorGoldenExclusions[code]
+		if !isTested && !isExcluded {
+			t.Errorf("testdata/error/%s.input is required", code)
+		}
+		if isTested && isExcluded {
+			t.Errorf("%s is tested but excluded", code)
+		}
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	for _, code := range yaml.ErrorCodes() {
+		if code == "" {
+			t.Fatal("found empty code")
+		}
+		if code.MessageFormat() == "" {
+			t.Fatalf("message format of %q is undefined", code)
+		}
+	}
+	if code := yaml.ErrorCodeOf(xerrors.New("error")); code != "" {
+		t.Fatalf("unexpected code: %q", code)
+	}
+}
@@ -0,0 +1,81 @@
+// Package yamltest provides assertion helpers to compare YAML documents in tests.
+// Documents are compared semantically by yaml.Diff, so tests don't depend on the formatting of marshaled output.
+package yamltest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+)
+
+// TestingT interface of *testing.T used by assertion helpers
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// AssertEqual reports error to t if expected and actual don't represent the same documents, and returns whether they are equal.
+// The error message has the path of each difference and the source lines of the different values.
+func AssertEqual(t TestingT, expected, actual []byte, opts ...yaml.EqualOption) bool {
+	t.Helper()
+	return assert(t, "not equal", expected, actual, opts)
+}
+
+// AssertSubset reports error to t if actual doesn't contain all values of expected, and returns whether it contains.
+// Mapping keys which exist only in actual are ignored ( e.g. `a: 1` is subset of `{a: 1, b: 2}` ),
+// but sequences must have the same length.
+func AssertSubset(t TestingT, expected, actual []byte, opts ...yaml.EqualOption) bool {
+	t.Helper()
+	subsetOpts := append([]yaml.EqualOption{}, opts...)
+	subsetOpts = append(subsetOpts, yaml.Subset(true))
+	return assert(t, "not subset", expected, actual, subsetOpts)
+}
+
+func assert(t TestingT, title string, expected, actual []byte, opts []yaml.EqualOption) bool {
+	t.Helper()
+	diffs, err := yaml.Diff(expected, actual, opts...)
+	if err != nil {
+		t.Errorf("failed to compare YAML: %v", err)
+		return false
+	}
+	if len(diffs) == 0 {
+		return true
+	}
+	t.Errorf("%s", formatDifferences(title, expected, actual, diffs))
+	return false
+}
+
+func formatDifferences(title string, expected, actual []byte, diffs []*yaml.Difference) string {
+	expectedLines := strings.Split(string(expected), "\n")
+	actualLines := strings.Split(string(actual), "\n")
+	var b strings.Builder
+	fmt.Fprintf(&b, "YAML is %s: found %d difference(s)\n", title, len(diffs))
+	for _, diff := range diffs {
+		b.WriteString("\n")
+		if diff.Document > 0 {
+			fmt.Fprintf(&b, "document %d: ", diff.Document)
+		}
+		fmt.Fprintf(&b, "%s: %s\n", diff.Path, diff.Message)
+		writeSourceLine(&b, "expected", expectedLines, diff.Expected)
+		writeSourceLine(&b, "actual", actualLines, diff.Actual)
+	}
+	return b.String()
+}
+
+// writeSourceLine writes the line which has node with line number ( e.g. `  3 | a: 1` )
+func writeSourceLine(b *strings.Builder, label string, lines []string, node ast.Node) {
+	if node == nil {
+		return
+	}
+	tk := node.GetToken()
+	if tk == nil || tk.Position == nil {
+		return
+	}
+	line := tk.Position.Line
+	if line < 1 || line > len(lines) {
+		return
+	}
+	fmt.Fprintf(b, "  %s:\n  %4d | %s\n", label, line, strings.TrimRight(lines[line-1], "\r"))
+}
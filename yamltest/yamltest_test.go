@@ -0,0 +1,106 @@
+package yamltest_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/yamltest"
+)
+
+type recorder struct {
+	messages []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.messages = append(r.messages, fmt.Sprintf(format, args...))
+}
+
+func TestAssertEqual(t *testing.T) {
+	t.Run("equal", func(t *testing.T) {
+		r := &recorder{}
+		if !yamltest.AssertEqual(r, []byte("a: {b: 1}\nc: [x]\n"), []byte("c:\n- x\na:\n  b: 1\n")) {
+			t.Fatalf("unexpected messages: %v", r.messages)
+		}
+	})
+	t.Run("not equal", func(t *testing.T) {
+		r := &recorder{}
+		expected := `
+a:
+  b: 1
+  c: 2
+d: [x, y]
+`
+		actual := `
+a:
+  b: 3
+d: [x, y, z]
+e: true
+`
+		if yamltest.AssertEqual(r, []byte(expected), []byte(actual)) {
+			t.Fatal("expected failure")
+		}
+		if len(r.messages) != 1 {
+			t.Fatalf("unexpected messages: %v", r.messages)
+		}
+		expectedMessage := `YAML is not equal: found 4 difference(s)
+
+$.a.b: expected 1 but got 3
+  expected:
+     3 |   b: 1
+  actual:
+     3 |   b: 3
+
+$.a.c: key "c" doesn't exist
+  expected:
+     4 |   c: 2
+
+$.d[2]: unexpected element
+  actual:
+     4 | d: [x, y, z]
+
+$.e: unexpected key "e"
+  actual:
+     5 | e: true
+`
+		if r.messages[0] != expectedMessage {
+			t.Fatalf("unexpected message:\n%s", r.messages[0])
+		}
+	})
+	t.Run("strict key order", func(t *testing.T) {
+		r := &recorder{}
+		if yamltest.AssertEqual(r, []byte("a: 1\nb: 2\n"), []byte("b: 2\na: 1\n"), yaml.StrictKeyOrder(true)) {
+			t.Fatal("expected failure")
+		}
+	})
+	t.Run("invalid source", func(t *testing.T) {
+		r := &recorder{}
+		if yamltest.AssertEqual(r, []byte("a: *x\n"), []byte("a: 1\n")) {
+			t.Fatal("expected failure")
+		}
+		if len(r.messages) != 1 {
+			t.Fatalf("unexpected messages: %v", r.messages)
+		}
+	})
+}
+
+func TestAssertSubset(t *testing.T) {
+	r := &recorder{}
+	if !yamltest.AssertSubset(r, []byte("a: {b: 1}\n"), []byte("a: {b: 1, c: 2}\nd: 3\n")) {
+		t.Fatalf("unexpected messages: %v", r.messages)
+	}
+	if yamltest.AssertSubset(r, []byte("a: {b: 1, e: 4}\n"), []byte("a: {b: 1, c: 2}\n")) {
+		t.Fatal("expected failure")
+	}
+	expectedMessage := `YAML is not subset: found 1 difference(s)
+
+$.a.e: key "e" doesn't exist
+  expected:
+     1 | a: {b: 1, e: 4}
+`
+	if len(r.messages) != 1 || r.messages[0] != expectedMessage {
+		t.Fatalf("unexpected messages: %v", r.messages)
+	}
+}